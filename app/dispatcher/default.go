@@ -22,11 +22,20 @@ import (
 	routing_session "github.com/xtls/xray-core/features/routing/session"
 	"github.com/xtls/xray-core/features/stats"
 	"github.com/xtls/xray-core/transport"
+	"github.com/xtls/xray-core/transport/internet/stat"
 	"github.com/xtls/xray-core/transport/pipe"
 )
 
 var errSniffingTimeout = errors.New("timeout on sniffing")
 
+// CachedPayload is the reader of a link whose first payload was cached while
+// sniffing.
+type CachedPayload interface {
+	// TakeCache returns the cached payload, or nil if there is none, and
+	// leaves the rest to be read.
+	TakeCache() buf.MultiBuffer
+}
+
 type cachedReader struct {
 	sync.Mutex
 	reader buf.TimeoutReader // *pipe.Reader or *buf.TimeoutWrapperReader
@@ -63,6 +72,11 @@ func (r *cachedReader) readInternal() buf.MultiBuffer {
 	return nil
 }
 
+// TakeCache implements CachedPayload.
+func (r *cachedReader) TakeCache() buf.MultiBuffer {
+	return r.readInternal()
+}
+
 func (r *cachedReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
 	mb := r.readInternal()
 	if mb != nil {
@@ -300,6 +314,7 @@ func (d *DefaultDispatcher) Dispatch(ctx context.Context, destination net.Destin
 		content = new(session.Content)
 		ctx = session.ContextWithContent(ctx, content)
 	}
+	recordInboundTLS(session.InboundFromContext(ctx))
 
 	sniffingRequest := content.SniffingRequest
 	inbound, outbound := d.getLink(ctx)
@@ -357,6 +372,7 @@ func (d *DefaultDispatcher) DispatchLink(ctx context.Context, destination net.De
 		content = new(session.Content)
 		ctx = session.ContextWithContent(ctx, content)
 	}
+	recordInboundTLS(session.InboundFromContext(ctx))
 	outbound = d.WrapLink(ctx, outbound)
 	sniffingRequest := content.SniffingRequest
 	if !sniffingRequest.Enabled {
@@ -453,6 +469,12 @@ func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.
 	outbounds := session.OutboundsFromContext(ctx)
 	ob := outbounds[len(outbounds)-1]
 
+	if inbound := session.InboundFromContext(ctx); inbound != nil && inbound.User != nil && d.policy != nil {
+		if priority := d.policy.ForLevel(inbound.User.Level).Mux.Priority; priority > 0 {
+			ctx = session.ContextWithMuxPriority(ctx, priority)
+		}
+	}
+
 	var handler outbound.Handler
 
 	routingLink := routing_session.AsRoutingContext(ctx)
@@ -521,3 +543,24 @@ func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.
 
 	handler.Dispatch(ctx, link)
 }
+
+// recordInboundTLS records the SNI profile an inbound TLS connection matched
+// and the user its client certificate maps to in the inbound session, unless
+// the inbound proxy authenticated a user itself.
+func recordInboundTLS(inbound *session.Inbound) {
+	if inbound == nil {
+		return
+	}
+	conn := inbound.Conn
+	if statConn, ok := conn.(*stat.CounterConnection); ok {
+		conn = statConn.Connection
+	}
+	if tlsConn, ok := conn.(interface{ SNIProfile() string }); ok && inbound.SniProfile == "" {
+		inbound.SniProfile = tlsConn.SNIProfile()
+	}
+	if tlsConn, ok := conn.(interface{ ClientUser() *protocol.MemoryUser }); ok && (inbound.User == nil || inbound.User.Email == "") {
+		if user := tlsConn.ClientUser(); user != nil {
+			inbound.User = user
+		}
+	}
+}
@@ -47,6 +47,7 @@ func NewMetricsHandler(ctx context.Context, config *Config) (*MetricsHandler, er
 			"inbound":  {},
 			"outbound": {},
 			"user":     {},
+			"relay":    {},
 		}
 		manager.VisitCounters(func(name string, counter feature_stats.Counter) bool {
 			nameSplit := strings.Split(name, ">>>")
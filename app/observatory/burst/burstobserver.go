@@ -37,22 +37,43 @@ func (o *Observer) createResult() []*observatory.OutboundStatus {
 	o.hp.access.Lock()
 	defer o.hp.access.Unlock()
 	for name, value := range o.hp.Results {
+		stats := value.getStatistics()
+		dist := value.GetDistribution()
 		status := observatory.OutboundStatus{
-			Alive:           value.getStatistics().All != value.getStatistics().Fail,
-			Delay:           value.getStatistics().Average.Milliseconds(),
+			Alive:           stats.All != stats.Fail,
+			Delay:           stats.Average.Milliseconds(),
 			LastErrorReason: "",
 			OutboundTag:     name,
 			LastSeenTime:    0,
 			LastTryTime:     0,
 			HealthPing: &observatory.HealthPingMeasurementResult{
-				All:       int64(value.getStatistics().All),
-				Fail:      int64(value.getStatistics().Fail),
-				Deviation: int64(value.getStatistics().Deviation),
-				Average:   int64(value.getStatistics().Average),
-				Max:       int64(value.getStatistics().Max),
-				Min:       int64(value.getStatistics().Min),
+				All:        int64(stats.All),
+				Fail:       int64(stats.Fail),
+				Deviation:  int64(stats.Deviation),
+				Average:    int64(stats.Average),
+				Max:        int64(stats.Max),
+				Min:        int64(stats.Min),
+				P50:        int64(dist.P50),
+				P90:        int64(dist.P90),
+				P99:        int64(dist.P99),
+				Loss:       float32(dist.Loss),
+				Throughput: o.hp.Throughput[name],
 			},
 		}
+		for i, target := range o.hp.Settings.Targets {
+			stats, dist := value.GetTarget(i)
+			status.HealthPing.Targets = append(status.HealthPing.Targets, &observatory.HealthPingTargetResult{
+				Name:        target.Name,
+				Destination: target.Destination,
+				All:         int64(stats.All),
+				Fail:        int64(stats.Fail),
+				Average:     int64(stats.Average),
+				Deviation:   int64(stats.Deviation),
+				P50:         int64(dist.P50),
+				P90:         int64(dist.P90),
+				P99:         int64(dist.P99),
+			})
+		}
 		result = append(result, &status)
 	}
 	return result
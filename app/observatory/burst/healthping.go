@@ -20,6 +20,17 @@ type HealthPingSettings struct {
 	SamplingCount int           `json:"sampling"`
 	Timeout       time.Duration `json:"timeout"`
 	HttpMethod    string        `json:"httpMethod"`
+	// Targets are probed in each check, Destination is the only one if empty
+	Targets        []*HealthPingTargetSettings `json:"targets"`
+	ThroughputURL  string                      `json:"throughputUrl"`
+	ThroughputSize int64                       `json:"throughputSize"`
+}
+
+// HealthPingTargetSettings holds settings for a probe target
+type HealthPingTargetSettings struct {
+	Name        string  `json:"name"`
+	Destination string  `json:"destination"`
+	Weight      float64 `json:"weight"`
 }
 
 // HealthPing is the health checker for balancers
@@ -30,8 +41,9 @@ type HealthPing struct {
 	ticker      *time.Ticker
 	tickerClose chan struct{}
 
-	Settings *HealthPingSettings
-	Results  map[string]*HealthPingRTTS
+	Settings   *HealthPingSettings
+	Results    map[string]*HealthPingRTTS
+	Throughput map[string]int64
 }
 
 // NewHealthPing creates a new HealthPing with settings
@@ -53,6 +65,20 @@ func NewHealthPing(ctx context.Context, dispatcher routing.Dispatcher, config *H
 			SamplingCount: int(config.SamplingCount),
 			Timeout:       time.Duration(config.Timeout),
 			HttpMethod:    httpMethod,
+
+			ThroughputURL:  strings.TrimSpace(config.ThroughputUrl),
+			ThroughputSize: config.ThroughputSize,
+		}
+		for _, target := range config.Targets {
+			weight := float64(target.Weight)
+			if weight <= 0 {
+				weight = 1
+			}
+			settings.Targets = append(settings.Targets, &HealthPingTargetSettings{
+				Name:        target.Name,
+				Destination: strings.TrimSpace(target.Destination),
+				Weight:      weight,
+			})
 		}
 	}
 	if settings.Destination == "" {
@@ -75,6 +101,23 @@ func NewHealthPing(ctx context.Context, dispatcher routing.Dispatcher, config *H
 		// a larger timeout could possibly makes checks run longer
 		settings.Timeout = time.Duration(5) * time.Second
 	}
+	if len(settings.Targets) == 0 {
+		settings.Targets = []*HealthPingTargetSettings{{
+			Destination: settings.Destination,
+			Weight:      1,
+		}}
+	}
+	for _, target := range settings.Targets {
+		if target.Destination == "" {
+			target.Destination = settings.Destination
+		}
+		if target.Name == "" {
+			target.Name = target.Destination
+		}
+	}
+	if settings.ThroughputURL != "" && settings.ThroughputSize <= 0 {
+		settings.ThroughputSize = 1024 * 1024
+	}
 	return &HealthPing{
 		ctx:        ctx,
 		dispatcher: dispatcher,
@@ -111,6 +154,7 @@ func (h *HealthPing) StartScheduler(selector func() ([]string, error)) {
 					return
 				}
 				h.doCheck(tags, interval, h.Settings.SamplingCount)
+				h.measureThroughput(tags)
 				h.Cleanup(tags)
 			}()
 			select {
@@ -146,74 +190,88 @@ func (h *HealthPing) Check(tags []string) error {
 
 type rtt struct {
 	handler string
+	target  int
 	value   time.Duration
 }
 
 // doCheck performs the 'rounds' amount checks in given 'duration'. You should make
 // sure all tags are valid for current balancer
 func (h *HealthPing) doCheck(tags []string, duration time.Duration, rounds int) {
-	count := len(tags) * rounds
+	count := len(tags) * len(h.Settings.Targets) * rounds
 	if count == 0 {
 		return
 	}
 	ch := make(chan *rtt, count)
 
 	for _, tag := range tags {
-		handler := tag
-		client := newPingClient(
-			h.ctx,
-			h.dispatcher,
-			h.Settings.Destination,
-			h.Settings.Timeout,
-			handler,
-		)
-		for i := 0; i < rounds; i++ {
-			delay := time.Duration(0)
-			if duration > 0 {
-				delay = time.Duration(dice.RollInt63n(int64(duration)))
-			}
-			time.AfterFunc(delay, func() {
-				errors.LogDebug(h.ctx, "checking ", handler)
-				delay, err := client.MeasureDelay(h.Settings.HttpMethod)
-				if err == nil {
-					ch <- &rtt{
-						handler: handler,
-						value:   delay,
-					}
-					return
-				}
-				if !h.checkConnectivity() {
-					errors.LogWarning(h.ctx, "network is down")
-					ch <- &rtt{
-						handler: handler,
-						value:   0,
-					}
-					return
-				}
-				errors.LogWarning(h.ctx, fmt.Sprintf(
-					"error ping %s with %s: %s",
-					h.Settings.Destination,
-					handler,
-					err,
-				))
-				ch <- &rtt{
-					handler: handler,
-					value:   rttFailed,
-				}
-			})
+		for target, settings := range h.Settings.Targets {
+			h.checkTarget(ch, tag, target, settings.Destination, duration, rounds)
 		}
 	}
 	for i := 0; i < count; i++ {
 		rtt := <-ch
 		if rtt.value > 0 {
 			// should not put results when network is down
-			h.PutResult(rtt.handler, rtt.value)
+			h.PutTargetResult(rtt.handler, rtt.target, rtt.value)
 		}
 	}
 }
 
+func (h *HealthPing) checkTarget(ch chan<- *rtt, handler string, target int, destination string, duration time.Duration, rounds int) {
+	client := newPingClient(
+		h.ctx,
+		h.dispatcher,
+		destination,
+		h.Settings.Timeout,
+		handler,
+	)
+	for i := 0; i < rounds; i++ {
+		delay := time.Duration(0)
+		if duration > 0 {
+			delay = time.Duration(dice.RollInt63n(int64(duration)))
+		}
+		time.AfterFunc(delay, func() {
+			errors.LogDebug(h.ctx, "checking ", handler)
+			delay, err := client.MeasureDelay(h.Settings.HttpMethod)
+			if err == nil {
+				ch <- &rtt{
+					handler: handler,
+					target:  target,
+					value:   delay,
+				}
+				return
+			}
+			if !h.checkConnectivity() {
+				errors.LogWarning(h.ctx, "network is down")
+				ch <- &rtt{
+					handler: handler,
+					target:  target,
+					value:   0,
+				}
+				return
+			}
+			errors.LogWarning(h.ctx, fmt.Sprintf(
+				"error ping %s with %s: %s",
+				destination,
+				handler,
+				err,
+			))
+			ch <- &rtt{
+				handler: handler,
+				target:  target,
+				value:   rttFailed,
+			}
+		})
+	}
+}
+
 // PutResult put a ping rtt to results
 func (h *HealthPing) PutResult(tag string, rtt time.Duration) {
+	h.PutTargetResult(tag, 0, rtt)
+}
+
+// PutTargetResult put a ping rtt of a probe target to results
+func (h *HealthPing) PutTargetResult(tag string, target int, rtt time.Duration) {
 	h.access.Lock()
 	defer h.access.Unlock()
 	if h.Results == nil {
@@ -226,10 +284,43 @@ func (h *HealthPing) PutResult(tag string, rtt time.Duration) {
 		// Previous checks are distributed on the left, and later ones
 		// on the right
 		validity := h.Settings.Interval * time.Duration(h.Settings.SamplingCount) * 2
-		r = NewHealthPingResult(h.Settings.SamplingCount, validity)
+		r = NewHealthPingResult(h.Settings.SamplingCount*len(h.Settings.Targets), validity)
+		if len(h.Settings.Targets) > 1 {
+			r.weights = make([]float64, len(h.Settings.Targets))
+			for i, target := range h.Settings.Targets {
+				r.weights[i] = target.Weight
+			}
+		}
 		h.Results[tag] = r
 	}
-	r.Put(rtt)
+	r.PutTarget(target, rtt)
+}
+
+// measureThroughput takes a throughput sample of each handler, if enabled
+func (h *HealthPing) measureThroughput(tags []string) {
+	if h.Settings.ThroughputURL == "" {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, tag := range tags {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// a download takes longer than a ping, give it the whole interval
+			client := newPingClient(h.ctx, h.dispatcher, h.Settings.ThroughputURL, h.Settings.Interval, tag)
+			throughput, err := client.MeasureThroughput(h.Settings.ThroughputSize)
+			if err != nil {
+				errors.LogWarning(h.ctx, "error measuring throughput with ", tag, ": ", err)
+			}
+			h.access.Lock()
+			defer h.access.Unlock()
+			if h.Throughput == nil {
+				h.Throughput = make(map[string]int64)
+			}
+			h.Throughput[tag] = throughput
+		}()
+	}
+	wg.Wait()
 }
 
 // Cleanup removes results of removed handlers,
@@ -247,6 +338,7 @@ func (h *HealthPing) Cleanup(tags []string) {
 		}
 		if !found {
 			delete(h.Results, tag)
+			delete(h.Throughput, tag)
 		}
 	}
 }
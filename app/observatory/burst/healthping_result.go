@@ -2,6 +2,7 @@ package burst
 
 import (
 	"math"
+	"sort"
 	"time"
 )
 
@@ -15,22 +16,36 @@ type HealthPingStats struct {
 	Min       time.Duration
 }
 
+// HealthPingDistribution is the latency distribution of HealthPingRTTS,
+// weighted by probe target
+type HealthPingDistribution struct {
+	P50  time.Duration
+	P90  time.Duration
+	P99  time.Duration
+	Loss float64
+}
+
 // HealthPingRTTS holds ping rtts for health Checker
 type HealthPingRTTS struct {
 	idx      int
 	cap      int
 	validity time.Duration
 	rtts     []*pingRTT
+	// weights of the probe targets, all 1 if nil
+	weights []float64
 
 	lastUpdateAt time.Time
 	stats        *HealthPingStats
 }
 
 type pingRTT struct {
-	time  time.Time
-	value time.Duration
+	time   time.Time
+	value  time.Duration
+	target int
 }
 
+const allTargets = -1
+
 // NewHealthPingResult returns a *HealthPingResult with specified capacity
 func NewHealthPingResult(cap int, validity time.Duration) *HealthPingRTTS {
 	return &HealthPingRTTS{cap: cap, validity: validity}
@@ -41,6 +56,37 @@ func (h *HealthPingRTTS) Get() *HealthPingStats {
 	return h.getStatistics()
 }
 
+// GetDistribution gets the latency percentiles and the loss rate of the
+// HealthPingRTTS
+func (h *HealthPingRTTS) GetDistribution() *HealthPingDistribution {
+	return h.getDistribution(allTargets)
+}
+
+// GetTarget gets statistics and distribution of one probe target
+func (h *HealthPingRTTS) GetTarget(target int) (*HealthPingStats, *HealthPingDistribution) {
+	return h.statistics(target), h.getDistribution(target)
+}
+
+func (h *HealthPingRTTS) weight(target int) float64 {
+	if target < len(h.weights) {
+		return h.weights[target]
+	}
+	return 1
+}
+
+// valid calls f with the rtts of target which are not outdated
+func (h *HealthPingRTTS) valid(target int, f func(rtt *pingRTT)) {
+	for _, rtt := range h.rtts {
+		if rtt.value == 0 || time.Since(rtt.time) > h.validity {
+			continue
+		}
+		if target != allTargets && rtt.target != target {
+			continue
+		}
+		f(rtt)
+	}
+}
+
 // GetWithCache get statistics and write cache for next call
 // Make sure use Mutex.Lock() before calling it, RWMutex.RLock()
 // is not an option since it writes cache
@@ -56,6 +102,11 @@ func (h *HealthPingRTTS) GetWithCache() *HealthPingStats {
 
 // Put puts a new rtt to the HealthPingResult
 func (h *HealthPingRTTS) Put(d time.Duration) {
+	h.PutTarget(0, d)
+}
+
+// PutTarget puts a new rtt of a probe target to the HealthPingResult
+func (h *HealthPingRTTS) PutTarget(target int, d time.Duration) {
 	if h.rtts == nil {
 		h.rtts = make([]*pingRTT, h.cap)
 		for i := 0; i < h.cap; i++ {
@@ -67,6 +118,7 @@ func (h *HealthPingRTTS) Put(d time.Duration) {
 	now := time.Now()
 	h.rtts[h.idx].time = now
 	h.rtts[h.idx].value = d
+	h.rtts[h.idx].target = target
 }
 
 func (h *HealthPingRTTS) calcIndex(step int) int {
@@ -79,37 +131,40 @@ func (h *HealthPingRTTS) calcIndex(step int) int {
 }
 
 func (h *HealthPingRTTS) getStatistics() *HealthPingStats {
+	return h.statistics(allTargets)
+}
+
+func (h *HealthPingRTTS) statistics(target int) *HealthPingStats {
 	stats := &HealthPingStats{}
 	stats.Fail = 0
 	stats.Max = 0
 	stats.Min = rttFailed
-	sum := time.Duration(0)
+	sum := float64(0)
+	weights := float64(0)
 	cnt := 0
-	validRTTs := make([]time.Duration, 0)
-	for _, rtt := range h.rtts {
-		switch {
-		case rtt.value == 0 || time.Since(rtt.time) > h.validity:
-			continue
-		case rtt.value == rttFailed:
+	validRTTs := make([]*pingRTT, 0)
+	h.valid(target, func(rtt *pingRTT) {
+		if rtt.value == rttFailed {
 			stats.Fail++
-			continue
+			return
 		}
 		cnt++
-		sum += rtt.value
-		validRTTs = append(validRTTs, rtt.value)
+		sum += float64(rtt.value) * h.weight(rtt.target)
+		weights += h.weight(rtt.target)
+		validRTTs = append(validRTTs, rtt)
 		if stats.Max < rtt.value {
 			stats.Max = rtt.value
 		}
 		if stats.Min > rtt.value {
 			stats.Min = rtt.value
 		}
-	}
+	})
 	stats.All = cnt + stats.Fail
-	if cnt == 0 {
+	if cnt == 0 || weights == 0 {
 		stats.Min = 0
 		return stats
 	}
-	stats.Average = time.Duration(int(sum) / cnt)
+	stats.Average = time.Duration(sum / weights)
 	var std float64
 	if cnt < 2 {
 		// no enough data for standard deviation, we assume it's half of the average rtt
@@ -119,14 +174,53 @@ func (h *HealthPingRTTS) getStatistics() *HealthPingStats {
 	} else {
 		variance := float64(0)
 		for _, rtt := range validRTTs {
-			variance += math.Pow(float64(rtt-stats.Average), 2)
+			variance += math.Pow(float64(rtt.value-stats.Average), 2) * h.weight(rtt.target)
 		}
-		std = math.Sqrt(variance / float64(cnt))
+		std = math.Sqrt(variance / weights)
 	}
 	stats.Deviation = time.Duration(std)
 	return stats
 }
 
+func (h *HealthPingRTTS) getDistribution(target int) *HealthPingDistribution {
+	dist := &HealthPingDistribution{}
+	var validRTTs []*pingRTT
+	all, fail := float64(0), float64(0)
+	h.valid(target, func(rtt *pingRTT) {
+		w := h.weight(rtt.target)
+		all += w
+		if rtt.value == rttFailed {
+			fail += w
+			return
+		}
+		validRTTs = append(validRTTs, rtt)
+	})
+	if all > 0 {
+		dist.Loss = fail / all
+	}
+	if len(validRTTs) == 0 {
+		return dist
+	}
+	sort.Slice(validRTTs, func(i, j int) bool {
+		return validRTTs[i].value < validRTTs[j].value
+	})
+	total := all - fail
+	percentile := func(p float64) time.Duration {
+		cumulative := float64(0)
+		for _, rtt := range validRTTs {
+			cumulative += h.weight(rtt.target)
+			if cumulative >= p*total {
+				return rtt.value
+			}
+		}
+		return validRTTs[len(validRTTs)-1].value
+	}
+	dist.P50 = percentile(0.5)
+	dist.P90 = percentile(0.9)
+	dist.P99 = percentile(0.99)
+	return dist
+}
+
 func (h *HealthPingRTTS) findOutdated(now time.Time) int {
 	for i := h.cap - 1; i < 2*h.cap; i++ {
 		// from oldest to latest
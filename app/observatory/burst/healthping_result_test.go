@@ -104,3 +104,40 @@ func TestHealthPingResultsIgnoreOutdated(t *testing.T) {
 		t.Errorf("expected: %v, actual: %v", expected, actual)
 	}
 }
+
+func TestHealthPingResultsDistribution(t *testing.T) {
+	rttFailed := time.Duration(math.MaxInt64)
+	hr := burst.NewHealthPingResult(20, time.Hour)
+	for i := 1; i <= 10; i++ {
+		hr.PutTarget(0, time.Duration(i*10))
+	}
+	for i := 1; i <= 8; i++ {
+		hr.PutTarget(1, time.Duration(i*100))
+	}
+	hr.PutTarget(1, rttFailed)
+	hr.PutTarget(1, rttFailed)
+
+	expected := &burst.HealthPingDistribution{
+		P50:  90,
+		P90:  700,
+		P99:  800,
+		Loss: 0.1,
+	}
+	if actual := hr.GetDistribution(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	stats, dist := hr.GetTarget(1)
+	if stats.All != 10 || stats.Fail != 2 || stats.Average != 450 {
+		t.Errorf("unexpected stats of target 1: %v", stats)
+	}
+	expected = &burst.HealthPingDistribution{
+		P50:  400,
+		P90:  800,
+		P99:  800,
+		Loss: 0.2,
+	}
+	if !reflect.DeepEqual(expected, dist) {
+		t.Errorf("expected: %v, actual: %v", expected, dist)
+	}
+}
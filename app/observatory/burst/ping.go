@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/features/routing"
 	"github.com/xtls/xray-core/transport/internet/tagged"
@@ -77,3 +78,31 @@ func (s *pingClient) MeasureDelay(httpMethod string) (time.Duration, error) {
 
 	return time.Since(start), nil
 }
+
+// MeasureThroughput downloads up to size bytes from dest, and returns the
+// speed in bytes per second
+func (s *pingClient) MeasureThroughput(size int64) (int64, error) {
+	if s.httpClient == nil {
+		panic("pingClient not initialized")
+	}
+
+	resp, err := s.httpClient.Get(s.destination)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, errors.New("unexpected status ", resp.Status)
+	}
+
+	start := time.Now()
+	n, err := io.CopyN(io.Discard, resp.Body, size)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	elapsed := time.Since(start)
+	if n == 0 || elapsed <= 0 {
+		return 0, errors.New("nothing downloaded")
+	}
+	return int64(float64(n) / elapsed.Seconds()), nil
+}
@@ -7,7 +7,10 @@ import (
 	"github.com/xtls/xray-core/common"
 	core "github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/features/extension"
+	"github.com/xtls/xray-core/features/outbound"
+	"github.com/xtls/xray-core/transport/internet/reality"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
 )
 
 type service struct {
@@ -15,6 +18,7 @@ type service struct {
 	v *core.Instance
 
 	observatory extension.Observatory
+	ohm         outbound.Manager
 }
 
 func (s *service) GetOutboundStatus(ctx context.Context, request *GetOutboundStatusRequest) (*GetOutboundStatusResponse, error) {
@@ -23,11 +27,46 @@ func (s *service) GetOutboundStatus(ctx context.Context, request *GetOutboundSta
 		return nil, err
 	}
 	retdata := resp.(*observatory.ObservationResult)
+	if s.ohm != nil {
+		status := make([]*observatory.OutboundStatus, 0, len(retdata.Status))
+		for _, v := range retdata.Status {
+			if health, ok := outbound.GetHealth(s.ohm, v.OutboundTag); ok {
+				v = proto.Clone(v).(*observatory.OutboundStatus)
+				v.PassiveHealth = observatory.PassiveHealth(health)
+			}
+			status = append(status, v)
+		}
+		retdata = &observatory.ObservationResult{Status: status}
+	}
 	return &GetOutboundStatusResponse{
 		Status: retdata,
 	}, nil
 }
 
+func (s *service) GetRealityTargetStatus(ctx context.Context, request *GetRealityTargetStatusRequest) (*GetRealityTargetStatusResponse, error) {
+	targets := reality.TargetStatuses()
+	status := make([]*RealityTargetStatus, len(targets))
+	for i, t := range targets {
+		status[i] = &RealityTargetStatus{
+			Dest:            t.Dest,
+			ServerNames:     t.ServerNames,
+			Alive:           t.Alive,
+			Active:          t.Active,
+			Delay:           t.Delay.Milliseconds(),
+			LastErrorReason: t.LastError,
+		}
+		if !t.LastSeen.IsZero() {
+			status[i].LastSeenTime = t.LastSeen.Unix()
+		}
+		if !t.LastTry.IsZero() {
+			status[i].LastTryTime = t.LastTry.Unix()
+		}
+	}
+	return &GetRealityTargetStatusResponse{
+		Status: status,
+	}, nil
+}
+
 func (s *service) Register(server *grpc.Server) {
 	RegisterObservatoryServiceServer(server, s)
 }
@@ -36,8 +75,9 @@ func init() {
 	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, cfg interface{}) (interface{}, error) {
 		s := core.MustFromContext(ctx)
 		sv := &service{v: s}
-		err := s.RequireFeatures(func(Observatory extension.Observatory) {
+		err := s.RequireFeatures(func(Observatory extension.Observatory, ohm outbound.Manager) {
 			sv.observatory = Observatory
+			sv.ohm = ohm
 		}, false)
 		if err != nil {
 			return nil, err
@@ -19,7 +19,8 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ObservatoryService_GetOutboundStatus_FullMethodName = "/xray.core.app.observatory.command.ObservatoryService/GetOutboundStatus"
+	ObservatoryService_GetOutboundStatus_FullMethodName      = "/xray.core.app.observatory.command.ObservatoryService/GetOutboundStatus"
+	ObservatoryService_GetRealityTargetStatus_FullMethodName = "/xray.core.app.observatory.command.ObservatoryService/GetRealityTargetStatus"
 )
 
 // ObservatoryServiceClient is the client API for ObservatoryService service.
@@ -27,6 +28,7 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ObservatoryServiceClient interface {
 	GetOutboundStatus(ctx context.Context, in *GetOutboundStatusRequest, opts ...grpc.CallOption) (*GetOutboundStatusResponse, error)
+	GetRealityTargetStatus(ctx context.Context, in *GetRealityTargetStatusRequest, opts ...grpc.CallOption) (*GetRealityTargetStatusResponse, error)
 }
 
 type observatoryServiceClient struct {
@@ -47,11 +49,22 @@ func (c *observatoryServiceClient) GetOutboundStatus(ctx context.Context, in *Ge
 	return out, nil
 }
 
+func (c *observatoryServiceClient) GetRealityTargetStatus(ctx context.Context, in *GetRealityTargetStatusRequest, opts ...grpc.CallOption) (*GetRealityTargetStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRealityTargetStatusResponse)
+	err := c.cc.Invoke(ctx, ObservatoryService_GetRealityTargetStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ObservatoryServiceServer is the server API for ObservatoryService service.
 // All implementations must embed UnimplementedObservatoryServiceServer
 // for forward compatibility.
 type ObservatoryServiceServer interface {
 	GetOutboundStatus(context.Context, *GetOutboundStatusRequest) (*GetOutboundStatusResponse, error)
+	GetRealityTargetStatus(context.Context, *GetRealityTargetStatusRequest) (*GetRealityTargetStatusResponse, error)
 	mustEmbedUnimplementedObservatoryServiceServer()
 }
 
@@ -65,6 +78,9 @@ type UnimplementedObservatoryServiceServer struct{}
 func (UnimplementedObservatoryServiceServer) GetOutboundStatus(context.Context, *GetOutboundStatusRequest) (*GetOutboundStatusResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetOutboundStatus not implemented")
 }
+func (UnimplementedObservatoryServiceServer) GetRealityTargetStatus(context.Context, *GetRealityTargetStatusRequest) (*GetRealityTargetStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRealityTargetStatus not implemented")
+}
 func (UnimplementedObservatoryServiceServer) mustEmbedUnimplementedObservatoryServiceServer() {}
 func (UnimplementedObservatoryServiceServer) testEmbeddedByValue()                            {}
 
@@ -104,6 +120,24 @@ func _ObservatoryService_GetOutboundStatus_Handler(srv interface{}, ctx context.
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ObservatoryService_GetRealityTargetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRealityTargetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ObservatoryServiceServer).GetRealityTargetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ObservatoryService_GetRealityTargetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ObservatoryServiceServer).GetRealityTargetStatus(ctx, req.(*GetRealityTargetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ObservatoryService_ServiceDesc is the grpc.ServiceDesc for ObservatoryService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -115,6 +149,10 @@ var ObservatoryService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetOutboundStatus",
 			Handler:    _ObservatoryService_GetOutboundStatus_Handler,
 		},
+		{
+			MethodName: "GetRealityTargetStatus",
+			Handler:    _ObservatoryService_GetRealityTargetStatus_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "app/observatory/command/command.proto",
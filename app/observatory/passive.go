@@ -0,0 +1,23 @@
+package observatory
+
+import (
+	"github.com/xtls/xray-core/features/outbound"
+)
+
+// PassiveHealth converts the health an outbound has tracked from real
+// traffic.
+func PassiveHealth(s outbound.HealthStatus) *PassiveHealthResult {
+	r := &PassiveHealthResult{
+		Success:          int64(s.Successes),
+		Fail:             int64(s.Failures),
+		DialFail:         int64(s.DialFailures),
+		HandshakeTimeout: int64(s.HandshakeTimeouts),
+		ConsecutiveFail:  int64(s.ConsecutiveFailures),
+		FirstByteLatency: int64(s.FirstByteLatency),
+		LastErrorReason:  s.LastError,
+	}
+	if !s.Available() {
+		r.OpenUntil = s.OpenUntil.Unix()
+	}
+	return r
+}
@@ -58,6 +58,11 @@ func (p *Policy) overrideWith(another *Policy) {
 			Connection: another.Buffer.Connection,
 		}
 	}
+	if another.Mux != nil {
+		p.Mux = &Policy_Mux{
+			Priority: another.Mux.Priority,
+		}
+	}
 }
 
 // ToCorePolicy converts this Policy to policy.Session.
@@ -78,6 +83,9 @@ func (p *Policy) ToCorePolicy() policy.Session {
 	if p.Buffer != nil {
 		cp.Buffer.PerConnection = p.Buffer.Connection
 	}
+	if p.Mux != nil {
+		cp.Mux.Priority = p.Mux.Priority
+	}
 	return cp
 }
 
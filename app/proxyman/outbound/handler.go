@@ -8,6 +8,7 @@ import (
 	"math/big"
 	gonet "net"
 	"os"
+	"time"
 
 	"github.com/xtls/xray-core/common/dice"
 
@@ -71,6 +72,8 @@ type Handler struct {
 	udp443          string
 	uplinkCounter   stats.Counter
 	downlinkCounter stats.Counter
+	health          healthTracker
+	direct          bool
 }
 
 // NewHandler creates a new Handler based on the given configuration.
@@ -82,6 +85,7 @@ func NewHandler(ctx context.Context, config *core.OutboundHandlerConfig) (outbou
 		outboundManager: v.GetFeature(outbound.ManagerType()).(outbound.Manager),
 		uplinkCounter:   uplinkCounter,
 		downlinkCounter: downlinkCounter,
+		health:          healthTracker{tag: config.Tag},
 	}
 
 	if config.SenderSettings != nil {
@@ -109,6 +113,9 @@ func NewHandler(ctx context.Context, config *core.OutboundHandlerConfig) (outbou
 	h.proxyConfig = proxyConfig
 
 	ctx = session.ContextWithFullHandler(ctx, h)
+	if h.streamSettings != nil {
+		ctx = internet.ContextWithStreamSettings(ctx, h.streamSettings)
+	}
 
 	rawProxyHandler, err := common.CreateObject(ctx, proxyConfig)
 	if err != nil {
@@ -134,10 +141,11 @@ func NewHandler(ctx context.Context, config *core.OutboundHandlerConfig) (outbou
 					Picker: &mux.IncrementalWorkerPicker{
 						Factory: &mux.DialingWorkerFactory{
 							Proxy:  proxyHandler,
-							Dialer: h,
+							Dialer: muxDialer{h},
 							Strategy: mux.ClientStrategy{
 								MaxConcurrency: uint32(config.Concurrency),
 								MaxConnection:  128,
+								FlowControl:    config.FlowControl,
 							},
 						},
 					},
@@ -155,10 +163,11 @@ func NewHandler(ctx context.Context, config *core.OutboundHandlerConfig) (outbou
 					Picker: &mux.IncrementalWorkerPicker{
 						Factory: &mux.DialingWorkerFactory{
 							Proxy:  proxyHandler,
-							Dialer: h,
+							Dialer: muxDialer{h},
 							Strategy: mux.ClientStrategy{
 								MaxConcurrency: uint32(config.XudpConcurrency),
 								MaxConnection:  128,
+								FlowControl:    config.FlowControl,
 							},
 						},
 					},
@@ -169,9 +178,15 @@ func NewHandler(ctx context.Context, config *core.OutboundHandlerConfig) (outbou
 	}
 
 	h.proxy = proxyHandler
+	_, h.direct = proxyHandler.(proxy.DirectOutbound)
 	return h, nil
 }
 
+// Health implements outbound.HealthReporter.
+func (h *Handler) Health() outbound.HealthStatus {
+	return h.health.get()
+}
+
 // Tag implements outbound.Handler.
 func (h *Handler) Tag() string {
 	return h.tag
@@ -240,7 +255,8 @@ func (h *Handler) Dispatch(ctx context.Context, link *transport.Link) {
 		}
 	}
 out:
-	err := h.proxy.Process(ctx, link, h)
+	dial := &dialState{tracker: &h.health, start: time.Now()}
+	err := h.proxy.Process(context.WithValue(ctx, dialStateKey, dial), link, h)
 	var errC error
 	if err != nil {
 		errC = errors.Cause(err)
@@ -248,6 +264,9 @@ out:
 			err = nil
 		}
 	}
+	if err != nil && !dial.failed && !dial.responded.Load() && !h.direct && isTransportError(err) {
+		h.health.failed(err)
+	}
 	if err != nil {
 		// Ensure outbound ray is properly closed.
 		err := errors.New("failed to process outbound traffic").Base(err)
@@ -315,13 +334,41 @@ func (h *Handler) Dial(ctx context.Context, dest net.Destination) (stat.Connecti
 	}
 
 	conn, err := internet.Dial(ctx, dest, h.streamSettings)
-	conn = h.getStatCouterConnection(conn)
+	dial, _ := ctx.Value(dialStateKey).(*dialState)
+	// the target of a direct outbound being down says nothing about it
+	if err != nil && !h.direct {
+		h.health.dialFailed(err)
+		if dial != nil {
+			dial.failed = true
+		}
+	}
+	if err == nil && dial != nil {
+		conn = &stat.CounterConnection{
+			Connection:   conn,
+			ReadCounter:  &responseCounter{counter: h.downlinkCounter, state: dial},
+			WriteCounter: h.uplinkCounter,
+		}
+	} else {
+		conn = h.getStatCouterConnection(conn)
+	}
 	outbounds := session.OutboundsFromContext(ctx)
 	ob := outbounds[len(outbounds)-1]
 	ob.Conn = conn
 	return conn, err
 }
 
+// muxDialer dials the connections of mux workers. They are not dialed by
+// Dispatch, so each one gets a dialState of its own to report its first
+// response to the health tracker.
+type muxDialer struct {
+	*Handler
+}
+
+func (d muxDialer) Dial(ctx context.Context, dest net.Destination) (stat.Connection, error) {
+	dial := &dialState{tracker: &d.health, start: time.Now()}
+	return d.Handler.Dial(context.WithValue(ctx, dialStateKey, dial), dest)
+}
+
 func (h *Handler) SetOutboundGateway(ctx context.Context, ob *session.Outbound) {
 	if ob.Gateway == nil && h.senderSettings != nil && h.senderSettings.Via != nil && !h.senderSettings.ProxySettings.HasTag() && (h.streamSettings.SocketSettings == nil || len(h.streamSettings.SocketSettings.DialerProxy) == 0) {
 		var domain string
@@ -372,6 +419,9 @@ func (h *Handler) GetOutbound() proxy.Outbound {
 
 // Start implements common.Runnable.
 func (h *Handler) Start() error {
+	if r, ok := h.proxy.(common.Runnable); ok {
+		return r.Start()
+	}
 	return nil
 }
 
@@ -3,6 +3,7 @@ package outbound_test
 import (
 	"context"
 	"fmt"
+	"io"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -12,17 +13,24 @@ import (
 	"github.com/xtls/xray-core/app/proxyman"
 	. "github.com/xtls/xray-core/app/proxyman/outbound"
 	"github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
 	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/common/session"
 	core "github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/features/outbound"
 	"github.com/xtls/xray-core/proxy/freedom"
+	"github.com/xtls/xray-core/proxy/socks"
+	"github.com/xtls/xray-core/transport"
 	"github.com/xtls/xray-core/transport/internet/stat"
+	_ "github.com/xtls/xray-core/transport/internet/tcp"
+	"github.com/xtls/xray-core/transport/pipe"
 )
 
 func TestInterfaces(t *testing.T) {
 	_ = (outbound.Handler)(new(Handler))
+	_ = (outbound.HealthReporter)(new(Handler))
 	_ = (outbound.Manager)(new(Manager))
 }
 
@@ -87,6 +95,129 @@ func TestOutboundWithStatCounter(t *testing.T) {
 	}
 }
 
+func TestOutboundCircuitBreaker(t *testing.T) {
+	v, _ := core.New(&core.Config{})
+	v.AddFeature((outbound.Manager)(new(Manager)))
+	ctx := context.WithValue(context.Background(), xrayKey, v)
+	ctx = session.ContextWithOutbounds(ctx, []*session.Outbound{{}})
+	// nothing listens on the port
+	dest := net.TCPDestination(net.LocalHostIP, net.Port(1))
+	h, _ := NewHandler(ctx, &core.OutboundHandlerConfig{
+		Tag: "tag",
+		ProxySettings: serial.ToTypedMessage(&socks.ClientConfig{
+			Server: &protocol.ServerEndpoint{
+				Address: net.NewIPOrDomain(dest.Address),
+				Port:    uint32(dest.Port),
+			},
+		}),
+	})
+
+	for i := 1; i <= 5; i++ {
+		if _, err := h.(*Handler).Dial(ctx, dest); err == nil {
+			t.Fatal("expected dial to fail")
+		}
+		health := h.(outbound.HealthReporter).Health()
+		if health.DialFailures != uint64(i) || health.ConsecutiveFailures != uint64(i) {
+			t.Fatal("unexpected health after ", i, " dials: ", health)
+		}
+		if health.Available() != (i < 5) {
+			t.Error("circuit breaker is not open after ", i, " failures")
+		}
+	}
+}
+
+func TestMuxOutboundHealth(t *testing.T) {
+	v, _ := core.New(&core.Config{})
+	v.AddFeature((outbound.Manager)(new(Manager)))
+	ctx := context.WithValue(context.Background(), xrayKey, v)
+	ctx = session.ContextWithOutbounds(ctx, []*session.Outbound{{}})
+
+	// a server that answers the SOCKS greeting and keeps the connection open
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.Write([]byte{5, 0})
+				io.Copy(io.Discard, conn)
+			}()
+		}
+	}()
+	server := net.DestinationFromAddr(listener.Addr())
+
+	h, err := NewHandler(ctx, &core.OutboundHandlerConfig{
+		Tag: "tag",
+		SenderSettings: serial.ToTypedMessage(&proxyman.SenderConfig{
+			MultiplexSettings: &proxyman.MultiplexingConfig{
+				Enabled:     true,
+				Concurrency: 8,
+			},
+		}),
+		ProxySettings: serial.ToTypedMessage(&socks.ClientConfig{
+			Server: &protocol.ServerEndpoint{
+				Address: net.NewIPOrDomain(server.Address),
+				Port:    uint32(server.Port),
+			},
+		}),
+	})
+	common.Must(err)
+
+	// nothing listens on the port
+	for range 4 {
+		if _, err := h.(*Handler).Dial(ctx, net.TCPDestination(net.LocalHostIP, net.Port(1))); err == nil {
+			t.Fatal("expected dial to fail")
+		}
+	}
+
+	uplinkReader, uplinkWriter := pipe.New()
+	downlinkReader, downlinkWriter := pipe.New()
+	defer uplinkWriter.Close()
+	defer downlinkReader.Interrupt()
+	dispatchCtx := session.ContextWithOutbounds(ctx, []*session.Outbound{{
+		Target: net.TCPDestination(net.DomainAddress("example.com"), 443),
+	}})
+	h.Dispatch(dispatchCtx, &transport.Link{Reader: uplinkReader, Writer: downlinkWriter})
+
+	for range 50 {
+		if health := h.(outbound.HealthReporter).Health(); health.Successes > 0 {
+			if health.ConsecutiveFailures != 0 || health.FirstByteLatency == 0 {
+				t.Error("unexpected health after the mux connection responded: ", health)
+			}
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Error("response of the mux connection not recorded: ", h.(outbound.HealthReporter).Health())
+}
+
+func TestDirectOutboundNotBrokenByTarget(t *testing.T) {
+	v, _ := core.New(&core.Config{})
+	v.AddFeature((outbound.Manager)(new(Manager)))
+	ctx := context.WithValue(context.Background(), xrayKey, v)
+	ctx = session.ContextWithOutbounds(ctx, []*session.Outbound{{}})
+	h, _ := NewHandler(ctx, &core.OutboundHandlerConfig{
+		Tag:           "tag",
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+	})
+
+	// the target is down, not the outbound
+	dest := net.TCPDestination(net.LocalHostIP, net.Port(1))
+	for range 5 {
+		if _, err := h.(*Handler).Dial(ctx, dest); err == nil {
+			t.Fatal("expected dial to fail")
+		}
+	}
+	if health := h.(outbound.HealthReporter).Health(); health.DialFailures != 0 || !health.Available() {
+		t.Error("failures of the target are charged to a direct outbound: ", health)
+	}
+}
+
 func TestTagsCache(t *testing.T) {
 
 	test_duration := 10 * time.Second
@@ -0,0 +1,153 @@
+package outbound
+
+import (
+	"context"
+	goerrors "errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/features/outbound"
+	"github.com/xtls/xray-core/features/stats"
+)
+
+const (
+	// consecutive failures that open the circuit breaker
+	breakerThreshold = 5
+	// how long the circuit breaker stays open, doubled while the outbound
+	// keeps failing after that
+	breakerCooldown    = 10 * time.Second
+	breakerMaxCooldown = 5 * time.Minute
+)
+
+// healthTracker records the health of an outbound from the connections it
+// dispatches, and opens a circuit breaker after consecutive failures. Once
+// the cooldown passes, the next connection decides whether the breaker
+// closes or opens again for twice as long.
+type healthTracker struct {
+	sync.Mutex
+	tag      string
+	status   outbound.HealthStatus
+	cooldown time.Duration
+}
+
+func (t *healthTracker) get() outbound.HealthStatus {
+	t.Lock()
+	defer t.Unlock()
+	return t.status
+}
+
+func (t *healthTracker) succeeded(latency time.Duration) {
+	t.Lock()
+	defer t.Unlock()
+	t.status.Successes++
+	t.status.ConsecutiveFailures = 0
+	t.status.OpenUntil = time.Time{}
+	t.cooldown = 0
+	if t.status.FirstByteLatency == 0 {
+		t.status.FirstByteLatency = latency
+	} else {
+		t.status.FirstByteLatency += (latency - t.status.FirstByteLatency) / 8
+	}
+}
+
+func (t *healthTracker) failed(err error) {
+	t.Lock()
+	defer t.Unlock()
+	t.status.Failures++
+	if isTimeout(err) {
+		t.status.HandshakeTimeouts++
+	}
+	t.failedLocked(err)
+}
+
+func (t *healthTracker) dialFailed(err error) {
+	t.Lock()
+	defer t.Unlock()
+	t.status.DialFailures++
+	t.failedLocked(err)
+}
+
+func (t *healthTracker) failedLocked(err error) {
+	t.status.ConsecutiveFailures++
+	t.status.LastError = err.Error()
+	if t.status.ConsecutiveFailures < breakerThreshold || !t.status.Available() {
+		return
+	}
+	if t.cooldown == 0 {
+		t.cooldown = breakerCooldown
+	} else {
+		t.cooldown = min(t.cooldown*2, breakerMaxCooldown)
+	}
+	t.status.OpenUntil = time.Now().Add(t.cooldown)
+	errors.LogWarning(context.Background(), "circuit breaker of [", t.tag, "] opened for ", t.cooldown, " after ", t.status.ConsecutiveFailures, " consecutive failures: ", t.status.LastError)
+}
+
+type dialStateKeyType int
+
+const dialStateKey dialStateKeyType = 0
+
+// dialState is shared by Dispatch and the dials of the connection it
+// processes.
+type dialState struct {
+	tracker *healthTracker
+	start   time.Time
+	// failed tells Dispatch that a failure was already recorded by Dial.
+	failed    bool
+	responded atomic.Bool
+}
+
+func isTimeout(err error) bool {
+	err = errors.Cause(err)
+	if goerrors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return goerrors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isTransportError tells whether err comes from a connection, rather than
+// from the proxy protocol or the link to the inbound.
+func isTransportError(err error) bool {
+	err = errors.Cause(err)
+	if goerrors.Is(err, context.DeadlineExceeded) || goerrors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return goerrors.As(err, &netErr)
+}
+
+// responseCounter reports the first bytes read from an outbound connection to
+// the tracker. It is the read counter of the connection, so that bytes copied
+// by splice are seen too.
+type responseCounter struct {
+	counter stats.Counter
+	state   *dialState
+}
+
+func (c *responseCounter) Value() int64 {
+	if c.counter == nil {
+		return 0
+	}
+	return c.counter.Value()
+}
+
+func (c *responseCounter) Set(v int64) int64 {
+	if c.counter == nil {
+		return 0
+	}
+	return c.counter.Set(v)
+}
+
+func (c *responseCounter) Add(delta int64) int64 {
+	if delta > 0 && !c.state.responded.Load() && c.state.responded.CompareAndSwap(false, true) {
+		c.state.tracker.succeeded(time.Since(c.state.start))
+	}
+	if c.counter == nil {
+		return 0
+	}
+	return c.counter.Add(delta)
+}
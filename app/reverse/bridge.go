@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/xtls/xray-core/app/dispatcher"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/mux"
 	"github.com/xtls/xray-core/common/net"
@@ -22,6 +24,7 @@ type Bridge struct {
 	dispatcher  routing.Dispatcher
 	tag         string
 	domain      string
+	identity    *Control
 	workers     []*BridgeWorker
 	monitorTask *task.Periodic
 }
@@ -40,6 +43,13 @@ func NewBridge(config *BridgeConfig, dispatcher routing.Dispatcher) (*Bridge, er
 		tag:        config.Tag,
 		domain:     config.Domain,
 	}
+	if config.Id != "" {
+		b.identity = &Control{
+			Bridge: config.Id,
+			Labels: config.Labels,
+			Weight: config.Weight,
+		}
+	}
 	b.monitorTask = &task.Periodic{
 		Execute:  b.monitor,
 		Interval: time.Second * 2,
@@ -80,7 +90,7 @@ func (b *Bridge) monitor() error {
 	}
 
 	if numWorker == 0 || numConnections/numWorker > 16 {
-		worker, err := NewBridgeWorker(b.domain, b.tag, b.dispatcher)
+		worker, err := newBridgeWorker(b.domain, b.tag, b.identity, b.dispatcher)
 		if err != nil {
 			errors.LogWarningInner(context.Background(), err, "failed to create bridge worker")
 			return nil
@@ -105,9 +115,15 @@ type BridgeWorker struct {
 	Dispatcher routing.Dispatcher
 	State      Control_State
 	Timer      *signal.ActivityTimer
+	// Identity is sent to the portal on the control connection, if not nil.
+	Identity *Control
 }
 
 func NewBridgeWorker(domain string, tag string, d routing.Dispatcher) (*BridgeWorker, error) {
+	return newBridgeWorker(domain, tag, nil, d)
+}
+
+func newBridgeWorker(domain string, tag string, identity *Control, d routing.Dispatcher) (*BridgeWorker, error) {
 	ctx := context.Background()
 	ctx = session.ContextWithInbound(ctx, &session.Inbound{
 		Tag: tag,
@@ -124,6 +140,7 @@ func NewBridgeWorker(domain string, tag string, d routing.Dispatcher) (*BridgeWo
 	w := &BridgeWorker{
 		Dispatcher: d,
 		Tag:        tag,
+		Identity:   identity,
 	}
 
 	worker, err := mux.NewServerWorker(context.Background(), w, link)
@@ -164,6 +181,13 @@ func (w *BridgeWorker) Connections() uint32 {
 }
 
 func (w *BridgeWorker) handleInternalConn(link *transport.Link) {
+	if w.Identity != nil {
+		b, err := proto.Marshal(w.Identity)
+		common.Must(err)
+		if err := link.Writer.WriteMultiBuffer(buf.MergeBytes(nil, b)); err != nil {
+			errors.LogInfoInner(context.Background(), err, "failed to send bridge identity")
+		}
+	}
 	reader := link.Reader
 	for {
 		mb, err := reader.ReadMultiBuffer()
@@ -0,0 +1,49 @@
+package command
+
+import (
+	"context"
+
+	"github.com/xtls/xray-core/app/reverse"
+	"github.com/xtls/xray-core/common"
+	core "github.com/xtls/xray-core/core"
+	"google.golang.org/grpc"
+)
+
+type service struct {
+	UnimplementedReverseServiceServer
+	reverse *reverse.Reverse
+}
+
+func (s *service) ListBridges(ctx context.Context, request *ListBridgesRequest) (*ListBridgesResponse, error) {
+	resp := &ListBridgesResponse{}
+	for _, b := range s.reverse.ListBridges(request.PortalTag) {
+		resp.Bridges = append(resp.Bridges, &BridgeInfo{
+			Portal:      b.Portal,
+			Id:          b.ID,
+			OutboundTag: b.OutboundTag,
+			Labels:      b.Labels,
+			Weight:      b.Weight,
+			Workers:     b.Workers,
+			Connections: b.Connections,
+		})
+	}
+	return resp, nil
+}
+
+func (s *service) Register(server *grpc.Server) {
+	RegisterReverseServiceServer(server, s)
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, cfg interface{}) (interface{}, error) {
+		s := core.MustFromContext(ctx)
+		sv := &service{}
+		err := s.RequireFeatures(func(r *reverse.Reverse) {
+			sv.reverse = r
+		}, false)
+		if err != nil {
+			return nil, err
+		}
+		return sv, nil
+	}))
+}
@@ -2,6 +2,7 @@ package reverse
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
@@ -21,11 +22,18 @@ import (
 )
 
 type Portal struct {
+	ctx    context.Context
 	ohm    outbound.Manager
 	tag    string
 	domain string
 	picker *StaticMuxPicker
 	client *mux.ClientManager
+
+	access  sync.Mutex
+	bridges map[string]*Outbound
+	allowed map[string]string
+
+	udpListeners []*udpListener
 }
 
 func NewPortal(config *PortalConfig, ohm outbound.Manager) (*Portal, error) {
@@ -42,7 +50,8 @@ func NewPortal(config *PortalConfig, ohm outbound.Manager) (*Portal, error) {
 		return nil, err
 	}
 
-	return &Portal{
+	p := &Portal{
+		ctx:    context.Background(),
 		ohm:    ohm,
 		tag:    config.Tag,
 		domain: config.Domain,
@@ -50,21 +59,122 @@ func NewPortal(config *PortalConfig, ohm outbound.Manager) (*Portal, error) {
 		client: &mux.ClientManager{
 			Picker: picker,
 		},
-	}, nil
+		bridges: make(map[string]*Outbound),
+		allowed: config.Bridges,
+	}
+	picker.onBridge = p.addBridge
+	picker.onBridgeGone = p.removeBridge
+
+	for _, lConfig := range config.UdpListener {
+		l, err := newUDPListener(p, lConfig)
+		if err != nil {
+			return nil, err
+		}
+		p.udpListeners = append(p.udpListeners, l)
+	}
+	return p, nil
+}
+
+// BridgeTag returns the tag of the outbound that sends connections only to
+// the bridge with the given id.
+func (p *Portal) BridgeTag(id string) string {
+	return p.tag + "@" + id
+}
+
+// authorizer returns whether a bridge connected as the inbound user of ctx
+// may register an id.
+func (p *Portal) authorizer(ctx context.Context) func(id string) bool {
+	var email string
+	if inbound := session.InboundFromContext(ctx); inbound != nil && inbound.User != nil {
+		email = inbound.User.Email
+	}
+	return func(id string) bool {
+		user, found := p.allowed[id]
+		return found && (user == "" || strings.EqualFold(user, email))
+	}
+}
+
+func (p *Portal) addBridge(id string) {
+	p.access.Lock()
+	defer p.access.Unlock()
+
+	if _, found := p.bridges[id]; found {
+		return
+	}
+	o := &Outbound{
+		portal: p,
+		tag:    p.BridgeTag(id),
+		client: &mux.ClientManager{
+			Picker: &bridgePicker{picker: p.picker, bridge: id},
+		},
+	}
+	if err := p.ohm.AddHandler(context.Background(), o); err != nil {
+		errors.LogWarningInner(context.Background(), err, "failed to add outbound for bridge ", id)
+		return
+	}
+	p.bridges[id] = o
+	errors.LogInfo(context.Background(), "bridge ", id, " connected to portal ", p.tag)
+}
+
+func (p *Portal) removeBridge(id string) {
+	p.access.Lock()
+	defer p.access.Unlock()
+
+	o, found := p.bridges[id]
+	if !found || p.picker.hasBridge(id) {
+		return
+	}
+	delete(p.bridges, id)
+	p.ohm.RemoveHandler(context.Background(), o.tag)
+	errors.LogInfo(context.Background(), "bridge ", id, " disconnected from portal ", p.tag)
 }
 
 func (p *Portal) Start() error {
-	return p.ohm.AddHandler(context.Background(), &Outbound{
+	if err := p.ohm.AddHandler(context.Background(), &Outbound{
 		portal: p,
 		tag:    p.tag,
-	})
+		client: p.client,
+	}); err != nil {
+		return err
+	}
+	for _, l := range p.udpListeners {
+		if err := l.Start(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (p *Portal) Close() error {
+	for _, l := range p.udpListeners {
+		l.Close()
+	}
+	p.access.Lock()
+	for id, o := range p.bridges {
+		p.ohm.RemoveHandler(context.Background(), o.tag)
+		delete(p.bridges, id)
+	}
+	p.access.Unlock()
 	return p.ohm.RemoveHandler(context.Background(), p.tag)
 }
 
+// Bridges returns the bridges connected to the portal.
+func (p *Portal) Bridges() []*BridgeStatus {
+	bridges := p.picker.Bridges()
+	for _, b := range bridges {
+		b.Portal = p.tag
+		if b.ID != "" {
+			b.OutboundTag = p.BridgeTag(b.ID)
+		}
+	}
+	return bridges
+}
+
 func (p *Portal) HandleConnection(ctx context.Context, link *transport.Link) error {
+	return p.handleConnection(ctx, link, p.client)
+}
+
+func (p *Portal) handleConnection(ctx context.Context, link *transport.Link, client *mux.ClientManager) error {
 	outbounds := session.OutboundsFromContext(ctx)
 	ob := outbounds[len(outbounds)-1]
 	if ob == nil {
@@ -77,7 +187,7 @@ func (p *Portal) HandleConnection(ctx context.Context, link *transport.Link) err
 			return errors.New("failed to create mux client worker").Base(err).AtWarning()
 		}
 
-		worker, err := NewPortalWorker(muxClient)
+		worker, err := NewPortalWorker(muxClient, p.authorizer(ctx))
 		if err != nil {
 			return errors.New("failed to create portal worker").Base(err)
 		}
@@ -98,12 +208,13 @@ func (p *Portal) HandleConnection(ctx context.Context, link *transport.Link) err
 		link.Writer = &buf.EndpointOverrideWriter{Writer: link.Writer, Dest: ob.Target.Address, OriginalDest: ob.OriginalTarget.Address}
 	}
 
-	return p.client.Dispatch(ctx, link)
+	return client.Dispatch(ctx, link)
 }
 
 type Outbound struct {
 	portal *Portal
 	tag    string
+	client *mux.ClientManager
 }
 
 func (o *Outbound) Tag() string {
@@ -111,7 +222,7 @@ func (o *Outbound) Tag() string {
 }
 
 func (o *Outbound) Dispatch(ctx context.Context, link *transport.Link) {
-	if err := o.portal.HandleConnection(ctx, link); err != nil {
+	if err := o.portal.handleConnection(ctx, link, o.client); err != nil {
 		errors.LogInfoInner(ctx, err, "failed to process reverse connection")
 		common.Interrupt(link.Writer)
 		common.Interrupt(link.Reader)
@@ -136,10 +247,37 @@ func (o *Outbound) ProxySettings() *serial.TypedMessage {
 	return nil
 }
 
+// bridgePicker picks the workers of one bridge only.
+type bridgePicker struct {
+	picker *StaticMuxPicker
+	bridge string
+}
+
+func (p *bridgePicker) PickAvailable() (*mux.ClientWorker, error) {
+	return p.picker.pick(p.bridge)
+}
+
+// BridgeStatus describes a bridge connected to a portal.
+type BridgeStatus struct {
+	Portal string
+	// ID is empty for the bridges that didn't send an identity.
+	ID          string
+	OutboundTag string
+	Labels      map[string]string
+	Weight      uint32
+	Workers     uint32
+	Connections uint32
+}
+
 type StaticMuxPicker struct {
 	access  sync.Mutex
 	workers []*PortalWorker
 	cTask   *task.Periodic
+
+	// called without the lock held, when a bridge identifies itself and
+	// after the last worker of a bridge is cleaned up
+	onBridge     func(id string)
+	onBridgeGone func(id string)
 }
 
 func NewStaticMuxPicker() (*StaticMuxPicker, error) {
@@ -154,25 +292,56 @@ func NewStaticMuxPicker() (*StaticMuxPicker, error) {
 
 func (p *StaticMuxPicker) cleanup() error {
 	p.access.Lock()
-	defer p.access.Unlock()
 
 	var activeWorkers []*PortalWorker
+	var gone []string
 	for _, w := range p.workers {
 		if !w.Closed() {
 			activeWorkers = append(activeWorkers, w)
 		} else {
 			w.timer.SetTimeout(0)
+			if id := w.Bridge(); id != "" {
+				gone = append(gone, id)
+			}
 		}
 	}
 
 	if len(activeWorkers) != len(p.workers) {
 		p.workers = activeWorkers
 	}
+	p.access.Unlock()
+
+	if p.onBridgeGone != nil {
+		for _, id := range gone {
+			if !p.hasBridge(id) {
+				p.onBridgeGone(id)
+			}
+		}
+	}
 
 	return nil
 }
 
+func (p *StaticMuxPicker) hasBridge(id string) bool {
+	p.access.Lock()
+	defer p.access.Unlock()
+
+	for _, w := range p.workers {
+		if !w.Closed() && w.Bridge() == id {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *StaticMuxPicker) PickAvailable() (*mux.ClientWorker, error) {
+	return p.pick("")
+}
+
+// pick picks a worker of the given bridge, or of any bridge if it is empty.
+// Bridges share connections by weight, and a bridge sends them to its worker
+// with the fewest connections.
+func (p *StaticMuxPicker) pick(bridge string) (*mux.ClientWorker, error) {
 	p.access.Lock()
 	defer p.access.Unlock()
 
@@ -180,45 +349,101 @@ func (p *StaticMuxPicker) PickAvailable() (*mux.ClientWorker, error) {
 		return nil, errors.New("empty worker list")
 	}
 
-	var minIdx int = -1
-	var minConn uint32 = 9999
-	for i, w := range p.workers {
-		if w.draining {
+	if w := p.pickFrom(bridge, false); w != nil {
+		return w.client, nil
+	}
+	if w := p.pickFrom(bridge, true); w != nil {
+		return w.client, nil
+	}
+
+	return nil, errors.New("no mux client worker available")
+}
+
+func (p *StaticMuxPicker) pickFrom(bridge string, draining bool) *PortalWorker {
+	type group struct {
+		weight      uint32
+		connections uint32
+		best        *PortalWorker
+	}
+	groups := make(map[string]*group)
+	var order []string
+	for _, w := range p.workers {
+		if w.draining && !draining {
 			continue
 		}
 		if w.IsFull() {
 			continue
 		}
-		if w.client.ActiveConnections() < minConn {
-			minConn = w.client.ActiveConnections()
-			minIdx = i
+		id, _, weight := w.identity()
+		if bridge != "" && id != bridge {
+			continue
+		}
+		g, found := groups[id]
+		if !found {
+			g = &group{weight: weight}
+			groups[id] = g
+			order = append(order, id)
+		}
+		conns := w.client.ActiveConnections()
+		g.connections += conns
+		if g.best == nil || conns < g.best.client.ActiveConnections() {
+			g.best = w
 		}
 	}
 
-	if minIdx == -1 {
-		for i, w := range p.workers {
-			if w.IsFull() {
-				continue
-			}
-			if w.client.ActiveConnections() < minConn {
-				minConn = w.client.ActiveConnections()
-				minIdx = i
-			}
+	var picked *group
+	for _, id := range order {
+		g := groups[id]
+		// compares (connections+1)/weight of the groups
+		if picked == nil || uint64(g.connections+1)*uint64(picked.weight) < uint64(picked.connections+1)*uint64(g.weight) {
+			picked = g
 		}
 	}
-
-	if minIdx != -1 {
-		return p.workers[minIdx].client, nil
+	if picked == nil {
+		return nil
 	}
-
-	return nil, errors.New("no mux client worker available")
+	return picked.best
 }
 
 func (p *StaticMuxPicker) AddWorker(worker *PortalWorker) {
+	p.access.Lock()
+	p.workers = append(p.workers, worker)
+	p.access.Unlock()
+
+	worker.onIdentity(func(id string) {
+		if p.onBridge != nil {
+			p.onBridge(id)
+		}
+	})
+}
+
+// Bridges returns the bridges of the workers that are not closed, the ones
+// without identity grouped together.
+func (p *StaticMuxPicker) Bridges() []*BridgeStatus {
 	p.access.Lock()
 	defer p.access.Unlock()
 
-	p.workers = append(p.workers, worker)
+	var bridges []*BridgeStatus
+	index := make(map[string]*BridgeStatus)
+	for _, w := range p.workers {
+		if w.Closed() {
+			continue
+		}
+		id, labels, weight := w.identity()
+		b, found := index[id]
+		if !found {
+			b = &BridgeStatus{
+				ID:     id,
+				Labels: labels,
+				Weight: weight,
+			}
+			index[id] = b
+			bridges = append(bridges, b)
+		}
+		b.Workers++
+		b.Connections += w.client.ActiveConnections()
+	}
+	return bridges
 }
 
 type PortalWorker struct {
@@ -229,9 +454,19 @@ type PortalWorker struct {
 	draining bool
 	counter  uint32
 	timer    *signal.ActivityTimer
+
+	access     sync.Mutex
+	authorize  func(id string) bool
+	bridge     string
+	labels     map[string]string
+	weight     uint32
+	identified func(id string)
 }
 
-func NewPortalWorker(client *mux.ClientWorker) (*PortalWorker, error) {
+// NewPortalWorker creates a worker on the control connection of a bridge.
+// The bridge may register an id if authorize accepts it; with a nil
+// authorize, it stays anonymous.
+func NewPortalWorker(client *mux.ClientWorker, authorize func(id string) bool) (*PortalWorker, error) {
 	opt := []pipe.Option{pipe.WithSizeLimit(16 * 1024)}
 	uplinkReader, uplinkWriter := pipe.New(opt...)
 	downlinkReader, downlinkWriter := pipe.New(opt...)
@@ -252,19 +487,94 @@ func NewPortalWorker(client *mux.ClientWorker) (*PortalWorker, error) {
 		client.Close()
 	}
 	w := &PortalWorker{
-		client: client,
-		reader: downlinkReader,
-		writer: uplinkWriter,
-		timer:  signal.CancelAfterInactivity(ctx, terminate, 24*time.Hour), // // prevent leak
+		client:    client,
+		reader:    downlinkReader,
+		writer:    uplinkWriter,
+		timer:     signal.CancelAfterInactivity(ctx, terminate, 24*time.Hour), // // prevent leak
+		weight:    1,
+		authorize: authorize,
 	}
 	w.control = &task.Periodic{
 		Execute:  w.heartbeat,
 		Interval: time.Second * 2,
 	}
 	w.control.Start()
+	go w.readIdentity(downlinkReader)
 	return w, nil
 }
 
+// readIdentity reads the identity that the bridge sends on the control
+// connection. Older bridges send nothing and stay anonymous. The identity is
+// fixed by the first message that carries one.
+func (w *PortalWorker) readIdentity(reader buf.Reader) {
+	for {
+		mb, err := reader.ReadMultiBuffer()
+		if err != nil {
+			return
+		}
+		for _, b := range mb {
+			var ctl Control
+			if err := proto.Unmarshal(b.Bytes(), &ctl); err != nil {
+				errors.LogInfoInner(context.Background(), err, "failed to parse bridge identity")
+				continue
+			}
+			if ctl.Bridge == "" {
+				continue
+			}
+			w.access.Lock()
+			if w.bridge != "" {
+				if ctl.Bridge != w.bridge {
+					errors.LogWarning(context.Background(), "bridge ", w.bridge, " tried to change its id to ", ctl.Bridge)
+				}
+				w.access.Unlock()
+				continue
+			}
+			if w.authorize == nil || !w.authorize(ctl.Bridge) {
+				w.authorize = nil
+				w.access.Unlock()
+				errors.LogWarning(context.Background(), "bridge is not allowed to register id ", ctl.Bridge)
+				continue
+			}
+			w.bridge = ctl.Bridge
+			w.labels = ctl.Labels
+			if ctl.Weight > 0 {
+				w.weight = ctl.Weight
+			}
+			identified := w.identified
+			w.access.Unlock()
+			if identified != nil {
+				identified(ctl.Bridge)
+			}
+		}
+		buf.ReleaseMulti(mb)
+	}
+}
+
+// onIdentity calls f once the worker knows the identity of its bridge.
+func (w *PortalWorker) onIdentity(f func(id string)) {
+	w.access.Lock()
+	w.identified = f
+	bridge := w.bridge
+	w.access.Unlock()
+	if bridge != "" {
+		f(bridge)
+	}
+}
+
+func (w *PortalWorker) identity() (string, map[string]string, uint32) {
+	w.access.Lock()
+	defer w.access.Unlock()
+	return w.bridge, w.labels, w.weight
+}
+
+// Bridge returns the id of the bridge of the worker, or empty if the bridge
+// didn't send one.
+func (w *PortalWorker) Bridge() string {
+	w.access.Lock()
+	defer w.access.Unlock()
+	return w.bridge
+}
+
 func (w *PortalWorker) heartbeat() error {
 	if w.Closed() {
 		return errors.New("client worker stopped")
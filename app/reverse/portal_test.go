@@ -1,10 +1,15 @@
 package reverse_test
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/xtls/xray-core/app/reverse"
 	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/mux"
+	"github.com/xtls/xray-core/transport"
+	"github.com/xtls/xray-core/transport/pipe"
 )
 
 func TestStaticPickerEmpty(t *testing.T) {
@@ -18,3 +23,107 @@ func TestStaticPickerEmpty(t *testing.T) {
 		t.Error("expected nil worker, but not nil")
 	}
 }
+
+// allowed authorizes the ids of the bridges in the tests.
+func allowed(id string) bool {
+	return id == "light" || id == "heavy"
+}
+
+func newBridgedWorker(t *testing.T, identity *reverse.Control) (*reverse.PortalWorker, *mux.ClientWorker) {
+	uplinkReader, uplinkWriter := pipe.New()
+	downlinkReader, downlinkWriter := pipe.New()
+
+	bridge := &reverse.BridgeWorker{
+		Identity: identity,
+	}
+	server, err := mux.NewServerWorker(context.Background(), bridge, &transport.Link{
+		Reader: uplinkReader,
+		Writer: downlinkWriter,
+	})
+	common.Must(err)
+	bridge.Worker = server
+
+	client, err := mux.NewClientWorker(transport.Link{
+		Reader: downlinkReader,
+		Writer: uplinkWriter,
+	}, mux.ClientStrategy{})
+	common.Must(err)
+	worker, err := reverse.NewPortalWorker(client, allowed)
+	common.Must(err)
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return worker, client
+}
+
+func TestStaticPickerBridges(t *testing.T) {
+	picker, err := reverse.NewStaticMuxPicker()
+	common.Must(err)
+
+	anonymous, _ := newBridgedWorker(t, nil)
+	light, _ := newBridgedWorker(t, &reverse.Control{Bridge: "light", Weight: 1})
+	heavy, heavyClient := newBridgedWorker(t, &reverse.Control{
+		Bridge: "heavy",
+		Labels: map[string]string{"site": "hq"},
+		Weight: 3,
+	})
+	picker.AddWorker(anonymous)
+	picker.AddWorker(light)
+	picker.AddWorker(heavy)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for light.Bridge() != "light" || heavy.Bridge() != "heavy" {
+		if time.Now().After(deadline) {
+			t.Fatal("bridges didn't identify")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	bridges := picker.Bridges()
+	if len(bridges) != 3 {
+		t.Fatal("expected 3 bridges, got ", len(bridges))
+	}
+	if b := bridges[2]; b.ID != "heavy" || b.Weight != 3 || b.Labels["site"] != "hq" || b.Workers != 1 {
+		t.Error("unexpected bridge: ", b)
+	}
+	if b := bridges[0]; b.ID != "" || b.Weight != 1 {
+		t.Error("unexpected anonymous bridge: ", b)
+	}
+
+	// without connections, the heaviest bridge is picked
+	client, err := picker.PickAvailable()
+	common.Must(err)
+	if client != heavyClient {
+		t.Error("expected worker of heavy bridge")
+	}
+}
+
+func TestPortalWorkerRejectsIdentity(t *testing.T) {
+	picker, err := reverse.NewStaticMuxPicker()
+	common.Must(err)
+
+	intruder, _ := newBridgedWorker(t, &reverse.Control{Bridge: "intruder", Weight: 100})
+	light, _ := newBridgedWorker(t, &reverse.Control{Bridge: "light"})
+	picker.AddWorker(intruder)
+	picker.AddWorker(light)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for light.Bridge() != "light" {
+		if time.Now().After(deadline) {
+			t.Fatal("bridge didn't identify")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// both bridges sent their identity at the same time
+	time.Sleep(100 * time.Millisecond)
+
+	if id := intruder.Bridge(); id != "" {
+		t.Error("registered id that is not allowed: ", id)
+	}
+	for _, b := range picker.Bridges() {
+		if b.ID == "" && b.Weight != 1 {
+			t.Error("weight of an unauthorized bridge is used: ", b.Weight)
+		}
+	}
+}
@@ -25,7 +25,7 @@ func isInternalDomain(dest net.Destination) bool {
 
 func init() {
 	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
-		r := new(Reverse)
+		r := &Reverse{ctx: ctx}
 		if err := core.RequireFeatures(ctx, func(d routing.Dispatcher, om outbound.Manager) error {
 			return r.Init(config.(*Config), d, om)
 		}); err != nil {
@@ -36,6 +36,7 @@ func init() {
 }
 
 type Reverse struct {
+	ctx     context.Context
 	bridges []*Bridge
 	portals []*Portal
 }
@@ -54,12 +55,27 @@ func (r *Reverse) Init(config *Config, d routing.Dispatcher, ohm outbound.Manage
 		if err != nil {
 			return err
 		}
+		if r.ctx != nil {
+			p.ctx = r.ctx
+		}
 		r.portals = append(r.portals, p)
 	}
 
 	return nil
 }
 
+// ListBridges returns the bridges connected to the portals, of the portal with
+// the given tag if it is not empty.
+func (r *Reverse) ListBridges(portalTag string) []*BridgeStatus {
+	var bridges []*BridgeStatus
+	for _, p := range r.portals {
+		if portalTag == "" || p.tag == portalTag {
+			bridges = append(bridges, p.Bridges()...)
+		}
+	}
+	return bridges
+}
+
 func (r *Reverse) Type() interface{} {
 	return (*Reverse)(nil)
 }
@@ -0,0 +1,182 @@
+package reverse
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/mux"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/signal"
+	"github.com/xtls/xray-core/common/xudp"
+	"github.com/xtls/xray-core/transport"
+	udp_proto "github.com/xtls/xray-core/transport/internet/udp"
+	"github.com/xtls/xray-core/transport/pipe"
+)
+
+const defaultUDPIdleTimeout = 5 * time.Minute
+
+// udpListener publishes a UDP service behind the bridges on the portal.
+//
+// Every client address is a session of its own, carried over mux with a
+// Global ID derived from the listener and the client address. The bridge
+// keeps the socket of a Global ID across mux connections, so the service
+// keeps seeing the same source for a client, and the replies from any address
+// on the bridge side reach the client.
+type udpListener struct {
+	portal  *Portal
+	listen  net.Destination
+	target  net.Destination
+	client  *mux.ClientManager
+	timeout time.Duration
+
+	hub      *udp_proto.Hub
+	access   sync.Mutex
+	sessions map[net.Destination]*udpSession
+}
+
+type udpSession struct {
+	source net.Destination
+	writer buf.Writer
+	timer  *signal.ActivityTimer
+}
+
+func newUDPListener(p *Portal, config *UDPListener) (*udpListener, error) {
+	if config.Port == 0 || config.Port > 65535 {
+		return nil, errors.New("invalid UDP listener port: ", config.Port)
+	}
+	if config.Address == nil || config.TargetPort == 0 || config.TargetPort > 65535 {
+		return nil, errors.New("UDP listener on port ", config.Port, " has no valid target")
+	}
+	l := &udpListener{
+		portal:   p,
+		listen:   net.UDPDestination(net.AnyIP, net.Port(config.Port)),
+		target:   net.UDPDestination(config.Address.AsAddress(), net.Port(config.TargetPort)),
+		client:   p.client,
+		timeout:  time.Duration(config.IdleTimeout) * time.Second,
+		sessions: make(map[net.Destination]*udpSession),
+	}
+	if config.Listen != nil {
+		l.listen.Address = config.Listen.AsAddress()
+	}
+	if config.Bridge != "" {
+		l.client = &mux.ClientManager{
+			Picker: &bridgePicker{picker: p.picker, bridge: config.Bridge},
+		}
+	}
+	if l.timeout == 0 {
+		l.timeout = defaultUDPIdleTimeout
+	}
+	return l, nil
+}
+
+func (l *udpListener) Start() error {
+	hub, err := udp_proto.ListenUDP(l.portal.ctx, l.listen.Address, l.listen.Port, nil)
+	if err != nil {
+		return errors.New("failed to listen UDP on ", l.listen).Base(err)
+	}
+	l.hub = hub
+	go l.serve()
+	return nil
+}
+
+func (l *udpListener) Close() error {
+	if l.hub == nil {
+		return nil
+	}
+	return l.hub.Close()
+}
+
+func (l *udpListener) serve() {
+	for packet := range l.hub.Receive() {
+		s, err := l.session(packet.Source)
+		if err != nil {
+			errors.LogInfoInner(l.portal.ctx, err, "failed to publish UDP packet from ", packet.Source)
+			packet.Payload.Release()
+			continue
+		}
+		s.timer.Update()
+		if err := s.writer.WriteMultiBuffer(buf.MultiBuffer{packet.Payload}); err != nil {
+			errors.LogInfoInner(l.portal.ctx, err, "failed to publish UDP packet from ", packet.Source)
+		}
+	}
+
+	l.access.Lock()
+	sessions := make([]*udpSession, 0, len(l.sessions))
+	for _, s := range l.sessions {
+		sessions = append(sessions, s)
+	}
+	l.access.Unlock()
+	for _, s := range sessions {
+		s.timer.SetTimeout(0)
+	}
+}
+
+func (l *udpListener) session(source net.Destination) (*udpSession, error) {
+	l.access.Lock()
+	defer l.access.Unlock()
+
+	if s, found := l.sessions[source]; found {
+		return s, nil
+	}
+
+	ctx := session.ContextWithInbound(l.portal.ctx, &session.Inbound{
+		Source: source,
+		Local:  l.listen,
+		Tag:    l.portal.tag,
+	})
+	ctx = session.ContextWithOutbounds(ctx, []*session.Outbound{{
+		Target:         l.target,
+		OriginalTarget: l.target,
+		Tag:            l.portal.tag,
+	}})
+	ctx = xudp.ContextWithGlobalID(ctx, xudp.NewGlobalID(l.listen.NetAddr()+"|"+source.String()))
+	ctx, cancel := context.WithCancel(ctx)
+
+	opts := pipe.OptionsFromContext(ctx)
+	uplinkReader, uplinkWriter := pipe.New(opts...)
+	downlinkReader, downlinkWriter := pipe.New(opts...)
+	if err := l.client.Dispatch(ctx, &transport.Link{Reader: uplinkReader, Writer: downlinkWriter}); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s := &udpSession{
+		source: source,
+		writer: uplinkWriter,
+	}
+	s.timer = signal.CancelAfterInactivity(ctx, func() {
+		cancel()
+		common.Interrupt(uplinkWriter)
+		common.Interrupt(downlinkReader)
+		l.access.Lock()
+		if l.sessions[source] == s {
+			delete(l.sessions, source)
+		}
+		l.access.Unlock()
+	}, l.timeout)
+	l.sessions[source] = s
+	errors.LogInfo(ctx, "new UDP session from ", source, " to ", l.target)
+
+	go func() {
+		defer s.timer.SetTimeout(0)
+		for {
+			mb, err := downlinkReader.ReadMultiBuffer()
+			if err != nil {
+				return
+			}
+			s.timer.Update()
+			for _, b := range mb {
+				if _, err := l.hub.WriteTo(b.Bytes(), source); err != nil {
+					errors.LogInfoInner(ctx, err, "failed to write UDP packet to ", source)
+				}
+			}
+			buf.ReleaseMulti(mb)
+		}
+	}()
+	return s, nil
+}
@@ -10,12 +10,19 @@ import (
 	"github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/features/extension"
 	"github.com/xtls/xray-core/features/outbound"
+	"github.com/xtls/xray-core/features/routing"
 )
 
 type BalancingStrategy interface {
 	PickOutbound([]string) string
 }
 
+// BalancingContextStrategy is a BalancingStrategy that picks outbounds by
+// the routing context of the connection.
+type BalancingContextStrategy interface {
+	PickOutboundFor(routing.Context, []string) string
+}
+
 type BalancingPrincipleTarget interface {
 	GetPrincipleTarget([]string) []string
 }
@@ -92,7 +99,7 @@ type Balancer struct {
 }
 
 // PickOutbound picks the tag of a outbound
-func (b *Balancer) PickOutbound() (string, error) {
+func (b *Balancer) PickOutbound(ctx routing.Context) (string, error) {
 	candidates, err := b.SelectOutbounds()
 	if err != nil {
 		if b.fallbackTag != "" {
@@ -104,6 +111,8 @@ func (b *Balancer) PickOutbound() (string, error) {
 	var tag string
 	if o := b.override.Get(); o != "" {
 		tag = o
+	} else if s, ok := b.strategy.(BalancingContextStrategy); ok {
+		tag = s.PickOutboundFor(ctx, candidates)
 	} else {
 		tag = b.strategy.PickOutbound(candidates)
 	}
@@ -149,6 +158,34 @@ func (r *Router) GetPrincipleTarget(tag string) ([]string, error) {
 	return nil, errors.New("cannot find tag")
 }
 
+// GetBalancerHealth implements routing.BalancerHealth
+func (r *Router) GetBalancerHealth(tag string) (map[string]outbound.HealthStatus, error) {
+	if b, ok := r.balancers[tag]; ok {
+		candidates, err := b.SelectOutbounds()
+		if err != nil {
+			return nil, errors.New("unable to select outbounds").Base(err)
+		}
+		ret := make(map[string]outbound.HealthStatus, len(candidates))
+		for _, candidate := range candidates {
+			if health, ok := outbound.GetHealth(b.ohm, candidate); ok {
+				ret[candidate] = health
+			}
+		}
+		return ret, nil
+	}
+	return nil, errors.New("cannot find tag")
+}
+
+// circuitOpen reports whether the circuit breaker of the outbound is open
+// due to failures of its real traffic.
+func circuitOpen(ohm outbound.Manager, tag string) bool {
+	if ohm == nil {
+		return false
+	}
+	health, ok := outbound.GetHealth(ohm, tag)
+	return ok && !health.Available()
+}
+
 // SetOverrideTarget implements routing.BalancerOverrider
 func (r *Router) SetOverrideTarget(tag, target string) error {
 	if b, ok := r.balancers[tag]; ok {
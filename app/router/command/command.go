@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/xtls/xray-core/app/observatory"
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/core"
@@ -43,6 +44,18 @@ func (s *routingServer) GetBalancerInfo(ctx context.Context, request *GetBalance
 			}
 		}
 	}
+
+	if bh, ok := s.router.(routing.BalancerHealth); ok {
+		res, err := bh.GetBalancerHealth(request.GetTag())
+		if err != nil {
+			errors.LogInfoInner(ctx, err, "unable to obtain passive health")
+		} else if len(res) > 0 {
+			ret.Balancer.PassiveHealth = make(map[string]*observatory.PassiveHealthResult, len(res))
+			for tag, health := range res {
+				ret.Balancer.PassiveHealth[tag] = observatory.PassiveHealth(health)
+			}
+		}
+	}
 	return &ret, nil
 }
 
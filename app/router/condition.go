@@ -2,6 +2,7 @@ package router
 
 import (
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/xtls/xray-core/common/errors"
@@ -266,6 +267,22 @@ func (v *InboundTagMatcher) Apply(ctx routing.Context) bool {
 	return false
 }
 
+type SniProfileMatcher struct {
+	profiles []string
+}
+
+func NewSniProfileMatcher(profiles []string) *SniProfileMatcher {
+	return &SniProfileMatcher{
+		profiles: slices.DeleteFunc(slices.Clone(profiles), func(p string) bool { return p == "" }),
+	}
+}
+
+// Apply implements Condition.
+func (v *SniProfileMatcher) Apply(ctx routing.Context) bool {
+	profile := ctx.GetSniProfile()
+	return len(profile) > 0 && slices.Contains(v.profiles, profile)
+}
+
 type ProtocolMatcher struct {
 	protocols []string
 }
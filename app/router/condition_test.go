@@ -202,6 +202,25 @@ func TestRoutingRule(t *testing.T) {
 				},
 			},
 		},
+		{
+			rule: &RoutingRule{
+				SniProfile: []string{"admin"},
+			},
+			test: []ruleTest{
+				{
+					input:  withInbound(&session.Inbound{SniProfile: "admin"}),
+					output: true,
+				},
+				{
+					input:  withInbound(&session.Inbound{SniProfile: "public"}),
+					output: false,
+				},
+				{
+					input:  withInbound(&session.Inbound{}),
+					output: false,
+				},
+			},
+		},
 		{
 			rule: &RoutingRule{
 				PortList: &net.PortList{
@@ -17,9 +17,9 @@ type Rule struct {
 	Condition Condition
 }
 
-func (r *Rule) GetTag() (string, error) {
+func (r *Rule) GetTag(ctx routing.Context) (string, error) {
 	if r.Balancer != nil {
-		return r.Balancer.PickOutbound()
+		return r.Balancer.PickOutbound(ctx)
 	}
 	return r.Tag, nil
 }
@@ -53,6 +53,10 @@ func (rr *RoutingRule) BuildCondition() (Condition, error) {
 		conds.Add(NewInboundTagMatcher(rr.InboundTag))
 	}
 
+	if len(rr.SniProfile) > 0 {
+		conds.Add(NewSniProfileMatcher(rr.SniProfile))
+	}
+
 	if rr.PortList != nil {
 		conds.Add(NewPortMatcher(rr.PortList, "target"))
 	}
@@ -146,6 +150,21 @@ func (br *BalancingRule) Build(ohm outbound.Manager, dispatcher routing.Dispatch
 			fallbackTag: br.FallbackTag,
 			strategy:    leastLoadStrategy,
 		}, nil
+	case "consistenthash":
+		i, err := br.StrategySettings.GetInstance()
+		if err != nil {
+			return nil, err
+		}
+		s, ok := i.(*StrategyConsistentHashConfig)
+		if !ok {
+			return nil, errors.New("not a StrategyConsistentHashConfig").AtError()
+		}
+		return &Balancer{
+			selectors:   br.OutboundSelector,
+			ohm:         ohm,
+			fallbackTag: br.FallbackTag,
+			strategy:    NewConsistentHashStrategy(s),
+		}, nil
 	case "random":
 		fallthrough
 	case "":
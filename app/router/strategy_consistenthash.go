@@ -0,0 +1,198 @@
+package router
+
+import (
+	"cmp"
+	"context"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/xtls/xray-core/app/observatory"
+	"github.com/xtls/xray-core/common/dice"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/extension"
+	"github.com/xtls/xray-core/features/routing"
+	"golang.org/x/net/publicsuffix"
+)
+
+const defaultConsistentHashReplicas = 100
+
+// ConsistentHashStrategy sticks connections with the same key to the same
+// outbound. Keys are hashed onto a ring of the alive outbounds, so when an
+// outbound goes down only the keys it served move to other outbounds.
+type ConsistentHashStrategy struct {
+	key      StrategyConsistentHashConfig_Key
+	replicas int
+
+	ctx         context.Context
+	observatory extension.Observatory
+
+	mu    sync.Mutex
+	nodes string
+	ring  hashRing
+}
+
+// NewConsistentHashStrategy creates a new ConsistentHashStrategy with settings
+func NewConsistentHashStrategy(settings *StrategyConsistentHashConfig) *ConsistentHashStrategy {
+	s := &ConsistentHashStrategy{
+		key:      settings.GetKey(),
+		replicas: int(settings.GetReplicas()),
+	}
+	if s.replicas <= 0 {
+		s.replicas = defaultConsistentHashReplicas
+	}
+	return s
+}
+
+func (s *ConsistentHashStrategy) InjectContext(ctx context.Context) {
+	s.ctx = ctx
+	core.OptionalFeatures(s.ctx, func(observatory extension.Observatory) {
+		s.observatory = observatory
+	})
+}
+
+func (s *ConsistentHashStrategy) GetPrincipleTarget(strings []string) []string {
+	return s.alive(strings)
+}
+
+// PickOutbound picks a random alive outbound, as there is no key to hash.
+func (s *ConsistentHashStrategy) PickOutbound(candidates []string) string {
+	candidates = s.alive(candidates)
+	if len(candidates) == 0 {
+		return ""
+	}
+	return candidates[dice.Roll(len(candidates))]
+}
+
+// PickOutboundFor implements BalancingContextStrategy.
+func (s *ConsistentHashStrategy) PickOutboundFor(ctx routing.Context, candidates []string) string {
+	key := s.keyOf(ctx)
+	if key == "" {
+		return s.PickOutbound(candidates)
+	}
+	candidates = s.alive(candidates)
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	candidates = slices.Clone(candidates)
+	slices.Sort(candidates)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if nodes := strings.Join(candidates, "\n"); nodes != s.nodes {
+		s.nodes = nodes
+		s.ring = newHashRing(candidates, s.replicas)
+	}
+	return s.ring.get(key)
+}
+
+func (s *ConsistentHashStrategy) keyOf(ctx routing.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	switch s.key {
+	case StrategyConsistentHashConfig_User:
+		return ctx.GetUser()
+	case StrategyConsistentHashConfig_Domain:
+		domain := ctx.GetTargetDomain()
+		if domain == "" {
+			if ips := ctx.GetTargetIPs(); len(ips) > 0 {
+				return ips[0].String()
+			}
+			return ""
+		}
+		domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+		if registered, err := publicsuffix.EffectiveTLDPlusOne(domain); err == nil {
+			return registered
+		}
+		return domain
+	default:
+		if ips := ctx.GetSourceIPs(); len(ips) > 0 {
+			return ips[0].String()
+		}
+		return ""
+	}
+}
+
+// alive filters out the candidates the observatory reports as down.
+func (s *ConsistentHashStrategy) alive(candidates []string) []string {
+	if s.observatory == nil {
+		return candidates
+	}
+	observeReport, err := s.observatory.GetObservation(s.ctx)
+	if err != nil {
+		return candidates
+	}
+	result, ok := observeReport.(*observatory.ObservationResult)
+	if !ok {
+		return candidates
+	}
+	statusMap := make(map[string]*observatory.OutboundStatus)
+	for _, outboundStatus := range result.Status {
+		statusMap[outboundStatus.OutboundTag] = outboundStatus
+	}
+	aliveTags := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		// unfound candidate is considered alive
+		if outboundStatus, found := statusMap[candidate]; !found || outboundStatus.Alive {
+			aliveTags = append(aliveTags, candidate)
+		}
+	}
+	return aliveTags
+}
+
+type hashRingNode struct {
+	hash uint64
+	tag  string
+}
+
+// hashRing places several virtual nodes of each outbound on a ring of hashes.
+type hashRing []hashRingNode
+
+func newHashRing(tags []string, replicas int) hashRing {
+	ring := make(hashRing, 0, len(tags)*replicas)
+	for _, tag := range tags {
+		for i := 0; i < replicas; i++ {
+			ring = append(ring, hashRingNode{
+				hash: hashKey(tag + "#" + strconv.Itoa(i)),
+				tag:  tag,
+			})
+		}
+	}
+	slices.SortFunc(ring, func(a, b hashRingNode) int {
+		if c := cmp.Compare(a.hash, b.hash); c != 0 {
+			return c
+		}
+		return strings.Compare(a.tag, b.tag)
+	})
+	return ring
+}
+
+// get returns the outbound of the first virtual node at or after the hash of
+// key.
+func (r hashRing) get(key string) string {
+	h := hashKey(key)
+	i, _ := slices.BinarySearchFunc(r, h, func(n hashRingNode, h uint64) int {
+		return cmp.Compare(n.hash, h)
+	})
+	if i == len(r) {
+		i = 0
+	}
+	return r[i].tag
+}
+
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	// FNV spreads similar short keys poorly, finalize it like splitmix64
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
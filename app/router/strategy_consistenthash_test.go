@@ -0,0 +1,74 @@
+package router
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/features/routing"
+	routing_session "github.com/xtls/xray-core/features/routing/session"
+)
+
+func fromSource(ip string, port net.Port) routing.Context {
+	ctx := session.ContextWithInbound(context.Background(), &session.Inbound{
+		Source: net.TCPDestination(net.ParseAddress(ip), port),
+	})
+	ctx = session.ContextWithOutbounds(ctx, []*session.Outbound{{
+		Target: net.TCPDestination(net.DomainAddress("example.com"), 443),
+	}})
+	return routing_session.AsRoutingContext(ctx)
+}
+
+func TestConsistentHashSourceIP(t *testing.T) {
+	s := NewConsistentHashStrategy(&StrategyConsistentHashConfig{})
+	all := []string{"a", "b", "c", "d"}
+	picked := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		ip := "10.0." + strconv.Itoa(i/256) + "." + strconv.Itoa(i%256)
+		picked[ip] = s.PickOutboundFor(fromSource(ip, 1234), all)
+	}
+
+	count := make(map[string]int)
+	for _, tag := range picked {
+		count[tag]++
+	}
+	for _, tag := range all {
+		if count[tag] < 100 {
+			t.Error("outbound ", tag, " got only ", count[tag], " keys")
+		}
+	}
+
+	// "b" goes down
+	alive := []string{"d", "c", "a"}
+	for ip, tag := range picked {
+		newTag := s.PickOutboundFor(fromSource(ip, 4321), alive)
+		if tag != "b" && newTag != tag {
+			t.Error("key ", ip, " moved from ", tag, " to ", newTag)
+		}
+		if newTag == "b" {
+			t.Error("key ", ip, " picked the dead outbound")
+		}
+	}
+}
+
+func TestConsistentHashDomain(t *testing.T) {
+	s := NewConsistentHashStrategy(&StrategyConsistentHashConfig{
+		Key: StrategyConsistentHashConfig_Domain,
+	})
+	pick := func(domain string) string {
+		ctx := session.ContextWithOutbounds(context.Background(), []*session.Outbound{{
+			Target: net.TCPDestination(net.DomainAddress(domain), 443),
+		}})
+		return s.PickOutboundFor(routing_session.AsRoutingContext(ctx), []string{"a", "b", "c", "d", "e"})
+	}
+	for _, domain := range []string{"example.com", "example.co.uk", "example.github.io"} {
+		tag := pick(domain)
+		for _, sub := range []string{"www.", "api.", "a.b."} {
+			if got := pick(sub + domain); got != tag {
+				t.Error(sub+domain, " picked ", got, " while ", domain, " picked ", tag)
+			}
+		}
+	}
+}
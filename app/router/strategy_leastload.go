@@ -4,6 +4,7 @@ import (
 	"context"
 	"math"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/xtls/xray-core/app/observatory"
@@ -12,6 +13,7 @@ import (
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/features/extension"
+	"github.com/xtls/xray-core/features/outbound"
 )
 
 // LeastLoadStrategy represents a least load balancing strategy
@@ -20,6 +22,7 @@ type LeastLoadStrategy struct {
 	costs    *WeightManager
 
 	observer extension.Observatory
+	ohm      outbound.Manager
 
 	ctx context.Context
 }
@@ -60,8 +63,9 @@ type node struct {
 
 func (s *LeastLoadStrategy) InjectContext(ctx context.Context) {
 	s.ctx = ctx
-	common.Must(core.RequireFeatures(s.ctx, func(observatory extension.Observatory) error {
+	common.Must(core.RequireFeatures(s.ctx, func(observatory extension.Observatory, ohm outbound.Manager) error {
 		s.observer = observatory
+		s.ohm = ohm
 		return nil
 	}))
 }
@@ -156,7 +160,7 @@ func (s *LeastLoadStrategy) getNodes(candidates []string, maxRTT time.Duration)
 	var ret []*node
 
 	for _, v := range results.Status {
-		if v.Alive && (v.Delay < maxRTT.Milliseconds() || maxRTT == 0) && outboundlist.contains(v.OutboundTag) {
+		if v.Alive && (v.Delay < maxRTT.Milliseconds() || maxRTT == 0) && outboundlist.contains(v.OutboundTag) && !circuitOpen(s.ohm, v.OutboundTag) {
 			record := &node{
 				Tag:              v.OutboundTag,
 				CountAll:         1,
@@ -169,7 +173,7 @@ func (s *LeastLoadStrategy) getNodes(candidates []string, maxRTT time.Duration)
 			if v.HealthPing != nil {
 				record.RTTAverage = time.Duration(v.HealthPing.Average)
 				record.RTTDeviation = time.Duration(v.HealthPing.Deviation)
-				record.RTTDeviationCost = time.Duration(s.costs.Apply(v.OutboundTag, float64(v.HealthPing.Deviation)))
+				record.RTTDeviationCost = time.Duration(s.costs.Apply(v.OutboundTag, float64(s.rankingValue(v.HealthPing))))
 				record.CountAll = int(v.HealthPing.All)
 				record.CountFail = int(v.HealthPing.Fail)
 
@@ -182,6 +186,52 @@ func (s *LeastLoadStrategy) getNodes(candidates []string, maxRTT time.Duration)
 	return ret
 }
 
+// rankingValue returns the latency an outbound is ranked by, according to
+// the metric and the target in settings. Except for the default deviation,
+// it is inflated by the loss rate, as lost probes have to be retried.
+func (s *LeastLoadStrategy) rankingValue(hp *observatory.HealthPingMeasurementResult) time.Duration {
+	average, deviation := hp.Average, hp.Deviation
+	p50, p90, p99, loss := hp.P50, hp.P90, hp.P99, hp.Loss
+	if s.settings.Target != "" {
+		for _, t := range hp.Targets {
+			if t.Name == s.settings.Target {
+				average, deviation = t.Average, t.Deviation
+				p50, p90, p99 = t.P50, t.P90, t.P99
+				if t.All > 0 {
+					loss = float32(t.Fail) / float32(t.All)
+				}
+				break
+			}
+		}
+	}
+
+	var value int64
+	switch strings.ToLower(s.settings.Metric) {
+	case "", "deviation":
+		return time.Duration(deviation)
+	case "average":
+		value = average
+	case "p50":
+		value = p50
+	case "p90":
+		value = p90
+	case "p99":
+		value = p99
+	case "throughput":
+		// time to download 1MiB
+		if hp.Throughput <= 0 {
+			return time.Hour
+		}
+		value = int64(time.Second) * 1024 * 1024 / hp.Throughput
+	default:
+		return time.Duration(deviation)
+	}
+	if loss > 0 && loss < 1 {
+		value = int64(float64(value) / float64(1-loss))
+	}
+	return time.Duration(value)
+}
+
 func leastloadSort(nodes []*node) {
 	sort.Slice(nodes, func(i, j int) bool {
 		left := nodes[i]
@@ -2,6 +2,8 @@ package router
 
 import (
 	"testing"
+
+	"github.com/xtls/xray-core/app/observatory"
 )
 
 /*
@@ -177,3 +179,22 @@ func TestSelectLeastLoadBaselinesNoQualified(t *testing.T) {
 		t.Errorf("expected: %v, actual: %v", expected, len(ns))
 	}
 }
+
+func TestRankingValueOfTarget(t *testing.T) {
+	strategy := &LeastLoadStrategy{
+		settings: &StrategyLeastLoadConfig{
+			Target: "video",
+		},
+	}
+	hp := &observatory.HealthPingMeasurementResult{
+		Average:   100,
+		Deviation: 50,
+		Targets: []*observatory.HealthPingTargetResult{
+			{Name: "default", Average: 100, Deviation: 50},
+			{Name: "video", Average: 300, Deviation: 20},
+		},
+	}
+	if v := strategy.rankingValue(hp); v != 20 {
+		t.Errorf("expected: %v, actual: %v", 20, v)
+	}
+}
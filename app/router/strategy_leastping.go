@@ -8,11 +8,13 @@ import (
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/features/extension"
+	"github.com/xtls/xray-core/features/outbound"
 )
 
 type LeastPingStrategy struct {
 	ctx         context.Context
 	observatory extension.Observatory
+	ohm         outbound.Manager
 }
 
 func (l *LeastPingStrategy) GetPrincipleTarget(strings []string) []string {
@@ -21,8 +23,9 @@ func (l *LeastPingStrategy) GetPrincipleTarget(strings []string) []string {
 
 func (l *LeastPingStrategy) InjectContext(ctx context.Context) {
 	l.ctx = ctx
-	common.Must(core.RequireFeatures(l.ctx, func(observatory extension.Observatory) error {
+	common.Must(core.RequireFeatures(l.ctx, func(observatory extension.Observatory, ohm outbound.Manager) error {
 		l.observatory = observatory
+		l.ohm = ohm
 		return nil
 	}))
 }
@@ -43,7 +46,7 @@ func (l *LeastPingStrategy) PickOutbound(strings []string) string {
 		leastPing := int64(99999999)
 		selectedOutboundName := ""
 		for _, v := range status {
-			if outboundsList.contains(v.OutboundTag) && v.Alive && v.Delay < leastPing {
+			if outboundsList.contains(v.OutboundTag) && v.Alive && v.Delay < leastPing && !circuitOpen(l.ohm, v.OutboundTag) {
 				selectedOutboundName = v.OutboundTag
 				leastPing = v.Delay
 			}
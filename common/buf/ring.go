@@ -0,0 +1,60 @@
+package buf
+
+// Ring keeps the last bytes written to a stream, so that they can be sent
+// again from any offset that is still in it.
+type Ring struct {
+	ring    []byte
+	written uint64
+}
+
+// NewRing creates a Ring that keeps the last size bytes.
+func NewRing(size int) *Ring {
+	return &Ring{ring: make([]byte, size)}
+}
+
+// Written returns the number of bytes written so far.
+func (r *Ring) Written() uint64 {
+	return r.written
+}
+
+// Start returns the offset of the oldest byte in the ring.
+func (r *Ring) Start() uint64 {
+	if size := uint64(len(r.ring)); r.written > size {
+		return r.written - size
+	}
+	return 0
+}
+
+// Since returns a copy of the bytes written after offset, and false if some
+// of them are no longer in the ring.
+func (r *Ring) Since(offset uint64) ([]byte, bool) {
+	size := uint64(len(r.ring))
+	if offset > r.written || r.written-offset > size {
+		return nil, false
+	}
+	b := make([]byte, 0, r.written-offset)
+	for i := offset; i < r.written; {
+		start := i % size
+		end := min(size, start+(r.written-i))
+		b = append(b, r.ring[start:end]...)
+		i += end - start
+	}
+	return b, true
+}
+
+// Write implements io.Writer. It never fails.
+func (r *Ring) Write(b []byte) (int, error) {
+	n := len(b)
+	size := uint64(len(r.ring))
+	if uint64(len(b)) > size {
+		r.written += uint64(len(b)) - size
+		b = b[uint64(len(b))-size:]
+	}
+	for len(b) > 0 {
+		start := r.written % size
+		n := copy(r.ring[start:], b)
+		r.written += uint64(n)
+		b = b[n:]
+	}
+	return n, nil
+}
@@ -0,0 +1,28 @@
+package buf_test
+
+import (
+	"testing"
+
+	. "github.com/xtls/xray-core/common/buf"
+)
+
+func TestRing(t *testing.T) {
+	r := NewRing(8)
+	r.Write([]byte("abcde"))
+	if b, ok := r.Since(2); !ok || string(b) != "cde" {
+		t.Error("since 2: ", string(b), ok)
+	}
+	r.Write([]byte("fghijklmnopq"))
+	if r.Written() != 17 || r.Start() != 9 {
+		t.Error("written ", r.Written(), " start ", r.Start())
+	}
+	if b, ok := r.Since(r.Start()); !ok || string(b) != "jklmnopq" {
+		t.Error("since start: ", string(b), ok)
+	}
+	if _, ok := r.Since(8); ok {
+		t.Error("overwritten bytes returned")
+	}
+	if _, ok := r.Since(18); ok {
+		t.Error("unwritten offset accepted")
+	}
+}
@@ -171,11 +171,14 @@ func (f *DialingWorkerFactory) Create() (*ClientWorker, error) {
 type ClientStrategy struct {
 	MaxConcurrency uint32
 	MaxConnection  uint32
+	// FlowControl asks the server for per-session windows.
+	FlowControl bool
 }
 
 type ClientWorker struct {
 	sessionManager *SessionManager
 	link           transport.Link
+	scheduler      *scheduler
 	done           *done.Instance
 	timer          *time.Ticker
 	strategy       ClientStrategy
@@ -191,6 +194,7 @@ func NewClientWorker(stream transport.Link, s ClientStrategy) (*ClientWorker, er
 	c := &ClientWorker{
 		sessionManager: NewSessionManager(),
 		link:           stream,
+		scheduler:      newScheduler(stream.Writer),
 		done:           done.New(),
 		timer:          time.NewTicker(time.Second * 16),
 		strategy:       s,
@@ -256,7 +260,7 @@ func writeFirstPayload(reader buf.Reader, writer *Writer) error {
 	return nil
 }
 
-func fetchInput(ctx context.Context, s *Session, output buf.Writer) {
+func fetchInput(ctx context.Context, s *Session, sched *scheduler) {
 	outbounds := session.OutboundsFromContext(ctx)
 	ob := outbounds[len(outbounds)-1]
 	transferType := protocol.TransferTypeStream
@@ -264,11 +268,13 @@ func fetchInput(ctx context.Context, s *Session, output buf.Writer) {
 		transferType = protocol.TransferTypePacket
 	}
 	s.transferType = transferType
+	output := &sessionWriter{scheduler: sched, id: s.ID, weight: sessionPriority(ctx, transferType)}
 	var inbound *session.Inbound
 	if session.IsReverseMuxFromContext(ctx) {
 		inbound = session.InboundFromContext(ctx)
 	}
 	writer := NewWriter(s.ID, ob.Target, output, transferType, xudp.GetGlobalID(ctx), inbound)
+	writer.flow = s.flow
 	defer s.Close(false)
 	defer writer.Close()
 
@@ -320,7 +326,11 @@ func (m *ClientWorker) Dispatch(ctx context.Context, link *transport.Link) bool
 	}
 	s.input = link.Reader
 	s.output = link.Writer
-	go fetchInput(ctx, s, m.link.Writer)
+	if m.strategy.FlowControl {
+		s.flow = newFlowControl()
+		go s.drain(&sessionWriter{scheduler: m.scheduler, id: s.ID, weight: PriorityInteractive})
+	}
+	go fetchInput(ctx, s, m.scheduler)
 	if _, ok := link.Reader.(*pipe.Reader); !ok {
 		select {
 		case <-ctx.Done():
@@ -345,6 +355,12 @@ func (m *ClientWorker) handleStatusNew(meta *FrameMetadata, reader *buf.Buffered
 }
 
 func (m *ClientWorker) handleStatusKeep(meta *FrameMetadata, reader *buf.BufferedReader) error {
+	if meta.isWindow() {
+		if s, found := m.sessionManager.Get(meta.SessionID); found {
+			s.handleWindow(meta)
+		}
+		return nil
+	}
 	if !meta.Option.Has(OptionData) {
 		return nil
 	}
@@ -359,7 +375,7 @@ func (m *ClientWorker) handleStatusKeep(meta *FrameMetadata, reader *buf.Buffere
 	}
 
 	rr := s.NewReader(reader, &meta.Target)
-	err := buf.Copy(rr, s.output)
+	err := buf.Copy(rr, s.dataWriter())
 	if err != nil && buf.IsWriteError(err) {
 		errors.LogInfoInner(context.Background(), err, "failed to write to downstream. closing session ", s.ID)
 		s.Close(false)
@@ -371,7 +387,7 @@ func (m *ClientWorker) handleStatusKeep(meta *FrameMetadata, reader *buf.Buffere
 
 func (m *ClientWorker) handleStatusEnd(meta *FrameMetadata, reader *buf.BufferedReader) error {
 	if s, found := m.sessionManager.Get(meta.SessionID); found {
-		s.Close(false)
+		s.end()
 	}
 	if meta.Option.Has(OptionData) {
 		return buf.Copy(NewStreamReader(reader), buf.Discard)
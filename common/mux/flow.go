@@ -0,0 +1,185 @@
+package mux
+
+import (
+	"io"
+	"sync"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+)
+
+/*
+Flow control
+
+A client that sets OptionWindow on the New frame of a session accepts up to
+DefaultWindow bytes of data from the server for that session before the
+server waits for more credit. A server that supports it acknowledges with a
+window frame, after which the client is limited by the credit of the server
+as well. Old servers ignore the option and never send window frames, so the
+session works as without flow control.
+
+A window frame is a Keep frame with OptionWindow and without OptionData. Its
+metadata carries the total number of data bytes the receiver accepts for the
+session so far, as an 8 bytes big endian integer after the option byte.
+
+The receiver queues the data of a session instead of blocking the connection
+on a slow reader, and grants more credit as the queue drains.
+*/
+
+// DefaultWindow is the number of bytes a session may have in flight in each
+// direction when flow control is enabled.
+const DefaultWindow = 512 * 1024
+
+// maxQueued bounds what a receiver queues for a session, as some data may be
+// sent before the peer learns the window.
+const maxQueued = 4 * DefaultWindow
+
+var errWindowExceeded = errors.New("flow control window exceeded")
+
+// flowControl holds the windows of one session in both directions.
+type flowControl struct {
+	access sync.Mutex
+	cond   *sync.Cond
+	closed bool
+
+	// sending side
+	sent    uint64
+	limit   uint64
+	limited bool // whether the peer has granted a window yet
+
+	// receiving side
+	queue    buf.MultiBuffer
+	consumed uint64
+	granted  uint64
+	finished bool
+}
+
+func newFlowControl() *flowControl {
+	f := &flowControl{
+		granted: DefaultWindow,
+	}
+	f.cond = sync.NewCond(&f.access)
+	return f
+}
+
+// setLimit applies a window frame of the peer.
+func (f *flowControl) setLimit(limit uint64) {
+	f.access.Lock()
+	defer f.access.Unlock()
+	if !f.limited || limit > f.limit {
+		f.limit = limit
+		f.limited = true
+		f.cond.Broadcast()
+	}
+}
+
+// acquire waits until n more bytes fit into the window of the peer.
+func (f *flowControl) acquire(n int32) error {
+	f.access.Lock()
+	defer f.access.Unlock()
+	for !f.closed && f.limited && f.sent+uint64(n) > f.limit {
+		f.cond.Wait()
+	}
+	if f.closed {
+		return io.ErrClosedPipe
+	}
+	f.sent += uint64(n)
+	return nil
+}
+
+// peerLimited returns whether the peer takes part in flow control.
+func (f *flowControl) peerLimited() bool {
+	f.access.Lock()
+	defer f.access.Unlock()
+	return f.limited
+}
+
+// WriteMultiBuffer implements buf.Writer. It queues the data read from the
+// connection without blocking.
+func (f *flowControl) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	f.access.Lock()
+	defer f.access.Unlock()
+	if f.closed {
+		buf.ReleaseMulti(mb)
+		return io.ErrClosedPipe
+	}
+	if uint64(f.queue.Len())+uint64(mb.Len()) > maxQueued {
+		buf.ReleaseMulti(mb)
+		return errWindowExceeded
+	}
+	f.queue = append(f.queue, mb...)
+	f.cond.Broadcast()
+	return nil
+}
+
+// finish lets drain return once the queue is empty.
+func (f *flowControl) finish() {
+	f.access.Lock()
+	defer f.access.Unlock()
+	f.finished = true
+	f.cond.Broadcast()
+}
+
+func (f *flowControl) close() {
+	f.access.Lock()
+	defer f.access.Unlock()
+	f.closed = true
+	buf.ReleaseMulti(f.queue)
+	f.queue = nil
+	f.cond.Broadcast()
+}
+
+// drain writes the queued data to output, and calls grant with the new total
+// credit of the peer whenever half of the window has been consumed. It
+// returns once the session is finished or closed, or output fails.
+func (f *flowControl) drain(output buf.Writer, grant func(limit uint64) error) error {
+	for {
+		f.access.Lock()
+		for !f.closed && !f.finished && f.queue.IsEmpty() {
+			f.cond.Wait()
+		}
+		if f.closed {
+			f.access.Unlock()
+			return io.ErrClosedPipe
+		}
+		mb := f.queue
+		f.queue = nil
+		f.access.Unlock()
+
+		if mb.IsEmpty() {
+			return nil
+		}
+		n := uint64(mb.Len())
+		if err := output.WriteMultiBuffer(mb); err != nil {
+			return err
+		}
+
+		f.access.Lock()
+		f.consumed += n
+		limit := f.consumed + DefaultWindow
+		update := limit-f.granted >= DefaultWindow/2
+		if update {
+			f.granted = limit
+		}
+		f.access.Unlock()
+		if update {
+			if err := grant(limit); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// writeWindow writes a window frame granting limit bytes of session id.
+func writeWindow(writer buf.Writer, id uint16, limit uint64) error {
+	meta := FrameMetadata{
+		SessionID:     id,
+		SessionStatus: SessionStatusKeep,
+		Window:        limit,
+	}
+	meta.Option.Set(OptionWindow)
+	frame := buf.New()
+	common.Must(meta.WriteTo(frame))
+	return writer.WriteMultiBuffer(buf.MultiBuffer{frame})
+}
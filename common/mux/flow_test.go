@@ -0,0 +1,162 @@
+package mux_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/mux"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/transport"
+	"github.com/xtls/xray-core/transport/pipe"
+)
+
+// newSmallLinkPair is newLinkPair with pipes that block after 16KB, like a
+// reader that doesn't keep up.
+func newSmallLinkPair() (*transport.Link, *transport.Link) {
+	opt := pipe.WithSizeLimit(16 * 1024)
+	uplinkReader, uplinkWriter := pipe.New(opt)
+	downlinkReader, downlinkWriter := pipe.New(opt)
+	return &transport.Link{Reader: uplinkReader, Writer: downlinkWriter},
+		&transport.Link{Reader: downlinkReader, Writer: uplinkWriter}
+}
+
+// flowTestSetup connects a mux client with flow control to a mux server, and
+// returns the client and server ends of session "stalled.example.com" and
+// "alive.example.com".
+func flowTestSetup(t *testing.T) (stalledClient, stalledServer, aliveClient, aliveServer *transport.Link) {
+	stalledServerEnd, stalledTarget := newSmallLinkPair()
+	aliveServerEnd, aliveTarget := newLinkPair()
+	dispatcher := TestDispatcher{
+		OnDispatch: func(ctx context.Context, dest net.Destination) (*transport.Link, error) {
+			if dest.Address.Domain() == "stalled.example.com" {
+				return stalledTarget, nil
+			}
+			return aliveTarget, nil
+		},
+	}
+
+	muxServerUplink, muxServerDownlink := newLinkPair()
+	server, err := mux.NewServerWorker(context.Background(), &dispatcher, muxServerUplink)
+	common.Must(err)
+	client, err := mux.NewClientWorker(*muxServerDownlink, mux.ClientStrategy{FlowControl: true})
+	common.Must(err)
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	dispatch := func(domain string, link *transport.Link) {
+		ctx := session.ContextWithOutbounds(context.Background(), []*session.Outbound{{
+			Target: net.TCPDestination(net.DomainAddress(domain), 80),
+		}})
+		if !client.Dispatch(ctx, link) {
+			t.Fatal("failed to dispatch ", domain)
+		}
+	}
+
+	stalledLink, stalledClientEnd := newSmallLinkPair()
+	dispatch("stalled.example.com", stalledLink)
+	common.Must(stalledClientEnd.Writer.WriteMultiBuffer(buf.MultiBuffer{buf.FromBytes([]byte("stall"))}))
+	if mb, err := stalledServerEnd.Reader.ReadMultiBuffer(); err != nil || mb.String() != "stall" {
+		t.Fatal("unexpected upload: ", mb.String(), err)
+	}
+
+	aliveLink, aliveClientEnd := newLinkPair()
+	dispatch("alive.example.com", aliveLink)
+
+	return stalledClientEnd, stalledServerEnd, aliveClientEnd, aliveServerEnd
+}
+
+// flood writes size bytes to writer in the background.
+func flood(writer buf.Writer, size int) {
+	go func() {
+		for size > 0 {
+			b := buf.New()
+			b.Extend(min(buf.Size, int32(size)))
+			size -= int(b.Len())
+			if writer.WriteMultiBuffer(buf.MultiBuffer{b}) != nil {
+				return
+			}
+		}
+	}()
+}
+
+// pingPong checks that a message gets through from one end to the other in
+// time.
+func pingPong(t *testing.T, from buf.Writer, to buf.Reader, msg string) {
+	common.Must(from.WriteMultiBuffer(buf.MultiBuffer{buf.FromBytes([]byte(msg))}))
+	result := make(chan string, 1)
+	go func() {
+		mb, _ := to.ReadMultiBuffer()
+		result <- mb.String()
+	}()
+	select {
+	case got := <-result:
+		if got != msg {
+			t.Error("expected ", msg, ", got ", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("session stalled with the other one: ", msg)
+	}
+}
+
+// readSize reads size bytes from reader.
+func readSize(t *testing.T, reader buf.Reader, size int) {
+	for size > 0 {
+		mb, err := reader.(buf.TimeoutReader).ReadMultiBufferTimeout(5 * time.Second)
+		if err != nil {
+			t.Fatal("failed to read the remaining ", size, " bytes: ", err)
+		}
+		size -= int(mb.Len())
+		buf.ReleaseMulti(mb)
+	}
+}
+
+func TestFlowControlStalledDownload(t *testing.T) {
+	stalledClient, stalledServer, aliveClient, aliveServer := flowTestSetup(t)
+
+	// the client doesn't read the stalled session while the server floods it
+	const size = 4 * mux.DefaultWindow
+	flood(stalledServer.Writer, size)
+	time.Sleep(100 * time.Millisecond)
+
+	pingPong(t, aliveServer.Writer, aliveClient.Reader, "download")
+	pingPong(t, aliveClient.Writer, aliveServer.Reader, "upload")
+
+	readSize(t, stalledClient.Reader, size)
+}
+
+func TestFlowControlStalledUpload(t *testing.T) {
+	stalledClient, stalledServer, aliveClient, aliveServer := flowTestSetup(t)
+
+	// the server doesn't read the stalled session while the client floods it
+	const size = 4 * mux.DefaultWindow
+	flood(stalledClient.Writer, size)
+	time.Sleep(100 * time.Millisecond)
+
+	pingPong(t, aliveClient.Writer, aliveServer.Reader, "upload")
+	pingPong(t, aliveServer.Writer, aliveClient.Reader, "download")
+
+	readSize(t, stalledServer.Reader, size)
+}
+
+func TestFrameWindow(t *testing.T) {
+	meta := mux.FrameMetadata{
+		SessionID:     3,
+		SessionStatus: mux.SessionStatusKeep,
+		Window:        1 << 40,
+	}
+	meta.Option.Set(mux.OptionWindow)
+	b := buf.New()
+	common.Must(meta.WriteTo(b))
+
+	var got mux.FrameMetadata
+	common.Must(got.Unmarshal(b, false))
+	if got.SessionID != 3 || !got.Option.Has(mux.OptionWindow) || got.Window != 1<<40 {
+		t.Error("unexpected window frame: ", got)
+	}
+}
@@ -24,8 +24,9 @@ const (
 )
 
 const (
-	OptionData  bitmask.Byte = 0x01
-	OptionError bitmask.Byte = 0x02
+	OptionData   bitmask.Byte = 0x01
+	OptionError  bitmask.Byte = 0x02
+	OptionWindow bitmask.Byte = 0x04
 )
 
 type TargetNetwork byte
@@ -53,6 +54,8 @@ Frame format
 2 bytes - port
 n bytes - address
 
+Window frames carry 8 bytes of window after the option instead.
+
 */
 
 type FrameMetadata struct {
@@ -62,6 +65,11 @@ type FrameMetadata struct {
 	SessionStatus SessionStatus
 	GlobalID      [8]byte
 	Inbound       *session.Inbound
+	Window        uint64
+}
+
+func (f FrameMetadata) isWindow() bool {
+	return f.SessionStatus == SessionStatusKeep && f.Option.Has(OptionWindow) && !f.Option.Has(OptionData)
 }
 
 func (f FrameMetadata) WriteTo(b *buf.Buffer) error {
@@ -74,7 +82,9 @@ func (f FrameMetadata) WriteTo(b *buf.Buffer) error {
 	common.Must(b.WriteByte(byte(f.SessionStatus)))
 	common.Must(b.WriteByte(byte(f.Option)))
 
-	if f.SessionStatus == SessionStatusNew {
+	if f.isWindow() {
+		binary.BigEndian.PutUint64(b.Extend(8), f.Window)
+	} else if f.SessionStatus == SessionStatusNew {
 		switch f.Target.Network {
 		case net.Network_TCP:
 			common.Must(b.WriteByte(byte(TargetNetworkTCP)))
@@ -141,6 +151,14 @@ func (f *FrameMetadata) UnmarshalFromBuffer(b *buf.Buffer, readSourceAndLocal bo
 	f.Option = bitmask.Byte(b.Byte(3))
 	f.Target.Network = net.Network_Unknown
 
+	if f.isWindow() {
+		if b.Len() < 12 {
+			return errors.New("insufficient buffer for window: ", b.Len())
+		}
+		f.Window = binary.BigEndian.Uint64(b.BytesRange(4, 12))
+		return nil
+	}
+
 	if f.SessionStatus == SessionStatusNew || (f.SessionStatus == SessionStatusKeep && b.Len() > 4 &&
 		TargetNetwork(b.Byte(4)) == TargetNetworkUDP) { // MUST check the flag first
 		if b.Len() < 8 {
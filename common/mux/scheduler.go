@@ -0,0 +1,168 @@
+package mux
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/session"
+)
+
+// Weights of sessions on a mux connection. A session gets a share of the
+// connection proportional to its weight while other sessions are sending.
+const (
+	PriorityBulk        uint32 = 1
+	PriorityNormal      uint32 = 4
+	PriorityInteractive uint32 = 16
+)
+
+// sessionPriority returns the weight of a session from the hint in ctx, or
+// guesses it from the sniffed protocol and the transfer type.
+func sessionPriority(ctx context.Context, transferType protocol.TransferType) uint32 {
+	if p := session.MuxPriorityFromContext(ctx); p > 0 {
+		return p
+	}
+	if content := session.ContentFromContext(ctx); content != nil {
+		switch content.Protocol {
+		case "dns":
+			return PriorityInteractive
+		case "bittorrent":
+			return PriorityBulk
+		}
+	}
+	if transferType == protocol.TransferTypePacket {
+		return PriorityInteractive
+	}
+	return PriorityNormal
+}
+
+// scheduler writes the frames of all sessions of a mux connection with
+// weighted fair queuing.
+//
+// Each frame gets a virtual finish time, which grows with the bytes its
+// session has queued divided by the weight of the session. Frames are written
+// in the order of their finish times, so a small frame of an interactive
+// session overtakes the frames a bulk session keeps queueing. Writers block
+// until their frame is written. Whoever finds the connection idle writes the
+// frames queued before its own, then hands the connection over to the writer
+// of the next frame.
+type scheduler struct {
+	writer buf.Writer
+
+	access  sync.Mutex
+	queue   frameQueue
+	writing bool
+	seq     uint64
+	vtime   uint64
+	finish  map[uint16]uint64
+}
+
+func newScheduler(writer buf.Writer) *scheduler {
+	return &scheduler{
+		writer: writer,
+		finish: make(map[uint16]uint64),
+	}
+}
+
+type queuedFrame struct {
+	session uint16
+	finish  uint64
+	seq     uint64
+	data    buf.MultiBuffer
+	result  chan error
+}
+
+// errTakeOver tells a queued writer to write the frames on the connection.
+var errTakeOver = errors.New("take over")
+
+// cost scales the size of frames so that weights up to 256 keep precision.
+const costScale = 256
+
+func (s *scheduler) write(id uint16, weight uint32, mb buf.MultiBuffer) error {
+	if weight == 0 {
+		weight = PriorityNormal
+	}
+	f := &queuedFrame{
+		session: id,
+		data:    mb,
+		result:  make(chan error, 1),
+	}
+
+	s.access.Lock()
+	start := max(s.vtime, s.finish[id])
+	f.finish = start + uint64(mb.Len()+1)*costScale/uint64(weight)
+	f.seq = s.seq
+	s.seq++
+	s.finish[id] = f.finish
+	heap.Push(&s.queue, f)
+	if s.writing {
+		s.access.Unlock()
+		if err := <-f.result; err != errTakeOver {
+			return err
+		}
+		s.access.Lock()
+	}
+	s.writing = true
+	for {
+		next := heap.Pop(&s.queue).(*queuedFrame)
+		s.vtime = next.finish
+		if s.finish[next.session] == next.finish {
+			delete(s.finish, next.session)
+		}
+		s.access.Unlock()
+		err := s.writer.WriteMultiBuffer(next.data)
+		if next != f {
+			next.result <- err
+			s.access.Lock()
+			continue
+		}
+		// hand the connection over to the writer of the next frame, so that
+		// this session can queue its next frame instead of writing everyone
+		// else's
+		s.access.Lock()
+		if s.queue.Len() > 0 {
+			s.queue[0].result <- errTakeOver
+		} else {
+			s.writing = false
+		}
+		s.access.Unlock()
+		return err
+	}
+}
+
+// sessionWriter is the buf.Writer of one session on a scheduler.
+type sessionWriter struct {
+	scheduler *scheduler
+	id        uint16
+	weight    uint32
+}
+
+func (w *sessionWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	return w.scheduler.write(w.id, w.weight, mb)
+}
+
+type frameQueue []*queuedFrame
+
+func (q frameQueue) Len() int { return len(q) }
+
+func (q frameQueue) Less(i, j int) bool {
+	if q[i].finish != q[j].finish {
+		return q[i].finish < q[j].finish
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q frameQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *frameQueue) Push(x any) { *q = append(*q, x.(*queuedFrame)) }
+
+func (q *frameQueue) Pop() any {
+	old := *q
+	f := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return f
+}
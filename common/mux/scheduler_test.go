@@ -0,0 +1,155 @@
+package mux
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xtls/xray-core/common/buf"
+)
+
+// roundWriter records the first byte of every write. Before a write returns,
+// it waits until every other session with frames left has queued its next
+// frame, like a slow connection would, so that the scheduler picks each frame
+// from all the sessions that are sending. Sessions only have one frame in
+// flight, as their writers block until it is written.
+type roundWriter struct {
+	scheduler *scheduler
+	gate      chan struct{}
+
+	access    sync.Mutex
+	written   []byte
+	remaining map[byte]int
+}
+
+func (w *roundWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	<-w.gate
+	id := mb[0].Byte(0)
+	buf.ReleaseMulti(mb)
+
+	w.access.Lock()
+	w.written = append(w.written, id)
+	w.remaining[id]--
+	others := 0
+	for session, n := range w.remaining {
+		if session != id && n > 0 {
+			others++
+		}
+	}
+	w.access.Unlock()
+
+	for {
+		w.scheduler.access.Lock()
+		n := w.scheduler.queue.Len()
+		w.scheduler.access.Unlock()
+		if n == others {
+			return nil
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func frameOf(id byte, size int) buf.MultiBuffer {
+	b := buf.New()
+	b.WriteByte(id)
+	b.Extend(int32(size - 1))
+	return buf.MultiBuffer{b}
+}
+
+// send starts sessions that write frames of size one after another, and waits
+// until their first frames are queued behind the blocked connection.
+func send(t *testing.T, s *scheduler, writer *roundWriter, weights map[byte]uint32, frames, size int) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for id, weight := range weights {
+		writer.access.Lock()
+		writer.remaining[id] = frames
+		writer.access.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range frames {
+				if err := s.write(uint16(id), weight, frameOf(id, size)); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	for {
+		s.access.Lock()
+		n, writing := s.queue.Len(), s.writing
+		s.access.Unlock()
+		writer.access.Lock()
+		started := len(writer.remaining)
+		writer.access.Unlock()
+		if writing && n == started-1 {
+			return &wg
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerInteractiveOvertakesBulk(t *testing.T) {
+	writer := &roundWriter{gate: make(chan struct{}), remaining: make(map[byte]int)}
+	s := newScheduler(writer)
+	writer.scheduler = s
+
+	// session 9 takes the connection, and blocks on it until all others queued
+	// their frame
+	wg := send(t, s, writer, map[byte]uint32{9: PriorityNormal}, 1, 100)
+	wg2 := send(t, s, writer, map[byte]uint32{
+		1: PriorityBulk,
+		2: PriorityBulk,
+		3: PriorityBulk,
+	}, 1, 8*1024)
+	wg3 := send(t, s, writer, map[byte]uint32{4: PriorityInteractive}, 1, 100)
+	close(writer.gate)
+	wg.Wait()
+	wg2.Wait()
+	wg3.Wait()
+
+	if r := cmp.Diff(writer.written[:2], []byte{9, 4}); r != "" {
+		t.Error(r)
+	}
+	if len(s.finish) != 0 {
+		t.Error("finish times of idle sessions are kept: ", s.finish)
+	}
+}
+
+func TestSchedulerShareByWeight(t *testing.T) {
+	writer := &roundWriter{gate: make(chan struct{}), remaining: make(map[byte]int)}
+	s := newScheduler(writer)
+	writer.scheduler = s
+
+	weights := map[byte]uint32{
+		1: PriorityNormal, 2: PriorityNormal, 3: PriorityNormal,
+		4: 2 * PriorityNormal, 5: 2 * PriorityNormal, 6: 2 * PriorityNormal,
+		7: 4 * PriorityNormal, 8: 4 * PriorityNormal, 9: 4 * PriorityNormal,
+	}
+	const frames = 32
+	wg := send(t, s, writer, weights, frames, 1024)
+	close(writer.gate)
+	wg.Wait()
+
+	if len(writer.written) != len(weights)*frames {
+		t.Fatal("written ", len(writer.written), " frames")
+	}
+	// while all sessions are sending, each gets a share of the connection
+	// proportional to its weight, give or take a frame
+	var total uint32
+	for _, weight := range weights {
+		total += weight
+	}
+	counts := make(map[byte]int)
+	for _, id := range writer.written[:total] {
+		counts[id]++
+	}
+	for id, weight := range weights {
+		if d := counts[id] - int(weight); d < -1 || d > 1 {
+			t.Error("session ", id, " with weight ", weight, " sent ", counts[id], " of ", total, " frames")
+		}
+	}
+	if len(s.finish) != 0 {
+		t.Error("finish times of idle sessions are kept: ", s.finish)
+	}
+}
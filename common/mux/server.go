@@ -89,6 +89,7 @@ func (s *Server) Close() error {
 type ServerWorker struct {
 	dispatcher     routing.Dispatcher
 	link           *transport.Link
+	scheduler      *scheduler
 	sessionManager *SessionManager
 	done           *done.Instance
 	timer          *time.Ticker
@@ -98,6 +99,7 @@ func NewServerWorker(ctx context.Context, d routing.Dispatcher, link *transport.
 	worker := &ServerWorker{
 		dispatcher:     d,
 		link:           link,
+		scheduler:      newScheduler(link.Writer),
 		sessionManager: NewSessionManager(),
 		done:           done.New(),
 		timer:          time.NewTicker(60 * time.Second),
@@ -110,8 +112,10 @@ func NewServerWorker(ctx context.Context, d routing.Dispatcher, link *transport.
 	return worker, nil
 }
 
-func handle(ctx context.Context, s *Session, output buf.Writer) {
+func handle(ctx context.Context, s *Session, sched *scheduler) {
+	output := &sessionWriter{scheduler: sched, id: s.ID, weight: sessionPriority(ctx, s.transferType)}
 	writer := NewResponseWriter(s.ID, output, s.transferType)
+	writer.flow = s.flow
 	if err := buf.Copy(s.input, writer); err != nil {
 		errors.LogInfoInner(ctx, err, "session ", s.ID, " ends.")
 		writer.hasError = true
@@ -259,7 +263,10 @@ func (w *ServerWorker) handleStatusNew(ctx context.Context, meta *FrameMetadata,
 			x.Mux.Close(false)
 			return errors.New("failed to add new session")
 		}
-		go handle(ctx, x.Mux, w.link.Writer)
+		if err := w.startFlowControl(meta, x.Mux); err != nil {
+			return err
+		}
+		go handle(ctx, x.Mux, w.scheduler)
 		return nil
 	}
 
@@ -284,13 +291,16 @@ func (w *ServerWorker) handleStatusNew(ctx context.Context, meta *FrameMetadata,
 		s.Close(false)
 		return errors.New("failed to add new session")
 	}
-	go handle(ctx, s, w.link.Writer)
+	if err := w.startFlowControl(meta, s); err != nil {
+		return err
+	}
+	go handle(ctx, s, w.scheduler)
 	if !meta.Option.Has(OptionData) {
 		return nil
 	}
 
 	rr := s.NewReader(reader, &meta.Target)
-	err = buf.Copy(rr, s.output)
+	err = buf.Copy(rr, s.dataWriter())
 
 	if err != nil && buf.IsWriteError(err) {
 		s.Close(false)
@@ -299,7 +309,30 @@ func (w *ServerWorker) handleStatusNew(ctx context.Context, meta *FrameMetadata,
 	return err
 }
 
+// startFlowControl enables flow control for a new session if the client asks
+// for it, and acknowledges with the initial window before any other frame of
+// the session.
+func (w *ServerWorker) startFlowControl(meta *FrameMetadata, s *Session) error {
+	if !meta.Option.Has(OptionWindow) {
+		return nil
+	}
+	s.flow = newFlowControl()
+	s.flow.setLimit(DefaultWindow)
+	writer := &sessionWriter{scheduler: w.scheduler, id: s.ID, weight: PriorityInteractive}
+	if err := writeWindow(writer, s.ID, DefaultWindow); err != nil {
+		return errors.New("failed to acknowledge flow control").Base(err)
+	}
+	go s.drain(writer)
+	return nil
+}
+
 func (w *ServerWorker) handleStatusKeep(meta *FrameMetadata, reader *buf.BufferedReader) error {
+	if meta.isWindow() {
+		if s, found := w.sessionManager.Get(meta.SessionID); found {
+			s.handleWindow(meta)
+		}
+		return nil
+	}
 	if !meta.Option.Has(OptionData) {
 		return nil
 	}
@@ -314,7 +347,7 @@ func (w *ServerWorker) handleStatusKeep(meta *FrameMetadata, reader *buf.Buffere
 	}
 
 	rr := s.NewReader(reader, &meta.Target)
-	err := buf.Copy(rr, s.output)
+	err := buf.Copy(rr, s.dataWriter())
 
 	if err != nil && buf.IsWriteError(err) {
 		errors.LogInfoInner(context.Background(), err, "failed to write to downstream writer. closing session ", s.ID)
@@ -327,7 +360,7 @@ func (w *ServerWorker) handleStatusKeep(meta *FrameMetadata, reader *buf.Buffere
 
 func (w *ServerWorker) handleStatusEnd(meta *FrameMetadata, reader *buf.BufferedReader) error {
 	if s, found := w.sessionManager.Get(meta.SessionID); found {
-		s.Close(false)
+		s.end()
 	}
 	if meta.Option.Has(OptionData) {
 		return buf.Copy(NewStreamReader(reader), buf.Discard)
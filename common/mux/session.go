@@ -163,6 +163,7 @@ type Session struct {
 	closed       bool
 	done         *done.Instance
 	XUDP         *XUDP
+	flow         *flowControl
 }
 
 // Close closes all resources associated with this session.
@@ -179,6 +180,9 @@ func (s *Session) Close(locked bool) error {
 	if s.done != nil {
 		s.done.Close()
 	}
+	if s.flow != nil {
+		s.flow.close()
+	}
 	if s.XUDP == nil {
 		common.Interrupt(s.input)
 		common.Close(s.output)
@@ -201,6 +205,44 @@ func (s *Session) Close(locked bool) error {
 	return nil
 }
 
+// dataWriter returns where the data of the session read from the connection
+// goes: the flow control queue once the peer takes part in flow control, or
+// the output directly.
+func (s *Session) dataWriter() buf.Writer {
+	if s.flow != nil && s.flow.peerLimited() {
+		return s.flow
+	}
+	return s.output
+}
+
+// drain writes the data queued by flow control to the output until the
+// session ends, granting credit to the peer through writer.
+func (s *Session) drain(writer buf.Writer) {
+	err := s.flow.drain(s.output, func(limit uint64) error {
+		return writeWindow(writer, s.ID, limit)
+	})
+	if err != nil && err != io.ErrClosedPipe {
+		errors.LogInfoInner(context.Background(), err, "failed to write to downstream. closing session ", s.ID)
+	}
+	s.Close(false)
+}
+
+// handleWindow applies a window frame to the session.
+func (s *Session) handleWindow(meta *FrameMetadata) {
+	if s.flow != nil {
+		s.flow.setLimit(meta.Window)
+	}
+}
+
+// end handles the End frame of the session.
+func (s *Session) end() {
+	if s.flow != nil && s.flow.peerLimited() {
+		s.flow.finish()
+		return
+	}
+	s.Close(false)
+}
+
 // NewReader creates a buf.Reader based on the transfer type of this Session.
 func (s *Session) NewReader(reader *buf.BufferedReader, dest *net.Destination) buf.Reader {
 	if s.transferType == protocol.TransferTypeStream {
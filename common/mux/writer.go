@@ -18,6 +18,7 @@ type Writer struct {
 	transferType protocol.TransferType
 	globalID     [8]byte
 	inbound      *session.Inbound
+	flow         *flowControl
 }
 
 func NewWriter(id uint16, dest net.Destination, writer buf.Writer, transferType protocol.TransferType, globalID [8]byte, inbound *session.Inbound) *Writer {
@@ -54,6 +55,9 @@ func (w *Writer) getNextFrameMeta() FrameMetadata {
 	} else {
 		w.followup = true
 		meta.SessionStatus = SessionStatusNew
+		if w.flow != nil {
+			meta.Option.Set(OptionWindow)
+		}
 	}
 
 	return meta
@@ -110,6 +114,12 @@ func (w *Writer) WriteMultiBuffer(mb buf.MultiBuffer) error {
 			mb = mb2
 			chunk = buf.MultiBuffer{b}
 		}
+		if w.flow != nil {
+			if err := w.flow.acquire(chunk.Len()); err != nil {
+				buf.ReleaseMulti(chunk)
+				return err
+			}
+		}
 		if err := w.writeData(chunk); err != nil {
 			return err
 		}
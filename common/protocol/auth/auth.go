@@ -0,0 +1,180 @@
+// Package auth authenticates the username and password users of inbounds,
+// against their own users and external backends.
+package auth // import "github.com/xtls/xray-core/common/protocol/auth"
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+	"sync"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/protocol"
+)
+
+// Account is an account authenticated by username and password.
+type Account interface {
+	protocol.Account
+	GetUsername() string
+	GetPassword() string
+}
+
+// Authenticator authenticates users that are not configured on an inbound.
+type Authenticator interface {
+	// Authenticate returns the user with the username and password, or nil
+	// if they are invalid.
+	Authenticate(ctx context.Context, username, password string) (*protocol.MemoryUser, error)
+}
+
+// NewAuthenticator creates the backend of config. Users it accepts get level
+// unless it says otherwise.
+func NewAuthenticator(config *Backend, level uint32) (Authenticator, error) {
+	switch backend := config.Backend.(type) {
+	case *Backend_Htpasswd:
+		return newHtpasswd(backend.Htpasswd, level)
+	case *Backend_Callback:
+		return newCallback(backend.Callback, level)
+	default:
+		return nil, errors.New("unknown authentication backend")
+	}
+}
+
+// Validator stores the users of an inbound, and asks its backends about
+// users it does not know.
+type Validator struct {
+	access         sync.RWMutex
+	users          []*protocol.MemoryUser
+	authenticators []Authenticator
+	required       bool
+}
+
+// NewValidator creates a Validator with backends, whose users get level unless
+// the backend says otherwise.
+func NewValidator(backends []*Backend, level uint32) (*Validator, error) {
+	v := &Validator{}
+	for _, backend := range backends {
+		authenticator, err := NewAuthenticator(backend, level)
+		if err != nil {
+			return nil, errors.New("failed to create authentication backend").Base(err)
+		}
+		v.authenticators = append(v.authenticators, authenticator)
+	}
+	v.required = len(v.authenticators) > 0
+	return v, nil
+}
+
+// Add adds a user with an Account.
+func (v *Validator) Add(u *protocol.MemoryUser) error {
+	account, ok := u.Account.(Account)
+	if !ok {
+		return errors.New("not a username and password account")
+	}
+	if account.GetUsername() == "" {
+		return errors.New("Username must not be empty.")
+	}
+
+	v.access.Lock()
+	defer v.access.Unlock()
+
+	for _, user := range v.users {
+		if user.Account.(Account).GetUsername() == account.GetUsername() {
+			return errors.New("User ", account.GetUsername(), " already exists.")
+		}
+		if u.Email != "" && strings.EqualFold(user.Email, u.Email) {
+			return errors.New("User ", u.Email, " already exists.")
+		}
+	}
+	v.users = append(v.users, u)
+	v.required = true
+	return nil
+}
+
+// Del removes the user with a non-empty email.
+func (v *Validator) Del(email string) error {
+	if email == "" {
+		return errors.New("Email must not be empty.")
+	}
+
+	v.access.Lock()
+	defer v.access.Unlock()
+
+	for i, user := range v.users {
+		if strings.EqualFold(user.Email, email) {
+			v.users = append(v.users[:i:i], v.users[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("User ", email, " not found.")
+}
+
+// GetByEmail returns the user with a non-empty email.
+func (v *Validator) GetByEmail(email string) *protocol.MemoryUser {
+	if email == "" {
+		return nil
+	}
+
+	v.access.RLock()
+	defer v.access.RUnlock()
+
+	for _, user := range v.users {
+		if strings.EqualFold(user.Email, email) {
+			return user
+		}
+	}
+	return nil
+}
+
+// GetAll returns all the users.
+func (v *Validator) GetAll() []*protocol.MemoryUser {
+	v.access.RLock()
+	defer v.access.RUnlock()
+
+	return append([]*protocol.MemoryUser(nil), v.users...)
+}
+
+// GetCount returns the number of users.
+func (v *Validator) GetCount() int64 {
+	v.access.RLock()
+	defer v.access.RUnlock()
+
+	return int64(len(v.users))
+}
+
+// Required reports whether clients have to authenticate. It is once the
+// validator has had a user or has a backend, so that removing the last user
+// does not open the inbound to everyone.
+func (v *Validator) Required() bool {
+	v.access.RLock()
+	defer v.access.RUnlock()
+
+	return v.required
+}
+
+// Authenticate returns the user with the username and password, or nil if
+// neither the validator nor any of its backends accept them.
+func (v *Validator) Authenticate(ctx context.Context, username, password string) *protocol.MemoryUser {
+	v.access.RLock()
+	for _, user := range v.users {
+		account := user.Account.(Account)
+		if account.GetUsername() == username {
+			v.access.RUnlock()
+			if subtle.ConstantTimeCompare([]byte(account.GetPassword()), []byte(password)) == 1 {
+				return user
+			}
+			return nil
+		}
+	}
+	v.access.RUnlock()
+
+	for _, authenticator := range v.authenticators {
+		user, err := authenticator.Authenticate(ctx, username, password)
+		if err != nil {
+			errors.LogWarningInner(ctx, err, "failed to authenticate ", username)
+			continue
+		}
+		if user != nil {
+			return user
+		}
+	}
+	return nil
+}
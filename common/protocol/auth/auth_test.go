@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/protocol"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/protobuf/proto"
+)
+
+type account struct {
+	username string
+	password string
+}
+
+func (a *account) Equals(another protocol.Account) bool { return false }
+func (a *account) ToProto() proto.Message               { return nil }
+func (a *account) GetUsername() string                  { return a.username }
+func (a *account) GetPassword() string                  { return a.password }
+
+func writeHtpasswd(t *testing.T, path, username, password string) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	common.Must(err)
+	common.Must(os.WriteFile(path, []byte("# users\n"+username+":"+string(hash)+"\nmd5:$apr1$x$y\n"), 0o600))
+}
+
+func TestValidator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	writeHtpasswd(t, path, "carol", "carol-password")
+
+	var requests []callbackRequest
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request callbackRequest
+		common.Must(json.NewDecoder(r.Body).Decode(&request))
+		requests = append(requests, request)
+		if request.Password != "dave-password" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"email": "dave@example.com", "level": 2}`))
+	}))
+	defer service.Close()
+
+	v, err := NewValidator([]*Backend{
+		{Backend: &Backend_Htpasswd{Htpasswd: &Htpasswd{Path: path}}},
+		{Backend: &Backend_Callback{Callback: &Callback{Url: service.URL, CacheTtl: 60}}},
+	}, 1)
+	common.Must(err)
+	common.Must(v.Add(&protocol.MemoryUser{Email: "alice@example.com", Level: 3, Account: &account{"alice", "alice-password"}}))
+	if err := v.Add(&protocol.MemoryUser{Email: "other@example.com", Account: &account{"alice", "x"}}); err == nil {
+		t.Error("added a duplicate username")
+	}
+
+	ctx := context.Background()
+	check := func(username, password, email string, level uint32) {
+		t.Helper()
+		user := v.Authenticate(ctx, username, password)
+		switch {
+		case email == "" && user != nil:
+			t.Error(username, " accepted as ", user.Email)
+		case email != "" && user == nil:
+			t.Error(username, " rejected")
+		case email != "" && (user.Email != email || user.Level != level):
+			t.Error(username, " authenticated as ", user.Email, " at level ", user.Level)
+		}
+	}
+	check("alice", "alice-password", "alice@example.com", 3)
+	check("alice", "wrong", "", 0)
+	check("carol", "carol-password", "carol", 1)
+	check("carol", "wrong", "", 0)
+	check("md5", "", "", 0)
+	check("dave", "dave-password", "dave@example.com", 2)
+	check("dave", "dave-password", "dave@example.com", 2)
+	check("dave", "wrong", "", 0)
+	if len(requests) != 4 {
+		t.Error("expected 4 callback requests, got ", len(requests))
+	}
+
+	writeHtpasswd(t, path, "carol", "new-password")
+	future := time.Now().Add(time.Hour)
+	common.Must(os.Chtimes(path, future, future))
+	h := v.authenticators[0].(*htpasswd)
+	h.access.Lock()
+	h.checkedAt = time.Time{}
+	h.access.Unlock()
+	check("carol", "carol-password", "", 0)
+	check("carol", "new-password", "carol", 1)
+
+	common.Must(v.Del("alice@example.com"))
+	if v.GetCount() != 0 || !v.Required() {
+		t.Error("removing the last user must keep authentication required")
+	}
+	check("alice", "alice-password", "", 0)
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/session"
+)
+
+const (
+	defaultCallbackTimeout = 5 * time.Second
+	callbackCacheSize      = 1024
+)
+
+type callbackRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Inbound  string `json:"inbound,omitempty"`
+	Source   string `json:"source,omitempty"`
+}
+
+type callbackResponse struct {
+	Email string  `json:"email"`
+	Level *uint32 `json:"level"`
+}
+
+type cachedUser struct {
+	user    *protocol.MemoryUser
+	expires time.Time
+}
+
+// callback authenticates users by posting their credentials as JSON to an
+// HTTP service. The service accepts them with 200 and optionally the email
+// and level of the user, and rejects them with 401 or 403.
+type callback struct {
+	url    string
+	level  uint32
+	ttl    time.Duration
+	client *http.Client
+
+	access sync.Mutex
+	cache  map[[sha256.Size]byte]cachedUser
+}
+
+func newCallback(config *Callback, level uint32) (*callback, error) {
+	if config.Url == "" {
+		return nil, errors.New("authentication callback URL is not specified")
+	}
+	timeout := time.Duration(config.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultCallbackTimeout
+	}
+	return &callback{
+		url:    config.Url,
+		level:  level,
+		ttl:    time.Duration(config.CacheTtl) * time.Second,
+		client: &http.Client{Timeout: timeout},
+		cache:  make(map[[sha256.Size]byte]cachedUser),
+	}, nil
+}
+
+// Authenticate implements Authenticator.
+func (c *callback) Authenticate(ctx context.Context, username, password string) (*protocol.MemoryUser, error) {
+	key := sha256.Sum256([]byte(username + "\x00" + password))
+	if c.ttl > 0 {
+		c.access.Lock()
+		cached, found := c.cache[key]
+		c.access.Unlock()
+		if found && time.Now().Before(cached.expires) {
+			return cached.user, nil
+		}
+	}
+
+	request := callbackRequest{
+		Username: username,
+		Password: password,
+	}
+	if inbound := session.InboundFromContext(ctx); inbound != nil {
+		request.Inbound = inbound.Tag
+		if inbound.Source.IsValid() {
+			request.Source = inbound.Source.Address.String()
+		}
+	}
+	body, _ := json.Marshal(request)
+	httpRequest, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.New("invalid authentication callback ", c.url).Base(err)
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(httpRequest)
+	if err != nil {
+		return nil, errors.New("authentication callback failed").Base(err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, nil
+	default:
+		return nil, errors.New("authentication callback returned ", resp.Status)
+	}
+
+	var response callbackResponse
+	if data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024)); err == nil && len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &response); err != nil {
+			return nil, errors.New("invalid response of authentication callback").Base(err)
+		}
+	}
+	user := &protocol.MemoryUser{
+		Email: username,
+		Level: c.level,
+	}
+	if response.Email != "" {
+		user.Email = response.Email
+	}
+	if response.Level != nil {
+		user.Level = *response.Level
+	}
+
+	if c.ttl > 0 {
+		now := time.Now()
+		c.access.Lock()
+		if len(c.cache) >= callbackCacheSize {
+			for k, v := range c.cache {
+				if now.After(v.expires) {
+					delete(c.cache, k)
+				}
+			}
+		}
+		if len(c.cache) < callbackCacheSize {
+			c.cache[key] = cachedUser{user: user, expires: now.Add(c.ttl)}
+		}
+		c.access.Unlock()
+	}
+	return user, nil
+}
@@ -0,0 +1,304 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.35.1
+// 	protoc        v5.28.2
+// source: common/protocol/auth/config.proto
+
+package auth
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Htpasswd authenticates users against an htpasswd file with bcrypt hashes.
+// The file is reloaded when modified.
+type Htpasswd struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+}
+
+func (x *Htpasswd) Reset() {
+	*x = Htpasswd{}
+	mi := &file_common_protocol_auth_config_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Htpasswd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Htpasswd) ProtoMessage() {}
+
+func (x *Htpasswd) ProtoReflect() protoreflect.Message {
+	mi := &file_common_protocol_auth_config_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Htpasswd.ProtoReflect.Descriptor instead.
+func (*Htpasswd) Descriptor() ([]byte, []int) {
+	return file_common_protocol_auth_config_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Htpasswd) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+// Callback authenticates users by posting their credentials to an HTTP
+// service.
+type Callback struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Timeout of a request in seconds.
+	Timeout uint32 `protobuf:"varint,2,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// Seconds to remember an accepted user for.
+	CacheTtl uint32 `protobuf:"varint,3,opt,name=cache_ttl,json=cacheTtl,proto3" json:"cache_ttl,omitempty"`
+}
+
+func (x *Callback) Reset() {
+	*x = Callback{}
+	mi := &file_common_protocol_auth_config_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Callback) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Callback) ProtoMessage() {}
+
+func (x *Callback) ProtoReflect() protoreflect.Message {
+	mi := &file_common_protocol_auth_config_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Callback.ProtoReflect.Descriptor instead.
+func (*Callback) Descriptor() ([]byte, []int) {
+	return file_common_protocol_auth_config_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Callback) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Callback) GetTimeout() uint32 {
+	if x != nil {
+		return x.Timeout
+	}
+	return 0
+}
+
+func (x *Callback) GetCacheTtl() uint32 {
+	if x != nil {
+		return x.CacheTtl
+	}
+	return 0
+}
+
+// Backend authenticates users that are not configured on the inbound.
+type Backend struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Backend:
+	//
+	//	*Backend_Htpasswd
+	//	*Backend_Callback
+	Backend isBackend_Backend `protobuf_oneof:"backend"`
+}
+
+func (x *Backend) Reset() {
+	*x = Backend{}
+	mi := &file_common_protocol_auth_config_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Backend) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Backend) ProtoMessage() {}
+
+func (x *Backend) ProtoReflect() protoreflect.Message {
+	mi := &file_common_protocol_auth_config_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Backend.ProtoReflect.Descriptor instead.
+func (*Backend) Descriptor() ([]byte, []int) {
+	return file_common_protocol_auth_config_proto_rawDescGZIP(), []int{2}
+}
+
+func (m *Backend) GetBackend() isBackend_Backend {
+	if m != nil {
+		return m.Backend
+	}
+	return nil
+}
+
+func (x *Backend) GetHtpasswd() *Htpasswd {
+	if x, ok := x.GetBackend().(*Backend_Htpasswd); ok {
+		return x.Htpasswd
+	}
+	return nil
+}
+
+func (x *Backend) GetCallback() *Callback {
+	if x, ok := x.GetBackend().(*Backend_Callback); ok {
+		return x.Callback
+	}
+	return nil
+}
+
+type isBackend_Backend interface {
+	isBackend_Backend()
+}
+
+type Backend_Htpasswd struct {
+	Htpasswd *Htpasswd `protobuf:"bytes,1,opt,name=htpasswd,proto3,oneof"`
+}
+
+type Backend_Callback struct {
+	Callback *Callback `protobuf:"bytes,2,opt,name=callback,proto3,oneof"`
+}
+
+func (*Backend_Htpasswd) isBackend_Backend() {}
+
+func (*Backend_Callback) isBackend_Backend() {}
+
+var File_common_protocol_auth_config_proto protoreflect.FileDescriptor
+
+var file_common_protocol_auth_config_proto_rawDesc = []byte{
+	0x0a, 0x21, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
+	0x6c, 0x2f, 0x61, 0x75, 0x74, 0x68, 0x2f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x12, 0x19, 0x78, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x22, 0x1e,
+	0x0a, 0x08, 0x48, 0x74, 0x70, 0x61, 0x73, 0x73, 0x77, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61,
+	0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x22, 0x53,
+	0x0a, 0x08, 0x43, 0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x72,
+	0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x6c, 0x12, 0x18, 0x0a, 0x07,
+	0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x74,
+	0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x61, 0x63, 0x68, 0x65, 0x5f,
+	0x74, 0x74, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x63, 0x61, 0x63, 0x68, 0x65,
+	0x54, 0x74, 0x6c, 0x22, 0x9a, 0x01, 0x0a, 0x07, 0x42, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x12,
+	0x41, 0x0a, 0x08, 0x68, 0x74, 0x70, 0x61, 0x73, 0x73, 0x77, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x23, 0x2e, 0x78, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x2e, 0x48, 0x74,
+	0x70, 0x61, 0x73, 0x73, 0x77, 0x64, 0x48, 0x00, 0x52, 0x08, 0x68, 0x74, 0x70, 0x61, 0x73, 0x73,
+	0x77, 0x64, 0x12, 0x41, 0x0a, 0x08, 0x63, 0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x78, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x6d, 0x6d,
+	0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x61, 0x75, 0x74, 0x68,
+	0x2e, 0x43, 0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x48, 0x00, 0x52, 0x08, 0x63, 0x61, 0x6c,
+	0x6c, 0x62, 0x61, 0x63, 0x6b, 0x42, 0x09, 0x0a, 0x07, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64,
+	0x42, 0x6d, 0x0a, 0x1d, 0x63, 0x6f, 0x6d, 0x2e, 0x78, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x6d,
+	0x6d, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x61, 0x75, 0x74,
+	0x68, 0x50, 0x01, 0x5a, 0x2e, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x78, 0x74, 0x6c, 0x73, 0x2f, 0x78, 0x72, 0x61, 0x79, 0x2d, 0x63, 0x6f, 0x72, 0x65, 0x2f, 0x63,
+	0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2f, 0x61,
+	0x75, 0x74, 0x68, 0xaa, 0x02, 0x19, 0x58, 0x72, 0x61, 0x79, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x6f,
+	0x6e, 0x2e, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_common_protocol_auth_config_proto_rawDescOnce sync.Once
+	file_common_protocol_auth_config_proto_rawDescData = file_common_protocol_auth_config_proto_rawDesc
+)
+
+func file_common_protocol_auth_config_proto_rawDescGZIP() []byte {
+	file_common_protocol_auth_config_proto_rawDescOnce.Do(func() {
+		file_common_protocol_auth_config_proto_rawDescData = protoimpl.X.CompressGZIP(file_common_protocol_auth_config_proto_rawDescData)
+	})
+	return file_common_protocol_auth_config_proto_rawDescData
+}
+
+var file_common_protocol_auth_config_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_common_protocol_auth_config_proto_goTypes = []any{
+	(*Htpasswd)(nil), // 0: xray.common.protocol.auth.Htpasswd
+	(*Callback)(nil), // 1: xray.common.protocol.auth.Callback
+	(*Backend)(nil),  // 2: xray.common.protocol.auth.Backend
+}
+var file_common_protocol_auth_config_proto_depIdxs = []int32{
+	0, // 0: xray.common.protocol.auth.Backend.htpasswd:type_name -> xray.common.protocol.auth.Htpasswd
+	1, // 1: xray.common.protocol.auth.Backend.callback:type_name -> xray.common.protocol.auth.Callback
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_common_protocol_auth_config_proto_init() }
+func file_common_protocol_auth_config_proto_init() {
+	if File_common_protocol_auth_config_proto != nil {
+		return
+	}
+	file_common_protocol_auth_config_proto_msgTypes[2].OneofWrappers = []any{
+		(*Backend_Htpasswd)(nil),
+		(*Backend_Callback)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_common_protocol_auth_config_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_common_protocol_auth_config_proto_goTypes,
+		DependencyIndexes: file_common_protocol_auth_config_proto_depIdxs,
+		MessageInfos:      file_common_protocol_auth_config_proto_msgTypes,
+	}.Build()
+	File_common_protocol_auth_config_proto = out.File
+	file_common_protocol_auth_config_proto_rawDesc = nil
+	file_common_protocol_auth_config_proto_goTypes = nil
+	file_common_protocol_auth_config_proto_depIdxs = nil
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/protocol"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const htpasswdCheckInterval = 5 * time.Second
+
+// htpasswd authenticates users against an htpasswd file with bcrypt hashes.
+// Passwords that matched are remembered until the file changes, so that
+// bcrypt does not run for every connection.
+type htpasswd struct {
+	path  string
+	level uint32
+
+	access    sync.RWMutex
+	hashes    map[string][]byte
+	verified  map[string][sha256.Size]byte
+	modified  time.Time
+	checkedAt time.Time
+}
+
+func newHtpasswd(config *Htpasswd, level uint32) (*htpasswd, error) {
+	h := &htpasswd{
+		path:  config.Path,
+		level: level,
+	}
+	if err := h.load(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *htpasswd) load() error {
+	info, err := os.Stat(h.path)
+	if err != nil {
+		return errors.New("failed to read htpasswd ", h.path).Base(err)
+	}
+	data, err := os.ReadFile(h.path)
+	if err != nil {
+		return errors.New("failed to read htpasswd ", h.path).Base(err)
+	}
+	hashes := make(map[string][]byte)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		username, hash, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			errors.LogWarning(context.Background(), "htpasswd ", h.path, ": ignoring ", username, ", only bcrypt hashes are supported")
+			continue
+		}
+		hashes[username] = []byte(hash)
+	}
+	h.access.Lock()
+	h.hashes = hashes
+	h.verified = make(map[string][sha256.Size]byte)
+	h.modified = info.ModTime()
+	h.checkedAt = time.Now()
+	h.access.Unlock()
+	return nil
+}
+
+func (h *htpasswd) reloadIfModified() {
+	h.access.RLock()
+	due := time.Since(h.checkedAt) >= htpasswdCheckInterval
+	h.access.RUnlock()
+	if !due {
+		return
+	}
+
+	h.access.Lock()
+	info, err := os.Stat(h.path)
+	changed := err == nil && !info.ModTime().Equal(h.modified)
+	h.checkedAt = time.Now()
+	h.access.Unlock()
+	if changed {
+		if err := h.load(); err != nil {
+			errors.LogWarningInner(context.Background(), err, "failed to reload htpasswd, keeping the previous one")
+		}
+	}
+}
+
+// Authenticate implements Authenticator.
+func (h *htpasswd) Authenticate(ctx context.Context, username, password string) (*protocol.MemoryUser, error) {
+	h.reloadIfModified()
+
+	sum := sha256.Sum256([]byte(password))
+	h.access.RLock()
+	hash, found := h.hashes[username]
+	verified, cached := h.verified[username]
+	h.access.RUnlock()
+	if !found {
+		return nil, nil
+	}
+	if !cached || verified != sum {
+		if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil {
+			return nil, nil
+		}
+		h.access.Lock()
+		if bytes.Equal(h.hashes[username], hash) {
+			h.verified[username] = sum
+		}
+		h.access.Unlock()
+	}
+	return &protocol.MemoryUser{
+		Email: username,
+		Level: h.level,
+	}, nil
+}
@@ -26,6 +26,7 @@ const (
 	fullHandlerKey            ctx.SessionKey = 10 // outbound gets full handler
 	mitmAlpn11Key             ctx.SessionKey = 11 // used by TLS dialer
 	mitmServerNameKey         ctx.SessionKey = 12 // used by TLS dialer
+	muxPriorityKey            ctx.SessionKey = 13 // weight of the session on mux connections
 )
 
 func ContextWithInbound(ctx context.Context, inbound *Inbound) context.Context {
@@ -86,6 +87,21 @@ func IsReverseMuxFromContext(ctx context.Context) bool {
 	return false
 }
 
+// ContextWithMuxPriority returns a context that gives the connection the
+// weight p on mux connections.
+func ContextWithMuxPriority(ctx context.Context, p uint32) context.Context {
+	return context.WithValue(ctx, muxPriorityKey, p)
+}
+
+// MuxPriorityFromContext returns the weight of the connection on mux
+// connections, or 0 if it isn't set.
+func MuxPriorityFromContext(ctx context.Context) uint32 {
+	if val, ok := ctx.Value(muxPriorityKey).(uint32); ok {
+		return val
+	}
+	return 0
+}
+
 func ContextWithSockopt(ctx context.Context, s *Sockopt) context.Context {
 	return context.WithValue(ctx, sockoptSessionKey, s)
 }
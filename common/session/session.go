@@ -48,6 +48,8 @@ type Inbound struct {
 	User *protocol.MemoryUser
 	// VlessRoute is the user-sent VLESS UUID's 7th<<8 | 8th bytes.
 	VlessRoute net.Port
+	// SniProfile is the name of the TLS SNI profile the connection matched, if any.
+	SniProfile string
 	// Used by splice copy. Conn is actually internet.Connection. May be nil.
 	Conn net.Conn
 	// Used by splice copy. Timer of the inbound buf copier. May be nil.
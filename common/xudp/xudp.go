@@ -47,15 +47,33 @@ func init() {
 	}()
 }
 
+type globalIDKey struct{}
+
+// ContextWithGlobalID returns a context that makes GetGlobalID return id, for
+// the connections whose source isn't an inbound, like the UDP listeners of
+// reverse portals.
+func ContextWithGlobalID(ctx context.Context, id [8]byte) context.Context {
+	return context.WithValue(ctx, globalIDKey{}, id)
+}
+
+// NewGlobalID derives a Global ID from key.
+func NewGlobalID(key string) (globalID [8]byte) {
+	h := blake3.New(8, BaseKey)
+	h.Write([]byte(key))
+	copy(globalID[:], h.Sum(nil))
+	return
+}
+
 func GetGlobalID(ctx context.Context) (globalID [8]byte) {
 	if cone := ctx.Value("cone"); cone == nil || !cone.(bool) { // cone is nil only in some unit tests
 		return
 	}
+	if id, ok := ctx.Value(globalIDKey{}).([8]byte); ok {
+		return id
+	}
 	if inbound := session.InboundFromContext(ctx); inbound != nil && inbound.Source.Network == net.Network_UDP &&
 		(inbound.Name == "dokodemo-door" || inbound.Name == "socks" || inbound.Name == "shadowsocks") {
-		h := blake3.New(8, BaseKey)
-		h.Write([]byte(inbound.Source.String()))
-		copy(globalID[:], h.Sum(nil))
+		globalID = NewGlobalID(inbound.Source.String())
 		if Show {
 			errors.LogInfo(ctx, fmt.Sprintf("XUDP inbound.Source.String(): %v\tglobalID: %v\n", inbound.Source.String(), globalID))
 		}
@@ -2,6 +2,7 @@ package outbound
 
 import (
 	"context"
+	"time"
 
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/serial"
@@ -20,6 +21,50 @@ type Handler interface {
 	ProxySettings() *serial.TypedMessage
 }
 
+// HealthStatus is the health of an outbound judged from the connections it
+// has dispatched.
+type HealthStatus struct {
+	// Successes counts connections that got a response.
+	Successes uint64
+	// Failures counts connections whose transport failed before any response.
+	Failures uint64
+	// DialFailures counts failed dials, except to the targets of direct
+	// outbounds.
+	DialFailures uint64
+	// HandshakeTimeouts counts connections that timed out before any response.
+	HandshakeTimeouts uint64
+	// ConsecutiveFailures counts failures since the last success.
+	ConsecutiveFailures uint64
+	// FirstByteLatency is the moving average of the time to the first byte of
+	// the responses.
+	FirstByteLatency time.Duration
+	// OpenUntil is set while the circuit breaker is open.
+	OpenUntil time.Time
+	// LastError is the reason of the last failure.
+	LastError string
+}
+
+// Available reports whether the circuit breaker lets connections through.
+func (s HealthStatus) Available() bool {
+	return s.OpenUntil.IsZero() || time.Now().After(s.OpenUntil)
+}
+
+// HealthReporter is implemented by Handlers that track the health of the
+// connections they dispatch.
+type HealthReporter interface {
+	Health() HealthStatus
+}
+
+// GetHealth returns the health of the Handler with the given tag, if it is
+// tracked.
+func GetHealth(m Manager, tag string) (HealthStatus, bool) {
+	h, ok := m.GetHandler(tag).(HealthReporter)
+	if !ok {
+		return HealthStatus{}, false
+	}
+	return h.Health(), true
+}
+
 type HandlerSelector interface {
 	Select([]string) []string
 }
@@ -55,11 +55,18 @@ type System struct {
 	Buffer Buffer
 }
 
+// Mux contains settings for the connections carried over mux.
+type Mux struct {
+	// Weight of the connections on a mux connection, 0 for automatic.
+	Priority uint32
+}
+
 // Session is session based settings for controlling Xray requests. It contains various settings (or limits) that may differ for different users in the context.
 type Session struct {
 	Timeouts Timeout // Timeout settings
 	Stats    Stats
 	Buffer   Buffer
+	Mux      Mux
 }
 
 // Manager is a feature that provides Policy for the given user by its id or level.
@@ -1,5 +1,9 @@
 package routing
 
+import (
+	"github.com/xtls/xray-core/features/outbound"
+)
+
 type BalancerOverrider interface {
 	SetOverrideTarget(tag, target string) error
 	GetOverrideTarget(tag string) (string, error)
@@ -8,3 +12,7 @@ type BalancerOverrider interface {
 type BalancerPrincipleTarget interface {
 	GetPrincipleTarget(tag string) ([]string, error)
 }
+
+type BalancerHealth interface {
+	GetBalancerHealth(tag string) (map[string]outbound.HealthStatus, error)
+}
@@ -44,6 +44,9 @@ type Context interface {
 	// GetVlessRoute returns the user-sent VLESS UUID's 7th<<8 | 8th bytes, if exists.
 	GetVlessRoute() net.Port
 
+	// GetSniProfile returns the name of the TLS SNI profile the inbound connection matched, if exists.
+	GetSniProfile() string
+
 	// GetAttributes returns extra attributes from the conneciont content.
 	GetAttributes() map[string]string
 
@@ -136,6 +136,14 @@ func (ctx *Context) GetVlessRoute() net.Port {
 	return ctx.Inbound.VlessRoute
 }
 
+// GetSniProfile implements routing.Context.
+func (ctx *Context) GetSniProfile() string {
+	if ctx.Inbound == nil {
+		return ""
+	}
+	return ctx.Inbound.SniProfile
+}
+
 // GetAttributes implements routing.Context.
 func (ctx *Context) GetAttributes() map[string]string {
 	if ctx.Content == nil {
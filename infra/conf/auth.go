@@ -0,0 +1,55 @@
+package conf
+
+import (
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/protocol/auth"
+)
+
+type AuthCallbackConfig struct {
+	URL      string `json:"url"`
+	Timeout  uint32 `json:"timeout"`
+	CacheTTL uint32 `json:"cacheTtl"`
+}
+
+// AuthBackendConfig is an authentication backend of HTTP and SOCKS inbounds.
+// Exactly one of its fields is set.
+type AuthBackendConfig struct {
+	Htpasswd string              `json:"htpasswd"`
+	Callback *AuthCallbackConfig `json:"callback"`
+}
+
+func (c *AuthBackendConfig) Build() (*auth.Backend, error) {
+	switch {
+	case c.Htpasswd != "" && c.Callback != nil:
+		return nil, errors.New(`only one of "htpasswd" and "callback" can be set in an authentication backend`)
+	case c.Htpasswd != "":
+		return &auth.Backend{
+			Backend: &auth.Backend_Htpasswd{Htpasswd: &auth.Htpasswd{Path: c.Htpasswd}},
+		}, nil
+	case c.Callback != nil:
+		if c.Callback.URL == "" {
+			return nil, errors.New("authentication callback URL is not specified")
+		}
+		return &auth.Backend{
+			Backend: &auth.Backend_Callback{Callback: &auth.Callback{
+				Url:      c.Callback.URL,
+				Timeout:  c.Callback.Timeout,
+				CacheTtl: c.Callback.CacheTTL,
+			}},
+		}, nil
+	default:
+		return nil, errors.New("empty authentication backend")
+	}
+}
+
+func buildAuthBackends(configs []*AuthBackendConfig) ([]*auth.Backend, error) {
+	backends := make([]*auth.Backend, 0, len(configs))
+	for _, config := range configs {
+		backend, err := config.Build()
+		if err != nil {
+			return nil, err
+		}
+		backends = append(backends, backend)
+	}
+	return backends, nil
+}
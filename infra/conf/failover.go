@@ -0,0 +1,25 @@
+package conf
+
+import (
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/proxy/failover"
+	"google.golang.org/protobuf/proto"
+)
+
+type FailoverConfig struct {
+	Outbounds []string `json:"outbounds"`
+	Stagger   uint32   `json:"stagger"`
+	Timeout   uint32   `json:"timeout"`
+}
+
+// Build implements Buildable.
+func (c *FailoverConfig) Build() (proto.Message, error) {
+	if len(c.Outbounds) == 0 {
+		return nil, errors.New("failover: no outbounds specified")
+	}
+	return &failover.Config{
+		OutboundTags: c.Outbounds,
+		Stagger:      c.Stagger,
+		Timeout:      c.Timeout,
+	}, nil
+}
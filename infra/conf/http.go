@@ -11,8 +11,10 @@ import (
 )
 
 type HTTPAccount struct {
-	Username string `json:"user"`
-	Password string `json:"pass"`
+	Username string  `json:"user"`
+	Password string  `json:"pass"`
+	Email    string  `json:"email"`
+	Level    *uint32 `json:"level"`
 }
 
 func (v *HTTPAccount) Build() *http.Account {
@@ -22,10 +24,28 @@ func (v *HTTPAccount) Build() *http.Account {
 	}
 }
 
+// BuildUser builds the user of the account. Its email defaults to the
+// username, and its level to userLevel.
+func (v *HTTPAccount) BuildUser(userLevel uint32) *protocol.User {
+	user := &protocol.User{
+		Email:   v.Email,
+		Level:   userLevel,
+		Account: serial.ToTypedMessage(v.Build()),
+	}
+	if user.Email == "" {
+		user.Email = v.Username
+	}
+	if v.Level != nil {
+		user.Level = *v.Level
+	}
+	return user
+}
+
 type HTTPServerConfig struct {
-	Accounts    []*HTTPAccount `json:"accounts"`
-	Transparent bool           `json:"allowTransparent"`
-	UserLevel   uint32         `json:"userLevel"`
+	Accounts     []*HTTPAccount       `json:"accounts"`
+	Transparent  bool                 `json:"allowTransparent"`
+	UserLevel    uint32               `json:"userLevel"`
+	AuthBackends []*AuthBackendConfig `json:"authBackends"`
 }
 
 func (c *HTTPServerConfig) Build() (proto.Message, error) {
@@ -34,11 +54,16 @@ func (c *HTTPServerConfig) Build() (proto.Message, error) {
 		UserLevel:        c.UserLevel,
 	}
 
-	if len(c.Accounts) > 0 {
-		config.Accounts = make(map[string]string)
-		for _, account := range c.Accounts {
-			config.Accounts[account.Username] = account.Password
+	for _, account := range c.Accounts {
+		config.Users = append(config.Users, account.BuildUser(c.UserLevel))
+	}
+
+	if len(c.AuthBackends) > 0 {
+		backends, err := buildAuthBackends(c.AuthBackends)
+		if err != nil {
+			return nil, errors.New("failed to build HTTP authentication backends").Base(err)
 		}
+		config.AuthBackends = backends
 	}
 
 	return config, nil
@@ -3,6 +3,9 @@ package conf_test
 import (
 	"testing"
 
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/protocol/auth"
+	"github.com/xtls/xray-core/common/serial"
 	. "github.com/xtls/xray-core/infra/conf"
 	"github.com/xtls/xray-core/proxy/http"
 )
@@ -26,12 +29,50 @@ func TestHTTPServerConfig(t *testing.T) {
 			}`,
 			Parser: loadJSON(creator),
 			Output: &http.ServerConfig{
-				Accounts: map[string]string{
-					"my-username": "my-password",
-				},
+				Users: []*protocol.User{{
+					Email: "my-username",
+					Level: 1,
+					Account: serial.ToTypedMessage(&http.Account{
+						Username: "my-username",
+						Password: "my-password",
+					}),
+				}},
 				AllowTransparent: true,
 				UserLevel:        1,
 			},
 		},
+		{
+			Input: `{
+				"accounts": [
+					{
+						"user": "my-username",
+						"pass": "my-password",
+						"email": "love@example.com",
+						"level": 0
+					}
+				],
+				"userLevel": 1,
+				"authBackends": [
+					{"htpasswd": "/etc/xray/htpasswd"},
+					{"callback": {"url": "http://127.0.0.1:8080/auth", "cacheTtl": 60}}
+				]
+			}`,
+			Parser: loadJSON(creator),
+			Output: &http.ServerConfig{
+				Users: []*protocol.User{{
+					Email: "love@example.com",
+					Level: 0,
+					Account: serial.ToTypedMessage(&http.Account{
+						Username: "my-username",
+						Password: "my-password",
+					}),
+				}},
+				UserLevel: 1,
+				AuthBackends: []*auth.Backend{
+					{Backend: &auth.Backend_Htpasswd{Htpasswd: &auth.Htpasswd{Path: "/etc/xray/htpasswd"}}},
+					{Backend: &auth.Backend_Callback{Callback: &auth.Callback{Url: "http://127.0.0.1:8080/auth", CacheTtl: 60}}},
+				},
+			},
+		},
 	})
 }
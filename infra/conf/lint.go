@@ -1,6 +1,17 @@
 package conf
 
-import "github.com/xtls/xray-core/common/errors"
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+)
 
 type ConfigureFilePostProcessingStage interface {
 	Process(conf *Config) error
@@ -23,3 +34,491 @@ func PostProcessConfigureFile(conf *Config) error {
 	}
 	return nil
 }
+
+// Levels of LintIssue.
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// LintIssue is a problem Lint finds in a config.
+type LintIssue struct {
+	Level   string `json:"level"`
+	Check   string `json:"check"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+type linter struct {
+	config *Config
+	now    time.Time
+	expiry time.Duration
+	issues []*LintIssue
+}
+
+func (l *linter) report(level, check, path string, message ...interface{}) {
+	l.issues = append(l.issues, &LintIssue{
+		Level:   level,
+		Check:   check,
+		Path:    path,
+		Message: fmt.Sprint(message...),
+	})
+}
+
+// Lint checks the references between the parts of the config, and other
+// mistakes that pass Build. Certificates that expire within certExpiry are
+// reported as warnings.
+func (c *Config) Lint(certExpiry time.Duration) []*LintIssue {
+	l := &linter{
+		config: c,
+		now:    time.Now(),
+		expiry: certExpiry,
+	}
+	rules := l.routingRules()
+	l.checkRoutingTags(rules)
+	l.checkBalancers()
+	l.checkShadowedRules(rules)
+	l.checkUnusedOutbounds(rules)
+	l.checkExpectedIPs()
+	l.checkUsers()
+	l.checkCertificates()
+	return l.issues
+}
+
+// lintRule is a routing rule with its match conditions normalized for
+// comparison. conditions is nil if the rule has conditions Lint doesn't know.
+type lintRule struct {
+	path        string
+	tag         string
+	outboundTag string
+	balancerTag string
+	conditions  *ruleConditions
+}
+
+type ruleConditions struct {
+	lists map[string][]string
+	ports map[string][]PortRange
+	attrs map[string]string
+}
+
+func (l *linter) routingRules() []*lintRule {
+	if l.config.RouterConfig == nil {
+		return nil
+	}
+	var rules []*lintRule
+	for i, raw := range l.config.RouterConfig.RuleList {
+		path := fmt.Sprint("routing.rules[", i, "]")
+		var rule RouterRule
+		if err := json.Unmarshal(raw, &rule); err != nil {
+			l.report(LintError, "routing.rule", path, "invalid rule: ", err)
+			continue
+		}
+		rules = append(rules, &lintRule{
+			path:        path,
+			tag:         rule.RuleTag,
+			outboundTag: rule.OutboundTag,
+			balancerTag: rule.BalancerTag,
+			conditions:  parseRuleConditions(raw),
+		})
+	}
+	return rules
+}
+
+func parseRuleConditions(raw json.RawMessage) *ruleConditions {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+	if _, found := fields["sourceIP"]; found {
+		delete(fields, "source")
+	}
+	c := &ruleConditions{
+		lists: make(map[string][]string),
+		ports: make(map[string][]PortRange),
+	}
+	for key, value := range fields {
+		switch key {
+		case "ruleTag", "outboundTag", "balancerTag", "type", "domainMatcher":
+		case "domain", "domains", "ip", "sourceIP", "source", "localIP", "user", "inboundTag", "protocol", "sniProfile":
+			var list StringList
+			if err := json.Unmarshal(value, &list); err != nil {
+				return nil
+			}
+			switch key {
+			case "domains":
+				key = "domain"
+			case "source":
+				key = "sourceIP"
+			}
+			c.lists[key] = append(c.lists[key], list...)
+		case "network":
+			var list NetworkList
+			if err := json.Unmarshal(value, &list); err != nil {
+				return nil
+			}
+			for _, network := range list.Build() {
+				c.lists[key] = append(c.lists[key], network.String())
+			}
+		case "port", "sourcePort", "localPort", "vlessRoute":
+			var list PortList
+			if err := json.Unmarshal(value, &list); err != nil {
+				return nil
+			}
+			c.ports[key] = list.Range
+		case "attrs":
+			if err := json.Unmarshal(value, &c.attrs); err != nil {
+				return nil
+			}
+		default:
+			return nil
+		}
+	}
+	return c
+}
+
+// covers returns whether every request that matches o matches c as well.
+func (c *ruleConditions) covers(o *ruleConditions) bool {
+	for key, list := range c.lists {
+		other, found := o.lists[key]
+		if !found {
+			return false
+		}
+		for _, s := range other {
+			if !slices.Contains(list, s) {
+				return false
+			}
+		}
+	}
+	for key, ranges := range c.ports {
+		other, found := o.ports[key]
+		if !found {
+			return false
+		}
+		for _, r := range other {
+			if !slices.ContainsFunc(ranges, func(cr PortRange) bool {
+				return cr.From <= r.From && r.To <= cr.To
+			}) {
+				return false
+			}
+		}
+	}
+	for key, value := range c.attrs {
+		if o.attrs[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *lintRule) name() string {
+	if r.tag != "" {
+		return "rule " + r.tag
+	}
+	return "rule"
+}
+
+func (l *linter) outboundTags() map[string]bool {
+	tags := make(map[string]bool)
+	for _, outbound := range l.config.OutboundConfigs {
+		if outbound.Tag != "" {
+			tags[outbound.Tag] = true
+		}
+	}
+	if l.config.Reverse != nil {
+		for _, portal := range l.config.Reverse.Portals {
+			tags[portal.Tag] = true
+		}
+	}
+	return tags
+}
+
+func (l *linter) checkRoutingTags(rules []*lintRule) {
+	outbounds := l.outboundTags()
+	balancers := make(map[string]bool)
+	if l.config.RouterConfig != nil {
+		for _, balancer := range l.config.RouterConfig.Balancers {
+			balancers[balancer.Tag] = true
+		}
+	}
+	for _, rule := range rules {
+		switch {
+		case rule.outboundTag != "":
+			if !outbounds[rule.outboundTag] {
+				l.report(LintError, "routing.unknown-outbound", rule.path, rule.name(), " routes to unknown outbound ", rule.outboundTag)
+			}
+		case rule.balancerTag != "":
+			if !balancers[rule.balancerTag] {
+				l.report(LintError, "routing.unknown-balancer", rule.path, rule.name(), " routes to unknown balancer ", rule.balancerTag)
+			}
+		default:
+			l.report(LintError, "routing.no-target", rule.path, rule.name(), " has neither outboundTag nor balancerTag")
+		}
+	}
+}
+
+// selected returns the outbound tags the selectors of a balancer match.
+func (l *linter) selected(selectors []string) []string {
+	var tags []string
+	for tag := range l.outboundTags() {
+		for _, selector := range selectors {
+			if strings.HasPrefix(tag, selector) {
+				tags = append(tags, tag)
+				break
+			}
+		}
+	}
+	return tags
+}
+
+func (l *linter) checkBalancers() {
+	if l.config.RouterConfig == nil {
+		return
+	}
+	outbounds := l.outboundTags()
+	for i, balancer := range l.config.RouterConfig.Balancers {
+		path := fmt.Sprint("routing.balancers[", i, "]")
+		if len(l.selected(balancer.Selectors)) == 0 {
+			l.report(LintError, "routing.empty-balancer", path, "selectors of balancer ", balancer.Tag, " match no outbound")
+		}
+		if balancer.FallbackTag != "" && !outbounds[balancer.FallbackTag] {
+			l.report(LintError, "routing.unknown-outbound", path, "balancer ", balancer.Tag, " falls back to unknown outbound ", balancer.FallbackTag)
+		}
+	}
+}
+
+func (l *linter) checkShadowedRules(rules []*lintRule) {
+	for i, rule := range rules {
+		if rule.conditions == nil {
+			continue
+		}
+		for _, earlier := range rules[:i] {
+			if earlier.conditions != nil && earlier.conditions.covers(rule.conditions) {
+				l.report(LintWarning, "routing.shadowed-rule", rule.path, rule.name(), " is unreachable, as ", earlier.path, " matches everything it does")
+				break
+			}
+		}
+	}
+}
+
+func (l *linter) checkUnusedOutbounds(rules []*lintRule) {
+	used := make(map[string]bool)
+	for _, rule := range rules {
+		used[rule.outboundTag] = true
+	}
+	if l.config.RouterConfig != nil {
+		for _, balancer := range l.config.RouterConfig.Balancers {
+			for _, tag := range l.selected(balancer.Selectors) {
+				used[tag] = true
+			}
+			used[balancer.FallbackTag] = true
+		}
+	}
+	for _, outbound := range l.config.OutboundConfigs {
+		if outbound.ProxySettings != nil {
+			used[outbound.ProxySettings.Tag] = true
+		}
+		if outbound.StreamSetting != nil && outbound.StreamSetting.SocketSettings != nil {
+			used[outbound.StreamSetting.SocketSettings.DialerProxy] = true
+		}
+		// outbounds that use other outbounds, like failover, refer to them
+		// by tag in their settings
+		if outbound.Settings != nil {
+			var settings interface{}
+			if json.Unmarshal(*outbound.Settings, &settings) == nil {
+				collectStrings(settings, used)
+			}
+		}
+	}
+
+	for i, outbound := range l.config.OutboundConfigs {
+		if i == 0 || used[outbound.Tag] && outbound.Tag != "" {
+			continue
+		}
+		path := fmt.Sprint("outbounds[", i, "]")
+		if outbound.Tag == "" {
+			l.report(LintWarning, "outbound.unused", path, "outbound without tag is never used, as it isn't the first one")
+			continue
+		}
+		l.report(LintWarning, "outbound.unused", path, "outbound ", outbound.Tag, " is never used")
+	}
+}
+
+func collectStrings(v interface{}, strs map[string]bool) {
+	switch v := v.(type) {
+	case string:
+		strs[v] = true
+	case []interface{}:
+		for _, e := range v {
+			collectStrings(e, strs)
+		}
+	case map[string]interface{}:
+		for _, e := range v {
+			collectStrings(e, strs)
+		}
+	}
+}
+
+func (l *linter) checkExpectedIPs() {
+	if l.config.DNSConfig == nil {
+		return
+	}
+	for i, server := range l.config.DNSConfig.Servers {
+		if server == nil || server.Address == nil {
+			continue
+		}
+		path := fmt.Sprint("dns.servers[", i, "]")
+		expected := server.ExpectedIPs
+		if len(expected) == 0 {
+			expected = server.ExpectIPs
+		}
+		expected = slices.DeleteFunc(slices.Clone(expected), func(s string) bool { return s == "*" })
+		if len(expected) == 0 {
+			continue
+		}
+
+		if !slices.ContainsFunc(expected, func(s string) bool { return !slices.Contains(server.UnexpectedIPs, s) }) {
+			l.report(LintWarning, "dns.expected-ips", path, "all expectedIPs of DNS server ", server.Address, " are in its unexpectedIPs")
+			continue
+		}
+
+		strategy := server.QueryStrategy
+		if strategy == "" {
+			strategy = l.config.DNSConfig.QueryStrategy
+		}
+		var family string
+		switch resolveQueryStrategy(strategy).String() {
+		case "USE_IP4":
+			family = "IPv4"
+		case "USE_IP6":
+			family = "IPv6"
+		default:
+			continue
+		}
+		mayMatch := func(s string) bool {
+			f := ipFamily(s)
+			return f == "" || f == family
+		}
+		if !slices.ContainsFunc(expected, mayMatch) {
+			l.report(LintWarning, "dns.expected-ips", path, "expectedIPs of DNS server ", server.Address, " can never match, as it only queries ", family, " addresses")
+		}
+	}
+}
+
+// ipFamily returns whether s is an IPv4 or IPv6 address or CIDR, or "" if it
+// is neither, like a geoip rule.
+func ipFamily(s string) string {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		var err error
+		if ip, _, err = net.ParseCIDR(s); err != nil {
+			return ""
+		}
+	}
+	if ip.To4() != nil {
+		return "IPv4"
+	}
+	return "IPv6"
+}
+
+func (l *linter) checkUsers() {
+	type user struct {
+		ID    string `json:"id"`
+		Email string `json:"email"`
+	}
+	ids := make(map[string]string)
+	emails := make(map[string]string)
+	for i, inbound := range l.config.InboundConfigs {
+		if inbound.Settings == nil {
+			continue
+		}
+		var settings struct {
+			Clients []*user `json:"clients"`
+			Users   []*user `json:"users"`
+		}
+		if json.Unmarshal(*inbound.Settings, &settings) != nil {
+			continue
+		}
+		inboundPath := fmt.Sprint("inbounds[", i, "]")
+		for j, u := range append(settings.Clients, settings.Users...) {
+			if u == nil {
+				continue
+			}
+			path := fmt.Sprint(inboundPath, ".settings.clients[", j, "]")
+			if j >= len(settings.Clients) {
+				path = fmt.Sprint(inboundPath, ".settings.users[", j-len(settings.Clients), "]")
+			}
+			if id := strings.ToLower(u.ID); id != "" {
+				l.checkDuplicate(ids, id, path, inboundPath, "id")
+			}
+			if email := strings.ToLower(u.Email); email != "" {
+				l.checkDuplicate(emails, email, path, inboundPath, "email")
+			}
+		}
+	}
+}
+
+// checkDuplicate reports a user value seen before. Duplicates in one inbound
+// are errors, while the same user in several inbounds may be intended.
+func (l *linter) checkDuplicate(seen map[string]string, value, path, inboundPath, kind string) {
+	first, found := seen[value]
+	if !found {
+		seen[value] = path
+		return
+	}
+	if strings.HasPrefix(first, inboundPath+".") {
+		l.report(LintError, "user.duplicate-"+kind, path, "user ", kind, " ", value, " is also used by ", first)
+	} else {
+		l.report(LintWarning, "user.duplicate-"+kind, path, "user ", kind, " ", value, " is also used by ", first)
+	}
+}
+
+func (l *linter) checkCertificates() {
+	for i, inbound := range l.config.InboundConfigs {
+		l.checkStreamCertificates(inbound.StreamSetting, fmt.Sprint("inbounds[", i, "]"))
+	}
+	for i, outbound := range l.config.OutboundConfigs {
+		l.checkStreamCertificates(outbound.StreamSetting, fmt.Sprint("outbounds[", i, "]"))
+	}
+}
+
+func (l *linter) checkStreamCertificates(stream *StreamConfig, path string) {
+	if stream == nil || stream.TLSSettings == nil {
+		return
+	}
+	for i, certConfig := range stream.TLSSettings.Certs {
+		if certConfig == nil || certConfig.CertFile == "" && len(certConfig.CertStr) == 0 {
+			continue
+		}
+		certPath := fmt.Sprint(path, ".streamSettings.tlsSettings.certificates[", i, "]")
+		data, err := readFileOrString(certConfig.CertFile, certConfig.CertStr)
+		if err != nil {
+			l.report(LintError, "tls.certificate", certPath, "failed to read certificate: ", err)
+			continue
+		}
+		for {
+			var block *pem.Block
+			block, data = pem.Decode(data)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				l.report(LintError, "tls.certificate", certPath, "invalid certificate: ", err)
+				break
+			}
+			name := cert.Subject.CommonName
+			if name == "" && len(cert.DNSNames) > 0 {
+				name = cert.DNSNames[0]
+			}
+			switch left := cert.NotAfter.Sub(l.now); {
+			case left <= 0:
+				l.report(LintError, "tls.certificate-expiry", certPath, "certificate ", name, " expired at ", cert.NotAfter.Format(time.RFC3339))
+			case left < l.expiry:
+				l.report(LintWarning, "tls.certificate-expiry", certPath, "certificate ", name, " expires at ", cert.NotAfter.Format(time.RFC3339))
+			}
+		}
+	}
+}
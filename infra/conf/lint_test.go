@@ -0,0 +1,121 @@
+package conf_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/protocol/tls/cert"
+	. "github.com/xtls/xray-core/infra/conf"
+)
+
+func lintChecks(t *testing.T, config string) []string {
+	t.Helper()
+	c := new(Config)
+	common.Must(json.Unmarshal([]byte(config), c))
+	var checks []string
+	for _, issue := range c.Lint(30 * 24 * time.Hour) {
+		checks = append(checks, issue.Level+" "+issue.Check+" "+issue.Path)
+	}
+	return checks
+}
+
+func TestLintRouting(t *testing.T) {
+	checks := lintChecks(t, `{
+		"outbounds": [
+			{"protocol": "freedom", "tag": "direct"},
+			{"protocol": "blackhole", "tag": "block"},
+			{"protocol": "freedom", "tag": "proxy-a"},
+			{"protocol": "freedom", "tag": "spare"},
+			{"protocol": "freedom", "tag": "chain"},
+			{"protocol": "failover", "tag": "failover", "settings": {"outbounds": ["chain"]}}
+		],
+		"routing": {
+			"balancers": [
+				{"tag": "b1", "selector": ["proxy-"]},
+				{"tag": "b2", "selector": ["none-"], "fallbackTag": "gone"}
+			],
+			"rules": [
+				{"port": "1-1000", "network": "tcp,udp", "outboundTag": "block"},
+				{"port": "53", "network": "udp", "outboundTag": "direct"},
+				{"port": "53", "domain": ["example.com"], "outboundTag": "direct"},
+				{"domain": ["example.com"], "outboundTag": "nowhere"},
+				{"domain": ["example.com", "example.org"], "balancerTag": "b1"},
+				{"domain": ["example.org"], "attrs": {":method": "GET"}, "balancerTag": "missing"},
+				{"domain": ["example.net"], "outboundTag": "failover"}
+			]
+		}
+	}`)
+	expected := []string{
+		"error routing.unknown-outbound routing.rules[3]",
+		"error routing.unknown-balancer routing.rules[5]",
+		"error routing.empty-balancer routing.balancers[1]",
+		"error routing.unknown-outbound routing.balancers[1]",
+		"warning routing.shadowed-rule routing.rules[1]",
+		"warning routing.shadowed-rule routing.rules[5]",
+		"warning outbound.unused outbounds[3]",
+	}
+	if r := cmp.Diff(checks, expected); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestLintDNSAndUsers(t *testing.T) {
+	checks := lintChecks(t, `{
+		"dns": {
+			"queryStrategy": "UseIPv6",
+			"servers": [
+				{"address": "1.1.1.1", "expectedIPs": ["1.0.0.0/8"]},
+				{"address": "1.1.1.1", "expectedIPs": ["1.0.0.0/8", "geoip:cn"]},
+				{"address": "1.1.1.1", "expectedIPs": ["1.0.0.0/8"], "queryStrategy": "UseIP"},
+				{"address": "1.1.1.1", "expectedIPs": ["geoip:cn", "*"], "unexpectedIPs": ["geoip:cn"]}
+			]
+		},
+		"inbounds": [
+			{"protocol": "vless", "settings": {"clients": [
+				{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "email": "a@example.com"},
+				{"id": "27848739-7E62-4138-9FD3-098A63964B6B"}
+			]}},
+			{"protocol": "shadowsocks", "settings": {"clients": [{"password": "p", "email": "A@example.com"}]}}
+		]
+	}`)
+	expected := []string{
+		"warning dns.expected-ips dns.servers[0]",
+		"warning dns.expected-ips dns.servers[3]",
+		"error user.duplicate-id inbounds[0].settings.clients[1]",
+		"warning user.duplicate-email inbounds[1].settings.clients[0]",
+	}
+	if r := cmp.Diff(checks, expected); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestLintCertificates(t *testing.T) {
+	pemOf := func(notAfter time.Time) string {
+		c := common.Must2(cert.Generate(nil, cert.NotBefore(notAfter.Add(-time.Hour)), cert.NotAfter(notAfter)))
+		certPEM, _ := c.ToPEM()
+		lines, _ := json.Marshal(strings.Split(strings.TrimSpace(string(certPEM)), "\n"))
+		return string(lines)
+	}
+	now := time.Now()
+	checks := lintChecks(t, `{
+		"inbounds": [{
+			"protocol": "vless",
+			"streamSettings": {"security": "tls", "tlsSettings": {"certificates": [
+				{"certificate": `+pemOf(now.Add(365*24*time.Hour))+`},
+				{"certificate": `+pemOf(now.Add(7*24*time.Hour))+`},
+				{"certificate": `+pemOf(now.Add(-time.Hour))+`}
+			]}}
+		}]
+	}`)
+	expected := []string{
+		"warning tls.certificate-expiry inbounds[0].streamSettings.tlsSettings.certificates[1]",
+		"error tls.certificate-expiry inbounds[0].streamSettings.tlsSettings.certificates[2]",
+	}
+	if r := cmp.Diff(checks, expected); r != "" {
+		t.Error(r)
+	}
+}
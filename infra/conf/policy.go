@@ -13,6 +13,7 @@ type Policy struct {
 	StatsUserDownlink bool    `json:"statsUserDownlink"`
 	StatsUserOnline   bool    `json:"statsUserOnline"`
 	BufferSize        *int32  `json:"bufferSize"`
+	MuxPriority       uint32  `json:"muxPriority"`
 }
 
 func (t *Policy) Build() (*policy.Policy, error) {
@@ -49,6 +50,12 @@ func (t *Policy) Build() (*policy.Policy, error) {
 		}
 	}
 
+	if t.MuxPriority > 0 {
+		p.Mux = &policy.Policy_Mux{
+			Priority: t.MuxPriority,
+		}
+	}
+
 	return p, nil
 }
 
@@ -1,32 +1,94 @@
 package conf
 
 import (
+	"strings"
+
 	"github.com/xtls/xray-core/app/reverse"
+	"github.com/xtls/xray-core/common/errors"
 	"google.golang.org/protobuf/proto"
 )
 
 type BridgeConfig struct {
-	Tag    string `json:"tag"`
-	Domain string `json:"domain"`
+	Tag    string            `json:"tag"`
+	Domain string            `json:"domain"`
+	ID     string            `json:"id"`
+	Labels map[string]string `json:"labels"`
+	Weight uint32            `json:"weight"`
 }
 
 func (c *BridgeConfig) Build() (*reverse.BridgeConfig, error) {
+	if c.ID == "" && (len(c.Labels) > 0 || c.Weight > 0) {
+		return nil, errors.New("labels and weight of bridge ", c.Tag, " require an id")
+	}
+	if strings.Contains(c.ID, "@") {
+		return nil, errors.New("invalid bridge id: ", c.ID)
+	}
 	return &reverse.BridgeConfig{
 		Tag:    c.Tag,
 		Domain: c.Domain,
+		Id:     c.ID,
+		Labels: c.Labels,
+		Weight: c.Weight,
 	}, nil
 }
 
+type PortalUDPListenerConfig struct {
+	Listen        *Address `json:"listen"`
+	Port          uint16   `json:"port"`
+	TargetAddress *Address `json:"targetAddress"`
+	TargetPort    uint16   `json:"targetPort"`
+	Bridge        string   `json:"bridge"`
+	IdleTimeout   uint32   `json:"idleTimeout"`
+}
+
+func (c *PortalUDPListenerConfig) Build() (*reverse.UDPListener, error) {
+	if c.Port == 0 {
+		return nil, errors.New("UDP listener port is not specified")
+	}
+	if c.TargetAddress == nil || c.TargetPort == 0 {
+		return nil, errors.New("UDP listener on port ", c.Port, " has no target")
+	}
+	config := &reverse.UDPListener{
+		Port:        uint32(c.Port),
+		Address:     c.TargetAddress.Build(),
+		TargetPort:  uint32(c.TargetPort),
+		Bridge:      c.Bridge,
+		IdleTimeout: c.IdleTimeout,
+	}
+	if c.Listen != nil {
+		config.Listen = c.Listen.Build()
+	}
+	return config, nil
+}
+
 type PortalConfig struct {
-	Tag    string `json:"tag"`
-	Domain string `json:"domain"`
+	Tag    string                     `json:"tag"`
+	Domain string                     `json:"domain"`
+	UDP    []*PortalUDPListenerConfig `json:"udp"`
+	// Bridges maps the ids bridges may register with to the email of the
+	// inbound user each must connect as, or to "" for any.
+	Bridges map[string]string `json:"bridges"`
 }
 
 func (c *PortalConfig) Build() (*reverse.PortalConfig, error) {
-	return &reverse.PortalConfig{
-		Tag:    c.Tag,
-		Domain: c.Domain,
-	}, nil
+	for id := range c.Bridges {
+		if id == "" || strings.Contains(id, "@") {
+			return nil, errors.New("invalid bridge id: ", id)
+		}
+	}
+	config := &reverse.PortalConfig{
+		Tag:     c.Tag,
+		Domain:  c.Domain,
+		Bridges: c.Bridges,
+	}
+	for _, l := range c.UDP {
+		listener, err := l.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.UdpListener = append(config.UdpListener, listener)
+	}
+	return config, nil
 }
 
 type ReverseConfig struct {
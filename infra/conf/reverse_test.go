@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/xtls/xray-core/app/reverse"
+	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/infra/conf"
 )
 
@@ -27,6 +28,29 @@ func TestReverseConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			Input: `{
+				"bridges": [{
+					"tag": "test",
+					"domain": "test.example.com",
+					"id": "office-1",
+					"labels": {"site": "berlin"},
+					"weight": 3
+				}]
+			}`,
+			Parser: loadJSON(creator),
+			Output: &reverse.Config{
+				BridgeConfig: []*reverse.BridgeConfig{
+					{
+						Tag:    "test",
+						Domain: "test.example.com",
+						Id:     "office-1",
+						Labels: map[string]string{"site": "berlin"},
+						Weight: 3,
+					},
+				},
+			},
+		},
 		{
 			Input: `{
 				"portals": [{
@@ -41,5 +65,46 @@ func TestReverseConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			Input: `{
+				"portals": [{
+					"tag": "test",
+					"domain": "test.example.com",
+					"udp": [{
+						"listen": "127.0.0.1",
+						"port": 5353,
+						"targetAddress": "10.0.0.53",
+						"targetPort": 53,
+						"bridge": "office-1"
+					}],
+					"bridges": {
+						"office-1": "office@example.com",
+						"office-2": ""
+					}
+				}]
+			}`,
+			Parser: loadJSON(creator),
+			Output: &reverse.Config{
+				PortalConfig: []*reverse.PortalConfig{
+					{
+						Tag:    "test",
+						Domain: "test.example.com",
+						UdpListener: []*reverse.UDPListener{
+							{
+								Listen:     net.NewIPOrDomain(net.ParseAddress("127.0.0.1")),
+								Port:       5353,
+								Address:    net.NewIPOrDomain(net.ParseAddress("10.0.0.53")),
+								TargetPort: 53,
+								Bridge:     "office-1",
+							},
+						},
+						Bridges: map[string]string{
+							"office-1": "office@example.com",
+							"office-2": "",
+						},
+					},
+				},
+			},
+		},
 	})
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/xtls/xray-core/app/observatory/burst"
 	"github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/infra/conf/cfgcommon/duration"
 )
 
@@ -14,6 +15,8 @@ const (
 	strategyLeastPing  string = "leastping"
 	strategyRoundRobin string = "roundrobin"
 	strategyLeastLoad  string = "leastload"
+
+	strategyConsistentHash string = "consistenthash"
 )
 
 var (
@@ -22,6 +25,8 @@ var (
 		strategyLeastPing:  func() interface{} { return new(strategyEmptyConfig) },
 		strategyRoundRobin: func() interface{} { return new(strategyEmptyConfig) },
 		strategyLeastLoad:  func() interface{} { return new(strategyLeastLoadConfig) },
+
+		strategyConsistentHash: func() interface{} { return new(strategyConsistentHashConfig) },
 	}, "type", "settings")
 )
 
@@ -43,6 +48,17 @@ type strategyLeastLoadConfig struct {
 	MaxRTT duration.Duration `json:"maxRTT,omitempty"`
 	// acceptable failure rate
 	Tolerance float64 `json:"tolerance,omitempty"`
+	// latency metric to rank by
+	Metric string `json:"metric,omitempty"`
+	// probe target to rank by
+	Target string `json:"target,omitempty"`
+}
+
+type strategyConsistentHashConfig struct {
+	// what to hash: "sourceIP" (default), "user" or "domain"
+	Key string `json:"key,omitempty"`
+	// virtual nodes of each outbound on the ring
+	Replicas int32 `json:"replicas,omitempty"`
 }
 
 // healthCheckSettings holds settings for health Checker
@@ -53,6 +69,17 @@ type healthCheckSettings struct {
 	SamplingCount int               `json:"sampling"`
 	Timeout       duration.Duration `json:"timeout"`
 	HttpMethod    string            `json:"httpMethod"`
+
+	Targets        []*healthCheckTarget `json:"targets"`
+	ThroughputURL  string               `json:"throughputUrl"`
+	ThroughputSize int64                `json:"throughputSize"`
+}
+
+// healthCheckTarget is a probe target of health Checker
+type healthCheckTarget struct {
+	Name        string  `json:"name"`
+	Destination string  `json:"destination"`
+	Weight      float32 `json:"weight"`
 }
 
 func (h healthCheckSettings) Build() (proto.Message, error) {
@@ -62,14 +89,30 @@ func (h healthCheckSettings) Build() (proto.Message, error) {
 	} else {
 		httpMethod = strings.TrimSpace(h.HttpMethod)
 	}
-	return &burst.HealthPingConfig{
-		Destination:   h.Destination,
-		Connectivity:  h.Connectivity,
-		Interval:      int64(h.Interval),
-		Timeout:       int64(h.Timeout),
-		SamplingCount: int32(h.SamplingCount),
-		HttpMethod:    httpMethod,
-	}, nil
+	config := &burst.HealthPingConfig{
+		Destination:    h.Destination,
+		Connectivity:   h.Connectivity,
+		Interval:       int64(h.Interval),
+		Timeout:        int64(h.Timeout),
+		SamplingCount:  int32(h.SamplingCount),
+		HttpMethod:     httpMethod,
+		ThroughputUrl:  h.ThroughputURL,
+		ThroughputSize: h.ThroughputSize,
+	}
+	for _, target := range h.Targets {
+		if target.Destination == "" {
+			return nil, errors.New("health check target without destination")
+		}
+		if target.Weight < 0 {
+			return nil, errors.New("negative weight of health check target: ", target.Destination)
+		}
+		config.Targets = append(config.Targets, &burst.HealthPingTarget{
+			Name:        target.Name,
+			Destination: target.Destination,
+			Weight:      target.Weight,
+		})
+	}
+	return config, nil
 }
 
 // Build implements Buildable.
@@ -91,6 +134,13 @@ func (v *strategyLeastLoadConfig) Build() (proto.Message, error) {
 	if config.MaxRTT < 0 {
 		config.MaxRTT = 0
 	}
+	switch strings.ToLower(v.Metric) {
+	case "", "deviation", "average", "p50", "p90", "p99", "throughput":
+		config.Metric = strings.ToLower(v.Metric)
+	default:
+		return nil, errors.New("unknown leastLoad metric: ", v.Metric)
+	}
+	config.Target = v.Target
 	config.Baselines = make([]int64, 0)
 	for _, b := range v.Baselines {
 		if b <= 0 {
@@ -100,3 +150,24 @@ func (v *strategyLeastLoadConfig) Build() (proto.Message, error) {
 	}
 	return config, nil
 }
+
+// Build implements Buildable.
+func (v *strategyConsistentHashConfig) Build() (proto.Message, error) {
+	config := &router.StrategyConsistentHashConfig{
+		Replicas: v.Replicas,
+	}
+	switch strings.ToLower(v.Key) {
+	case "", "sourceip", "ip":
+		config.Key = router.StrategyConsistentHashConfig_SourceIp
+	case "user", "email":
+		config.Key = router.StrategyConsistentHashConfig_User
+	case "domain":
+		config.Key = router.StrategyConsistentHashConfig_Domain
+	default:
+		return nil, errors.New("unknown consistentHash key: ", v.Key)
+	}
+	if config.Replicas < 0 {
+		config.Replicas = 0
+	}
+	return config, nil
+}
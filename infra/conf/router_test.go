@@ -144,6 +144,17 @@ func TestRouterConfig(t *testing.T) {
 							}
 						},
 						"fallbackTag": "fall"
+					},
+					{
+						"tag": "b3",
+						"selector": ["test"],
+						"strategy": {
+							"type": "consistentHash",
+							"settings": {
+								"key": "domain",
+								"replicas": 50
+							}
+						}
 					}
 				]
 			}`,
@@ -179,6 +190,15 @@ func TestRouterConfig(t *testing.T) {
 						}),
 						FallbackTag: "fall",
 					},
+					{
+						Tag:              "b3",
+						OutboundSelector: []string{"test"},
+						Strategy:         "consistenthash",
+						StrategySettings: serial.ToTypedMessage(&router.StrategyConsistentHashConfig{
+							Key:      router.StrategyConsistentHashConfig_Domain,
+							Replicas: 50,
+						}),
+					},
 				},
 				Rule: []*router.RoutingRule{
 					{
@@ -44,6 +44,12 @@ func mergeConfigs(files []*core.ConfigSource) (*conf.Config, error) {
 	return cf, nil
 }
 
+// DecodeConfigFromFiles decodes and merges the config files without building
+// them.
+func DecodeConfigFromFiles(files []*core.ConfigSource) (*conf.Config, error) {
+	return mergeConfigs(files)
+}
+
 func BuildConfig(files []*core.ConfigSource) (*core.Config, error) {
 	config, err := mergeConfigs(files)
 	if err != nil {
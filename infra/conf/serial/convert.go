@@ -0,0 +1,506 @@
+package serial
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/xtls/xray-core/common/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// ConvertConfig converts a config between the json, yaml and toml formats.
+//
+// Comments are kept, as far as the target format has a place for them. As
+// TOML puts tables after the other keys of a table, keys may be reordered
+// from and to TOML. TOML has no null, so null values are dropped.
+func ConvertConfig(data []byte, from, to string) ([]byte, error) {
+	var doc *yaml.Node
+	var err error
+	switch from {
+	case "json":
+		doc, err = decodeJSONNode(data)
+	case "yaml":
+		doc = new(yaml.Node)
+		err = yaml.Unmarshal(data, doc)
+	case "toml":
+		doc, err = decodeTOMLNode(data)
+	default:
+		return nil, errors.New("unsupported config format: ", from)
+	}
+	if err != nil {
+		return nil, errors.New("failed to decode ", from, " config").Base(err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("config is not an object")
+	}
+
+	switch to {
+	case "json":
+		return encodeJSONNode(doc)
+	case "yaml":
+		var b bytes.Buffer
+		encoder := yaml.NewEncoder(&b)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(doc); err != nil {
+			return nil, errors.New("failed to encode yaml config").Base(err)
+		}
+		return b.Bytes(), nil
+	case "toml":
+		return encodeTOMLNode(doc)
+	default:
+		return nil, errors.New("unsupported config format: ", to)
+	}
+}
+
+// commentLines splits comments of nodes into lines without their markers.
+func commentLines(comments ...string) []string {
+	var lines []string
+	for _, comment := range comments {
+		for _, line := range strings.Split(comment, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			line = strings.TrimPrefix(line, "#")
+			lines = append(lines, strings.TrimPrefix(line, " "))
+		}
+	}
+	return lines
+}
+
+// joinComments returns lines as comments of a node.
+func joinComments(lines []string) string {
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(strings.TrimSpace("# " + line))
+	}
+	return b.String()
+}
+
+// resolve returns the node an alias refers to.
+func resolve(n *yaml.Node) (*yaml.Node, error) {
+	for n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if n.Kind == yaml.MappingNode {
+		for i := 0; i < len(n.Content); i += 2 {
+			if n.Content[i].Tag == "!!merge" {
+				return nil, errors.New("yaml merge keys are not supported")
+			}
+		}
+	}
+	return n, nil
+}
+
+// scalarValue decodes a scalar node into a JSON value.
+func scalarValue(n *yaml.Node) (interface{}, error) {
+	switch n.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!bool", "!!int", "!!float":
+		var v interface{}
+		if err := n.Decode(&v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	default:
+		return n.Value, nil
+	}
+}
+
+// marshalJSON is json.Marshal without escaping HTML.
+func marshalJSON(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// jsonDecoder decodes JSON with comments into a yaml.Node.
+type jsonDecoder struct {
+	data     []byte
+	pos      int
+	comments []string
+}
+
+func decodeJSONNode(data []byte) (*yaml.Node, error) {
+	d := &jsonDecoder{data: data}
+	d.skip()
+	doc := &yaml.Node{Kind: yaml.DocumentNode}
+	doc.HeadComment = d.takeComments()
+	root, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	if root.LineComment != "" {
+		doc.HeadComment = joinComments(commentLines(doc.HeadComment, root.LineComment))
+		root.LineComment = ""
+	}
+	d.skip()
+	if d.pos < len(d.data) {
+		return nil, d.error("unexpected data after the config")
+	}
+	doc.FootComment = d.takeComments()
+	doc.Content = []*yaml.Node{root}
+	return doc, nil
+}
+
+func (d *jsonDecoder) error(msg string) error {
+	line := bytes.Count(d.data[:d.pos], []byte("\n")) + 1
+	return errors.New(msg, " at line ", line)
+}
+
+// skip skips white space and collects the comments on its way.
+func (d *jsonDecoder) skip() {
+	for d.pos < len(d.data) {
+		switch c := d.data[d.pos]; {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			d.pos++
+		case c == '#' || c == '/' && d.peek(1) == '/' || c == '/' && d.peek(1) == '*':
+			d.comments = append(d.comments, d.comment()...)
+		default:
+			return
+		}
+	}
+}
+
+func (d *jsonDecoder) peek(offset int) byte {
+	if d.pos+offset < len(d.data) {
+		return d.data[d.pos+offset]
+	}
+	return 0
+}
+
+// comment reads a comment at the current position.
+func (d *jsonDecoder) comment() []string {
+	if d.data[d.pos] == '/' && d.peek(1) == '*' {
+		end := bytes.Index(d.data[d.pos+2:], []byte("*/"))
+		if end < 0 {
+			end = len(d.data) - d.pos - 2
+		}
+		text := string(d.data[d.pos+2 : d.pos+2+end])
+		d.pos = min(d.pos+end+4, len(d.data))
+		var lines []string
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimSpace(line)
+			line = strings.TrimSpace(strings.TrimPrefix(line, "*"))
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+		return lines
+	}
+	start := d.pos
+	for d.pos < len(d.data) && d.data[d.pos] != '\n' {
+		d.pos++
+	}
+	text := string(d.data[start:d.pos])
+	text = strings.TrimLeft(text, "/#")
+	return []string{strings.TrimSpace(text)}
+}
+
+// lineComment reads a comment after a value on the same line.
+func (d *jsonDecoder) lineComment(n *yaml.Node) {
+	for d.pos < len(d.data) && (d.data[d.pos] == ' ' || d.data[d.pos] == '\t') {
+		d.pos++
+	}
+	if d.pos < len(d.data) && d.data[d.pos] == ',' {
+		return
+	}
+	c := d.peek(0)
+	if c == '#' || c == '/' && (d.peek(1) == '/' || d.peek(1) == '*') {
+		lines := append(commentLines(n.LineComment), d.comment()...)
+		n.LineComment = joinComments(lines)
+	}
+}
+
+func (d *jsonDecoder) takeComments() string {
+	comments := joinComments(d.comments)
+	d.comments = nil
+	return comments
+}
+
+func (d *jsonDecoder) value() (*yaml.Node, error) {
+	if d.pos >= len(d.data) {
+		return nil, d.error("unexpected end of config")
+	}
+	switch d.data[d.pos] {
+	case '{':
+		return d.object()
+	case '[':
+		return d.array()
+	case '"':
+		s, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}, nil
+	}
+
+	start := d.pos
+	for d.pos < len(d.data) && !strings.ContainsRune(",:]} \t\r\n/#", rune(d.data[d.pos])) {
+		d.pos++
+	}
+	literal := string(d.data[start:d.pos])
+	var v interface{}
+	if err := json.Unmarshal([]byte(literal), &v); err != nil {
+		d.pos = start
+		return nil, d.error("invalid value " + literal)
+	}
+	n := &yaml.Node{Kind: yaml.ScalarNode, Value: literal}
+	switch v.(type) {
+	case nil:
+		n.Tag = "!!null"
+	case bool:
+		n.Tag = "!!bool"
+	default:
+		n.Tag = "!!int"
+		if strings.ContainsAny(literal, ".eE") {
+			n.Tag = "!!float"
+		}
+	}
+	return n, nil
+}
+
+func (d *jsonDecoder) string() (string, error) {
+	start := d.pos
+	d.pos++
+	for d.pos < len(d.data) && d.data[d.pos] != '"' {
+		if d.data[d.pos] == '\\' {
+			d.pos++
+		}
+		d.pos++
+	}
+	if d.pos >= len(d.data) {
+		d.pos = start
+		return "", d.error("unterminated string")
+	}
+	d.pos++
+	var s string
+	if err := json.Unmarshal(d.data[start:d.pos], &s); err != nil {
+		d.pos = start
+		return "", d.error("invalid string")
+	}
+	return s, nil
+}
+
+// item reads a value in an object or array, and the comment after it. The
+// comment goes to the LineComment of scalars and of objects and arrays on a
+// single line, and to the FootComment of other objects and arrays.
+func (d *jsonDecoder) item() (*yaml.Node, error) {
+	start := d.pos
+	n, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	tail := n
+	if n.Kind != yaml.ScalarNode && bytes.ContainsRune(d.data[start:d.pos], '\n') {
+		tail = &yaml.Node{}
+	}
+	d.lineComment(tail)
+	d.skip()
+	if d.pos < len(d.data) && d.data[d.pos] == ',' {
+		d.pos++
+		d.lineComment(tail)
+	}
+	if tail != n {
+		n.FootComment = tail.LineComment
+	}
+	return n, nil
+}
+
+func (d *jsonDecoder) object() (*yaml.Node, error) {
+	n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	d.pos++
+	d.lineComment(n)
+	for {
+		d.skip()
+		if d.pos >= len(d.data) {
+			return nil, d.error("unterminated object")
+		}
+		if d.data[d.pos] == '}' {
+			d.pos++
+			if len(n.Content) > 0 {
+				n.Content[len(n.Content)-2].FootComment = d.takeComments()
+			}
+			return n, nil
+		}
+		if d.data[d.pos] != '"' {
+			return nil, d.error("expected a key")
+		}
+		head := d.comments
+		d.comments = nil
+		k, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+		d.skip()
+		if d.pos >= len(d.data) || d.data[d.pos] != ':' {
+			return nil, d.error("expected :")
+		}
+		d.pos++
+		d.skip()
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}
+		key.HeadComment = joinComments(append(head, d.comments...))
+		d.comments = nil
+		v, err := d.item()
+		if err != nil {
+			return nil, err
+		}
+		if v.Kind != yaml.ScalarNode {
+			key.LineComment, v.LineComment = v.LineComment, ""
+			key.FootComment, v.FootComment = v.FootComment, ""
+		}
+		n.Content = append(n.Content, key, v)
+	}
+}
+
+func (d *jsonDecoder) array() (*yaml.Node, error) {
+	n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	d.pos++
+	d.lineComment(n)
+	for {
+		d.skip()
+		if d.pos >= len(d.data) {
+			return nil, d.error("unterminated array")
+		}
+		if d.data[d.pos] == ']' {
+			d.pos++
+			if len(n.Content) > 0 {
+				n.Content[len(n.Content)-1].FootComment = d.takeComments()
+			}
+			return n, nil
+		}
+		head := d.comments
+		d.comments = nil
+		v, err := d.item()
+		if err != nil {
+			return nil, err
+		}
+		if v.Kind != yaml.ScalarNode {
+			head = append(head, commentLines(v.LineComment)...)
+			v.LineComment = ""
+		}
+		v.HeadComment = joinComments(head)
+		n.Content = append(n.Content, v)
+	}
+}
+
+// jsonEncoder writes a yaml.Node as JSON with comments.
+type jsonEncoder struct {
+	b bytes.Buffer
+}
+
+func encodeJSONNode(doc *yaml.Node) ([]byte, error) {
+	e := &jsonEncoder{}
+	e.comments("", doc.HeadComment)
+	root := doc.Content[0]
+	if err := e.value(root, "", root.LineComment); err != nil {
+		return nil, err
+	}
+	e.b.WriteByte('\n')
+	e.comments("", root.FootComment, doc.FootComment)
+	return e.b.Bytes(), nil
+}
+
+func (e *jsonEncoder) comments(indent string, comments ...string) {
+	for _, line := range commentLines(comments...) {
+		e.b.WriteString(indent + strings.TrimSpace("// "+line) + "\n")
+	}
+}
+
+func (e *jsonEncoder) lineComment(comments ...string) {
+	if lines := commentLines(comments...); len(lines) > 0 {
+		e.b.WriteString(" // " + strings.Join(lines, " "))
+	}
+}
+
+// value writes n. The comment on the line of the opening bracket of objects
+// and arrays is open.
+func (e *jsonEncoder) value(n *yaml.Node, indent, open string) error {
+	n, err := resolve(n)
+	if err != nil {
+		return err
+	}
+	switch n.Kind {
+	case yaml.MappingNode:
+		if len(n.Content) == 0 {
+			e.b.WriteString("{}")
+			return nil
+		}
+		e.b.WriteString("{")
+		e.lineComment(open)
+		e.b.WriteByte('\n')
+		inner := indent + "  "
+		for i := 0; i < len(n.Content); i += 2 {
+			key, v := n.Content[i], n.Content[i+1]
+			e.comments(inner, key.HeadComment, v.HeadComment)
+			k, _ := marshalJSON(key.Value)
+			e.b.WriteString(inner)
+			e.b.Write(k)
+			e.b.WriteString(": ")
+			if err := e.item(v, inner, i+2 < len(n.Content), key.LineComment); err != nil {
+				return err
+			}
+			e.comments(inner, key.FootComment, v.FootComment)
+		}
+		e.b.WriteString(indent + "}")
+	case yaml.SequenceNode:
+		if len(n.Content) == 0 {
+			e.b.WriteString("[]")
+			return nil
+		}
+		e.b.WriteString("[")
+		e.lineComment(open)
+		e.b.WriteByte('\n')
+		inner := indent + "  "
+		for i, v := range n.Content {
+			e.comments(inner, v.HeadComment)
+			e.b.WriteString(inner)
+			if err := e.item(v, inner, i+1 < len(n.Content), ""); err != nil {
+				return err
+			}
+			e.comments(inner, v.FootComment)
+		}
+		e.b.WriteString(indent + "]")
+	default:
+		v, err := scalarValue(n)
+		if err != nil {
+			return err
+		}
+		b, err := marshalJSON(v)
+		if err != nil {
+			return err
+		}
+		e.b.Write(b)
+	}
+	return nil
+}
+
+// item writes a value in an object or array, and the comma and comment
+// after it.
+func (e *jsonEncoder) item(v *yaml.Node, indent string, comma bool, keyComment string) error {
+	scalar := v.Kind == yaml.ScalarNode
+	open := ""
+	if !scalar {
+		open = joinComments(commentLines(keyComment, v.LineComment))
+	}
+	if err := e.value(v, indent, open); err != nil {
+		return err
+	}
+	if comma {
+		e.b.WriteByte(',')
+	}
+	if scalar {
+		e.lineComment(keyComment, v.LineComment)
+	}
+	e.b.WriteByte('\n')
+	return nil
+}
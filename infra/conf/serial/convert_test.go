@@ -0,0 +1,141 @@
+package serial_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/infra/conf/serial"
+	"google.golang.org/protobuf/proto"
+)
+
+const convertInput = `// Xray config
+
+{
+  "log": {"loglevel": "warning"}, // quiet
+  "inbounds": [
+    // the socks inbound
+    {
+      "port": 1080, // local port
+      "protocol": "socks",
+      "settings": {"udp": true, "ratio": 1.5},
+      "tag": "socks:in"
+    }
+  ],
+  "outbounds": [{"protocol": "freedom"}]
+  /* outbounds end */
+}
+`
+
+func TestConvertConfigRoundTrip(t *testing.T) {
+	decode := func(f func(io.Reader) (*core.Config, error)) func([]byte) *core.Config {
+		return func(b []byte) *core.Config {
+			return common.Must2(f(bytes.NewReader(b)))
+		}
+	}
+	decoders := map[string]func([]byte) *core.Config{
+		"json": decode(serial.LoadJSONConfig),
+		"yaml": decode(serial.LoadYAMLConfig),
+		"toml": decode(serial.LoadTOMLConfig),
+	}
+	expected := decoders["json"]([]byte(convertInput))
+
+	for _, format := range []string{"yaml", "toml", "json"} {
+		converted, err := serial.ConvertConfig([]byte(convertInput), "json", format)
+		if err != nil {
+			t.Fatal(format, ": ", err)
+		}
+		for _, comment := range []string{"Xray config", "quiet", "the socks inbound", "local port", "outbounds end"} {
+			if !strings.Contains(string(converted), comment) {
+				t.Error(format, " lost comment ", comment, ":\n", string(converted))
+			}
+		}
+		if !proto.Equal(decoders[format](converted), expected) {
+			t.Error(format, ": config changed")
+		}
+
+		back, err := serial.ConvertConfig(converted, format, "json")
+		if err != nil {
+			t.Fatal(format, ": ", err)
+		}
+		if !proto.Equal(decoders["json"](back), expected) {
+			t.Error(format, " to json: config changed")
+		}
+		for _, comment := range []string{"Xray config", "quiet", "the socks inbound", "local port", "outbounds end"} {
+			if !strings.Contains(string(back), comment) {
+				t.Error(format, " to json lost comment ", comment, ":\n", string(back))
+			}
+		}
+	}
+}
+
+func TestConvertConfigYAML(t *testing.T) {
+	converted, err := serial.ConvertConfig([]byte(convertInput), "json", "yaml")
+	common.Must(err)
+	expected := `# Xray config
+
+log: # quiet
+  loglevel: warning
+inbounds:
+  # the socks inbound
+  - port: 1080 # local port
+    protocol: socks
+    settings:
+      udp: true
+      ratio: 1.5
+    tag: socks:in
+outbounds:
+  - protocol: freedom
+# outbounds end
+`
+	if r := cmp.Diff(string(converted), expected); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestConvertConfigTOML(t *testing.T) {
+	converted, err := serial.ConvertConfig([]byte(convertInput), "json", "toml")
+	common.Must(err)
+	expected := `# Xray config
+
+[log] # quiet
+loglevel = "warning"
+
+# the socks inbound
+[[inbounds]]
+port = 1080 # local port
+protocol = "socks"
+tag = "socks:in"
+
+[inbounds.settings]
+udp = true
+ratio = 1.5
+
+[[outbounds]]
+protocol = "freedom"
+# outbounds end
+`
+	if r := cmp.Diff(string(converted), expected); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestConvertConfigTOMLIntegerRange(t *testing.T) {
+	for _, input := range []string{
+		`{"a": 12345678901234567890}`,
+		`{"a": -9223372036854775809}`,
+	} {
+		if converted, err := serial.ConvertConfig([]byte(input), "json", "toml"); err == nil {
+			t.Error("converted ", input, " to ", string(converted))
+		}
+	}
+	converted, err := serial.ConvertConfig([]byte(`{"a": 9223372036854775807}`), "json", "toml")
+	common.Must(err)
+	if s := string(converted); s != "a = 9223372036854775807\n" {
+		t.Error("converted to ", s)
+	}
+}
@@ -0,0 +1,423 @@
+package serial
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml"
+	"github.com/xtls/xray-core/common/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// tomlComments finds the comments around the keys of a TOML document by
+// their lines, as the TOML parser drops comments.
+type tomlComments struct {
+	lines []string
+	used  map[int]bool
+}
+
+// trailing returns the comment after the content on line i, if any.
+func (c *tomlComments) trailing(i int) (string, bool) {
+	var quote byte
+	line := c.lines[i]
+	for j := 0; j < len(line); j++ {
+		switch ch := line[j]; {
+		case quote != 0:
+			if ch == '\\' && quote == '"' {
+				j++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '#':
+			return line[j:], strings.TrimSpace(line[:j]) != ""
+		}
+	}
+	return "", false
+}
+
+func (c *tomlComments) isComment(i int) bool {
+	return strings.HasPrefix(strings.TrimSpace(c.lines[i]), "#")
+}
+
+// head returns the comment lines above line (1-based), up to the previous
+// content.
+func (c *tomlComments) head(line int) string {
+	var comments []string
+	for i := line - 2; i >= 0 && !c.used[i]; i-- {
+		if !c.isComment(i) {
+			if strings.TrimSpace(c.lines[i]) != "" {
+				break
+			}
+			continue
+		}
+		c.used[i] = true
+		comments = append([]string{strings.TrimSpace(c.lines[i])}, comments...)
+	}
+	return strings.Join(comments, "\n")
+}
+
+// line returns the comment at the end of line (1-based).
+func (c *tomlComments) line(line int) string {
+	i := line - 1
+	if i < 0 || i >= len(c.lines) || c.used[i] {
+		return ""
+	}
+	if comment, afterContent := c.trailing(i); afterContent {
+		c.used[i] = true
+		return comment
+	}
+	return ""
+}
+
+// rest returns the comment lines from line i that are not used yet.
+func (c *tomlComments) rest(from, to int) string {
+	var comments []string
+	for i := from; i < to && i < len(c.lines); i++ {
+		if c.isComment(i) && !c.used[i] {
+			c.used[i] = true
+			comments = append(comments, strings.TrimSpace(c.lines[i]))
+		}
+	}
+	return strings.Join(comments, "\n")
+}
+
+func decodeTOMLNode(data []byte) (*yaml.Node, error) {
+	tree, err := toml.LoadBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	c := &tomlComments{
+		lines: strings.Split(string(data), "\n"),
+		used:  make(map[int]bool),
+	}
+
+	// the leading comments separated from the first key by an empty line
+	// belong to the document
+	doc := &yaml.Node{Kind: yaml.DocumentNode}
+	end := 0
+	for end < len(c.lines) && c.isComment(end) {
+		end++
+	}
+	if end > 0 && end < len(c.lines) && strings.TrimSpace(c.lines[end]) == "" {
+		doc.HeadComment = c.rest(0, end)
+	}
+
+	root, err := tomlTreeNode(tree, c)
+	if err != nil {
+		return nil, err
+	}
+	doc.FootComment = c.rest(0, len(c.lines))
+	doc.Content = []*yaml.Node{root}
+	return doc, nil
+}
+
+func tomlTreeNode(tree *toml.Tree, c *tomlComments) (*yaml.Node, error) {
+	keys := tree.Keys()
+	position := func(key string) toml.Position {
+		p := tree.GetPosition(key)
+		if p.Invalid() {
+			return toml.Position{Line: math.MaxInt}
+		}
+		return p
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		pi, pj := position(keys[i]), position(keys[j])
+		if pi.Line != pj.Line {
+			return pi.Line < pj.Line
+		}
+		return pi.Col < pj.Col
+	})
+
+	n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, key := range keys {
+		k := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+		line := position(key).Line
+		if line != math.MaxInt {
+			k.HeadComment = c.head(line)
+			k.LineComment = c.line(line)
+		}
+		v, err := tomlValueNode(tree.GetPath([]string{key}), c)
+		if err != nil {
+			return nil, err
+		}
+		if v.Kind == yaml.ScalarNode {
+			v.LineComment, k.LineComment = k.LineComment, ""
+		}
+		n.Content = append(n.Content, k, v)
+	}
+	return n, nil
+}
+
+func tomlValueNode(v interface{}, c *tomlComments) (*yaml.Node, error) {
+	scalar := func(tag, value string) *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+	}
+	switch v := v.(type) {
+	case *toml.Tree:
+		return tomlTreeNode(v, c)
+	case []*toml.Tree:
+		n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, t := range v {
+			e, err := tomlTreeNode(t, c)
+			if err != nil {
+				return nil, err
+			}
+			if line := t.Position().Line; line > 0 {
+				e.HeadComment = c.head(line)
+				e.LineComment = c.line(line)
+			}
+			n.Content = append(n.Content, e)
+		}
+		return n, nil
+	case []interface{}:
+		n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, e := range v {
+			en, err := tomlValueNode(e, c)
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, en)
+		}
+		return n, nil
+	case string:
+		return scalar("!!str", v), nil
+	case bool:
+		return scalar("!!bool", strconv.FormatBool(v)), nil
+	case int64:
+		return scalar("!!int", strconv.FormatInt(v, 10)), nil
+	case uint64:
+		return scalar("!!int", strconv.FormatUint(v, 10)), nil
+	case float64:
+		return scalar("!!float", strconv.FormatFloat(v, 'g', -1, 64)), nil
+	case time.Time:
+		return scalar("!!str", v.Format(time.RFC3339Nano)), nil
+	case fmt.Stringer:
+		// local dates and times
+		return scalar("!!str", v.String()), nil
+	default:
+		return nil, errors.New("unsupported toml value: ", v)
+	}
+}
+
+// tomlEncoder writes a yaml.Node as TOML with comments.
+type tomlEncoder struct {
+	b bytes.Buffer
+}
+
+func encodeTOMLNode(doc *yaml.Node) ([]byte, error) {
+	e := &tomlEncoder{}
+	e.comments(doc.HeadComment)
+	e.b.WriteByte('\n')
+	root := doc.Content[0]
+	if err := e.table(root, nil); err != nil {
+		return nil, err
+	}
+	if doc.FootComment != "" || root.FootComment != "" {
+		e.b.WriteByte('\n')
+		e.comments(root.FootComment, doc.FootComment)
+	}
+	b := bytes.TrimLeft(e.b.Bytes(), "\n")
+	for bytes.Contains(b, []byte("\n\n\n")) {
+		b = bytes.ReplaceAll(b, []byte("\n\n\n"), []byte("\n\n"))
+	}
+	return b, nil
+}
+
+func (e *tomlEncoder) comments(comments ...string) {
+	for _, line := range commentLines(comments...) {
+		e.b.WriteString(strings.TrimSpace("# "+line) + "\n")
+	}
+}
+
+func (e *tomlEncoder) lineComment(comments ...string) {
+	if lines := commentLines(comments...); len(lines) > 0 {
+		e.b.WriteString(" # " + strings.Join(lines, " "))
+	}
+}
+
+// isTable returns whether n is written as a table.
+func isTable(n *yaml.Node) bool {
+	return n.Kind == yaml.MappingNode && len(n.Content) > 0
+}
+
+// isTableArray returns whether n is written as an array of tables.
+func isTableArray(n *yaml.Node) bool {
+	if n.Kind != yaml.SequenceNode || len(n.Content) == 0 {
+		return false
+	}
+	for _, e := range n.Content {
+		if e, err := resolve(e); err != nil || e.Kind != yaml.MappingNode {
+			return false
+		}
+	}
+	return true
+}
+
+// table writes the keys of mapping n, and then its tables.
+func (e *tomlEncoder) table(n *yaml.Node, path []string) error {
+	n, err := resolve(n)
+	if err != nil {
+		return err
+	}
+	type pair struct{ key, value *yaml.Node }
+	var tables []pair
+	for i := 0; i < len(n.Content); i += 2 {
+		key := n.Content[i]
+		value, err := resolve(n.Content[i+1])
+		if err != nil {
+			return err
+		}
+		if value.ShortTag() == "!!null" {
+			continue
+		}
+		if isTable(value) || isTableArray(value) {
+			tables = append(tables, pair{key, value})
+			continue
+		}
+		e.comments(key.HeadComment, value.HeadComment)
+		e.b.WriteString(tomlKey(key.Value) + " = ")
+		if err := e.inline(value); err != nil {
+			return errors.New("invalid value of ", strings.Join(append(path, key.Value), ".")).Base(err)
+		}
+		e.lineComment(key.LineComment, value.LineComment)
+		e.b.WriteByte('\n')
+		e.comments(key.FootComment, value.FootComment)
+	}
+
+	for _, t := range tables {
+		tablePath := append(append([]string{}, path...), tomlKey(t.key.Value))
+		name := strings.Join(tablePath, ".")
+		if t.value.Kind == yaml.MappingNode {
+			e.b.WriteByte('\n')
+			e.comments(t.key.HeadComment, t.value.HeadComment)
+			e.b.WriteString("[" + name + "]")
+			e.lineComment(t.key.LineComment, t.value.LineComment)
+			e.b.WriteByte('\n')
+			if err := e.table(t.value, tablePath); err != nil {
+				return err
+			}
+			e.comments(t.key.FootComment, t.value.FootComment)
+			continue
+		}
+		for i, element := range t.value.Content {
+			e.b.WriteByte('\n')
+			if i == 0 {
+				e.comments(t.key.HeadComment)
+			}
+			e.comments(element.HeadComment)
+			e.b.WriteString("[[" + name + "]]")
+			if i == 0 {
+				e.lineComment(t.key.LineComment, t.value.LineComment, element.LineComment)
+			} else {
+				e.lineComment(element.LineComment)
+			}
+			e.b.WriteByte('\n')
+			if err := e.table(element, tablePath); err != nil {
+				return err
+			}
+			e.comments(element.FootComment)
+		}
+		e.comments(t.key.FootComment, t.value.FootComment)
+	}
+	return nil
+}
+
+// inline writes n as an inline value.
+func (e *tomlEncoder) inline(n *yaml.Node) error {
+	n, err := resolve(n)
+	if err != nil {
+		return err
+	}
+	switch n.Kind {
+	case yaml.MappingNode:
+		e.b.WriteString("{")
+		first := true
+		for i := 0; i < len(n.Content); i += 2 {
+			value, err := resolve(n.Content[i+1])
+			if err != nil {
+				return err
+			}
+			if value.ShortTag() == "!!null" {
+				continue
+			}
+			if !first {
+				e.b.WriteString(",")
+			}
+			first = false
+			e.b.WriteString(" " + tomlKey(n.Content[i].Value) + " = ")
+			if err := e.inline(value); err != nil {
+				return err
+			}
+		}
+		if !first {
+			e.b.WriteString(" ")
+		}
+		e.b.WriteString("}")
+	case yaml.SequenceNode:
+		e.b.WriteString("[")
+		for i, element := range n.Content {
+			if i > 0 {
+				e.b.WriteString(", ")
+			}
+			if err := e.inline(element); err != nil {
+				return err
+			}
+		}
+		e.b.WriteString("]")
+	default:
+		v, err := scalarValue(n)
+		if err != nil {
+			if n.ShortTag() == "!!int" {
+				return errors.New("integer ", n.Value, " is out of the range of toml")
+			}
+			return err
+		}
+		switch v := v.(type) {
+		case nil:
+			return errors.New("toml has no null")
+		case uint64:
+			// toml integers are 64-bit signed
+			if v > math.MaxInt64 {
+				return errors.New("integer ", v, " is out of the range of toml")
+			}
+			fmt.Fprint(&e.b, v)
+		case string:
+			b, _ := marshalJSON(v)
+			e.b.Write(b)
+		case float64:
+			s := strconv.FormatFloat(v, 'g', -1, 64)
+			switch {
+			case math.IsInf(v, 1):
+				s = "inf"
+			case math.IsInf(v, -1):
+				s = "-inf"
+			case math.IsNaN(v):
+				s = "nan"
+			case !strings.ContainsAny(s, ".e"):
+				s += ".0"
+			}
+			e.b.WriteString(s)
+		default:
+			fmt.Fprint(&e.b, v)
+		}
+	}
+	return nil
+}
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func tomlKey(key string) string {
+	if bareKey.MatchString(key) {
+		return key
+	}
+	b, _ := marshalJSON(key)
+	return string(b)
+}
@@ -23,6 +23,14 @@ func cipherFromString(c string) shadowsocks.CipherType {
 		return shadowsocks.CipherType_CHACHA20_POLY1305
 	case "xchacha20-poly1305", "aead_xchacha20_poly1305", "xchacha20-ietf-poly1305":
 		return shadowsocks.CipherType_XCHACHA20_POLY1305
+	case "aes-128-cfb":
+		return shadowsocks.CipherType_AES_128_CFB
+	case "aes-256-cfb":
+		return shadowsocks.CipherType_AES_256_CFB
+	case "chacha20":
+		return shadowsocks.CipherType_CHACHA20
+	case "chacha20-ietf":
+		return shadowsocks.CipherType_CHACHA20_IETF
 	case "none", "plain":
 		return shadowsocks.CipherType_NONE
 	default:
@@ -89,6 +97,9 @@ func (v *ShadowsocksServerConfig) Build() (proto.Message, error) {
 		if account.CipherType == shadowsocks.CipherType_UNKNOWN {
 			return nil, errors.New("unknown cipher method: ", v.Cipher)
 		}
+		if account.CipherType < shadowsocks.CipherType_AES_128_GCM {
+			return nil, errors.New("stream cipher method is only supported by outbounds: ", v.Cipher)
+		}
 		config.Users = append(config.Users, &protocol.User{
 			Email:   v.Email,
 			Level:   uint32(v.Level),
@@ -152,6 +163,7 @@ func buildShadowsocks2022(v *ShadowsocksServerConfig) (proto.Message, error) {
 		config.Destinations = append(config.Destinations, &shadowsocks_2022.RelayDestination{
 			Key:     user.Password,
 			Email:   user.Email,
+			Level:   int32(user.Level),
 			Address: user.Address.Build(),
 			Port:    uint32(user.Port),
 		})
@@ -182,6 +194,9 @@ type ShadowsocksClientConfig struct {
 	UoT        bool                       `json:"uot"`
 	UoTVersion int                        `json:"uotVersion"`
 	Servers    []*ShadowsocksServerTarget `json:"servers"`
+	Plugin     string                     `json:"plugin"`
+	PluginOpts string                     `json:"pluginOpts"`
+	PluginArgs []string                   `json:"pluginArgs"`
 }
 
 func (v *ShadowsocksClientConfig) Build() (proto.Message, error) {
@@ -207,6 +222,9 @@ func (v *ShadowsocksClientConfig) Build() (proto.Message, error) {
 	if len(v.Servers) == 1 {
 		server := v.Servers[0]
 		if C.Contains(shadowaead_2022.List, server.Cipher) {
+			if v.Plugin != "" {
+				return nil, errors.New("Shadowsocks 2022 does not support plugins")
+			}
 			if server.Address == nil {
 				return nil, errors.New("Shadowsocks server address is not set.")
 			}
@@ -265,6 +283,9 @@ func (v *ShadowsocksClientConfig) Build() (proto.Message, error) {
 		config.Server = ss
 		break
 	}
+	config.Plugin = v.Plugin
+	config.PluginOpts = v.PluginOpts
+	config.PluginArgs = v.PluginArgs
 
 	return config, nil
 }
@@ -33,4 +33,49 @@ func TestShadowsocksServerConfigParsing(t *testing.T) {
 			},
 		},
 	})
+
+	for _, input := range []string{
+		`{"method": "aes-256-cfb", "password": "xray-password"}`,
+		`{"clients": [{"method": "chacha20-ietf", "password": "xray-password"}]}`,
+	} {
+		if _, err := loadJSON(creator)(input); err == nil {
+			t.Error("stream cipher accepted by inbound: ", input)
+		}
+	}
+}
+
+func TestShadowsocksClientConfigParsing(t *testing.T) {
+	creator := func() Buildable {
+		return new(ShadowsocksClientConfig)
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"address": "127.0.0.1",
+				"port": 8388,
+				"method": "chacha20-poly1305",
+				"password": "xray-password",
+				"plugin": "obfs-local",
+				"pluginOpts": "obfs=http;obfs-host=example.com",
+				"pluginArgs": ["-v"]
+			}`,
+			Parser: loadJSON(creator),
+			Output: &shadowsocks.ClientConfig{
+				Server: &protocol.ServerEndpoint{
+					Address: net.NewIPOrDomain(net.LocalHostIP),
+					Port:    8388,
+					User: &protocol.User{
+						Account: serial.ToTypedMessage(&shadowsocks.Account{
+							CipherType: shadowsocks.CipherType_CHACHA20_POLY1305,
+							Password:   "xray-password",
+						}),
+					},
+				},
+				Plugin:     "obfs-local",
+				PluginOpts: "obfs=http;obfs-host=example.com",
+				PluginArgs: []string{"-v"},
+			},
+		},
+	})
 }
@@ -0,0 +1,234 @@
+package sharelink
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/infra/conf"
+)
+
+// account holds the fields of servers and users in the settings of
+// outbounds and inbounds, in both the flat and the nested forms.
+type account struct {
+	Address    string `json:"address"`
+	Port       uint16 `json:"port"`
+	ID         string `json:"id"`
+	Password   string `json:"password"`
+	Method     string `json:"method"`
+	Security   string `json:"security"`
+	Flow       string `json:"flow"`
+	Encryption string `json:"encryption"`
+	Email      string `json:"email"`
+
+	Users []*account `json:"users"`
+}
+
+// with returns a copy of the server a with the user fields of u.
+func (a *account) with(u *account) *account {
+	merged := *a
+	merged.Users = nil
+	merged.ID = first(u.ID, a.ID)
+	merged.Password = first(u.Password, a.Password)
+	merged.Method = first(u.Method, a.Method)
+	merged.Security = first(u.Security, a.Security)
+	merged.Flow = first(u.Flow, a.Flow)
+	merged.Encryption = first(u.Encryption, a.Encryption)
+	merged.Email = first(u.Email, a.Email)
+	return &merged
+}
+
+// Supported returns whether outbounds of protocol have share links.
+func Supported(protocol string) bool {
+	switch protocol {
+	case "vless", "vmess", "trojan", "shadowsocks":
+		return true
+	}
+	return false
+}
+
+// FromOutbound returns a share link for every server and user of an
+// outbound.
+func FromOutbound(outbound *conf.OutboundDetourConfig) ([]string, error) {
+	var settings struct {
+		account
+		Vnext   []*account `json:"vnext"`
+		Servers []*account `json:"servers"`
+	}
+	if outbound.Settings != nil {
+		if err := json.Unmarshal(*outbound.Settings, &settings); err != nil {
+			return nil, errors.New("invalid settings of outbound ", outbound.Tag).Base(err)
+		}
+	}
+	query, err := streamQuery(outbound.StreamSetting)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := append(settings.Vnext, settings.Servers...)
+	if settings.Address != "" {
+		targets = append([]*account{&settings.account}, targets...)
+	}
+	var links []string
+	for _, target := range targets {
+		users := target.Users
+		if len(users) == 0 {
+			users = []*account{{}}
+		}
+		for _, user := range users {
+			a := target.with(user)
+			link, err := formatLink(outbound.Protocol, a, first(outbound.Tag, a.Email), query)
+			if err != nil {
+				return nil, err
+			}
+			links = append(links, link)
+		}
+	}
+	if len(links) == 0 {
+		return nil, errors.New("outbound ", outbound.Tag, " has no server")
+	}
+	return links, nil
+}
+
+// FromInbound returns a share link for every user of an inbound, for clients
+// that connect to the inbound at address.
+func FromInbound(inbound *conf.InboundDetourConfig, address string) ([]string, error) {
+	var settings struct {
+		Clients    []*account `json:"clients"`
+		Method     string     `json:"method"`
+		Password   string     `json:"password"`
+		Email      string     `json:"email"`
+		Decryption string     `json:"decryption"`
+	}
+	if inbound.Settings != nil {
+		if err := json.Unmarshal(*inbound.Settings, &settings); err != nil {
+			return nil, errors.New("invalid settings of inbound ", inbound.Tag).Base(err)
+		}
+	}
+	if inbound.PortList == nil || len(inbound.PortList.Range) == 0 {
+		return nil, errors.New("inbound ", inbound.Tag, " has no port")
+	}
+	if address == "" && inbound.ListenOn != nil && inbound.ListenOn.Family().IsIP() && !inbound.ListenOn.IP().IsUnspecified() {
+		address = inbound.ListenOn.IP().String()
+	}
+	if address == "" {
+		return nil, errors.New("address of inbound ", inbound.Tag, " is unknown")
+	}
+	if inbound.Protocol == "vless" && settings.Decryption != "" && settings.Decryption != "none" {
+		return nil, errors.New("VLESS encryption of inbound ", inbound.Tag, " has no share link format")
+	}
+	query, err := streamQuery(inbound.StreamSetting)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &account{
+		Address:  address,
+		Port:     uint16(inbound.PortList.Range[0].From),
+		Method:   settings.Method,
+		Password: settings.Password,
+		Email:    settings.Email,
+	}
+	users := settings.Clients
+	if len(users) == 0 {
+		users = []*account{{}}
+	}
+	var links []string
+	for _, user := range users {
+		a := server.with(user)
+		if inbound.Protocol == "shadowsocks" && strings.HasPrefix(a.Method, "2022-") && settings.Password != "" && user.Password != "" {
+			// users of multi-user AEAD-2022 servers need both keys
+			a.Password = settings.Password + ":" + user.Password
+		}
+		link, err := formatLink(inbound.Protocol, a, first(a.Email, inbound.Tag), query)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+func formatLink(protocol string, a *account, name string, streamQuery url.Values) (string, error) {
+	query := url.Values{}
+	for k, v := range streamQuery {
+		query[k] = v
+	}
+	u := &url.URL{
+		Scheme:   protocol,
+		Host:     hostPort(a.Address, a.Port),
+		Fragment: name,
+	}
+	switch protocol {
+	case "vless":
+		u.User = url.User(a.ID)
+		query.Set("encryption", first(a.Encryption, "none"))
+		if a.Flow != "" {
+			query.Set("flow", a.Flow)
+		}
+	case "trojan":
+		u.User = url.User(a.Password)
+		if a.Flow != "" {
+			query.Set("flow", a.Flow)
+		}
+	case "shadowsocks":
+		u.Scheme = "ss"
+		if strings.HasPrefix(a.Method, "2022-") {
+			u.User = url.UserPassword(a.Method, a.Password)
+		} else {
+			u.User = url.User(base64.RawURLEncoding.EncodeToString([]byte(a.Method + ":" + a.Password)))
+		}
+		if query.Get("type") == "tcp" {
+			query.Del("type")
+		}
+		if query.Get("security") == "none" {
+			query.Del("security")
+		}
+	case "vmess":
+		return formatVMess(a, name, query)
+	default:
+		return "", errors.New("protocol ", protocol, " has no share link format")
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+func formatVMess(a *account, name string, q url.Values) (string, error) {
+	v := &vmessLink{
+		V:    "2",
+		Ps:   name,
+		Add:  a.Address,
+		Port: strconv.Itoa(int(a.Port)),
+		ID:   a.ID,
+		Aid:  "0",
+		Scy:  first(a.Security, "auto"),
+		Net:  q.Get("type"),
+		Type: first(q.Get("headerType"), q.Get("mode"), "none"),
+		Host: q.Get("host"),
+		Path: q.Get("path"),
+		SNI:  q.Get("sni"),
+		ALPN: q.Get("alpn"),
+		FP:   q.Get("fp"),
+	}
+	switch v.Net {
+	case "grpc":
+		v.Path = q.Get("serviceName")
+	case "kcp":
+		v.Path = q.Get("seed")
+	}
+	switch security := q.Get("security"); security {
+	case "none":
+	case "tls":
+		v.TLS = security
+	default:
+		return "", errors.New("security ", security, " has no vmess share link format")
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return "vmess://" + base64.StdEncoding.EncodeToString(b), nil
+}
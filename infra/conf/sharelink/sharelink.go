@@ -0,0 +1,268 @@
+// Package sharelink converts between outbound configs and the share links
+// clients commonly exchange, like vless://, vmess://, trojan:// and ss://.
+package sharelink
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/xtls/xray-core/common/errors"
+)
+
+// Outbound is the JSON config of an outbound parsed from a share link.
+type Outbound struct {
+	Tag            string                 `json:"tag,omitempty"`
+	Protocol       string                 `json:"protocol"`
+	Settings       map[string]interface{} `json:"settings"`
+	StreamSettings map[string]interface{} `json:"streamSettings,omitempty"`
+}
+
+// Parse converts a share link into an outbound config.
+func Parse(link string) (*Outbound, error) {
+	link = strings.TrimSpace(link)
+	scheme, _, found := strings.Cut(link, "://")
+	if !found {
+		return nil, errors.New("not a share link: ", link)
+	}
+	switch strings.ToLower(scheme) {
+	case "vless":
+		return parseVLESS(link)
+	case "vmess":
+		return parseVMess(link)
+	case "trojan":
+		return parseTrojan(link)
+	case "ss":
+		return parseShadowsocks(link)
+	default:
+		return nil, errors.New("unsupported share link scheme: ", scheme)
+	}
+}
+
+// server is the common part of share links, with the transport and security
+// in query parameters the way vless:// links carry them.
+type server struct {
+	name    string
+	address string
+	port    uint16
+	user    string
+	query   url.Values
+}
+
+func parseURL(link string) (*server, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, errors.New("invalid share link").Base(err)
+	}
+	if u.User == nil || u.Hostname() == "" {
+		return nil, errors.New("share link without user or host: ", link)
+	}
+	port, err := strconv.ParseUint(u.Port(), 10, 16)
+	if err != nil || port == 0 {
+		return nil, errors.New("invalid port in share link: ", u.Port())
+	}
+	return &server{
+		name:    u.Fragment,
+		address: u.Hostname(),
+		port:    uint16(port),
+		user:    u.User.Username(),
+		query:   u.Query(),
+	}, nil
+}
+
+func (s *server) outbound(protocol string, settings map[string]interface{}) (*Outbound, error) {
+	stream, err := streamSettings(s.query)
+	if err != nil {
+		return nil, err
+	}
+	settings["address"] = s.address
+	settings["port"] = s.port
+	return &Outbound{
+		Tag:            s.name,
+		Protocol:       protocol,
+		Settings:       settings,
+		StreamSettings: stream,
+	}, nil
+}
+
+func parseVLESS(link string) (*Outbound, error) {
+	s, err := parseURL(link)
+	if err != nil {
+		return nil, err
+	}
+	settings := map[string]interface{}{
+		"id":         s.user,
+		"encryption": "none",
+	}
+	if encryption := s.query.Get("encryption"); encryption != "" {
+		settings["encryption"] = encryption
+	}
+	if flow := s.query.Get("flow"); flow != "" {
+		settings["flow"] = flow
+	}
+	return s.outbound("vless", settings)
+}
+
+func parseTrojan(link string) (*Outbound, error) {
+	s, err := parseURL(link)
+	if err != nil {
+		return nil, err
+	}
+	if !s.query.Has("security") {
+		s.query.Set("security", "tls")
+	}
+	settings := map[string]interface{}{
+		"password": s.user,
+	}
+	if flow := s.query.Get("flow"); flow != "" {
+		settings["flow"] = flow
+	}
+	return s.outbound("trojan", settings)
+}
+
+func parseShadowsocks(link string) (*Outbound, error) {
+	body := strings.TrimPrefix(link[len("ss://"):], "//")
+	body, name, _ := strings.Cut(body, "#")
+	if !strings.Contains(body, "@") {
+		// legacy links encode everything but the name
+		decoded, err := decodeBase64(strings.TrimSuffix(body, "/"))
+		if err != nil {
+			return nil, errors.New("invalid shadowsocks share link").Base(err)
+		}
+		body = string(decoded)
+	}
+	link = "ss://" + body
+	if name != "" {
+		link += "#" + name
+	}
+
+	s, err := parseURL(link)
+	if err != nil {
+		return nil, err
+	}
+	if plugin := s.query.Get("plugin"); plugin != "" {
+		return nil, errors.New("shadowsocks plugins are not supported: ", plugin)
+	}
+	u, _ := url.Parse(link)
+	userInfo := u.User.String()
+	if password, found := u.User.Password(); found {
+		// SIP002 allows plain user info for AEAD-2022 ciphers
+		userInfo = u.User.Username() + ":" + password
+	} else if decoded, err := decodeBase64(s.user); err == nil {
+		userInfo = string(decoded)
+	}
+	method, password, found := strings.Cut(userInfo, ":")
+	if !found {
+		return nil, errors.New("shadowsocks share link without method or password")
+	}
+	return s.outbound("shadowsocks", map[string]interface{}{
+		"method":   method,
+		"password": password,
+	})
+}
+
+// vmessLink is the JSON of vmess:// links, as v2rayN defines it.
+type vmessLink struct {
+	V    interface{} `json:"v,omitempty"`
+	Ps   string      `json:"ps"`
+	Add  string      `json:"add"`
+	Port interface{} `json:"port"`
+	ID   string      `json:"id"`
+	Aid  interface{} `json:"aid"`
+	Scy  string      `json:"scy,omitempty"`
+	Net  string      `json:"net"`
+	Type string      `json:"type"`
+	Host string      `json:"host"`
+	Path string      `json:"path"`
+	TLS  string      `json:"tls"`
+	SNI  string      `json:"sni,omitempty"`
+	ALPN string      `json:"alpn,omitempty"`
+	FP   string      `json:"fp,omitempty"`
+}
+
+func parseVMess(link string) (*Outbound, error) {
+	decoded, err := decodeBase64(link[len("vmess://"):])
+	if err != nil {
+		return nil, errors.New("invalid vmess share link").Base(err)
+	}
+	var v vmessLink
+	if err := json.Unmarshal(decoded, &v); err != nil {
+		return nil, errors.New("invalid vmess share link").Base(err)
+	}
+	port, err := strconv.ParseUint(strings.TrimSpace(jsonString(v.Port)), 10, 16)
+	if err != nil || port == 0 {
+		return nil, errors.New("invalid port in vmess share link: ", v.Port)
+	}
+
+	query := url.Values{}
+	setIf := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	setIf("type", v.Net)
+	setIf("security", v.TLS)
+	setIf("sni", v.SNI)
+	setIf("alpn", v.ALPN)
+	setIf("fp", v.FP)
+	setIf("host", v.Host)
+	switch v.Net {
+	case "grpc":
+		setIf("serviceName", v.Path)
+		setIf("mode", v.Type)
+	case "kcp", "mkcp":
+		setIf("seed", v.Path)
+		setIf("headerType", v.Type)
+	case "xhttp", "splithttp":
+		setIf("path", v.Path)
+		setIf("mode", v.Type)
+	default:
+		setIf("path", v.Path)
+		setIf("headerType", v.Type)
+	}
+
+	security := v.Scy
+	if security == "" {
+		security = "auto"
+	}
+	s := &server{
+		name:    v.Ps,
+		address: v.Add,
+		port:    uint16(port),
+		query:   query,
+	}
+	return s.outbound("vmess", map[string]interface{}{
+		"id":       v.ID,
+		"security": security,
+	})
+}
+
+// jsonString returns the string or number v as a string.
+func jsonString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// decodeBase64 decodes standard or URL base64, with or without padding.
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimRight(s, "=")
+	if strings.ContainsAny(s, "-_") {
+		return base64.RawURLEncoding.DecodeString(s)
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
+
+// hostPort joins address and port, with brackets around IPv6 addresses.
+func hostPort(address string, port uint16) string {
+	return net.JoinHostPort(address, strconv.Itoa(int(port)))
+}
@@ -0,0 +1,152 @@
+package sharelink_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/infra/conf"
+	"github.com/xtls/xray-core/infra/conf/sharelink"
+)
+
+// roundTrip parses link, checks that the outbound builds, and converts it
+// back to a share link.
+func roundTrip(t *testing.T, link string) (*sharelink.Outbound, string) {
+	t.Helper()
+	outbound, err := sharelink.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := common.Must2(json.Marshal(outbound))
+	config := new(conf.OutboundDetourConfig)
+	common.Must(json.Unmarshal(b, config))
+	if _, err := config.Build(); err != nil {
+		t.Fatal("failed to build ", string(b), ": ", err)
+	}
+	// Build fills in defaults
+	config = new(conf.OutboundDetourConfig)
+	common.Must(json.Unmarshal(b, config))
+	links, err := sharelink.FromOutbound(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 1 {
+		t.Fatal("unexpected links: ", links)
+	}
+	return outbound, links[0]
+}
+
+func TestParseAndFormat(t *testing.T) {
+	cases := []struct {
+		link     string
+		settings map[string]interface{}
+		stream   string
+		back     string
+	}{
+		{
+			link:     "vless://27848739-7e62-4138-9fd3-098a63964b6b@example.com:443?security=reality&sni=www.apple.com&fp=chrome&pbk=tt55239UQpXjpvPBD7BvQoa9bj5XB8fpv3LHs4X3zxk&sid=6ba85179e30d4fc2&type=tcp&flow=xtls-rprx-vision#reality",
+			settings: map[string]interface{}{"address": "example.com", "port": uint16(443), "id": "27848739-7e62-4138-9fd3-098a63964b6b", "encryption": "none", "flow": "xtls-rprx-vision"},
+			stream:   `{"network":"raw","realitySettings":{"fingerprint":"chrome","password":"tt55239UQpXjpvPBD7BvQoa9bj5XB8fpv3LHs4X3zxk","serverName":"www.apple.com","shortId":"6ba85179e30d4fc2"},"security":"reality"}`,
+			back:     "vless://27848739-7e62-4138-9fd3-098a63964b6b@example.com:443?encryption=none&flow=xtls-rprx-vision&fp=chrome&pbk=tt55239UQpXjpvPBD7BvQoa9bj5XB8fpv3LHs4X3zxk&security=reality&sid=6ba85179e30d4fc2&sni=www.apple.com&type=tcp#reality",
+		},
+		{
+			link:     "vless://27848739-7e62-4138-9fd3-098a63964b6b@[2001:db8::1]:8443?security=tls&sni=a.com&type=ws&path=%2Fws%3Fed%3D2048&host=a.com&alpn=h2,http/1.1&insecure=1#ws%20node",
+			settings: map[string]interface{}{"address": "2001:db8::1", "port": uint16(8443), "id": "27848739-7e62-4138-9fd3-098a63964b6b", "encryption": "none"},
+			stream:   `{"network":"ws","security":"tls","tlsSettings":{"allowInsecure":true,"alpn":["h2","http/1.1"],"serverName":"a.com"},"wsSettings":{"host":"a.com","path":"/ws?ed=2048"}}`,
+			back:     "vless://27848739-7e62-4138-9fd3-098a63964b6b@[2001:db8::1]:8443?allowInsecure=1&alpn=h2%2Chttp%2F1.1&encryption=none&host=a.com&path=%2Fws%3Fed%3D2048&security=tls&sni=a.com&type=ws#ws%20node",
+		},
+		{
+			link:     "trojan://pass%40word@t.example.com:443?type=grpc&serviceName=svc&mode=multi&peer=sni.com#trojan",
+			settings: map[string]interface{}{"address": "t.example.com", "port": uint16(443), "password": "pass@word"},
+			stream:   `{"grpcSettings":{"multiMode":true,"serviceName":"svc"},"network":"grpc","security":"tls","tlsSettings":{"serverName":"sni.com"}}`,
+			back:     "trojan://pass%40word@t.example.com:443?mode=multi&security=tls&serviceName=svc&sni=sni.com&type=grpc#trojan",
+		},
+		{
+			link:     "ss://YWVzLTI1Ni1nY206cGFzcw@1.2.3.4:8388#ss",
+			settings: map[string]interface{}{"address": "1.2.3.4", "port": uint16(8388), "method": "aes-256-gcm", "password": "pass"},
+			back:     "ss://YWVzLTI1Ni1nY206cGFzcw@1.2.3.4:8388#ss",
+		},
+		{
+			// legacy link
+			link:     "ss://YWVzLTI1Ni1nY206cGFzc0AxLjIuMy40Ojg0MDA#legacy",
+			settings: map[string]interface{}{"address": "1.2.3.4", "port": uint16(8400), "method": "aes-256-gcm", "password": "pass"},
+			back:     "ss://YWVzLTI1Ni1nY206cGFzcw@1.2.3.4:8400#legacy",
+		},
+		{
+			link:     "ss://2022-blake3-aes-128-gcm:MDEyMzQ1Njc4OWFiY2RlZg%3D%3D%3AZmVkY2JhOTg3NjU0MzIxMA%3D%3D@example.com:8388#ss2022",
+			settings: map[string]interface{}{"address": "example.com", "port": uint16(8388), "method": "2022-blake3-aes-128-gcm", "password": "MDEyMzQ1Njc4OWFiY2RlZg==:ZmVkY2JhOTg3NjU0MzIxMA=="},
+			back:     "ss://2022-blake3-aes-128-gcm:MDEyMzQ1Njc4OWFiY2RlZg==%3AZmVkY2JhOTg3NjU0MzIxMA==@example.com:8388#ss2022",
+		},
+		{
+			// {"v":"2","ps":"vm","add":"vm.example.com","port":443,"id":"27848739-7e62-4138-9fd3-098a63964b6b","aid":0,"net":"ws","type":"none","host":"vs.com","path":"/ws","tls":"tls","sni":"vs.com"}
+			link:     "vmess://eyJ2IjoiMiIsInBzIjoidm0iLCJhZGQiOiJ2bS5leGFtcGxlLmNvbSIsInBvcnQiOjQ0MywiaWQiOiIyNzg0ODczOS03ZTYyLTQxMzgtOWZkMy0wOThhNjM5NjRiNmIiLCJhaWQiOjAsIm5ldCI6IndzIiwidHlwZSI6Im5vbmUiLCJob3N0IjoidnMuY29tIiwicGF0aCI6Ii93cyIsInRscyI6InRscyIsInNuaSI6InZzLmNvbSJ9",
+			settings: map[string]interface{}{"address": "vm.example.com", "port": uint16(443), "id": "27848739-7e62-4138-9fd3-098a63964b6b", "security": "auto"},
+			stream:   `{"network":"ws","security":"tls","tlsSettings":{"serverName":"vs.com"},"wsSettings":{"host":"vs.com","path":"/ws"}}`,
+		},
+	}
+
+	for _, c := range cases {
+		outbound, back := roundTrip(t, c.link)
+		if r := cmp.Diff(outbound.Settings, c.settings); r != "" {
+			t.Error(c.link, r)
+		}
+		if stream := string(common.Must2(json.Marshal(outbound.StreamSettings))); c.stream != "" && stream != c.stream {
+			t.Error(c.link, ": unexpected streamSettings ", stream)
+		}
+		if c.back != "" && back != c.back {
+			t.Error("expected ", c.back, ", got ", back)
+		}
+		// formatting is stable
+		if _, again := roundTrip(t, back); again != back {
+			t.Error("expected ", back, ", got ", again)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, link := range []string{
+		"hysteria2://auth@example.com:443",
+		"vless://27848739-7e62-4138-9fd3-098a63964b6b@example.com:443?type=quic",
+		"vless://27848739-7e62-4138-9fd3-098a63964b6b@example.com:443?security=reality",
+		"trojan://password@example.com",
+		"ss://YWVzLTI1Ni1nY206cGFzcw@1.2.3.4:8388/?plugin=obfs-local%3Bobfs%3Dhttp",
+	} {
+		if _, err := sharelink.Parse(link); err == nil {
+			t.Error("expected an error for ", link)
+		}
+	}
+}
+
+func TestFromInbound(t *testing.T) {
+	config := new(conf.InboundDetourConfig)
+	common.Must(json.Unmarshal([]byte(`{
+		"tag": "in",
+		"port": 443,
+		"protocol": "vless",
+		"settings": {"decryption": "none", "clients": [
+			{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "flow": "xtls-rprx-vision", "email": "alice"},
+			{"id": "27848739-7e62-4138-9fd3-098a63964b6c"}
+		]},
+		"streamSettings": {"security": "reality", "realitySettings": {
+			"target": "www.apple.com:443",
+			"serverNames": ["www.apple.com"],
+			"privateKey": "QKD1pUUKkfkNTgIw9P_cWmw0Tm6Ez1tCVoHcuzLAY3k",
+			"shortIds": ["6ba85179e30d4fc2"]
+		}}
+	}`), config))
+
+	links, err := sharelink.FromInbound(config, "example.com")
+	common.Must(err)
+	expected := []string{
+		"vless://27848739-7e62-4138-9fd3-098a63964b6b@example.com:443?encryption=none&flow=xtls-rprx-vision&fp=chrome&pbk=tt55239UQpXjpvPBD7BvQoa9bj5XB8fpv3LHs4X3zxk&security=reality&sid=6ba85179e30d4fc2&sni=www.apple.com&type=tcp#alice",
+		"vless://27848739-7e62-4138-9fd3-098a63964b6c@example.com:443?encryption=none&fp=chrome&pbk=tt55239UQpXjpvPBD7BvQoa9bj5XB8fpv3LHs4X3zxk&security=reality&sid=6ba85179e30d4fc2&sni=www.apple.com&type=tcp#in",
+	}
+	if r := cmp.Diff(links, expected); r != "" {
+		t.Error(r)
+	}
+
+	if _, err := sharelink.FromInbound(config, ""); err == nil {
+		t.Error("expected an error without address")
+	}
+}
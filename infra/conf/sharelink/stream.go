@@ -0,0 +1,293 @@
+package sharelink
+
+import (
+	"crypto/ecdh"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/infra/conf"
+)
+
+// streamSettings converts the query parameters of a share link into
+// streamSettings, or nil for plain TCP.
+func streamSettings(q url.Values) (map[string]interface{}, error) {
+	stream := make(map[string]interface{})
+	network := strings.ToLower(q.Get("type"))
+	switch network {
+	case "", "tcp", "raw":
+		network = "raw"
+		if q.Get("headerType") == "http" {
+			request := make(map[string]interface{})
+			if path := q.Get("path"); path != "" {
+				request["path"] = strings.Split(path, ",")
+			}
+			if host := q.Get("host"); host != "" {
+				request["headers"] = map[string]interface{}{"Host": strings.Split(host, ",")}
+			}
+			stream["rawSettings"] = map[string]interface{}{
+				"header": map[string]interface{}{"type": "http", "request": request},
+			}
+		}
+	case "ws", "websocket":
+		network = "ws"
+		stream["wsSettings"] = pathAndHost(q)
+	case "httpupgrade":
+		stream["httpupgradeSettings"] = pathAndHost(q)
+	case "xhttp", "splithttp":
+		network = "xhttp"
+		settings := pathAndHost(q)
+		if mode := q.Get("mode"); mode != "" {
+			settings["mode"] = mode
+		}
+		stream["xhttpSettings"] = settings
+	case "grpc":
+		settings := map[string]interface{}{"serviceName": q.Get("serviceName")}
+		if authority := q.Get("authority"); authority != "" {
+			settings["authority"] = authority
+		}
+		if q.Get("mode") == "multi" {
+			settings["multiMode"] = true
+		}
+		stream["grpcSettings"] = settings
+	case "kcp", "mkcp":
+		network = "kcp"
+		settings := make(map[string]interface{})
+		if header := q.Get("headerType"); header != "" && header != "none" {
+			settings["header"] = map[string]interface{}{"type": header}
+		}
+		if seed := q.Get("seed"); seed != "" {
+			settings["seed"] = seed
+		}
+		stream["kcpSettings"] = settings
+	default:
+		return nil, errors.New("unsupported transport in share link: ", network)
+	}
+	stream["network"] = network
+
+	switch security := strings.ToLower(q.Get("security")); security {
+	case "", "none":
+	case "tls", "xtls":
+		settings := make(map[string]interface{})
+		if sni := first(q.Get("sni"), q.Get("peer")); sni != "" {
+			settings["serverName"] = sni
+		}
+		if fp := q.Get("fp"); fp != "" {
+			settings["fingerprint"] = fp
+		}
+		if alpn := q.Get("alpn"); alpn != "" {
+			settings["alpn"] = strings.Split(alpn, ",")
+		}
+		if isTrue(first(q.Get("allowInsecure"), q.Get("insecure"))) {
+			settings["allowInsecure"] = true
+		}
+		stream["security"] = "tls"
+		stream["tlsSettings"] = settings
+	case "reality":
+		settings := map[string]interface{}{
+			"serverName":  first(q.Get("sni"), q.Get("peer")),
+			"fingerprint": first(q.Get("fp"), "chrome"),
+			"password":    first(q.Get("pbk"), q.Get("password")),
+		}
+		if settings["password"] == "" {
+			return nil, errors.New("REALITY share link without public key")
+		}
+		if sid := q.Get("sid"); sid != "" {
+			settings["shortId"] = sid
+		}
+		if spx := q.Get("spx"); spx != "" {
+			settings["spiderX"] = spx
+		}
+		if pqv := q.Get("pqv"); pqv != "" {
+			settings["mldsa65Verify"] = pqv
+		}
+		stream["security"] = "reality"
+		stream["realitySettings"] = settings
+	default:
+		return nil, errors.New("unsupported security in share link: ", security)
+	}
+
+	if len(stream) == 1 {
+		return nil, nil
+	}
+	return stream, nil
+}
+
+func pathAndHost(q url.Values) map[string]interface{} {
+	settings := make(map[string]interface{})
+	if path := q.Get("path"); path != "" {
+		settings["path"] = path
+	}
+	if host := q.Get("host"); host != "" {
+		settings["host"] = host
+	}
+	return settings
+}
+
+// streamQuery converts streamSettings into the query parameters of a share
+// link. The REALITY settings of inbounds are converted to what clients need.
+func streamQuery(stream *conf.StreamConfig) (url.Values, error) {
+	q := url.Values{}
+	setIf := func(key, value string) {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	if stream == nil {
+		q.Set("type", "tcp")
+		return q, nil
+	}
+
+	network := "tcp"
+	if stream.Network != nil {
+		network = strings.ToLower(string(*stream.Network))
+	}
+	switch network {
+	case "tcp", "raw":
+		network = "tcp"
+		settings := stream.RAWSettings
+		if settings == nil {
+			settings = stream.TCPSettings
+		}
+		if settings != nil && len(settings.HeaderConfig) > 0 {
+			var header struct {
+				Type    string `json:"type"`
+				Request struct {
+					Path    conf.StringList            `json:"path"`
+					Headers map[string]conf.StringList `json:"headers"`
+				} `json:"request"`
+			}
+			if err := json.Unmarshal(settings.HeaderConfig, &header); err != nil {
+				return nil, errors.New("invalid raw header").Base(err)
+			}
+			if header.Type == "http" {
+				q.Set("headerType", "http")
+				setIf("path", strings.Join(header.Request.Path, ","))
+				setIf("host", strings.Join(header.Request.Headers["Host"], ","))
+			}
+		}
+	case "ws", "websocket":
+		network = "ws"
+		if s := stream.WSSettings; s != nil {
+			setIf("path", s.Path)
+			setIf("host", first(s.Host, s.Headers["Host"]))
+		}
+	case "httpupgrade":
+		if s := stream.HTTPUPGRADESettings; s != nil {
+			setIf("path", s.Path)
+			setIf("host", first(s.Host, s.Headers["Host"]))
+		}
+	case "xhttp", "splithttp":
+		network = "xhttp"
+		s := stream.XHTTPSettings
+		if s == nil {
+			s = stream.SplitHTTPSettings
+		}
+		if s != nil {
+			setIf("path", s.Path)
+			setIf("host", s.Host)
+			setIf("mode", s.Mode)
+		}
+	case "grpc":
+		if s := stream.GRPCSettings; s != nil {
+			setIf("serviceName", s.ServiceName)
+			setIf("authority", s.Authority)
+			if s.MultiMode {
+				q.Set("mode", "multi")
+			}
+		}
+	case "kcp", "mkcp":
+		network = "kcp"
+		if s := stream.KCPSettings; s != nil {
+			if len(s.HeaderConfig) > 0 {
+				var header struct {
+					Type string `json:"type"`
+				}
+				json.Unmarshal(s.HeaderConfig, &header)
+				setIf("headerType", header.Type)
+			}
+			if s.Seed != nil {
+				setIf("seed", *s.Seed)
+			}
+		}
+	default:
+		return nil, errors.New("transport ", network, " has no share link format")
+	}
+	q.Set("type", network)
+
+	switch security := strings.ToLower(stream.Security); security {
+	case "", "none":
+		q.Set("security", "none")
+	case "tls":
+		q.Set("security", "tls")
+		if s := stream.TLSSettings; s != nil {
+			setIf("sni", s.ServerName)
+			setIf("fp", s.Fingerprint)
+			if s.ALPN != nil {
+				setIf("alpn", strings.Join(*s.ALPN, ","))
+			}
+			if s.Insecure {
+				q.Set("allowInsecure", "1")
+			}
+		}
+	case "reality":
+		q.Set("security", "reality")
+		s := stream.REALITYSettings
+		if s == nil {
+			return nil, errors.New("REALITY without realitySettings")
+		}
+		q.Set("fp", first(s.Fingerprint, "chrome"))
+		if s.PrivateKey != "" {
+			publicKey, err := realityPublicKey(s.PrivateKey)
+			if err != nil {
+				return nil, err
+			}
+			q.Set("pbk", publicKey)
+			if len(s.ServerNames) > 0 {
+				setIf("sni", s.ServerNames[0])
+			}
+			if len(s.ShortIds) > 0 {
+				setIf("sid", s.ShortIds[0])
+			}
+		} else {
+			q.Set("pbk", first(s.Password, s.PublicKey))
+			setIf("sni", s.ServerName)
+			setIf("sid", s.ShortId)
+			setIf("spx", s.SpiderX)
+			setIf("pqv", s.Mldsa65Verify)
+		}
+	default:
+		return nil, errors.New("security ", security, " has no share link format")
+	}
+	return q, nil
+}
+
+// realityPublicKey returns the public key clients use for the REALITY private
+// key of a server.
+func realityPublicKey(privateKey string) (string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(privateKey)
+	if err != nil {
+		return "", errors.New("invalid REALITY private key").Base(err)
+	}
+	key, err := ecdh.X25519().NewPrivateKey(b)
+	if err != nil {
+		return "", errors.New("invalid REALITY private key").Base(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()), nil
+}
+
+// first returns the first non-empty string.
+func first(s ...string) string {
+	for _, v := range s {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func isTrue(s string) bool {
+	return s == "1" || strings.EqualFold(s, "true")
+}
@@ -11,8 +11,10 @@ import (
 )
 
 type SocksAccount struct {
-	Username string `json:"user"`
-	Password string `json:"pass"`
+	Username string  `json:"user"`
+	Password string  `json:"pass"`
+	Email    string  `json:"email"`
+	Level    *uint32 `json:"level"`
 }
 
 func (v *SocksAccount) Build() *socks.Account {
@@ -22,17 +24,36 @@ func (v *SocksAccount) Build() *socks.Account {
 	}
 }
 
+// BuildUser builds the user of the account. Its email defaults to the
+// username, and its level to userLevel.
+func (v *SocksAccount) BuildUser(userLevel uint32) *protocol.User {
+	user := &protocol.User{
+		Email:   v.Email,
+		Level:   userLevel,
+		Account: serial.ToTypedMessage(v.Build()),
+	}
+	if user.Email == "" {
+		user.Email = v.Username
+	}
+	if v.Level != nil {
+		user.Level = *v.Level
+	}
+	return user
+}
+
 const (
 	AuthMethodNoAuth   = "noauth"
 	AuthMethodUserPass = "password"
 )
 
 type SocksServerConfig struct {
-	AuthMethod string          `json:"auth"`
-	Accounts   []*SocksAccount `json:"accounts"`
-	UDP        bool            `json:"udp"`
-	Host       *Address        `json:"ip"`
-	UserLevel  uint32          `json:"userLevel"`
+	AuthMethod   string               `json:"auth"`
+	Accounts     []*SocksAccount      `json:"accounts"`
+	UDP          bool                 `json:"udp"`
+	Host         *Address             `json:"ip"`
+	UserLevel    uint32               `json:"userLevel"`
+	AuthBackends []*AuthBackendConfig `json:"authBackends"`
+	Bind         bool                 `json:"bind"`
 }
 
 func (v *SocksServerConfig) Build() (proto.Message, error) {
@@ -47,14 +68,23 @@ func (v *SocksServerConfig) Build() (proto.Message, error) {
 		config.AuthType = socks.AuthType_NO_AUTH
 	}
 
-	if len(v.Accounts) > 0 {
-		config.Accounts = make(map[string]string, len(v.Accounts))
-		for _, account := range v.Accounts {
-			config.Accounts[account.Username] = account.Password
+	for _, account := range v.Accounts {
+		config.Users = append(config.Users, account.BuildUser(v.UserLevel))
+	}
+
+	if len(v.AuthBackends) > 0 {
+		if config.AuthType != socks.AuthType_PASSWORD {
+			return nil, errors.New(`SOCKS authentication backends require "auth": "password"`)
+		}
+		backends, err := buildAuthBackends(v.AuthBackends)
+		if err != nil {
+			return nil, errors.New("failed to build SOCKS authentication backends").Base(err)
 		}
+		config.AuthBackends = backends
 	}
 
 	config.UdpEnabled = v.UDP
+	config.BindEnabled = v.Bind
 	if v.Host != nil {
 		config.Address = v.Host.Build()
 	}
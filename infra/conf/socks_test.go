@@ -32,9 +32,14 @@ func TestSocksInboundConfig(t *testing.T) {
 			Parser: loadJSON(creator),
 			Output: &socks.ServerConfig{
 				AuthType: socks.AuthType_PASSWORD,
-				Accounts: map[string]string{
-					"my-username": "my-password",
-				},
+				Users: []*protocol.User{{
+					Email: "my-username",
+					Level: 1,
+					Account: serial.ToTypedMessage(&socks.Account{
+						Username: "my-username",
+						Password: "my-password",
+					}),
+				}},
 				UdpEnabled: false,
 				Address: &net.IPOrDomain{
 					Address: &net.IPOrDomain_Ip{
@@ -44,6 +49,17 @@ func TestSocksInboundConfig(t *testing.T) {
 				UserLevel: 1,
 			},
 		},
+		{
+			Input: `{
+				"auth": "noauth",
+				"bind": true
+			}`,
+			Parser: loadJSON(creator),
+			Output: &socks.ServerConfig{
+				AuthType:    socks.AuthType_NO_AUTH,
+				BindEnabled: true,
+			},
+		},
 	})
 }
 
@@ -1,9 +1,12 @@
 package conf
 
 import (
+	"encoding/hex"
 	"sort"
+	"strings"
 
 	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/transport/internet/headers/custom"
 	"github.com/xtls/xray-core/transport/internet/headers/dns"
 	"github.com/xtls/xray-core/transport/internet/headers/http"
 	"github.com/xtls/xray-core/transport/internet/headers/noop"
@@ -70,6 +73,111 @@ func (DTLSAuthenticator) Build() (proto.Message, error) {
 	return new(tls.PacketConfig), nil
 }
 
+type CustomHeaderSegment struct {
+	Type         string `json:"type"`
+	Hex          string `json:"hex"`
+	Text         string `json:"text"`
+	Length       uint32 `json:"length"`
+	Min          uint32 `json:"min"`
+	Max          uint32 `json:"max"`
+	Size         uint32 `json:"size"`
+	Start        uint64 `json:"start"`
+	RandomStart  bool   `json:"randomStart"`
+	Step         uint64 `json:"step"`
+	Milliseconds bool   `json:"milliseconds"`
+	Adjust       int32  `json:"adjust"`
+	LittleEndian bool   `json:"littleEndian"`
+}
+
+func (v *CustomHeaderSegment) Build() (*custom.Segment, error) {
+	switch strings.ToLower(v.Type) {
+	case "constant", "static":
+		data := []byte(v.Text)
+		if len(v.Hex) > 0 {
+			var err error
+			data, err = hex.DecodeString(v.Hex)
+			if err != nil {
+				return nil, errors.New("invalid hex in custom header segment").Base(err)
+			}
+		}
+		return &custom.Segment{Value: &custom.Segment_Constant{Constant: &custom.ConstantSegment{
+			Data: data,
+		}}}, nil
+	case "random":
+		return &custom.Segment{Value: &custom.Segment_Random{Random: &custom.RandomSegment{
+			Length: v.Length,
+			Min:    v.Min,
+			Max:    v.Max,
+		}}}, nil
+	case "counter":
+		return &custom.Segment{Value: &custom.Segment_Counter{Counter: &custom.CounterSegment{
+			Size:         v.Size,
+			Start:        v.Start,
+			RandomStart:  v.RandomStart,
+			Step:         v.Step,
+			LittleEndian: v.LittleEndian,
+		}}}, nil
+	case "timestamp":
+		return &custom.Segment{Value: &custom.Segment_Timestamp{Timestamp: &custom.TimestampSegment{
+			Size:         v.Size,
+			Milliseconds: v.Milliseconds,
+			LittleEndian: v.LittleEndian,
+		}}}, nil
+	case "length":
+		return &custom.Segment{Value: &custom.Segment_Length{Length: &custom.LengthSegment{
+			Size:         v.Size,
+			Adjust:       v.Adjust,
+			LittleEndian: v.LittleEndian,
+		}}}, nil
+	default:
+		return nil, errors.New("unknown custom header segment type: ", v.Type)
+	}
+}
+
+func buildCustomHeaderSegments(segments []*CustomHeaderSegment) ([]*custom.Segment, error) {
+	result := make([]*custom.Segment, 0, len(segments))
+	for _, segment := range segments {
+		s, err := segment.Build()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+type CustomAuthenticator struct {
+	Segments []*CustomHeaderSegment `json:"segments"`
+}
+
+func (v *CustomAuthenticator) Build() (proto.Message, error) {
+	segments, err := buildCustomHeaderSegments(v.Segments)
+	if err != nil {
+		return nil, err
+	}
+	return &custom.Config{Segments: segments}, nil
+}
+
+type CustomConnectionAuthenticator struct {
+	Client []*CustomHeaderSegment `json:"client"`
+	Server []*CustomHeaderSegment `json:"server"`
+}
+
+func (v *CustomConnectionAuthenticator) Build() (proto.Message, error) {
+	client, err := buildCustomHeaderSegments(v.Client)
+	if err != nil {
+		return nil, errors.New("invalid client header").Base(err)
+	}
+	server, err := buildCustomHeaderSegments(v.Server)
+	if err != nil {
+		return nil, errors.New("invalid server header").Base(err)
+	}
+	return &custom.ConnectionConfig{
+		Client: client,
+		Server: server,
+	}, nil
+}
+
 type AuthenticatorRequest struct {
 	Version string                 `json:"version"`
 	Method  string                 `json:"method"`
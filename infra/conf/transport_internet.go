@@ -1,12 +1,15 @@
 package conf
 
 import (
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
 	"math"
 	"net/url"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
@@ -14,6 +17,7 @@ import (
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/platform/filesystem"
+	"github.com/xtls/xray-core/common/protocol"
 	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/transport/internet"
 	"github.com/xtls/xray-core/transport/internet/httpupgrade"
@@ -35,11 +39,13 @@ var (
 		"dtls":         func() interface{} { return new(DTLSAuthenticator) },
 		"wireguard":    func() interface{} { return new(WireguardAuthenticator) },
 		"dns":          func() interface{} { return new(DNSAuthenticator) },
+		"custom":       func() interface{} { return new(CustomAuthenticator) },
 	}, "type", "")
 
 	tcpHeaderLoader = NewJSONConfigLoader(ConfigCreatorCache{
-		"none": func() interface{} { return new(NoOpConnectionAuthenticator) },
-		"http": func() interface{} { return new(Authenticator) },
+		"none":   func() interface{} { return new(NoOpConnectionAuthenticator) },
+		"http":   func() interface{} { return new(Authenticator) },
+		"custom": func() interface{} { return new(CustomConnectionAuthenticator) },
 	}, "type", "")
 )
 
@@ -234,6 +240,10 @@ type SplitHTTPConfig struct {
 	ScStreamUpServerSecs Int32Range        `json:"scStreamUpServerSecs"`
 	Xmux                 XmuxConfig        `json:"xmux"`
 	DownloadSettings     *StreamConfig     `json:"downloadSettings"`
+	ScSessionGraceSecs   int32             `json:"scSessionGraceSecs"`
+	ScResumeBufferBytes  int32             `json:"scResumeBufferBytes"`
+	ResumeAttempts       int32             `json:"resumeAttempts"`
+	AlternativeSettings  []*StreamConfig   `json:"alternativeSettings"`
 	Extra                json.RawMessage   `json:"extra"`
 }
 
@@ -317,6 +327,9 @@ func (c *SplitHTTPConfig) Build() (proto.Message, error) {
 			HMaxReusableSecs: newRangeConfig(c.Xmux.HMaxReusableSecs),
 			HKeepAlivePeriod: c.Xmux.HKeepAlivePeriod,
 		},
+		ScSessionGraceSecs:  c.ScSessionGraceSecs,
+		ScResumeBufferBytes: c.ScResumeBufferBytes,
+		ResumeAttempts:      c.ResumeAttempts,
 	}
 
 	if c.DownloadSettings != nil {
@@ -329,6 +342,22 @@ func (c *SplitHTTPConfig) Build() (proto.Message, error) {
 		}
 	}
 
+	if len(c.AlternativeSettings) > 0 {
+		if c.Mode == "stream-one" {
+			return nil, errors.New(`Can not use "alternativeSettings" in "stream-one" mode.`)
+		}
+		for _, alternative := range c.AlternativeSettings {
+			if alternative.Address == nil {
+				return nil, errors.New(`"alternativeSettings" must contain an address.`)
+			}
+			as, err := alternative.Build()
+			if err != nil {
+				return nil, errors.New(`Failed to build "alternativeSettings".`).Base(err)
+			}
+			config.AlternativeSettings = append(config.AlternativeSettings, as)
+		}
+	}
+
 	return config, nil
 }
 
@@ -343,20 +372,30 @@ func readFileOrString(f string, s []string) ([]byte, error) {
 }
 
 type TLSCertConfig struct {
-	CertFile       string   `json:"certificateFile"`
-	CertStr        []string `json:"certificate"`
-	KeyFile        string   `json:"keyFile"`
-	KeyStr         []string `json:"key"`
-	Usage          string   `json:"usage"`
-	OcspStapling   uint64   `json:"ocspStapling"`
-	OneTimeLoading bool     `json:"oneTimeLoading"`
-	BuildChain     bool     `json:"buildChain"`
+	CertFile       string         `json:"certificateFile"`
+	CertStr        []string       `json:"certificate"`
+	KeyFile        string         `json:"keyFile"`
+	KeyStr         []string       `json:"key"`
+	Usage          string         `json:"usage"`
+	OcspStapling   uint64         `json:"ocspStapling"`
+	OneTimeLoading bool           `json:"oneTimeLoading"`
+	BuildChain     bool           `json:"buildChain"`
+	Acme           *TLSAcmeConfig `json:"acme"`
 }
 
 // Build implements Buildable.
 func (c *TLSCertConfig) Build() (*tls.Certificate, error) {
 	certificate := new(tls.Certificate)
 
+	if c.Acme != nil {
+		acme, err := c.Acme.Build()
+		if err != nil {
+			return nil, errors.New("failed to build ACME config").Base(err)
+		}
+		certificate.Acme = acme
+		return certificate, nil
+	}
+
 	cert, err := readFileOrString(c.CertFile, c.CertStr)
 	if err != nil {
 		return nil, errors.New("failed to parse certificate").Base(err)
@@ -394,6 +433,156 @@ func (c *TLSCertConfig) Build() (*tls.Certificate, error) {
 	return certificate, nil
 }
 
+type TLSAcmeConfig struct {
+	DirectoryURL   string   `json:"directoryUrl"`
+	Email          string   `json:"email"`
+	Domains        []string `json:"domains"`
+	StoragePath    string   `json:"storagePath"`
+	HTTPInbound    string   `json:"httpInbound"`
+	DisableTLSALPN bool     `json:"disableTlsAlpn"`
+	RenewBefore    uint64   `json:"renewBefore"`
+}
+
+// Build implements Buildable.
+func (c *TLSAcmeConfig) Build() (*tls.Acme, error) {
+	if len(c.Domains) == 0 {
+		return nil, errors.New("no domains specified")
+	}
+	domains := make([]string, len(c.Domains))
+	for i, domain := range c.Domains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain == "" || strings.ContainsAny(domain, "*/:") {
+			return nil, errors.New("invalid domain: ", c.Domains[i])
+		}
+		domains[i] = domain
+	}
+	if c.DisableTLSALPN && c.HTTPInbound == "" {
+		return nil, errors.New("both TLS-ALPN-01 and HTTP-01 challenges are disabled")
+	}
+	return &tls.Acme{
+		DirectoryUrl:   c.DirectoryURL,
+		Email:          c.Email,
+		Domains:        domains,
+		StoragePath:    c.StoragePath,
+		HttpInbound:    c.HTTPInbound,
+		DisableTlsAlpn: c.DisableTLSALPN,
+		RenewBefore:    c.RenewBefore,
+	}, nil
+}
+
+type TLSSniProfile struct {
+	Name                     string           `json:"name"`
+	ServerNames              StringList       `json:"serverNames"`
+	Certs                    []*TLSCertConfig `json:"certificates"`
+	ALPN                     *StringList      `json:"alpn"`
+	MinVersion               string           `json:"minVersion"`
+	RequireClientCertificate bool             `json:"requireClientCertificate"`
+	ClientCAFile             string           `json:"clientCaFile"`
+	ClientCA                 []string         `json:"clientCa"`
+	RejectUnknownSNI         bool             `json:"rejectUnknownSni"`
+}
+
+// Build implements Buildable.
+func (c *TLSSniProfile) Build() (*tls.SniProfile, error) {
+	if c.Name == "" {
+		return nil, errors.New("empty name")
+	}
+	if len(c.ServerNames) == 0 {
+		return nil, errors.New("no server names in SNI profile ", c.Name)
+	}
+	profile := &tls.SniProfile{
+		Name:                     c.Name,
+		ServerNames:              []string(c.ServerNames),
+		MinVersion:               c.MinVersion,
+		RequireClientCertificate: c.RequireClientCertificate,
+		RejectUnknownSni:         c.RejectUnknownSNI,
+	}
+	for _, certConf := range c.Certs {
+		cert, err := certConf.Build()
+		if err != nil {
+			return nil, err
+		}
+		profile.Certificate = append(profile.Certificate, cert)
+	}
+	if c.ALPN != nil {
+		profile.NextProtocol = []string(*c.ALPN)
+	}
+	if c.ClientCAFile != "" || len(c.ClientCA) > 0 {
+		ca, err := readFileOrString(c.ClientCAFile, c.ClientCA)
+		if err != nil {
+			return nil, errors.New("failed to read client CA of SNI profile ", c.Name).Base(err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(ca) {
+			return nil, errors.New("invalid client CA of SNI profile ", c.Name)
+		}
+		profile.ClientCa = ca
+	} else if c.RequireClientCertificate {
+		return nil, errors.New("SNI profile ", c.Name, " requires client certificates without clientCa or clientCaFile")
+	}
+	return profile, nil
+}
+
+type TLSClientUser struct {
+	Subject    string `json:"subject"`
+	SAN        string `json:"san"`
+	SPKISha256 string `json:"spkiSha256"`
+	Email      string `json:"email"`
+	Level      uint32 `json:"level"`
+}
+
+type TLSClientAuth struct {
+	Required bool             `json:"required"`
+	CRLFiles StringList       `json:"crlFiles"`
+	Users    []*TLSClientUser `json:"users"`
+}
+
+// Build implements Buildable.
+func (c *TLSClientAuth) Build() (*tls.ClientAuth, error) {
+	clientAuth := &tls.ClientAuth{
+		Required: c.Required,
+		CrlPath:  []string(c.CRLFiles),
+	}
+	// the files are read again by the listener, which reloads them when they
+	// change
+	for _, path := range c.CRLFiles {
+		data, err := filesystem.ReadFile(path)
+		if err != nil {
+			return nil, errors.New("failed to read CRL ", path).Base(err)
+		}
+		if block, _ := pem.Decode(data); block != nil {
+			data = block.Bytes
+		}
+		if _, err := x509.ParseRevocationList(data); err != nil {
+			return nil, errors.New("invalid CRL ", path).Base(err)
+		}
+	}
+	for _, u := range c.Users {
+		if u.Email == "" {
+			return nil, errors.New("client user without email")
+		}
+		if u.Subject == "" && u.SAN == "" && u.SPKISha256 == "" {
+			return nil, errors.New("client user ", u.Email, " has none of subject, san and spkiSha256")
+		}
+		user := &tls.ClientUser{
+			Subject: u.Subject,
+			San:     u.SAN,
+			User: &protocol.User{
+				Email: u.Email,
+				Level: u.Level,
+			},
+		}
+		if u.SPKISha256 != "" {
+			hash, err := base64.StdEncoding.DecodeString(u.SPKISha256)
+			if err != nil || len(hash) != 32 {
+				return nil, errors.New("invalid spkiSha256 of client user ", u.Email)
+			}
+			user.SpkiSha256 = hash
+		}
+		clientAuth.User = append(clientAuth.User, user)
+	}
+	return clientAuth, nil
+}
+
 type TLSConfig struct {
 	Insecure                             bool             `json:"allowInsecure"`
 	Certs                                []*TLSCertConfig `json:"certificates"`
@@ -416,6 +605,18 @@ type TLSConfig struct {
 	ECHConfigList                        string           `json:"echConfigList"`
 	ECHForceQuery                        string           `json:"echForceQuery"`
 	ECHSocketSettings                    *SocketConfig    `json:"echSockopt"`
+	SniProfiles                          []*TLSSniProfile `json:"sniProfiles"`
+	ClientAuth                           *TLSClientAuth   `json:"clientAuth"`
+	PresentClientCertificate             bool             `json:"presentClientCertificate"`
+}
+
+func (c *TLSConfig) hasAcme() bool {
+	isAcme := func(cert *TLSCertConfig) bool {
+		return cert.Acme != nil
+	}
+	return slices.ContainsFunc(c.Certs, isAcme) || slices.ContainsFunc(c.SniProfiles, func(p *TLSSniProfile) bool {
+		return slices.ContainsFunc(p.Certs, isAcme)
+	})
 }
 
 // Build implements Buildable.
@@ -429,6 +630,11 @@ func (c *TLSConfig) Build() (proto.Message, error) {
 		}
 		config.Certificate[idx] = cert
 	}
+	if c.hasAcme() && slices.ContainsFunc(config.Certificate, func(cert *tls.Certificate) bool {
+		return cert.Usage == tls.Certificate_AUTHORITY_ISSUE
+	}) {
+		return nil, errors.New(`ACME certificates cannot be used together with certificates of "issue" usage`)
+	}
 	serverName := c.ServerName
 	config.AllowInsecure = c.Insecure
 	if len(c.ServerName) > 0 {
@@ -510,6 +716,28 @@ func (c *TLSConfig) Build() (proto.Message, error) {
 		config.EchSocketSettings = ss
 	}
 
+	config.PresentClientCertificate = c.PresentClientCertificate
+	if c.ClientAuth != nil {
+		clientAuth, err := c.ClientAuth.Build()
+		if err != nil {
+			return nil, errors.New("failed to build client authentication").Base(err)
+		}
+		config.ClientAuth = clientAuth
+	}
+
+	names := make(map[string]bool)
+	for i, profileConf := range c.SniProfiles {
+		profile, err := profileConf.Build()
+		if err != nil {
+			return nil, errors.New("failed to build SNI profile ", i).Base(err)
+		}
+		if names[profile.Name] {
+			return nil, errors.New("duplicate SNI profile name: ", profile.Name)
+		}
+		names[profile.Name] = true
+		config.SniProfile = append(config.SniProfile, profile)
+	}
+
 	return config, nil
 }
 
@@ -537,6 +765,14 @@ type REALITYConfig struct {
 	LimitFallbackUpload   LimitFallback `json:"limitFallbackUpload"`
 	LimitFallbackDownload LimitFallback `json:"limitFallbackDownload"`
 
+	Targets             []*REALITYTarget `json:"targets"`
+	TargetCheckInterval uint64           `json:"targetCheckInterval"`
+
+	ShortIdSeed      string `json:"shortIdSeed"`
+	ShortIdPeriod    uint64 `json:"shortIdPeriod"`
+	ShortIdOverlap   uint64 `json:"shortIdOverlap"`
+	ReplayProtection bool   `json:"replayProtection"`
+
 	Fingerprint   string `json:"fingerprint"`
 	ServerName    string `json:"serverName"`
 	Password      string `json:"password"`
@@ -546,6 +782,33 @@ type REALITYConfig struct {
 	SpiderX       string `json:"spiderX"`
 }
 
+// REALITYTarget is a candidate REALITY server falls back to when the
+// configured target is unhealthy. It has to serve all of "serverNames".
+type REALITYTarget struct {
+	Target      string   `json:"target"`
+	Dest        string   `json:"dest"`
+	ServerNames []string `json:"serverNames"`
+}
+
+func (c *REALITYTarget) Build() (*reality.Target, error) {
+	if c.Target != "" {
+		c.Dest = c.Target
+	}
+	if _, err := strconv.Atoi(c.Dest); err == nil {
+		c.Dest = "localhost:" + c.Dest
+	}
+	if _, _, err := net.SplitHostPort(c.Dest); err != nil {
+		return nil, errors.New(`invalid "target" of REALITY target: `, c.Dest)
+	}
+	if len(c.ServerNames) == 0 {
+		return nil, errors.New(`empty "serverNames" of REALITY target `, c.Dest)
+	}
+	return &reality.Target{
+		Dest:        c.Dest,
+		ServerNames: c.ServerNames,
+	}, nil
+}
+
 func (c *REALITYConfig) Build() (proto.Message, error) {
 	config := new(reality.Config)
 	config.MasterKeyLog = c.MasterKeyLog
@@ -623,7 +886,19 @@ func (c *REALITYConfig) Build() (proto.Message, error) {
 				}
 			}
 		}
-		if len(c.ShortIds) == 0 {
+		if c.ShortIdSeed != "" {
+			if config.ShortIdSeed, err = base64.RawURLEncoding.DecodeString(c.ShortIdSeed); err != nil || len(config.ShortIdSeed) < 16 {
+				return nil, errors.New(`invalid "shortIdSeed": `, c.ShortIdSeed)
+			}
+			if c.ShortIdPeriod == 0 {
+				return nil, errors.New(`empty "shortIdPeriod"`)
+			}
+			if c.ShortIdOverlap > c.ShortIdPeriod/2 {
+				return nil, errors.New(`"shortIdOverlap" should be at most half of "shortIdPeriod"`)
+			}
+			config.ShortIdPeriod = c.ShortIdPeriod
+			config.ShortIdOverlap = c.ShortIdOverlap
+		} else if len(c.ShortIds) == 0 {
 			return nil, errors.New(`empty "shortIds"`)
 		}
 		config.ShortIds = make([][]byte, len(c.ShortIds))
@@ -639,6 +914,27 @@ func (c *REALITYConfig) Build() (proto.Message, error) {
 		config.ServerNames = c.ServerNames
 		config.MaxTimeDiff = c.MaxTimeDiff
 
+		if len(c.Targets) > 0 && c.Type != "tcp" {
+			return nil, errors.New(`"targets" require a TCP "target"`)
+		}
+		for _, t := range c.Targets {
+			target, err := t.Build()
+			if err != nil {
+				return nil, err
+			}
+			for _, name := range c.ServerNames {
+				if !slices.Contains(target.ServerNames, name) {
+					return nil, errors.New(`REALITY target `, target.Dest, ` does not serve "serverNames" entry `, name)
+				}
+			}
+			config.Targets = append(config.Targets, target)
+		}
+		config.TargetCheckInterval = c.TargetCheckInterval
+		if c.ReplayProtection && c.MaxTimeDiff == 0 {
+			return nil, errors.New(`"replayProtection" requires a non-zero "maxTimeDiff"`)
+		}
+		config.ReplayProtection = c.ReplayProtection
+
 		if c.Mldsa65Seed != "" {
 			if c.Mldsa65Seed == c.PrivateKey {
 				return nil, errors.New(`"mldsa65Seed" and "privateKey" can not be the same value: `, c.Mldsa65Seed)
@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/net"
@@ -99,12 +100,49 @@ type TrojanInboundFallback struct {
 	Xver uint64          `json:"xver"`
 }
 
+// TrojanCredentialConfig is an additional password of a trojan user, valid
+// between the RFC 3339 times notBefore and notAfter if they are set.
+type TrojanCredentialConfig struct {
+	Password  string `json:"password"`
+	NotBefore string `json:"notBefore"`
+	NotAfter  string `json:"notAfter"`
+}
+
+// Build builds the credential.
+func (c *TrojanCredentialConfig) Build() (*trojan.Credential, error) {
+	if c.Password == "" {
+		return nil, errors.New("Trojan password is not specified.")
+	}
+	credential := &trojan.Credential{
+		Password: c.Password,
+	}
+	if c.NotBefore != "" {
+		t, err := time.Parse(time.RFC3339, c.NotBefore)
+		if err != nil {
+			return nil, errors.New("invalid notBefore of trojan password").Base(err)
+		}
+		credential.NotBefore = t.Unix()
+	}
+	if c.NotAfter != "" {
+		t, err := time.Parse(time.RFC3339, c.NotAfter)
+		if err != nil {
+			return nil, errors.New("invalid notAfter of trojan password").Base(err)
+		}
+		credential.NotAfter = t.Unix()
+	}
+	if credential.NotBefore != 0 && credential.NotAfter != 0 && credential.NotAfter <= credential.NotBefore {
+		return nil, errors.New("notAfter of trojan password must be later than notBefore")
+	}
+	return credential, nil
+}
+
 // TrojanUserConfig is user configuration
 type TrojanUserConfig struct {
-	Password string `json:"password"`
-	Level    byte   `json:"level"`
-	Email    string `json:"email"`
-	Flow     string `json:"flow"`
+	Password  string                    `json:"password"`
+	Passwords []*TrojanCredentialConfig `json:"passwords"`
+	Level     byte                      `json:"level"`
+	Email     string                    `json:"email"`
+	Flow      string                    `json:"flow"`
 }
 
 // TrojanServerConfig is Inbound configuration
@@ -123,13 +161,25 @@ func (c *TrojanServerConfig) Build() (proto.Message, error) {
 		if rawUser.Flow != "" {
 			return nil, errors.PrintRemovedFeatureError(`Flow for Trojan`, ``)
 		}
+		if rawUser.Password == "" && len(rawUser.Passwords) == 0 {
+			return nil, errors.New("Trojan password is not specified.")
+		}
+
+		account := &trojan.Account{
+			Password: rawUser.Password,
+		}
+		for _, rawCredential := range rawUser.Passwords {
+			credential, err := rawCredential.Build()
+			if err != nil {
+				return nil, err
+			}
+			account.Credentials = append(account.Credentials, credential)
+		}
 
 		config.Users[idx] = &protocol.User{
-			Level: uint32(rawUser.Level),
-			Email: rawUser.Email,
-			Account: serial.ToTypedMessage(&trojan.Account{
-				Password: rawUser.Password,
-			}),
+			Level:   uint32(rawUser.Level),
+			Email:   rawUser.Email,
+			Account: serial.ToTypedMessage(account),
 		}
 	}
 
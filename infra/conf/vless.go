@@ -34,12 +34,17 @@ type VLessInboundConfig struct {
 	Decryption string                  `json:"decryption"`
 	Fallbacks  []*VLessInboundFallback `json:"fallbacks"`
 	Flow       string                  `json:"flow"`
+
+	ResumeSeconds uint32 `json:"resumeSeconds"`
+	ResumeBuffer  uint32 `json:"resumeBuffer"`
 }
 
 // Build implements Buildable
 func (c *VLessInboundConfig) Build() (proto.Message, error) {
 	config := new(inbound.Config)
 	config.Clients = make([]*protocol.User, len(c.Clients))
+	config.ResumeSeconds = c.ResumeSeconds
+	config.ResumeBuffer = c.ResumeBuffer
 	switch c.Flow {
 	case vless.None:
 		c.Flow = ""
@@ -213,11 +218,16 @@ type VLessOutboundConfig struct {
 	Encryption string                `json:"encryption"`
 	Reverse    *vless.Reverse        `json:"reverse"`
 	Vnext      []*VLessOutboundVnext `json:"vnext"`
+
+	ResumeAttempts uint32 `json:"resumeAttempts"`
+	ResumeBuffer   uint32 `json:"resumeBuffer"`
 }
 
 // Build implements Buildable
 func (c *VLessOutboundConfig) Build() (proto.Message, error) {
 	config := new(outbound.Config)
+	config.ResumeAttempts = c.ResumeAttempts
+	config.ResumeBuffer = c.ResumeBuffer
 	if c.Address != nil {
 		c.Vnext = []*VLessOutboundVnext{
 			{
@@ -36,6 +36,7 @@ var (
 		"block":       func() interface{} { return new(BlackholeConfig) },
 		"blackhole":   func() interface{} { return new(BlackholeConfig) },
 		"loopback":    func() interface{} { return new(LoopbackConfig) },
+		"failover":    func() interface{} { return new(FailoverConfig) },
 		"direct":      func() interface{} { return new(FreedomConfig) },
 		"freedom":     func() interface{} { return new(FreedomConfig) },
 		"http":        func() interface{} { return new(HTTPClientConfig) },
@@ -100,6 +101,7 @@ type MuxConfig struct {
 	Concurrency     int16  `json:"concurrency"`
 	XudpConcurrency int16  `json:"xudpConcurrency"`
 	XudpProxyUDP443 string `json:"xudpProxyUDP443"`
+	FlowControl     bool   `json:"flowControl"`
 }
 
 // Build creates MultiplexingConfig, Concurrency < 0 completely disables mux.
@@ -116,6 +118,7 @@ func (m *MuxConfig) Build() (*proxyman.MultiplexingConfig, error) {
 		Concurrency:     int32(m.Concurrency),
 		XudpConcurrency: int32(m.XudpConcurrency),
 		XudpProxyUDP443: m.XudpProxyUDP443,
+		FlowControl:     m.FlowControl,
 	}, nil
 }
 
@@ -270,6 +273,9 @@ func (c *OutboundDetourConfig) Build() (*core.OutboundHandlerConfig, error) {
 	}
 
 	if c.StreamSetting != nil {
+		if c.StreamSetting.TLSSettings != nil && c.StreamSetting.TLSSettings.hasAcme() {
+			return nil, errors.New("ACME certificates are only supported by inbounds")
+		}
 		ss, err := c.StreamSetting.Build()
 		if err != nil {
 			return nil, errors.New("failed to build stream settings for outbound detour").Base(err)
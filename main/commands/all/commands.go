@@ -2,6 +2,7 @@ package all
 
 import (
 	"github.com/xtls/xray-core/main/commands/all/api"
+	"github.com/xtls/xray-core/main/commands/all/config"
 	"github.com/xtls/xray-core/main/commands/all/convert"
 	"github.com/xtls/xray-core/main/commands/all/tls"
 	"github.com/xtls/xray-core/main/commands/base"
@@ -11,10 +12,12 @@ func init() {
 	base.RootCommand.Commands = append(
 		base.RootCommand.Commands,
 		api.CmdAPI,
+		config.CmdConfig,
 		convert.CmdConvert,
 		tls.CmdTLS,
 		cmdUUID,
 		cmdX25519,
+		cmdShortId,
 		cmdWG,
 		cmdMLDSA65,
 		cmdMLKEM768,
@@ -0,0 +1,16 @@
+package config
+
+import (
+	"github.com/xtls/xray-core/main/commands/base"
+)
+
+// CmdConfig holds all config sub commands
+var CmdConfig = &base.Command{
+	UsageLine: "{{.Exec}} config",
+	Short:     "Config tools",
+	Long: `{{.Exec}} {{.LongName}} provides tools to check configs.
+`,
+	Commands: []*base.Command{
+		cmdLint,
+	},
+}
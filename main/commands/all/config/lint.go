@@ -0,0 +1,123 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	clog "github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/infra/conf"
+	"github.com/xtls/xray-core/infra/conf/serial"
+	"github.com/xtls/xray-core/main/commands/base"
+)
+
+var cmdLint = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} config lint [-format <config format>] [-expiry <days>] <config files>",
+	Short:       "Check configs for semantic errors",
+	Long: `
+Check configs for mistakes that Xray accepts but that are unlikely to be
+intended, and print the issues found as JSON. Multiple configs are merged
+like in {{.Exec}} run.
+
+The checks are:
+
+  - the config fails to build
+  - routing rules route to unknown outbounds or balancers
+  - balancer selectors match no outbound
+  - routing rules are unreachable, as an earlier rule matches everything
+    they do
+  - outbounds are never used
+  - expectedIPs of DNS servers can never match
+  - user ids or emails are used more than once
+  - TLS certificates have expired or expire soon
+
+The command exits with 1 if any issue is an error.
+
+Arguments:
+
+	-format <config format>
+		Format of the input configs, as in {{.Exec}} run: json, yaml or
+		toml. Guessed from the file extension by default, or with auto.
+		It doesn't change the output, which is always JSON.
+
+	-expiry <days>
+		Warn about certificates that expire within the days. Default 30.
+
+Examples:
+
+	{{.Exec}} {{.LongName}} config.json
+	{{.Exec}} {{.LongName}} -expiry 7 base.json outbounds.yaml
+`,
+	Run: executeLint,
+}
+
+type lintReport struct {
+	Errors   int               `json:"errors"`
+	Warnings int               `json:"warnings"`
+	Issues   []*conf.LintIssue `json:"issues"`
+}
+
+func executeLint(cmd *base.Command, args []string) {
+	format := cmd.Flag.String("format", "auto", "")
+	expiry := cmd.Flag.Uint("expiry", 30, "")
+	cmd.Flag.Parse(args)
+
+	if cmd.Flag.NArg() < 1 {
+		base.Fatalf("empty config list")
+	}
+	clog.ReplaceWithSeverityLogger(clog.Severity_Warning)
+
+	var files []*core.ConfigSource
+	for _, name := range cmd.Flag.Args() {
+		f := *format
+		if f == "auto" {
+			f = core.GetFormatByExtension(strings.TrimPrefix(filepath.Ext(name), "."))
+		}
+		if f == "" {
+			f = "json"
+		}
+		if _, found := serial.ReaderDecoderByFormat[f]; !found {
+			base.Fatalf("unsupported config format: %s", f)
+		}
+		files = append(files, &core.ConfigSource{Name: name, Format: f})
+	}
+
+	report := &lintReport{Issues: []*conf.LintIssue{}}
+	config, err := serial.DecodeConfigFromFiles(files)
+	if err != nil {
+		report.Issues = append(report.Issues, &conf.LintIssue{
+			Level:   conf.LintError,
+			Check:   "config.decode",
+			Message: err.Error(),
+		})
+	} else {
+		report.Issues = append(report.Issues, config.Lint(time.Duration(*expiry)*24*time.Hour)...)
+		if _, err := config.Build(); err != nil {
+			report.Issues = append(report.Issues, &conf.LintIssue{
+				Level:   conf.LintError,
+				Check:   "config.build",
+				Message: err.Error(),
+			})
+		}
+	}
+
+	for _, issue := range report.Issues {
+		if issue.Level == conf.LintError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		base.Fatalf("failed to write report: %s", err)
+	}
+	if report.Errors > 0 {
+		base.SetExitStatus(1)
+	}
+}
@@ -0,0 +1,85 @@
+package convert
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/infra/conf/serial"
+	"github.com/xtls/xray-core/main/commands/base"
+	"github.com/xtls/xray-core/main/confloader"
+)
+
+var cmdConfig = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} convert config [-from format] -to format [-o file] <config file>",
+	Short:       "Convert a config between JSON, YAML and TOML",
+	Long: `
+Convert ONE config between JSON, YAML and TOML, keeping its comments.
+
+TOML puts tables after the other keys of a table, so keys may be reordered
+when converting from or to TOML. Null values are dropped in TOML.
+
+Arguments:
+
+	-from <format>
+		Format of the input: json, yaml or toml. Guessed from the file
+		extension by default.
+
+	-to <format>
+		Format of the output: json, yaml or toml.
+
+	-o <file>
+		Write the output to the file instead of stdout.
+
+Examples:
+
+	{{.Exec}} {{.LongName}} -to yaml config.json
+	{{.Exec}} {{.LongName}} -to json -o config.json config.toml
+`,
+	Run: executeConvertConfig,
+}
+
+func executeConvertConfig(cmd *base.Command, args []string) {
+	var from, to, output string
+	cmd.Flag.StringVar(&from, "from", "", "")
+	cmd.Flag.StringVar(&to, "to", "", "")
+	cmd.Flag.StringVar(&output, "o", "", "")
+	cmd.Flag.Parse(args)
+
+	if cmd.Flag.NArg() != 1 {
+		base.Fatalf("expected one config file")
+	}
+	input := cmd.Flag.Arg(0)
+	if from == "" {
+		from = core.GetFormatByExtension(getFileExtension(input))
+		if from == "" {
+			from = "json"
+		}
+	}
+	if to == "" {
+		base.Fatalf("-to not specified")
+	}
+
+	reader, err := confloader.LoadConfig(input)
+	if err != nil {
+		base.Fatalf("failed to load config: %s", err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		base.Fatalf("failed to read config: %s", err)
+	}
+	converted, err := serial.ConvertConfig(data, from, to)
+	if err != nil {
+		base.Fatalf("failed to convert config: %s", err)
+	}
+
+	if output == "" {
+		fmt.Print(string(converted))
+		return
+	}
+	if err := os.WriteFile(output, converted, 0o644); err != nil {
+		base.Fatalf("failed to write config: %s", err)
+	}
+}
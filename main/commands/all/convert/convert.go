@@ -13,5 +13,8 @@ var CmdConvert = &base.Command{
 	Commands: []*base.Command{
 		cmdProtobuf,
 		cmdJson,
+		cmdConfig,
+		cmdLink,
+		cmdShare,
 	},
 }
@@ -0,0 +1,91 @@
+package convert
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/xtls/xray-core/infra/conf/serial"
+	"github.com/xtls/xray-core/infra/conf/sharelink"
+	"github.com/xtls/xray-core/main/commands/base"
+	"github.com/xtls/xray-core/main/confloader"
+)
+
+var cmdLink = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} convert link [-to format] <share links>",
+	Short:       "Convert share links to outbounds",
+	Long: `
+Convert share links to an outbounds config, which can be merged with other
+configs. vless://, vmess://, trojan:// and ss:// links are supported.
+
+A file, or stdin:, with one link per line can be given instead of links.
+
+Arguments:
+
+	-to <format>
+		Format of the output: json, yaml or toml. Default json.
+
+Examples:
+
+	{{.Exec}} {{.LongName}} "vless://27848739-7e62-4138-9fd3-098a63964b6b@example.com:443?security=tls&type=ws&path=%2Fws#proxy"
+	{{.Exec}} {{.LongName}} -to yaml links.txt
+`,
+	Run: executeConvertLink,
+}
+
+func executeConvertLink(cmd *base.Command, args []string) {
+	var to string
+	cmd.Flag.StringVar(&to, "to", "json", "")
+	cmd.Flag.Parse(args)
+
+	if cmd.Flag.NArg() < 1 {
+		base.Fatalf("empty link list")
+	}
+
+	var links []string
+	for _, arg := range cmd.Flag.Args() {
+		if strings.Contains(arg, "://") {
+			links = append(links, arg)
+			continue
+		}
+		reader, err := confloader.LoadConfig(arg)
+		if err != nil {
+			base.Fatalf("failed to load links: %s", err)
+		}
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+				links = append(links, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			base.Fatalf("failed to read links: %s", err)
+		}
+	}
+
+	var config struct {
+		Outbounds []*sharelink.Outbound `json:"outbounds"`
+	}
+	for _, link := range links {
+		outbound, err := sharelink.Parse(link)
+		if err != nil {
+			base.Fatalf("failed to convert %s: %s", link, err)
+		}
+		config.Outbounds = append(config.Outbounds, outbound)
+	}
+
+	b, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		base.Fatalf("failed to marshal outbounds: %s", err)
+	}
+	if to != "json" {
+		if b, err = serial.ConvertConfig(b, "json", to); err != nil {
+			base.Fatalf("failed to convert outbounds: %s", err)
+		}
+	} else {
+		b = append(b, '\n')
+	}
+	fmt.Print(string(b))
+}
@@ -0,0 +1,99 @@
+package convert
+
+import (
+	"fmt"
+
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/infra/conf/serial"
+	"github.com/xtls/xray-core/infra/conf/sharelink"
+	"github.com/xtls/xray-core/main/commands/base"
+)
+
+var cmdShare = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} convert share [-address host] [-inbound tag] [-outbound tag] <config files>",
+	Short:       "Convert outbounds or inbound users to share links",
+	Long: `
+Print share links for the outbounds of configs, or for the users of the
+inbounds with -inbound. Multiple configs are merged like in {{.Exec}} run.
+
+Arguments:
+
+	-outbound <tag>
+		Only convert the outbound with the tag.
+
+	-inbound <tag>
+		Convert the users of the inbound with the tag, instead of the
+		outbounds. Use -inbound "*" for all inbounds.
+
+	-address <host>
+		The address clients connect to the inbounds at. Defaults to the
+		listen address of the inbound.
+
+Examples:
+
+	{{.Exec}} {{.LongName}} client.json
+	{{.Exec}} {{.LongName}} -inbound vless-in -address example.com server.json
+`,
+	Run: executeConvertShare,
+}
+
+func executeConvertShare(cmd *base.Command, args []string) {
+	var address, inbound, outbound string
+	cmd.Flag.StringVar(&address, "address", "", "")
+	cmd.Flag.StringVar(&inbound, "inbound", "", "")
+	cmd.Flag.StringVar(&outbound, "outbound", "", "")
+	cmd.Flag.Parse(args)
+
+	if cmd.Flag.NArg() < 1 {
+		base.Fatalf("empty config list")
+	}
+	var files []*core.ConfigSource
+	for _, name := range cmd.Flag.Args() {
+		format := core.GetFormatByExtension(getFileExtension(name))
+		if format == "" {
+			format = "json"
+		}
+		files = append(files, &core.ConfigSource{Name: name, Format: format})
+	}
+	config, err := serial.DecodeConfigFromFiles(files)
+	if err != nil {
+		base.Fatalf("failed to load config: %s", err)
+	}
+
+	var links []string
+	if inbound != "" {
+		for i := range config.InboundConfigs {
+			in := &config.InboundConfigs[i]
+			if inbound != "*" && in.Tag != inbound || inbound == "*" && !sharelink.Supported(in.Protocol) {
+				continue
+			}
+			l, err := sharelink.FromInbound(in, address)
+			if err != nil {
+				base.Fatalf("failed to convert inbound %s: %s", in.Tag, err)
+			}
+			links = append(links, l...)
+		}
+	} else {
+		for i := range config.OutboundConfigs {
+			out := &config.OutboundConfigs[i]
+			if outbound != "" && out.Tag != outbound {
+				continue
+			}
+			if outbound == "" && !sharelink.Supported(out.Protocol) {
+				continue
+			}
+			l, err := sharelink.FromOutbound(out)
+			if err != nil {
+				base.Fatalf("failed to convert outbound %s: %s", out.Tag, err)
+			}
+			links = append(links, l...)
+		}
+	}
+	if len(links) == 0 {
+		base.Fatalf("nothing to convert")
+	}
+	for _, link := range links {
+		fmt.Println(link)
+	}
+}
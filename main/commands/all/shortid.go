@@ -0,0 +1,60 @@
+package all
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/xtls/xray-core/main/commands/base"
+	"github.com/xtls/xray-core/transport/internet/reality"
+)
+
+var cmdShortId = &base.Command{
+	UsageLine: `{{.Exec}} shortid [-i "seed (base64.RawURLEncoding)"] [-period seconds]`,
+	Short:     `Generate a seed for rotating REALITY short IDs and show the current and next ID`,
+	Long: `
+Generate a seed for rotating REALITY short IDs (REALITY "shortIdSeed"), and
+show the short ID of the current and the next period for clients.
+
+Random: {{.Exec}} shortid -period 86400
+
+From seed: {{.Exec}} shortid -i "seed (base64.RawURLEncoding)" -period 86400
+`,
+}
+
+func init() {
+	cmdShortId.Run = executeShortId // break init loop
+}
+
+var (
+	input_shortIdSeed   = cmdShortId.Flag.String("i", "", "")
+	input_shortIdPeriod = cmdShortId.Flag.Uint64("period", 86400, "")
+)
+
+func executeShortId(cmd *base.Command, args []string) {
+	var seed []byte
+	if *input_shortIdSeed != "" {
+		seed, _ = base64.RawURLEncoding.DecodeString(*input_shortIdSeed)
+		if len(seed) < 16 {
+			fmt.Println("Invalid short ID seed.")
+			return
+		}
+	} else {
+		seed = make([]byte, 32)
+		rand.Read(seed)
+	}
+	if *input_shortIdPeriod == 0 {
+		fmt.Println("Invalid short ID period.")
+		return
+	}
+	period := time.Duration(*input_shortIdPeriod) * time.Second
+	now := time.Now()
+	start, end := reality.ShortIdEpoch(period, now)
+	fmt.Printf("Seed: %v\nPeriod: %v\nCurrent: %v (%v - %v)\nNext: %v (%v - %v)\n",
+		base64.RawURLEncoding.EncodeToString(seed),
+		*input_shortIdPeriod,
+		hex.EncodeToString(reality.DeriveShortId(seed, period, now)), start.Format(time.RFC3339), end.Format(time.RFC3339),
+		hex.EncodeToString(reality.DeriveShortId(seed, period, end)), end.Format(time.RFC3339), end.Add(period).Format(time.RFC3339))
+}
@@ -16,6 +16,7 @@ import (
 
 	// Developer preview services
 	_ "github.com/xtls/xray-core/app/observatory/command"
+	_ "github.com/xtls/xray-core/app/reverse/command"
 
 	// Other optional features.
 	_ "github.com/xtls/xray-core/app/dns"
@@ -37,6 +38,7 @@ import (
 	_ "github.com/xtls/xray-core/proxy/blackhole"
 	_ "github.com/xtls/xray-core/proxy/dns"
 	_ "github.com/xtls/xray-core/proxy/dokodemo"
+	_ "github.com/xtls/xray-core/proxy/failover"
 	_ "github.com/xtls/xray-core/proxy/freedom"
 	_ "github.com/xtls/xray-core/proxy/http"
 	_ "github.com/xtls/xray-core/proxy/loopback"
@@ -61,6 +63,7 @@ import (
 	_ "github.com/xtls/xray-core/transport/internet/websocket"
 
 	// Transport headers
+	_ "github.com/xtls/xray-core/transport/internet/headers/custom"
 	_ "github.com/xtls/xray-core/transport/internet/headers/http"
 	_ "github.com/xtls/xray-core/transport/internet/headers/noop"
 	_ "github.com/xtls/xray-core/transport/internet/headers/srtp"
@@ -0,0 +1,322 @@
+// Package failover implements an outbound that tries other outbounds in order
+// and commits to the first one that works.
+//
+// The first payload of the client, as cached by the dispatcher while sniffing
+// or read by the failover itself, is sent to every outbound it tries. An
+// outbound works once it responds; one that fails before responding is
+// replaced by the next, and one that stays silent for the configured timeout
+// is committed to as it is.
+package failover
+
+import (
+	"context"
+	"time"
+
+	"github.com/xtls/xray-core/app/dispatcher"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/signal/done"
+	"github.com/xtls/xray-core/common/task"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/outbound"
+	"github.com/xtls/xray-core/proxy"
+	"github.com/xtls/xray-core/transport"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/pipe"
+)
+
+const (
+	defaultTimeout = 3 * time.Second
+	// how long to wait for the first payload if the dispatcher cached none,
+	// as without sniffing or for UDP
+	payloadTimeout = 100 * time.Millisecond
+)
+
+// Handler is an outbound connection handler that fails over between other
+// outbounds.
+type Handler struct {
+	tags    []string
+	stagger time.Duration
+	timeout time.Duration
+	ohm     outbound.Manager
+}
+
+// New creates a new failover handler.
+func New(ctx context.Context, config *Config) (*Handler, error) {
+	if len(config.OutboundTags) == 0 {
+		return nil, errors.New("no outbound to fail over")
+	}
+	h := &Handler{
+		tags:    config.OutboundTags,
+		stagger: time.Duration(config.Stagger) * time.Millisecond,
+		timeout: time.Duration(config.Timeout) * time.Millisecond,
+	}
+	if h.timeout == 0 {
+		h.timeout = defaultTimeout
+	}
+	var tag string
+	if handler := session.FullHandlerFromContext(ctx); handler != nil {
+		tag = handler.Tag()
+	}
+	if err := core.RequireFeatures(ctx, func(ohm outbound.Manager) error {
+		h.ohm = ohm
+		if tag == "" {
+			return nil
+		}
+		// the other outbounds of a cycle were created before this one
+		if h.uses(tag, make(map[string]bool)) {
+			return errors.New("failover [", tag, "] uses itself")
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// uses tells whether the failover tries the outbound tag, directly or through
+// other failovers.
+func (h *Handler) uses(tag string, seen map[string]bool) bool {
+	for _, t := range h.tags {
+		if t == tag {
+			return true
+		}
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		handler, ok := h.ohm.GetHandler(t).(proxy.GetOutbound)
+		if !ok {
+			continue
+		}
+		if f, ok := handler.GetOutbound().(*Handler); ok && f.uses(tag, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// attempt is one outbound tried for a connection.
+type attempt struct {
+	tag      string
+	started  time.Time
+	cancel   context.CancelFunc
+	uplink   *pipe.Writer
+	downlink *pipe.Reader
+
+	// set before done is closed
+	response buf.MultiBuffer
+	err      error
+	done     *done.Instance
+}
+
+func (a *attempt) abort() {
+	a.cancel()
+	common.Interrupt(a.uplink)
+	common.Interrupt(a.downlink)
+	go func() {
+		<-a.done.Wait()
+		buf.ReleaseMulti(a.response)
+	}()
+}
+
+// candidates returns the tags to try, leaving out the outbounds whose circuit
+// breakers are open unless all of them are.
+func (h *Handler) candidates() []string {
+	tags := make([]string, 0, len(h.tags))
+	for _, tag := range h.tags {
+		if health, ok := outbound.GetHealth(h.ohm, tag); !ok || health.Available() {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 {
+		return h.tags
+	}
+	return tags
+}
+
+func (h *Handler) start(ctx context.Context, tag string, payload buf.MultiBuffer, notify chan<- *attempt) (*attempt, error) {
+	handler := h.ohm.GetHandler(tag)
+	if handler == nil {
+		return nil, errors.New("outbound handler [", tag, "] not found")
+	}
+
+	outbounds := session.OutboundsFromContext(ctx)
+	ob := outbounds[len(outbounds)-1]
+	ctx = session.ContextWithOutbounds(ctx, append(outbounds[:len(outbounds):len(outbounds)], &session.Outbound{
+		OriginalTarget: ob.OriginalTarget,
+		Target:         ob.Target,
+		RouteTarget:    ob.RouteTarget,
+		Tag:            tag,
+	}))
+	ctx, cancel := context.WithCancel(ctx)
+
+	opts := pipe.OptionsFromContext(ctx)
+	uplinkReader, uplinkWriter := pipe.New(opts...)
+	downlinkReader, downlinkWriter := pipe.New(opts...)
+	a := &attempt{
+		tag:      tag,
+		started:  time.Now(),
+		cancel:   cancel,
+		uplink:   uplinkWriter,
+		downlink: downlinkReader,
+		done:     done.New(),
+	}
+	if !payload.IsEmpty() {
+		copied := make(buf.MultiBuffer, 0, len(payload))
+		for _, b := range payload {
+			c := buf.New()
+			c.Write(b.Bytes())
+			c.UDP = b.UDP
+			copied = append(copied, c)
+		}
+		if err := uplinkWriter.WriteMultiBuffer(copied); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	errors.LogInfo(ctx, "trying outbound [", tag, "]")
+	go handler.Dispatch(ctx, &transport.Link{Reader: uplinkReader, Writer: downlinkWriter})
+	go func() {
+		for a.response.IsEmpty() && a.err == nil {
+			a.response, a.err = downlinkReader.ReadMultiBuffer()
+		}
+		a.done.Close()
+		notify <- a
+	}()
+	return a, nil
+}
+
+// Process implements proxy.Outbound.
+func (h *Handler) Process(ctx context.Context, link *transport.Link, _ internet.Dialer) error {
+	outbounds := session.OutboundsFromContext(ctx)
+	ob := outbounds[len(outbounds)-1]
+	if !ob.Target.IsValid() {
+		return errors.New("target not specified")
+	}
+	ob.Name = "failover"
+
+	var payload buf.MultiBuffer
+	if reader, ok := link.Reader.(dispatcher.CachedPayload); ok {
+		payload = reader.TakeCache()
+	}
+	uplink := link.Reader
+	if payload.IsEmpty() {
+		reader, ok := uplink.(buf.TimeoutReader)
+		if !ok {
+			reader = &buf.TimeoutWrapperReader{Reader: uplink}
+			uplink = reader
+		}
+		mb, err := reader.ReadMultiBufferTimeout(payloadTimeout)
+		if err != nil && err != buf.ErrReadTimeout {
+			return errors.New("failed to read first payload").Base(err)
+		}
+		payload = mb
+	}
+
+	tags := h.candidates()
+	notify := make(chan *attempt, len(tags))
+	var pending []*attempt
+	var lastErr error
+	next := 0
+	startNext := func() {
+		for next < len(tags) {
+			a, err := h.start(ctx, tags[next], payload, notify)
+			next++
+			if err == nil {
+				pending = append(pending, a)
+				return
+			}
+			lastErr = err
+		}
+	}
+	abortAll := func() {
+		for _, a := range pending {
+			a.abort()
+		}
+	}
+
+	var winner *attempt
+	startNext()
+	for winner == nil {
+		if len(pending) == 0 {
+			buf.ReleaseMulti(payload)
+			return errors.New("all outbounds failed").Base(lastErr)
+		}
+		var stagger <-chan time.Time
+		if h.stagger > 0 && next < len(tags) {
+			stagger = time.After(h.stagger - time.Since(pending[len(pending)-1].started))
+		}
+		silent := time.After(h.timeout - time.Since(pending[0].started))
+
+		select {
+		case a := <-notify:
+			for i, p := range pending {
+				if p == a {
+					pending = append(pending[:i], pending[i+1:]...)
+					break
+				}
+			}
+			if a.err == nil {
+				winner = a
+				break
+			}
+			errors.LogInfoInner(ctx, a.err, "outbound [", a.tag, "] failed before responding")
+			lastErr = a.err
+			a.abort()
+			if h.stagger == 0 || len(pending) == 0 {
+				startNext()
+			}
+		case <-stagger:
+			startNext()
+		case <-silent:
+			winner = pending[0]
+			pending = pending[1:]
+		case <-ctx.Done():
+			abortAll()
+			buf.ReleaseMulti(payload)
+			return ctx.Err()
+		}
+	}
+	abortAll()
+	buf.ReleaseMulti(payload)
+	defer winner.cancel()
+	errors.LogInfo(ctx, "committed to outbound [", winner.tag, "]")
+
+	requestDone := func() error {
+		if err := buf.Copy(uplink, winner.uplink); err != nil {
+			return errors.New("failed to transfer request").Base(err)
+		}
+		return nil
+	}
+
+	responseDone := func() error {
+		<-winner.done.Wait()
+		if winner.err != nil {
+			return winner.err
+		}
+		if err := link.Writer.WriteMultiBuffer(winner.response); err != nil {
+			return err
+		}
+		if err := buf.Copy(winner.downlink, link.Writer); err != nil {
+			return errors.New("failed to transfer response").Base(err)
+		}
+		return nil
+	}
+
+	if err := task.Run(ctx, task.OnSuccess(requestDone, task.Close(winner.uplink)), responseDone); err != nil {
+		common.Interrupt(winner.uplink)
+		common.Interrupt(winner.downlink)
+		return errors.New("connection ends").Base(err)
+	}
+	return nil
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
+		return New(ctx, config.(*Config))
+	}))
+}
@@ -34,6 +34,7 @@ type Client struct {
 	server        *protocol.ServerSpec
 	policyManager policy.Manager
 	header        []*Header
+	h3            *tls.Config
 }
 
 type h2Conn struct {
@@ -61,6 +62,7 @@ func NewClient(ctx context.Context, config *ClientConfig) (*Client, error) {
 		server:        server,
 		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
 		header:        config.Header,
+		h3:            http3Config(ctx),
 	}, nil
 }
 
@@ -74,33 +76,59 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 	ob.Name = "http"
 	ob.CanSpliceCopy = 2
 	target := ob.Target
-	targetAddr := target.NetAddr()
-
-	if target.Network == net.Network_UDP {
-		return errors.New("UDP is not supported by HTTP outbound")
+	isUDP := target.Network == net.Network_UDP
+	if isUDP {
+		ob.CanSpliceCopy = 3
 	}
+	// UDP goes over HTTP/3 if it is enabled
+	isHTTP3 := isUDP && c.h3 != nil
 
 	server := c.server
 	dest := server.Destination
 	user := server.User
-	var conn stat.Connection
+	var conn io.Closer
+	var reader buf.Reader
+	var writer buf.Writer
 
+	var firstPayload []byte
 	mbuf, _ := link.Reader.ReadMultiBuffer()
-	len := mbuf.Len()
-	firstPayload := bytespool.Alloc(len)
-	mbuf, _ = buf.SplitBytes(mbuf, firstPayload)
-	firstPayload = firstPayload[:len]
-
-	buf.ReleaseMulti(mbuf)
-	defer bytespool.Free(firstPayload)
+	if isUDP && !isHTTP3 {
+		// the first packet goes out in a capsule right after the request
+		var capsules bytes.Buffer
+		(&capsuleWriter{writer: &capsules}).WriteMultiBuffer(mbuf)
+		firstPayload = capsules.Bytes()
+	} else if !isUDP {
+		len := mbuf.Len()
+		firstPayload = bytespool.Alloc(len)
+		mbuf, _ = buf.SplitBytes(mbuf, firstPayload)
+		firstPayload = firstPayload[:len]
+
+		buf.ReleaseMulti(mbuf)
+		defer bytespool.Free(firstPayload)
+	}
 
 	header, err := fillRequestHeader(ctx, c.header)
 	if err != nil {
 		return errors.New("failed to fill out header").Base(err)
 	}
 
-	if err := retry.ExponentialBackoff(5, 100).On(func() error {
-		netConn, err := setUpHTTPTunnel(ctx, dest, targetAddr, user, dialer, header, firstPayload)
+	if isHTTP3 {
+		if err := retry.ExponentialBackoff(5, 100).On(func() error {
+			tunnel, err := setUpHTTP3Tunnel(ctx, dest, target, user, dialer, header, c.h3)
+			if tunnel != nil {
+				conn, reader, writer = tunnel, tunnel, tunnel
+			}
+			return err
+		}); err != nil {
+			buf.ReleaseMulti(mbuf)
+			return errors.New("failed to find an available destination").Base(err)
+		}
+		if err := writer.WriteMultiBuffer(mbuf); err != nil {
+			conn.Close()
+			return errors.New("failed to write first packets").Base(err)
+		}
+	} else if err := retry.ExponentialBackoff(5, 100).On(func() error {
+		netConn, err := setUpHTTPTunnel(ctx, dest, target, user, dialer, header, firstPayload)
 		if netConn != nil {
 			if _, ok := netConn.(*http2Conn); !ok {
 				if _, err := netConn.Write(firstPayload); err != nil {
@@ -108,7 +136,12 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 					return err
 				}
 			}
-			conn = stat.Connection(netConn)
+			conn = netConn
+			reader, writer = buf.NewReader(netConn), buf.NewWriter(netConn)
+			if isUDP {
+				writer = &capsuleWriter{writer: netConn}
+				reader = &capsuleReader{reader: bufio.NewReaderSize(netConn, buf.Size)}
+			}
 		}
 		return err
 	}); err != nil {
@@ -142,12 +175,14 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 
 	requestFunc := func() error {
 		defer timer.SetTimeout(p.Timeouts.DownlinkOnly)
-		return buf.Copy(link.Reader, buf.NewWriter(conn), buf.UpdateActivity(timer))
+		return buf.Copy(link.Reader, writer, buf.UpdateActivity(timer))
 	}
 	responseFunc := func() error {
-		ob.CanSpliceCopy = 1
+		if !isUDP {
+			ob.CanSpliceCopy = 1
+		}
 		defer timer.SetTimeout(p.Timeouts.UplinkOnly)
-		return buf.Copy(buf.NewReader(conn), link.Writer, buf.UpdateActivity(timer))
+		return buf.Copy(reader, link.Writer, buf.UpdateActivity(timer))
 	}
 
 	if newCtx != nil {
@@ -201,15 +236,9 @@ func fillRequestHeader(ctx context.Context, header []*Header) ([]*Header, error)
 	return filled, nil
 }
 
-// setUpHTTPTunnel will create a socket tunnel via HTTP CONNECT method
-func setUpHTTPTunnel(ctx context.Context, dest net.Destination, target string, user *protocol.MemoryUser, dialer internet.Dialer, header []*Header, firstPayload []byte) (net.Conn, error) {
-	req := &http.Request{
-		Method: http.MethodConnect,
-		URL:    &url.URL{Host: target},
-		Header: make(http.Header),
-		Host:   target,
-	}
-
+// setProxyHeaders sets the credentials of user and the configured headers on
+// a request to the proxy.
+func setProxyHeaders(req *http.Request, user *protocol.MemoryUser, header []*Header) {
 	if user != nil && user.Account != nil {
 		account := user.Account.(*Account)
 		auth := account.GetUsername() + ":" + account.GetPassword()
@@ -219,9 +248,40 @@ func setUpHTTPTunnel(ctx context.Context, dest net.Destination, target string, u
 	for _, h := range header {
 		req.Header.Set(h.Key, h.Value)
 	}
+}
+
+// setUpHTTPTunnel will create a socket tunnel via HTTP CONNECT method, or a
+// stream of capsules carrying UDP (RFC 9298) if target is UDP.
+func setUpHTTPTunnel(ctx context.Context, dest net.Destination, target net.Destination, user *protocol.MemoryUser, dialer internet.Dialer, header []*Header, firstPayload []byte) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Host: target.NetAddr()},
+		Header: make(http.Header),
+		Host:   target.NetAddr(),
+	}
+	isUDP := target.Network == net.Network_UDP
+	if isUDP {
+		u, err := url.Parse("https://" + dest.NetAddr() + masquePath(target))
+		if err != nil {
+			return nil, err
+		}
+		req.URL = u
+		req.Host = u.Host
+		req.Header.Set("Capsule-Protocol", "?1")
+	}
+
+	setProxyHeaders(req, user, header)
 
 	connectHTTP1 := func(rawConn net.Conn) (net.Conn, error) {
-		req.Header.Set("Proxy-Connection", "Keep-Alive")
+		expected := http.StatusOK
+		if isUDP {
+			req.Method = http.MethodGet
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", connectUDP)
+			expected = http.StatusSwitchingProtocols
+		} else {
+			req.Header.Set("Proxy-Connection", "Keep-Alive")
+		}
 
 		err := req.Write(rawConn)
 		if err != nil {
@@ -229,21 +289,28 @@ func setUpHTTPTunnel(ctx context.Context, dest net.Destination, target string, u
 			return nil, err
 		}
 
-		resp, err := http.ReadResponse(bufio.NewReader(rawConn), req)
+		reader := bufio.NewReader(rawConn)
+		resp, err := http.ReadResponse(reader, req)
 		if err != nil {
 			rawConn.Close()
 			return nil, err
 		}
 		defer resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode != expected {
 			rawConn.Close()
-			return nil, errors.New("Proxy responded with non 200 code: " + resp.Status)
+			return nil, errors.New("Proxy responded with unexpected code: " + resp.Status)
+		}
+		if reader.Buffered() > 0 {
+			return &bufferedConn{Conn: rawConn, reader: reader}, nil
 		}
 		return rawConn, nil
 	}
 
 	connectHTTP2 := func(rawConn net.Conn, h2clientConn *http2.ClientConn) (net.Conn, error) {
+		if isUDP {
+			req.Header.Set(":protocol", connectUDP)
+		}
 		pr, pw := io.Pipe()
 		req.Body = pr
 
@@ -302,12 +369,12 @@ func setUpHTTPTunnel(ctx context.Context, dest net.Destination, target string, u
 	}
 
 	nextProto := ""
-	if tlsConn, ok := iConn.(*tls.Conn); ok {
+	if tlsConn, ok := iConn.(tls.Interface); ok {
 		if err := tlsConn.HandshakeContext(ctx); err != nil {
 			rawConn.Close()
 			return nil, err
 		}
-		nextProto = tlsConn.ConnectionState().NegotiatedProtocol
+		nextProto = tlsConn.NegotiatedProtocol()
 	}
 
 	switch nextProto {
@@ -3,7 +3,9 @@ package http
 import (
 	"google.golang.org/protobuf/proto"
 
+	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/protocol/auth"
 )
 
 func (a *Account) Equals(another protocol.Account) bool {
@@ -21,14 +23,31 @@ func (a *Account) AsAccount() (protocol.Account, error) {
 	return a, nil
 }
 
-func (sc *ServerConfig) HasAccount(username, password string) bool {
-	if sc.Accounts == nil {
-		return false
+// newValidator creates the validator of the users and authentication backends
+// of sc. Deprecated accounts become users named after their username.
+func (sc *ServerConfig) newValidator() (*auth.Validator, error) {
+	validator, err := auth.NewValidator(sc.AuthBackends, sc.UserLevel)
+	if err != nil {
+		return nil, err
 	}
-
-	p, found := sc.Accounts[username]
-	if !found {
-		return false
+	for _, user := range sc.Users {
+		u, err := user.ToMemoryUser()
+		if err != nil {
+			return nil, errors.New("failed to get http user").Base(err).AtError()
+		}
+		if err := validator.Add(u); err != nil {
+			return nil, errors.New("failed to add user").Base(err).AtError()
+		}
+	}
+	for username, password := range sc.Accounts {
+		u := &protocol.MemoryUser{
+			Email:   username,
+			Level:   sc.UserLevel,
+			Account: &Account{Username: username, Password: password},
+		}
+		if err := validator.Add(u); err != nil {
+			return nil, errors.New("failed to add user").Base(err).AtError()
+		}
 	}
-	return p == password
+	return validator, nil
 }
@@ -0,0 +1,302 @@
+package http
+
+// http3.go carries CONNECT-UDP (RFC 9298) over HTTP/3. It is enabled by "h3"
+// in the alpn of the TLS settings of the handler: an inbound then also
+// serves QUIC on its UDP port, and an outbound proxies UDP over QUIC to the
+// UDP port of its server. UDP payloads go in HTTP datagrams if the peer
+// takes them, and in DATAGRAM capsules on the request stream otherwise.
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/quic-go/quicvarint"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/stat"
+	"github.com/xtls/xray-core/transport/internet/tls"
+	"github.com/xtls/xray-core/transport/pipe"
+)
+
+// http3Config returns the TLS settings of the handler being created if they
+// enable HTTP/3.
+func http3Config(ctx context.Context) *tls.Config {
+	config := tls.ConfigFromStreamSettings(internet.StreamSettingsFromContext(ctx))
+	if config == nil || !slices.Contains(config.NextProtocol, "h3") {
+		return nil
+	}
+	return config
+}
+
+// serveHTTP3 serves the QUIC connection of the client of a UDP association.
+func (s *Server) serveHTTP3(ctx context.Context, conn stat.Connection, dispatcher routing.Dispatcher) error {
+	if s.h3 == nil {
+		return errors.New("HTTP/3 is not enabled")
+	}
+	reader, ok := conn.(buf.Reader)
+	if !ok {
+		reader = buf.NewPacketReader(conn)
+	}
+	tlsConfig := s.h3.GetTLSConfig()
+	defer tls.Release(tlsConfig)
+	quicTLSConfig := tlsConfig.Clone()
+	quicTLSConfig.NextProtos = []string{"h3"}
+
+	listener, err := quic.ListenEarly(&packetConn{Connection: conn, reader: reader}, quicTLSConfig, &quic.Config{
+		MaxIdleTimeout:  net.ConnIdleTimeout,
+		EnableDatagrams: true,
+	})
+	if err != nil {
+		return errors.New("failed to listen QUIC").Base(err)
+	}
+	defer listener.Close()
+	// stops the listener reading from conn before it is closed
+	defer conn.Close()
+
+	quicConn, err := listener.Accept(ctx)
+	if err != nil {
+		return errors.New("failed to accept QUIC connection").Base(err)
+	}
+	defer quicConn.CloseWithError(0, "")
+
+	server := &http3.Server{
+		EnableDatagrams: true,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.serveStream(w, r, conn.RemoteAddr(), dispatcher)
+		}),
+		ConnContext: func(connCtx context.Context, _ *quic.Conn) context.Context {
+			ctx, cancel := context.WithCancel(ctx)
+			context.AfterFunc(connCtx, cancel)
+			return ctx
+		},
+	}
+	if err := server.ServeQUICConn(quicConn); err != nil {
+		return errors.New("HTTP/3 connection ends").Base(err)
+	}
+	return nil
+}
+
+// packetConn is the net.PacketConn of a UDP association of the inbound,
+// which only has packets of one client.
+type packetConn struct {
+	stat.Connection
+	reader buf.Reader
+	cache  buf.MultiBuffer
+}
+
+func (c *packetConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	for c.cache.IsEmpty() {
+		mb, err := c.reader.ReadMultiBuffer()
+		if err != nil {
+			return 0, nil, err
+		}
+		c.cache = mb
+	}
+	var b *buf.Buffer
+	c.cache, b = buf.SplitFirst(c.cache)
+	n := copy(p, b.Bytes())
+	b.Release()
+	return n, c.RemoteAddr(), nil
+}
+
+func (c *packetConn) WriteTo(p []byte, _ net.Addr) (int, error) {
+	return c.Write(p)
+}
+
+func (c *packetConn) SetReadBuffer(int) error {
+	// only there to keep quic-go from warning about UDP buffers
+	return nil
+}
+
+// http3Stream is a CONNECT-UDP request stream over HTTP/3, on either end.
+type http3Stream interface {
+	io.ReadWriter
+	SendDatagram([]byte) error
+	ReceiveDatagram(context.Context) ([]byte, error)
+}
+
+// newHTTP3UDPReader reads UDP payloads from both HTTP datagrams and DATAGRAM
+// capsules of stream, until the stream ends. Datagrams are received until
+// ctx is done.
+func newHTTP3UDPReader(ctx context.Context, stream http3Stream) buf.Reader {
+	reader, writer := pipe.New(pipe.DiscardOverflow(), pipe.WithSizeLimit(64*1024))
+	go func() {
+		for {
+			b, err := stream.ReceiveDatagram(ctx)
+			if err != nil {
+				return
+			}
+			contextID, n, err := quicvarint.Parse(b)
+			if err != nil || contextID != 0 || len(b)-n > buf.Size {
+				continue
+			}
+			payload := buf.New()
+			payload.Write(b[n:])
+			if writer.WriteMultiBuffer(buf.MultiBuffer{payload}) != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		buf.Copy(&capsuleReader{reader: bufio.NewReaderSize(stream, buf.Size)}, writer)
+		writer.Close()
+	}()
+	return reader
+}
+
+// http3UDPWriter sends UDP payloads in HTTP datagrams, or in DATAGRAM
+// capsules if the peer does not take datagrams or a payload does not fit in
+// one.
+type http3UDPWriter struct {
+	stream    http3Stream
+	datagrams bool
+	capsules  *capsuleWriter
+}
+
+func newHTTP3UDPWriter(stream http3Stream, datagrams bool) *http3UDPWriter {
+	return &http3UDPWriter{
+		stream:    stream,
+		datagrams: datagrams,
+		capsules:  &capsuleWriter{writer: stream},
+	}
+}
+
+func (w *http3UDPWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	if !w.datagrams {
+		return w.capsules.WriteMultiBuffer(mb)
+	}
+	var rest buf.MultiBuffer
+	for _, b := range mb {
+		datagram := quicvarint.Append(make([]byte, 0, b.Len()+1), 0) // context ID of UDP payloads
+		if err := w.stream.SendDatagram(append(datagram, b.Bytes()...)); err != nil {
+			rest = append(rest, b)
+			continue
+		}
+		b.Release()
+	}
+	return w.capsules.WriteMultiBuffer(rest)
+}
+
+// http3Tunnel is a CONNECT-UDP request stream on a QUIC connection of its
+// own.
+type http3Tunnel struct {
+	buf.Reader
+	buf.Writer
+	rawConn net.Conn
+	conn    *quic.Conn
+	stream  *http3.RequestStream
+	cancel  context.CancelFunc
+}
+
+func (t *http3Tunnel) Close() error {
+	t.cancel()
+	t.stream.Close()
+	t.conn.CloseWithError(0, "")
+	return t.rawConn.Close()
+}
+
+// setUpHTTP3Tunnel sends a CONNECT-UDP request for target to the HTTP/3
+// proxy at dest.
+func setUpHTTP3Tunnel(ctx context.Context, dest net.Destination, target net.Destination, user *protocol.MemoryUser, dialer internet.Dialer, header []*Header, config *tls.Config) (*http3Tunnel, error) {
+	u, err := url.Parse("https://" + dest.NetAddr() + masquePath(target))
+	if err != nil {
+		return nil, err
+	}
+	req := &http.Request{
+		Method: http.MethodConnect,
+		Proto:  connectUDP,
+		URL:    u,
+		Header: make(http.Header),
+		Host:   u.Host,
+	}
+	req.Header.Set("Capsule-Protocol", "?1")
+	setProxyHeaders(req, user, header)
+
+	rawConn, err := dialer.Dial(ctx, net.UDPDestination(dest.Address, dest.Port))
+	if err != nil {
+		return nil, err
+	}
+	addr, err := net.ResolveUDPAddr("udp", rawConn.RemoteAddr().String())
+	if err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	tlsConfig := config.GetTLSConfig(tls.WithDestination(dest))
+	tlsConfig.NextProtos = []string{"h3"}
+	conn, err := quic.DialEarly(ctx, &internet.FakePacketConn{Conn: rawConn}, addr, tlsConfig, &quic.Config{
+		MaxIdleTimeout:  net.ConnIdleTimeout,
+		KeepAlivePeriod: net.QuicgoH3KeepAlivePeriod,
+		EnableDatagrams: true,
+	})
+	if err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	fail := func(err error) (*http3Tunnel, error) {
+		conn.CloseWithError(0, "")
+		rawConn.Close()
+		return nil, err
+	}
+
+	clientConn := (&http3.Transport{EnableDatagrams: true}).NewClientConn(conn)
+	select {
+	case <-clientConn.ReceivedSettings():
+	case <-ctx.Done():
+		return fail(ctx.Err())
+	}
+	settings := clientConn.Settings()
+	if !settings.EnableExtendedConnect {
+		return fail(errors.New("proxy does not support extended CONNECT over HTTP/3"))
+	}
+	stream, err := clientConn.OpenRequestStream(ctx)
+	if err != nil {
+		return fail(err)
+	}
+	if err := stream.SendRequestHeader(req); err != nil {
+		return fail(err)
+	}
+	resp, err := stream.ReadResponse()
+	if err != nil {
+		return fail(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fail(errors.New("Proxy responded with non 200 code: " + resp.Status))
+	}
+
+	streamCtx, cancel := context.WithCancel(context.Background())
+	return &http3Tunnel{
+		Reader:  newHTTP3UDPReader(streamCtx, stream),
+		Writer:  newHTTP3UDPWriter(stream, settings.EnableDatagrams),
+		rawConn: rawConn,
+		conn:    conn,
+		stream:  stream,
+		cancel:  cancel,
+	}, nil
+}
+
+// serveHTTP3UDP takes over the HTTP/3 stream of a CONNECT-UDP request whose
+// response header has been set, and proxies UDP to dest over it.
+func (s *Server) serveHTTP3UDP(ctx context.Context, w http.ResponseWriter, from net.Addr, dest net.Destination, dispatcher routing.Dispatcher, inbound *session.Inbound) error {
+	conn := w.(http3.Hijacker).Connection()
+	stream := w.(http3.HTTPStreamer).HTTPStream()
+	defer stream.Close()
+	datagrams := false
+	select {
+	case <-conn.ReceivedSettings():
+		datagrams = conn.Settings().EnableDatagrams
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return s.dispatchUDP(ctx, from, dest, newHTTP3UDPReader(ctx, stream), newHTTP3UDPWriter(stream, datagrams), dispatcher, inbound)
+}
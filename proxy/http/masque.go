@@ -0,0 +1,129 @@
+package http
+
+// masque.go implements proxying UDP in HTTP (RFC 9298). UDP payloads are
+// carried in DATAGRAM capsules (RFC 9297) on the request stream, and the
+// target is taken from the default URI template. HTTP/1.1 upgrades and
+// HTTP/2 extended CONNECT are served here, and HTTP/3 in http3.go.
+
+import (
+	"bufio"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/quic-go/quic-go/quicvarint"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+)
+
+const (
+	connectUDP       = "connect-udp"
+	masquePathPrefix = "/.well-known/masque/udp/"
+
+	capsuleDatagram = 0x00
+)
+
+// masquePath returns the path of the default URI template for dest.
+func masquePath(dest net.Destination) string {
+	host := dest.Address.String()
+	if dest.Address.Family().IsIP() {
+		host = dest.Address.IP().String()
+	}
+	// template expansion escapes the colons of IPv6 addresses too
+	host = strings.ReplaceAll(url.PathEscape(host), ":", "%3A")
+	return masquePathPrefix + host + "/" + dest.Port.String() + "/"
+}
+
+// parseMasquePath returns the UDP target of an escaped path following the
+// default URI template.
+func parseMasquePath(path string) (net.Destination, error) {
+	rest, ok := strings.CutPrefix(path, masquePathPrefix)
+	if !ok {
+		return net.Destination{}, errors.New("not a MASQUE path: ", path)
+	}
+	s := strings.Split(strings.TrimSuffix(rest, "/"), "/")
+	if len(s) != 2 {
+		return net.Destination{}, errors.New("malformed MASQUE path: ", path)
+	}
+	host, err := url.PathUnescape(s[0])
+	if err != nil || host == "" {
+		return net.Destination{}, errors.New("malformed MASQUE target host: ", s[0])
+	}
+	port, err := strconv.ParseUint(s[1], 10, 16)
+	if err != nil || port == 0 {
+		return net.Destination{}, errors.New("malformed MASQUE target port: ", s[1])
+	}
+	return net.UDPDestination(net.ParseAddress(host), net.Port(port)), nil
+}
+
+// capsuleReader reads UDP payloads from DATAGRAM capsules, one buffer per
+// packet. Other capsules and datagrams with a non-zero context ID are
+// skipped.
+type capsuleReader struct {
+	reader *bufio.Reader
+}
+
+func (r *capsuleReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	for {
+		capsuleType, err := quicvarint.Read(r.reader)
+		if err != nil {
+			return nil, err
+		}
+		length, err := quicvarint.Read(r.reader)
+		if err != nil {
+			return nil, errors.New("failed to read capsule length").Base(err)
+		}
+		body := io.LimitReader(r.reader, int64(length))
+		if capsuleType != capsuleDatagram {
+			if _, err := io.Copy(io.Discard, body); err != nil {
+				return nil, errors.New("failed to skip capsule").Base(err)
+			}
+			continue
+		}
+		contextID, err := quicvarint.Read(r.reader)
+		if err != nil {
+			return nil, errors.New("failed to read context ID").Base(err)
+		}
+		size := int64(length) - int64(quicvarint.Len(contextID))
+		if size < 0 {
+			return nil, errors.New("malformed DATAGRAM capsule of length ", length)
+		}
+		if contextID != 0 || size > buf.Size {
+			if _, err := io.CopyN(io.Discard, r.reader, size); err != nil {
+				return nil, errors.New("failed to skip datagram").Base(err)
+			}
+			continue
+		}
+		b := buf.New()
+		if _, err := b.ReadFullFrom(r.reader, int32(size)); err != nil {
+			b.Release()
+			return nil, errors.New("failed to read datagram").Base(err)
+		}
+		return buf.MultiBuffer{b}, nil
+	}
+}
+
+// capsuleWriter writes each buffer as a UDP payload in a DATAGRAM capsule.
+type capsuleWriter struct {
+	writer io.Writer
+}
+
+func (w *capsuleWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	defer buf.ReleaseMulti(mb)
+	var data []byte
+	for _, b := range mb {
+		data = quicvarint.Append(data, capsuleDatagram)
+		data = quicvarint.Append(data, uint64(b.Len())+1)
+		data = quicvarint.Append(data, 0) // context ID of UDP payloads
+		data = append(data, b.Bytes()...)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if _, err := w.writer.Write(data); err != nil {
+		return errors.New("failed to write capsules").Base(err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+)
+
+func TestCapsuleReaderRejectsShortDatagram(t *testing.T) {
+	// a DATAGRAM capsule of length 0, too short for its context ID
+	r := &capsuleReader{reader: bufio.NewReader(bytes.NewReader([]byte{0x00, 0x00, 0x00}))}
+	if _, err := r.ReadMultiBuffer(); err == nil {
+		t.Error("accepted a DATAGRAM capsule without room for the context ID")
+	}
+}
+
+func TestCapsuleReaderSkipsOtherCapsules(t *testing.T) {
+	r := &capsuleReader{reader: bufio.NewReader(bytes.NewReader([]byte{
+		0x01, 0x02, 0xaa, 0xbb, // unknown capsule
+		0x00, 0x03, 0x01, 0xcc, 0xdd, // datagram of another context
+		0x00, 0x03, 0x00, 'h', 'i',
+	}))}
+	mb, err := r.ReadMultiBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := mb.String(); s != "hi" {
+		t.Error("read ", s)
+	}
+}
+
+type datagramStream struct {
+	bytes.Buffer
+	datagrams [][]byte
+	maxSize   int
+}
+
+func (s *datagramStream) SendDatagram(b []byte) error {
+	if len(b) > s.maxSize {
+		return errors.New("datagram too large")
+	}
+	s.datagrams = append(s.datagrams, b)
+	return nil
+}
+
+func (s *datagramStream) ReceiveDatagram(ctx context.Context) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestHTTP3UDPWriterFallsBackToCapsules(t *testing.T) {
+	stream := &datagramStream{maxSize: 4}
+	w := newHTTP3UDPWriter(stream, true)
+	mb := buf.MultiBuffer{buf.New(), buf.New()}
+	mb[0].WriteString("hi")
+	mb[1].WriteString("too large")
+	common.Must(w.WriteMultiBuffer(mb))
+	if len(stream.datagrams) != 1 || string(stream.datagrams[0]) != "\x00hi" {
+		t.Error("datagrams ", stream.datagrams)
+	}
+	r := &capsuleReader{reader: bufio.NewReader(&stream.Buffer)}
+	mb, err := r.ReadMultiBuffer()
+	common.Must(err)
+	if s := mb.String(); s != "too large" {
+		t.Error("capsule ", s)
+	}
+}
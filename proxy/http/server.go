@@ -7,15 +7,19 @@ import (
 	"encoding/base64"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/quic-go/quic-go/http3"
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/buf"
+	c "github.com/xtls/xray-core/common/ctx"
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/log"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/protocol/auth"
 	http_proto "github.com/xtls/xray-core/common/protocol/http"
 	"github.com/xtls/xray-core/common/session"
 	"github.com/xtls/xray-core/common/task"
@@ -25,33 +29,87 @@ import (
 	"github.com/xtls/xray-core/proxy"
 	"github.com/xtls/xray-core/transport"
 	"github.com/xtls/xray-core/transport/internet/stat"
+	"github.com/xtls/xray-core/transport/internet/tls"
+	"golang.org/x/net/http2"
 )
 
 // Server is an HTTP proxy server.
 type Server struct {
 	config        *ServerConfig
+	validator     *auth.Validator
 	policyManager policy.Manager
+	h3            *tls.Config
 }
 
 // NewServer creates a new HTTP inbound handler.
 func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
+	validator, err := config.newValidator()
+	if err != nil {
+		return nil, err
+	}
+
 	v := core.MustFromContext(ctx)
 	s := &Server{
 		config:        config,
+		validator:     validator,
 		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
+		h3:            http3Config(ctx),
 	}
 
 	return s, nil
 }
 
+// Validator returns the validator of the users of the server.
+func (s *Server) Validator() *auth.Validator {
+	return s.validator
+}
+
+// AddUser implements proxy.UserManager.AddUser().
+func (s *Server) AddUser(ctx context.Context, u *protocol.MemoryUser) error {
+	return s.validator.Add(u)
+}
+
+// RemoveUser implements proxy.UserManager.RemoveUser().
+func (s *Server) RemoveUser(ctx context.Context, e string) error {
+	return s.validator.Del(e)
+}
+
+// GetUser implements proxy.UserManager.GetUser().
+func (s *Server) GetUser(ctx context.Context, email string) *protocol.MemoryUser {
+	return s.validator.GetByEmail(email)
+}
+
+// GetUsers implements proxy.UserManager.GetUsers().
+func (s *Server) GetUsers(ctx context.Context) []*protocol.MemoryUser {
+	return s.validator.GetAll()
+}
+
+// GetUsersCount implements proxy.UserManager.GetUsersCount().
+func (s *Server) GetUsersCount(context.Context) int64 {
+	return s.validator.GetCount()
+}
+
+// authenticate returns the user of the Proxy-Authorization header, or nil
+// if it is invalid.
+func (s *Server) authenticate(ctx context.Context, header http.Header) *protocol.MemoryUser {
+	username, password, ok := parseBasicAuth(header.Get("Proxy-Authorization"))
+	if !ok {
+		return nil
+	}
+	return s.validator.Authenticate(ctx, username, password)
+}
+
 func (s *Server) policy() policy.Session {
 	config := s.config
 	p := s.policyManager.ForLevel(config.UserLevel)
 	return p
 }
 
-// Network implements proxy.Inbound.
-func (*Server) Network() []net.Network {
+// Network implements proxy.Inbound. UDP is served with HTTP/3.
+func (s *Server) Network() []net.Network {
+	if s.h3 != nil {
+		return []net.Network{net.Network_TCP, net.Network_UDP, net.Network_UNIX}
+	}
 	return []net.Network{net.Network_TCP, net.Network_UNIX}
 }
 
@@ -82,6 +140,11 @@ type readerOnly struct {
 }
 
 func (s *Server) Process(ctx context.Context, network net.Network, conn stat.Connection, dispatcher routing.Dispatcher) error {
+	if network == net.Network_UDP {
+		inbound := session.InboundFromContext(ctx)
+		inbound.Name = "http"
+		return s.serveHTTP3(ctx, conn, dispatcher)
+	}
 	return s.ProcessWithFirstbyte(ctx, network, conn, dispatcher)
 }
 
@@ -113,6 +176,12 @@ Start:
 		errors.LogInfoInner(ctx, err, "failed to set read deadline")
 	}
 
+	if b, _ := reader.Peek(3); string(b) == "PRI" {
+		if b, _ := reader.Peek(len(http2.ClientPreface)); string(b) == http2.ClientPreface {
+			return s.serveHTTP2(ctx, conn, reader, dispatcher)
+		}
+	}
+
 	request, err := http.ReadRequest(reader)
 	if err != nil {
 		trace := errors.New("failed to read http request").Base(err)
@@ -122,14 +191,17 @@ Start:
 		return trace
 	}
 
-	if len(s.config.Accounts) > 0 {
-		user, pass, ok := parseBasicAuth(request.Header.Get("Proxy-Authorization"))
-		if !ok || !s.config.HasAccount(user, pass) {
+	if response, found := acmeChallengeResponse(inbound.Tag, request); found {
+		errors.LogInfo(ctx, "answering ACME HTTP-01 challenge ", request.URL.Path)
+		return common.Error2(io.WriteString(conn, "HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\nContent-Length: "+strconv.Itoa(len(response))+"\r\nConnection: close\r\n\r\n"+response))
+	}
+
+	if s.validator.Required() {
+		user := s.authenticate(ctx, request.Header)
+		if user == nil {
 			return common.Error2(conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\nProxy-Authenticate: Basic realm=\"proxy\"\r\n\r\n")))
 		}
-		if inbound != nil {
-			inbound.User.Email = user
-		}
+		inbound.User = user
 	}
 
 	errors.LogInfo(ctx, "request to Method [", request.Method, "] Host [", request.Host, "] with URL [", request.URL, "]")
@@ -137,6 +209,10 @@ Start:
 		errors.LogDebugInner(ctx, err, "failed to clear read deadline")
 	}
 
+	if strings.EqualFold(request.Header.Get("Upgrade"), connectUDP) {
+		return s.handleConnectUDP(ctx, request, reader, conn, dispatcher, inbound)
+	}
+
 	defaultPort := net.Port(80)
 	if strings.EqualFold(request.URL.Scheme, "https") {
 		defaultPort = net.Port(443)
@@ -201,8 +277,166 @@ func (s *Server) handleConnect(ctx context.Context, _ *http.Request, buffer *buf
 	return nil
 }
 
+// handleConnectUDP serves an HTTP/1.1 upgrade to proxy UDP (RFC 9298).
+func (s *Server) handleConnectUDP(ctx context.Context, request *http.Request, reader *bufio.Reader, conn stat.Connection, dispatcher routing.Dispatcher, inbound *session.Inbound) error {
+	dest, err := parseMasquePath(request.URL.EscapedPath())
+	if err != nil || request.Method != http.MethodGet {
+		conn.Write([]byte("HTTP/1.1 400 Bad Request\r\nConnection: close\r\n\r\n"))
+		return errors.New("invalid CONNECT-UDP request").Base(err).AtWarning()
+	}
+	if _, err := conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: connect-udp\r\nCapsule-Protocol: ?1\r\n\r\n")); err != nil {
+		return errors.New("failed to write back upgrade response").Base(err)
+	}
+	return s.dispatchUDP(ctx, conn.RemoteAddr(), dest, &capsuleReader{reader: reader}, &capsuleWriter{writer: conn}, dispatcher, inbound)
+}
+
+func (s *Server) dispatchUDP(ctx context.Context, from net.Addr, dest net.Destination, reader buf.Reader, writer buf.Writer, dispatcher routing.Dispatcher, inbound *session.Inbound) error {
+	inbound.CanSpliceCopy = 3
+	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
+		From:   from,
+		To:     dest,
+		Status: log.AccessAccepted,
+		Reason: "",
+		Email:  inbound.User.Email,
+	})
+	errors.LogInfo(ctx, "proxying UDP to ", dest)
+	if err := dispatcher.DispatchLink(ctx, dest, &transport.Link{
+		Reader: reader,
+		Writer: writer},
+	); err != nil {
+		return errors.New("failed to dispatch UDP").Base(err)
+	}
+	return nil
+}
+
+// serveHTTP2 serves proxy requests over HTTP/2: CONNECT tunnels, and UDP in
+// extended CONNECT (RFC 9298). Note that x/net/http2 only accepts extended
+// CONNECT if xray runs with GODEBUG=http2xconnect=1, so CONNECT-UDP over
+// HTTP/2 needs it to be set by the operator.
+func (s *Server) serveHTTP2(ctx context.Context, conn stat.Connection, reader *bufio.Reader, dispatcher routing.Dispatcher) error {
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		errors.LogDebugInner(ctx, err, "failed to clear read deadline")
+	}
+	server := &http2.Server{}
+	server.ServeConn(&bufferedConn{Conn: conn, reader: reader}, &http2.ServeConnOpts{
+		Context: ctx,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.serveStream(w, r, conn.RemoteAddr(), dispatcher)
+		}),
+	})
+	return nil
+}
+
+// serveStream serves a proxy request over HTTP/2 or HTTP/3.
+func (s *Server) serveStream(w http.ResponseWriter, r *http.Request, from net.Addr, dispatcher routing.Dispatcher) {
+	// streams are dispatched independently, so each one gets its own metadata
+	ctx := c.ContextWithID(r.Context(), session.NewID())
+	inbound := *session.InboundFromContext(ctx)
+	inbound.User = &protocol.MemoryUser{
+		Level: s.config.UserLevel,
+	}
+	ctx = session.ContextWithInbound(ctx, &inbound)
+	outbound := *session.OutboundsFromContext(ctx)[0]
+	ctx = session.ContextWithOutbounds(ctx, []*session.Outbound{&outbound})
+
+	if s.validator.Required() {
+		user := s.authenticate(ctx, r.Header)
+		if user == nil {
+			w.Header().Set("Proxy-Authenticate", `Basic realm="proxy"`)
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		inbound.User = user
+	}
+
+	errors.LogInfo(ctx, "request to Method [", r.Method, "] Host [", r.Host, "] with URL [", r.URL, "] over HTTP/", r.ProtoMajor)
+	if r.Method != http.MethodConnect {
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+
+	writer := &flushWriter{w: w}
+	var err error
+	// x/net/http2 passes the protocol of extended CONNECT as a header, and
+	// quic-go/http3 as Proto
+	if r.Header.Get(":protocol") == connectUDP || r.Proto == connectUDP {
+		var dest net.Destination
+		if dest, err = parseMasquePath(r.URL.EscapedPath()); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			errors.LogWarningInner(ctx, err, "invalid CONNECT-UDP request")
+			return
+		}
+		w.Header().Set("Capsule-Protocol", "?1")
+		w.WriteHeader(http.StatusOK)
+		if _, ok := w.(http3.HTTPStreamer); ok {
+			err = s.serveHTTP3UDP(ctx, w, from, dest, dispatcher, &inbound)
+		} else {
+			writer.Flush()
+			err = s.dispatchUDP(ctx, from, dest, &capsuleReader{reader: bufio.NewReaderSize(r.Body, buf.Size)}, &capsuleWriter{writer: writer}, dispatcher, &inbound)
+		}
+	} else {
+		var dest net.Destination
+		if dest, err = http_proto.ParseHost(r.Host, net.Port(443)); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			errors.LogWarningInner(ctx, err, "malformed proxy host: ", r.Host)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		writer.Flush()
+		inbound.CanSpliceCopy = 3
+		ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
+			From:   from,
+			To:     dest,
+			Status: log.AccessAccepted,
+			Reason: "",
+			Email:  inbound.User.Email,
+		})
+		err = dispatcher.DispatchLink(ctx, dest, &transport.Link{
+			Reader: buf.NewReader(r.Body),
+			Writer: buf.NewWriter(writer)},
+		)
+	}
+	if err != nil {
+		errors.LogInfoInner(ctx, err, "HTTP/", r.ProtoMajor, " stream ends")
+	}
+}
+
+// bufferedConn is a connection whose first bytes have been read into reader.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// flushWriter sends every write out in a DATA frame right away.
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (w *flushWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	w.Flush()
+	return n, err
+}
+
+func (w *flushWriter) Flush() {
+	w.w.(http.Flusher).Flush()
+}
+
 var errWaitAnother = errors.New("keep alive")
 
+// acmeChallengeResponse returns the response to an ACME HTTP-01 challenge
+// request sent to an inbound that ACME configs answer challenges on.
+func acmeChallengeResponse(inboundTag string, request *http.Request) (string, bool) {
+	if request.Method != http.MethodGet || request.URL.Host != "" || !strings.HasPrefix(request.URL.Path, "/.well-known/acme-challenge/") {
+		return "", false
+	}
+	return tls.AcmeHTTP01Response(inboundTag, request.URL.Path)
+}
+
 func (s *Server) handlePlainHTTP(ctx context.Context, request *http.Request, writer io.Writer, dest net.Destination, dispatcher routing.Dispatcher) error {
 	if !s.config.AllowTransparent && request.URL.Host == "" {
 		// RFC 2068 (HTTP/1.1) requires URL to be absolute URL in HTTP proxy.
@@ -90,6 +90,14 @@ type UserManager interface {
 	GetUsersCount(context.Context) int64
 }
 
+// DirectOutbound is an Outbound that connects to the target itself, so that
+// failing to reach the target is not a failure of the outbound.
+type DirectOutbound interface {
+	Outbound
+	// DirectOutbound is a marker method.
+	DirectOutbound()
+}
+
 type GetInbound interface {
 	GetInbound() Inbound
 }
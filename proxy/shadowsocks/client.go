@@ -24,6 +24,7 @@ import (
 type Client struct {
 	server        *protocol.ServerSpec
 	policyManager policy.Manager
+	plugin        *plugin
 }
 
 // NewClient create a new Shadowsocks client.
@@ -41,9 +42,28 @@ func NewClient(ctx context.Context, config *ClientConfig) (*Client, error) {
 		server:        server,
 		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
 	}
+	if config.Plugin != "" {
+		client.plugin = newPlugin(config, server.Destination)
+	}
 	return client, nil
 }
 
+// Start implements common.Runnable.
+func (c *Client) Start() error {
+	if c.plugin != nil {
+		return c.plugin.Start()
+	}
+	return nil
+}
+
+// Close implements common.Closable.
+func (c *Client) Close() error {
+	if c.plugin != nil {
+		return c.plugin.Close()
+	}
+	return nil
+}
+
 // Process implements OutboundHandler.Process().
 func (c *Client) Process(ctx context.Context, link *transport.Link, dialer internet.Dialer) error {
 	outbounds := session.OutboundsFromContext(ctx)
@@ -62,7 +82,15 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 	var conn stat.Connection
 
 	err := retry.ExponentialBackoff(5, 100).On(func() error {
-		rawConn, err := dialer.Dial(ctx, dest)
+		var rawConn stat.Connection
+		var err error
+		if c.plugin != nil && network == net.Network_TCP {
+			// SIP003 plugins only carry TCP, UDP goes to the server directly.
+			var d net.Dialer
+			rawConn, err = d.DialContext(ctx, "tcp", c.plugin.local.NetAddr())
+		} else {
+			rawConn, err = dialer.Dial(ctx, dest)
+		}
 		if err != nil {
 			return err
 		}
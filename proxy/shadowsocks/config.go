@@ -98,6 +98,34 @@ func (a *Account) getCipher() (Cipher, error) {
 			IVBytes:         32,
 			AEADAuthCreator: createXChaCha20Poly1305,
 		}, nil
+	case CipherType_AES_128_CFB:
+		return &StreamCipher{
+			KeyBytes:       16,
+			IVBytes:        16,
+			EncryptCreator: crypto.NewAesEncryptionStream,
+			DecryptCreator: crypto.NewAesDecryptionStream,
+		}, nil
+	case CipherType_AES_256_CFB:
+		return &StreamCipher{
+			KeyBytes:       32,
+			IVBytes:        16,
+			EncryptCreator: crypto.NewAesEncryptionStream,
+			DecryptCreator: crypto.NewAesDecryptionStream,
+		}, nil
+	case CipherType_CHACHA20:
+		return &StreamCipher{
+			KeyBytes:       32,
+			IVBytes:        8,
+			EncryptCreator: crypto.NewChaCha20Stream,
+			DecryptCreator: crypto.NewChaCha20Stream,
+		}, nil
+	case CipherType_CHACHA20_IETF:
+		return &StreamCipher{
+			KeyBytes:       32,
+			IVBytes:        12,
+			EncryptCreator: crypto.NewChaCha20Stream,
+			DecryptCreator: crypto.NewChaCha20Stream,
+		}, nil
 	case CipherType_NONE:
 		return NoneCipher{}, nil
 	default:
@@ -205,6 +233,54 @@ func (c *AEADCipher) DecodePacket(key []byte, b *buf.Buffer) error {
 	return nil
 }
 
+// StreamCipher is a legacy Shadowsocks stream cipher.
+type StreamCipher struct {
+	KeyBytes       int32
+	IVBytes        int32
+	EncryptCreator func(key []byte, iv []byte) cipher.Stream
+	DecryptCreator func(key []byte, iv []byte) cipher.Stream
+}
+
+func (*StreamCipher) IsAEAD() bool {
+	return false
+}
+
+func (c *StreamCipher) KeySize() int32 {
+	return c.KeyBytes
+}
+
+func (c *StreamCipher) IVSize() int32 {
+	return c.IVBytes
+}
+
+func (c *StreamCipher) NewEncryptionWriter(key []byte, iv []byte, writer io.Writer) (buf.Writer, error) {
+	stream := c.EncryptCreator(key, iv)
+	return &buf.SequentialWriter{Writer: crypto.NewCryptionWriter(stream, writer)}, nil
+}
+
+func (c *StreamCipher) NewDecryptionReader(key []byte, iv []byte, reader io.Reader) (buf.Reader, error) {
+	stream := c.DecryptCreator(key, iv)
+	return &buf.SingleReader{Reader: crypto.NewCryptionReader(stream, reader)}, nil
+}
+
+func (c *StreamCipher) EncodePacket(key []byte, b *buf.Buffer) error {
+	iv := b.BytesTo(c.IVBytes)
+	stream := c.EncryptCreator(key, iv)
+	stream.XORKeyStream(b.BytesFrom(c.IVBytes), b.BytesFrom(c.IVBytes))
+	return nil
+}
+
+func (c *StreamCipher) DecodePacket(key []byte, b *buf.Buffer) error {
+	if b.Len() <= c.IVBytes {
+		return errors.New("insufficient data: ", b.Len())
+	}
+	iv := b.BytesTo(c.IVBytes)
+	stream := c.DecryptCreator(key, iv)
+	stream.XORKeyStream(b.BytesFrom(c.IVBytes), b.BytesFrom(c.IVBytes))
+	b.Advance(c.IVBytes)
+	return nil
+}
+
 type NoneCipher struct{}
 
 func (NoneCipher) KeySize() int32 { return 0 }
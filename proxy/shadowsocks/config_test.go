@@ -36,3 +36,40 @@ func TestAEADCipherUDP(t *testing.T) {
 		t.Error(diff)
 	}
 }
+
+func TestStreamCipherUDP(t *testing.T) {
+	for _, cipherType := range []shadowsocks.CipherType{
+		shadowsocks.CipherType_AES_128_CFB,
+		shadowsocks.CipherType_AES_256_CFB,
+		shadowsocks.CipherType_CHACHA20,
+		shadowsocks.CipherType_CHACHA20_IETF,
+	} {
+		rawAccount := &shadowsocks.Account{
+			CipherType: cipherType,
+			Password:   "test",
+		}
+		account, err := rawAccount.AsAccount()
+		common.Must(err)
+
+		cipher := account.(*shadowsocks.MemoryAccount).Cipher
+
+		key := make([]byte, cipher.KeySize())
+		common.Must2(rand.Read(key))
+
+		payload := make([]byte, 1024)
+		common.Must2(rand.Read(payload))
+
+		b1 := buf.New()
+		common.Must2(b1.ReadFullFrom(rand.Reader, cipher.IVSize()))
+		common.Must2(b1.Write(payload))
+		common.Must(cipher.EncodePacket(key, b1))
+		if cmp.Equal(b1.BytesFrom(cipher.IVSize()), payload) {
+			t.Error(cipherType, ": payload is not encrypted")
+		}
+
+		common.Must(cipher.DecodePacket(key, b1))
+		if diff := cmp.Diff(b1.Bytes(), payload); diff != "" {
+			t.Error(cipherType, ": ", diff)
+		}
+	}
+}
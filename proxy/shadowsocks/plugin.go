@@ -0,0 +1,154 @@
+package shadowsocks
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+)
+
+const (
+	pluginRestartDelay    = time.Second
+	pluginMaxRestartDelay = time.Minute
+)
+
+// plugin runs a SIP003 plugin, and restarts it whenever it exits until it is
+// closed.
+type plugin struct {
+	path    string
+	options string
+	args    []string
+	remote  net.Destination
+	local   net.Destination
+
+	access  sync.Mutex
+	cmd     *exec.Cmd
+	closed  bool
+	closing chan struct{}
+	done    chan struct{}
+	started bool
+}
+
+func newPlugin(config *ClientConfig, remote net.Destination) *plugin {
+	return &plugin{
+		path:    config.Plugin,
+		options: config.PluginOpts,
+		args:    config.PluginArgs,
+		remote:  remote,
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start starts the plugin on a free local port.
+func (p *plugin) Start() error {
+	p.access.Lock()
+	defer p.access.Unlock()
+	if p.started {
+		return nil
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return errors.New("failed to find a local port for plugin ", p.path).Base(err)
+	}
+	addr := listener.Addr().(*net.TCPAddr)
+	listener.Close()
+	p.local = net.TCPDestination(net.IPAddress(addr.IP), net.Port(addr.Port))
+
+	if err := p.start(); err != nil {
+		return err
+	}
+	p.started = true
+	go p.supervise()
+	return nil
+}
+
+func (p *plugin) start() error {
+	cmd := exec.Command(p.path, p.args...)
+	cmd.Env = append(os.Environ(),
+		"SS_REMOTE_HOST="+p.remote.Address.String(),
+		"SS_REMOTE_PORT="+p.remote.Port.String(),
+		"SS_LOCAL_HOST="+p.local.Address.String(),
+		"SS_LOCAL_PORT="+p.local.Port.String(),
+		"SS_PLUGIN_OPTIONS="+p.options,
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return errors.New("failed to start plugin ", p.path).Base(err)
+	}
+	errors.LogInfo(context.Background(), "plugin ", p.path, " started for ", p.remote, " on ", p.local)
+	p.cmd = cmd
+	return nil
+}
+
+// supervise waits for the plugin to exit and restarts it, backing off while
+// it keeps crashing.
+func (p *plugin) supervise() {
+	defer close(p.done)
+	delay := pluginRestartDelay
+	for {
+		p.access.Lock()
+		cmd := p.cmd
+		p.access.Unlock()
+
+		started := time.Now()
+		err := cmd.Wait()
+
+		p.access.Lock()
+		if p.closed {
+			p.access.Unlock()
+			return
+		}
+		p.cmd = nil
+		p.access.Unlock()
+
+		if time.Since(started) > pluginMaxRestartDelay {
+			delay = pluginRestartDelay
+		}
+		errors.LogWarning(context.Background(), "plugin ", p.path, " exited, restarting in ", delay, ": ", err)
+		for {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-p.closing:
+				timer.Stop()
+				return
+			}
+			delay = min(delay*2, pluginMaxRestartDelay)
+
+			p.access.Lock()
+			if p.closed {
+				p.access.Unlock()
+				return
+			}
+			err := p.start()
+			p.access.Unlock()
+			if err == nil {
+				break
+			}
+			errors.LogWarning(context.Background(), err, ", retrying in ", delay)
+		}
+	}
+}
+
+// Close stops the plugin and waits for it to exit.
+func (p *plugin) Close() error {
+	p.access.Lock()
+	if !p.started || p.closed {
+		p.access.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.closing)
+	if p.cmd != nil {
+		p.cmd.Process.Kill()
+	}
+	p.access.Unlock()
+	<-p.done
+	return nil
+}
@@ -0,0 +1,124 @@
+package shadowsocks
+
+import (
+	"io"
+	gonet "net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+)
+
+// TestPluginHelper is not a test, but a SIP003 plugin forwarding
+// SS_LOCAL_HOST:SS_LOCAL_PORT to SS_REMOTE_HOST:SS_REMOTE_PORT, run by
+// TestPlugin.
+func TestPluginHelper(t *testing.T) {
+	if os.Getenv("XRAY_TEST_PLUGIN") != "1" {
+		t.Skip("not run as a plugin")
+	}
+	listener, err := net.Listen("tcp", gonet.JoinHostPort(os.Getenv("SS_LOCAL_HOST"), os.Getenv("SS_LOCAL_PORT")))
+	common.Must(err)
+	remote := gonet.JoinHostPort(os.Getenv("SS_REMOTE_HOST"), os.Getenv("SS_REMOTE_PORT"))
+	for {
+		conn, err := listener.Accept()
+		common.Must(err)
+		go func() {
+			defer conn.Close()
+			upstream, err := net.Dial("tcp", remote)
+			if err != nil {
+				return
+			}
+			defer upstream.Close()
+			upstream.Write([]byte(os.Getenv("SS_PLUGIN_OPTIONS")))
+			go io.Copy(upstream, conn)
+			io.Copy(conn, upstream)
+		}()
+	}
+}
+
+func TestPlugin(t *testing.T) {
+	server, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer server.Close()
+	go func() {
+		for {
+			conn, err := server.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(conn, conn)
+				conn.Close()
+			}()
+		}
+	}()
+	addr := server.Addr().(*net.TCPAddr)
+
+	os.Setenv("XRAY_TEST_PLUGIN", "1")
+	defer os.Unsetenv("XRAY_TEST_PLUGIN")
+	p := newPlugin(&ClientConfig{
+		Plugin:     os.Args[0],
+		PluginOpts: "obfs=http",
+		PluginArgs: []string{"-test.run=^TestPluginHelper$"},
+	}, net.TCPDestination(net.IPAddress(addr.IP), net.Port(addr.Port)))
+	common.Must(p.Start())
+	defer p.Close()
+
+	roundTrip := func() string {
+		var conn net.Conn
+		var err error
+		for i := 0; i < 100; i++ {
+			if conn, err = net.Dial("tcp", p.local.NetAddr()); err == nil {
+				break
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		common.Must(err)
+		defer conn.Close()
+		conn.Write([]byte("|ping"))
+		b := make([]byte, len("obfs=http|ping"))
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if _, err := io.ReadFull(conn, b); err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	if r := roundTrip(); r != "obfs=http|ping" {
+		t.Fatal("unexpected response ", r)
+	}
+
+	p.access.Lock()
+	p.cmd.Process.Kill()
+	p.access.Unlock()
+	time.Sleep(100 * time.Millisecond)
+	if r := roundTrip(); r != "obfs=http|ping" {
+		t.Fatal("unexpected response after restart ", r)
+	}
+
+	p.access.Lock()
+	process := p.cmd.Process
+	p.access.Unlock()
+	common.Must(p.Close())
+	if err := process.Signal(os.Kill); err == nil {
+		t.Error("plugin still running after close")
+	}
+}
+
+func TestPluginCloseWhileRestarting(t *testing.T) {
+	p := newPlugin(&ClientConfig{
+		Plugin:     os.Args[0],
+		PluginArgs: []string{"-test.run=^$"},
+	}, net.TCPDestination(net.LocalHostIP, 1))
+	common.Must(p.Start())
+	// The plugin exits right away, and waits to be restarted.
+	time.Sleep(200 * time.Millisecond)
+
+	start := time.Now()
+	common.Must(p.Close())
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Error("close waited ", d, " for the restart delay")
+	}
+}
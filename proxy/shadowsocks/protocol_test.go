@@ -153,6 +153,38 @@ func TestTCPRequest(t *testing.T) {
 			},
 			payload: []byte("test string"),
 		},
+		{
+			request: &protocol.RequestHeader{
+				Version: Version,
+				Command: protocol.RequestCommandTCP,
+				Address: net.DomainAddress("example.com"),
+				Port:    1234,
+				User: &protocol.MemoryUser{
+					Email: "love@example.com",
+					Account: toAccount(&Account{
+						Password:   "password",
+						CipherType: CipherType_AES_256_CFB,
+					}),
+				},
+			},
+			payload: []byte("test string long enough for the 50 bytes the server reads first"),
+		},
+		{
+			request: &protocol.RequestHeader{
+				Version: Version,
+				Command: protocol.RequestCommandTCP,
+				Address: net.LocalHostIP,
+				Port:    1234,
+				User: &protocol.MemoryUser{
+					Email: "love@example.com",
+					Account: toAccount(&Account{
+						Password:   "password",
+						CipherType: CipherType_CHACHA20_IETF,
+					}),
+				},
+			},
+			payload: []byte("test string long enough for the 50 bytes the server reads first"),
+		},
 	}
 
 	runTest := func(request *protocol.RequestHeader, payload []byte) {
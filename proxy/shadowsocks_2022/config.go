@@ -3,7 +3,10 @@ package shadowsocks_2022
 import (
 	"google.golang.org/protobuf/proto"
 
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/serial"
 )
 
 // MemoryAccount is an account type converted from Account.
@@ -31,3 +34,49 @@ func (a *MemoryAccount) ToProto() proto.Message {
 		Key: a.Key,
 	}
 }
+
+// MemoryRelayAccount is an account type converted from RelayAccount.
+type MemoryRelayAccount struct {
+	Key         string
+	Destination net.Destination
+}
+
+// AsAccount implements protocol.AsAccount.
+func (u *RelayAccount) AsAccount() (protocol.Account, error) {
+	if u.Address == nil {
+		return nil, errors.New("relay destination without address")
+	}
+	return &MemoryRelayAccount{
+		Key:         u.Key,
+		Destination: net.TCPDestination(u.Address.AsAddress(), net.Port(u.Port)),
+	}, nil
+}
+
+// Equals implements protocol.Account.Equals().
+func (a *MemoryRelayAccount) Equals(another protocol.Account) bool {
+	if account, ok := another.(*MemoryRelayAccount); ok {
+		return a.Key == account.Key && a.Destination == account.Destination
+	}
+	return false
+}
+
+func (a *MemoryRelayAccount) ToProto() proto.Message {
+	return &RelayAccount{
+		Key:     a.Key,
+		Address: net.NewIPOrDomain(a.Destination.Address),
+		Port:    uint32(a.Destination.Port),
+	}
+}
+
+// AsUser returns the user a relay destination is managed as.
+func (d *RelayDestination) AsUser() *protocol.User {
+	return &protocol.User{
+		Email: d.Email,
+		Level: uint32(d.Level),
+		Account: serial.ToTypedMessage(&RelayAccount{
+			Key:     d.Key,
+			Address: d.Address,
+			Port:    d.Port,
+		}),
+	}
+}
@@ -3,6 +3,7 @@ package shadowsocks_2022
 import (
 	"context"
 	"encoding/base64"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -38,7 +39,7 @@ type MultiUserInbound struct {
 	sync.Mutex
 	networks []net.Network
 	users    []*protocol.MemoryUser
-	service  *shadowaead_2022.MultiService[int]
+	service  *shadowaead_2022.MultiService[*protocol.MemoryUser]
 }
 
 func NewMultiServer(ctx context.Context, config *MultiUserServerConfig) (*MultiUserInbound, error) {
@@ -73,27 +74,39 @@ func NewMultiServer(ctx context.Context, config *MultiUserServerConfig) (*MultiU
 	if err != nil {
 		return nil, errors.New("parse config").Base(err)
 	}
-	service, err := shadowaead_2022.NewMultiService[int](config.Method, psk, 500, inbound, nil)
+	service, err := shadowaead_2022.NewMultiService[*protocol.MemoryUser](config.Method, psk, 500, inbound, nil)
 	if err != nil {
 		return nil, errors.New("create service").Base(err)
 	}
-	err = service.UpdateUsersWithPasswords(
-		C.MapIndexed(memUsers, func(index int, it *protocol.MemoryUser) int { return index }),
-		C.Map(memUsers, func(it *protocol.MemoryUser) string { return it.Account.(*MemoryAccount).Key }),
-	)
-	if err != nil {
+	inbound.service = service
+	if err := inbound.updateUsers(memUsers); err != nil {
 		return nil, errors.New("create service").Base(err)
 	}
-
-	inbound.service = service
 	return inbound, nil
 }
 
+// updateUsers syncs users to the multi service. Users are keyed by pointer,
+// so the users that stay keep their identity and their live sessions.
+func (i *MultiUserInbound) updateUsers(users []*protocol.MemoryUser) error {
+	err := i.service.UpdateUsersWithPasswords(
+		users,
+		C.Map(users, func(it *protocol.MemoryUser) string { return it.Account.(*MemoryAccount).Key }),
+	)
+	if err != nil {
+		return err
+	}
+	i.users = users
+	return nil
+}
+
 // AddUser implements proxy.UserManager.AddUser().
 func (i *MultiUserInbound) AddUser(ctx context.Context, u *protocol.MemoryUser) error {
 	i.Lock()
 	defer i.Unlock()
 
+	if _, ok := u.Account.(*MemoryAccount); !ok {
+		return errors.New("not a shadowsocks 2022 account")
+	}
 	if u.Email != "" {
 		for idx := range i.users {
 			if i.users[idx].Email == u.Email {
@@ -101,15 +114,9 @@ func (i *MultiUserInbound) AddUser(ctx context.Context, u *protocol.MemoryUser)
 			}
 		}
 	}
-	i.users = append(i.users, u)
-
-	// sync to multi service
-	// Considering implements shadowsocks2022 in xray-core may have better performance.
-	i.service.UpdateUsersWithPasswords(
-		C.MapIndexed(i.users, func(index int, it *protocol.MemoryUser) int { return index }),
-		C.Map(i.users, func(it *protocol.MemoryUser) string { return it.Account.(*MemoryAccount).Key }),
-	)
-
+	if err := i.updateUsers(append(slices.Clip(i.users), u)); err != nil {
+		return errors.New("failed to add user ", u.Email).Base(err)
+	}
 	return nil
 }
 
@@ -134,20 +141,7 @@ func (i *MultiUserInbound) RemoveUser(ctx context.Context, email string) error {
 		return errors.New("User ", email, " not found.")
 	}
 
-	ulen := len(i.users)
-
-	i.users[idx] = i.users[ulen-1]
-	i.users[ulen-1] = nil
-	i.users = i.users[:ulen-1]
-
-	// sync to multi service
-	// Considering implements shadowsocks2022 in xray-core may have better performance.
-	i.service.UpdateUsersWithPasswords(
-		C.MapIndexed(i.users, func(index int, it *protocol.MemoryUser) int { return index }),
-		C.Map(i.users, func(it *protocol.MemoryUser) string { return it.Account.(*MemoryAccount).Key }),
-	)
-
-	return nil
+	return i.updateUsers(slices.Delete(slices.Clone(i.users), idx, idx+1))
 }
 
 // GetUser implements proxy.UserManager.GetUser().
@@ -226,8 +220,7 @@ func (i *MultiUserInbound) Process(ctx context.Context, network net.Network, con
 
 func (i *MultiUserInbound) NewConnection(ctx context.Context, conn net.Conn, metadata M.Metadata) error {
 	inbound := session.InboundFromContext(ctx)
-	userInt, _ := A.UserFromContext[int](ctx)
-	user := i.users[userInt]
+	user, _ := A.UserFromContext[*protocol.MemoryUser](ctx)
 	inbound.User = user
 	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
 		From:   metadata.Source,
@@ -251,8 +244,7 @@ func (i *MultiUserInbound) NewConnection(ctx context.Context, conn net.Conn, met
 
 func (i *MultiUserInbound) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata M.Metadata) error {
 	inbound := session.InboundFromContext(ctx)
-	userInt, _ := A.UserFromContext[int](ctx)
-	user := i.users[userInt]
+	user, _ := A.UserFromContext[*protocol.MemoryUser](ctx)
 	inbound.User = user
 	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
 		From:   metadata.Source,
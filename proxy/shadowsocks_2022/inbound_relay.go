@@ -2,8 +2,10 @@ package shadowsocks_2022
 
 import (
 	"context"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/sagernet/sing-shadowsocks/shadowaead_2022"
 	C "github.com/sagernet/sing/common"
@@ -22,7 +24,10 @@ import (
 	"github.com/xtls/xray-core/common/session"
 	"github.com/xtls/xray-core/common/singbridge"
 	"github.com/xtls/xray-core/common/uuid"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/policy"
 	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/features/stats"
 	"github.com/xtls/xray-core/transport/internet/stat"
 )
 
@@ -33,9 +38,12 @@ func init() {
 }
 
 type RelayInbound struct {
-	networks     []net.Network
-	destinations []*RelayDestination
-	service      *shadowaead_2022.RelayService[int]
+	sync.Mutex
+	networks      []net.Network
+	users         []*protocol.MemoryUser
+	service       *shadowaead_2022.RelayService[*protocol.MemoryUser]
+	policyManager policy.Manager
+	stats         stats.Manager
 }
 
 func NewRelayServer(ctx context.Context, config *RelayServerConfig) (*RelayInbound, error) {
@@ -46,39 +54,137 @@ func NewRelayServer(ctx context.Context, config *RelayServerConfig) (*RelayInbou
 			net.Network_UDP,
 		}
 	}
+	v := core.MustFromContext(ctx)
 	inbound := &RelayInbound{
-		networks:     networks,
-		destinations: config.Destinations,
+		networks:      networks,
+		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
+		stats:         v.GetFeature(stats.ManagerType()).(stats.Manager),
 	}
 	if !C.Contains(shadowaead_2022.List, config.Method) || !strings.Contains(config.Method, "aes") {
 		return nil, errors.New("unsupported method ", config.Method)
 	}
-	service, err := shadowaead_2022.NewRelayServiceWithPassword[int](config.Method, config.Key, 500, inbound)
+	service, err := shadowaead_2022.NewRelayServiceWithPassword[*protocol.MemoryUser](config.Method, config.Key, 500, inbound)
 	if err != nil {
 		return nil, errors.New("create service").Base(err)
 	}
+	inbound.service = service
 
+	users := make([]*protocol.MemoryUser, 0, len(config.Destinations))
 	for i, destination := range config.Destinations {
 		if destination.Email == "" {
 			u := uuid.New()
 			destination.Email = "unnamed-destination-" + strconv.Itoa(i) + "-" + u.String()
 		}
+		user, err := destination.AsUser().ToMemoryUser()
+		if err != nil {
+			return nil, errors.New("failed to get relay destination").Base(err).AtError()
+		}
+		users = append(users, user)
+	}
+	if err := inbound.updateUsers(users); err != nil {
+		return nil, errors.New("create service").Base(err)
 	}
-	err = service.UpdateUsersWithPasswords(
-		C.MapIndexed(config.Destinations, func(index int, it *RelayDestination) int { return index }),
-		C.Map(config.Destinations, func(it *RelayDestination) string { return it.Key }),
-		C.Map(config.Destinations, func(it *RelayDestination) M.Socksaddr {
-			return singbridge.ToSocksaddr(net.Destination{
-				Address: it.Address.AsAddress(),
-				Port:    net.Port(it.Port),
-			})
+	return inbound, nil
+}
+
+// updateUsers syncs users to the relay service. Users are keyed by pointer,
+// so the users that stay keep their identity and their live sessions.
+func (i *RelayInbound) updateUsers(users []*protocol.MemoryUser) error {
+	err := i.service.UpdateUsersWithPasswords(
+		users,
+		C.Map(users, func(it *protocol.MemoryUser) string { return it.Account.(*MemoryRelayAccount).Key }),
+		C.Map(users, func(it *protocol.MemoryUser) M.Socksaddr {
+			return singbridge.ToSocksaddr(it.Account.(*MemoryRelayAccount).Destination)
 		}),
 	)
 	if err != nil {
-		return nil, errors.New("create service").Base(err)
+		return err
 	}
-	inbound.service = service
-	return inbound, nil
+	i.users = users
+	return nil
+}
+
+// AddUser implements proxy.UserManager.AddUser().
+func (i *RelayInbound) AddUser(ctx context.Context, u *protocol.MemoryUser) error {
+	if _, ok := u.Account.(*MemoryRelayAccount); !ok {
+		return errors.New("not a shadowsocks 2022 relay account")
+	}
+	if u.Email == "" {
+		return errors.New("Email must not be empty.")
+	}
+
+	i.Lock()
+	defer i.Unlock()
+
+	for _, user := range i.users {
+		if strings.EqualFold(user.Email, u.Email) {
+			return errors.New("User ", u.Email, " already exists.")
+		}
+	}
+	if err := i.updateUsers(append(slices.Clip(i.users), u)); err != nil {
+		return errors.New("failed to add user ", u.Email).Base(err)
+	}
+	return nil
+}
+
+// RemoveUser implements proxy.UserManager.RemoveUser().
+func (i *RelayInbound) RemoveUser(ctx context.Context, email string) error {
+	if email == "" {
+		return errors.New("Email must not be empty.")
+	}
+
+	i.Lock()
+	defer i.Unlock()
+
+	idx := slices.IndexFunc(i.users, func(u *protocol.MemoryUser) bool { return strings.EqualFold(u.Email, email) })
+	if idx == -1 {
+		return errors.New("User ", email, " not found.")
+	}
+	return i.updateUsers(slices.Delete(slices.Clone(i.users), idx, idx+1))
+}
+
+// GetUser implements proxy.UserManager.GetUser().
+func (i *RelayInbound) GetUser(ctx context.Context, email string) *protocol.MemoryUser {
+	if email == "" {
+		return nil
+	}
+
+	i.Lock()
+	defer i.Unlock()
+
+	for _, u := range i.users {
+		if strings.EqualFold(u.Email, email) {
+			return u
+		}
+	}
+	return nil
+}
+
+// GetUsers implements proxy.UserManager.GetUsers().
+func (i *RelayInbound) GetUsers(ctx context.Context) []*protocol.MemoryUser {
+	i.Lock()
+	defer i.Unlock()
+	return slices.Clone(i.users)
+}
+
+// GetUsersCount implements proxy.UserManager.GetUsersCount().
+func (i *RelayInbound) GetUsersCount(context.Context) int64 {
+	i.Lock()
+	defer i.Unlock()
+	return int64(len(i.users))
+}
+
+// counters returns the traffic counters of a relay destination, if inbound
+// traffic is counted.
+func (i *RelayInbound) counters(user *protocol.MemoryUser) (uplink stats.Counter, downlink stats.Counter) {
+	p := i.policyManager.ForSystem()
+	if p.Stats.InboundUplink {
+		uplink, _ = stats.GetOrRegisterCounter(i.stats, "relay>>>"+user.Email+">>>traffic>>>uplink")
+	}
+	if p.Stats.InboundDownlink {
+		downlink, _ = stats.GetOrRegisterCounter(i.stats, "relay>>>"+user.Email+">>>traffic>>>downlink")
+	}
+	return
 }
 
 func (i *RelayInbound) Network() []net.Network {
@@ -124,12 +230,8 @@ func (i *RelayInbound) Process(ctx context.Context, network net.Network, connect
 
 func (i *RelayInbound) NewConnection(ctx context.Context, conn net.Conn, metadata M.Metadata) error {
 	inbound := session.InboundFromContext(ctx)
-	userInt, _ := A.UserFromContext[int](ctx)
-	user := i.destinations[userInt]
-	inbound.User = &protocol.MemoryUser{
-		Email: user.Email,
-		Level: uint32(user.Level),
-	}
+	user, _ := A.UserFromContext[*protocol.MemoryUser](ctx)
+	inbound.User = user
 	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
 		From:   metadata.Source,
 		To:     metadata.Destination,
@@ -142,17 +244,20 @@ func (i *RelayInbound) NewConnection(ctx context.Context, conn net.Conn, metadat
 	if err != nil {
 		return err
 	}
+	if uplink, downlink := i.counters(user); uplink != nil || downlink != nil {
+		conn = &stat.CounterConnection{
+			Connection:   conn,
+			ReadCounter:  uplink,
+			WriteCounter: downlink,
+		}
+	}
 	return singbridge.CopyConn(ctx, nil, link, conn)
 }
 
 func (i *RelayInbound) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata M.Metadata) error {
 	inbound := session.InboundFromContext(ctx)
-	userInt, _ := A.UserFromContext[int](ctx)
-	user := i.destinations[userInt]
-	inbound.User = &protocol.MemoryUser{
-		Email: user.Email,
-		Level: uint32(user.Level),
-	}
+	user, _ := A.UserFromContext[*protocol.MemoryUser](ctx)
+	inbound.User = user
 	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
 		From:   metadata.Source,
 		To:     metadata.Destination,
@@ -171,9 +276,39 @@ func (i *RelayInbound) NewPacketConnection(ctx context.Context, conn N.PacketCon
 		Writer: link.Writer,
 		Dest:   destination,
 	}
+	if uplink, downlink := i.counters(user); uplink != nil || downlink != nil {
+		conn = &counterPacketConn{
+			PacketConn: conn,
+			uplink:     uplink,
+			downlink:   downlink,
+		}
+	}
 	return bufio.CopyPacketConn(ctx, conn, outConn)
 }
 
+type counterPacketConn struct {
+	N.PacketConn
+	uplink   stats.Counter
+	downlink stats.Counter
+}
+
+func (c *counterPacketConn) ReadPacket(buffer *B.Buffer) (M.Socksaddr, error) {
+	destination, err := c.PacketConn.ReadPacket(buffer)
+	if err == nil && c.uplink != nil {
+		c.uplink.Add(int64(buffer.Len()))
+	}
+	return destination, err
+}
+
+func (c *counterPacketConn) WritePacket(buffer *B.Buffer, destination M.Socksaddr) error {
+	n := buffer.Len()
+	err := c.PacketConn.WritePacket(buffer, destination)
+	if err == nil && c.downlink != nil {
+		c.downlink.Add(int64(n))
+	}
+	return err
+}
+
 func (i *RelayInbound) NewError(ctx context.Context, err error) {
 	if E.IsClosed(err) {
 		return
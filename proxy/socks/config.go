@@ -20,14 +20,3 @@ func (a *Account) ToProto() proto.Message {
 func (a *Account) AsAccount() (protocol.Account, error) {
 	return a, nil
 }
-
-func (c *ServerConfig) HasAccount(username, password string) bool {
-	if c.Accounts == nil {
-		return false
-	}
-	storedPassed, found := c.Accounts[username]
-	if !found {
-		return false
-	}
-	return storedPassed == password
-}
@@ -12,11 +12,17 @@ import (
 	"github.com/xtls/xray-core/common/log"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/protocol/auth"
 	udp_proto "github.com/xtls/xray-core/common/protocol/udp"
 	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/signal"
+	"github.com/xtls/xray-core/common/task"
 	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/outbound"
 	"github.com/xtls/xray-core/features/policy"
 	"github.com/xtls/xray-core/features/routing"
+	routing_session "github.com/xtls/xray-core/features/routing/session"
+	"github.com/xtls/xray-core/features/stats"
 	"github.com/xtls/xray-core/proxy"
 	"github.com/xtls/xray-core/proxy/http"
 	"github.com/xtls/xray-core/transport"
@@ -27,7 +33,11 @@ import (
 // Server is a SOCKS 5 proxy server
 type Server struct {
 	config        *ServerConfig
+	validator     *auth.Validator
 	policyManager policy.Manager
+	stats         stats.Manager
+	router        routing.Router
+	ohm           outbound.Manager
 	cone          bool
 	udpFilter     *UDPFilter
 	httpServer    *http.Server
@@ -39,19 +49,63 @@ func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
 	s := &Server{
 		config:        config,
 		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
+		stats:         v.GetFeature(stats.ManagerType()).(stats.Manager),
 		cone:          ctx.Value("cone").(bool),
 	}
+	if err := core.RequireFeatures(ctx, func(router routing.Router, ohm outbound.Manager) error {
+		s.router = router
+		s.ohm = ohm
+		return nil
+	}); err != nil {
+		return nil, err
+	}
 	httpConfig := &http.ServerConfig{
 		UserLevel: config.UserLevel,
 	}
 	if config.AuthType == AuthType_PASSWORD {
 		httpConfig.Accounts = config.Accounts
+		httpConfig.Users = config.Users
+		httpConfig.AuthBackends = config.AuthBackends
 		s.udpFilter = new(UDPFilter) // We only use this when auth is enabled
 	}
-	s.httpServer, _ = http.NewServer(ctx, httpConfig)
+	httpServer, err := http.NewServer(ctx, httpConfig)
+	if err != nil {
+		return nil, err
+	}
+	s.httpServer = httpServer
+	// SOCKS and HTTP on the same port share their users.
+	s.validator = httpServer.Validator()
 	return s, nil
 }
 
+// AddUser implements proxy.UserManager.AddUser().
+func (s *Server) AddUser(ctx context.Context, u *protocol.MemoryUser) error {
+	if s.config.AuthType != AuthType_PASSWORD {
+		return errors.New("password authentication is not enabled")
+	}
+	return s.validator.Add(u)
+}
+
+// RemoveUser implements proxy.UserManager.RemoveUser().
+func (s *Server) RemoveUser(ctx context.Context, e string) error {
+	return s.validator.Del(e)
+}
+
+// GetUser implements proxy.UserManager.GetUser().
+func (s *Server) GetUser(ctx context.Context, email string) *protocol.MemoryUser {
+	return s.validator.GetByEmail(email)
+}
+
+// GetUsers implements proxy.UserManager.GetUsers().
+func (s *Server) GetUsers(ctx context.Context) []*protocol.MemoryUser {
+	return s.validator.GetAll()
+}
+
+// GetUsersCount implements proxy.UserManager.GetUsersCount().
+func (s *Server) GetUsersCount(context.Context) int64 {
+	return s.validator.GetCount()
+}
+
 func (s *Server) policy() policy.Session {
 	config := s.config
 	p := s.policyManager.ForLevel(config.UserLevel)
@@ -113,7 +167,9 @@ func (s *Server) processTCP(ctx context.Context, conn stat.Connection, dispatche
 	}
 
 	svrSession := &ServerSession{
+		ctx:          ctx,
 		config:       s.config,
+		validator:    s.validator,
 		address:      inbound.Gateway.Address,
 		port:         inbound.Gateway.Port,
 		localAddress: net.IPAddress(conn.LocalAddr().(*net.TCPAddr).IP),
@@ -139,7 +195,7 @@ func (s *Server) processTCP(ctx context.Context, conn stat.Connection, dispatche
 		return errors.New("failed to read request").Base(err)
 	}
 	if request.User != nil {
-		inbound.User.Email = request.User.Email
+		inbound.User = request.User
 	}
 
 	if err := conn.SetReadDeadline(time.Time{}); err != nil {
@@ -171,11 +227,130 @@ func (s *Server) processTCP(ctx context.Context, conn stat.Connection, dispatche
 
 	if request.Command == protocol.RequestCommandUDP {
 		if s.udpFilter != nil {
-			s.udpFilter.Add(conn.RemoteAddr())
+			s.udpFilter.Add(conn.RemoteAddr(), inbound.User)
 		}
 		return s.handleUDP(conn)
 	}
 
+	if request.Command == requestCommandBind {
+		return s.handleBind(ctx, conn, reader, request)
+	}
+
+	return nil
+}
+
+// bindAllowed reports whether the router sends the destination of a BIND
+// request to an outbound that connects directly, as this server is going to
+// accept the connection from it itself.
+func (s *Server) bindAllowed(ctx context.Context, dest net.Destination) bool {
+	if s.router == nil || s.ohm == nil {
+		return false
+	}
+	ctx = session.ContextWithOutbounds(ctx, []*session.Outbound{{Target: dest}})
+	var handler outbound.Handler
+	if route, err := s.router.PickRoute(routing_session.AsRoutingContext(ctx)); err == nil {
+		handler = s.ohm.GetHandler(route.GetOutboundTag())
+	}
+	if handler == nil {
+		handler = s.ohm.GetDefaultHandler()
+	}
+	if handler, ok := handler.(proxy.GetOutbound); ok {
+		_, direct := handler.GetOutbound().(proxy.DirectOutbound)
+		return direct
+	}
+	return false
+}
+
+// handleBind listens for the connection a BIND request expects on this
+// server, and relays it to the client. Only connections from the requested
+// address are accepted, unless it is unspecified or a domain, and only if
+// the router sends the requested address to a direct outbound.
+func (s *Server) handleBind(ctx context.Context, conn stat.Connection, reader buf.Reader, request *protocol.RequestHeader) error {
+	inbound := session.InboundFromContext(ctx)
+	if !s.bindAllowed(ctx, request.Destination()) {
+		writeSocks5Response(conn, statusNotAllowed, net.AnyIP, net.Port(0))
+		return errors.New("TCP bind for ", request.Destination(), " is not routed to a direct outbound")
+	}
+	listener, err := net.ListenTCP("tcp", &net.TCPAddr{IP: conn.LocalAddr().(*net.TCPAddr).IP})
+	if err != nil {
+		writeSocks5Response(conn, statusServerFailure, net.AnyIP, net.Port(0))
+		return errors.New("failed to listen for TCP bind").Base(err)
+	}
+	defer listener.Close()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	bindAddress := net.IPAddress(addr.IP)
+	if s.config.Address != nil {
+		bindAddress = s.config.Address.AsAddress()
+	}
+	if err := writeSocks5Response(conn, statusSuccess, bindAddress, net.Port(addr.Port)); err != nil {
+		return err
+	}
+	errors.LogInfo(ctx, "TCP Bind request for ", request.Destination(), " on ", addr)
+
+	sessionPolicy := s.policyManager.ForLevel(inbound.User.Level)
+	listener.SetDeadline(time.Now().Add(sessionPolicy.Timeouts.ConnectionIdle))
+	var peer *net.TCPConn
+	for peer == nil {
+		c, err := listener.AcceptTCP()
+		if err != nil {
+			writeSocks5Response(conn, statusTTLExpired, net.AnyIP, net.Port(0))
+			return errors.New("no incoming connection for TCP bind").Base(err)
+		}
+		from := c.RemoteAddr().(*net.TCPAddr)
+		if request.Address.Family().IsIP() && !request.Address.IP().IsUnspecified() && !request.Address.IP().Equal(from.IP) {
+			errors.LogWarning(ctx, "rejected incoming connection from ", from, " for TCP bind to ", request.Address)
+			c.Close()
+			continue
+		}
+		peer = c
+	}
+	listener.Close()
+	defer peer.Close()
+
+	from := peer.RemoteAddr().(*net.TCPAddr)
+	if err := writeSocks5Response(conn, statusSuccess, net.IPAddress(from.IP), net.Port(from.Port)); err != nil {
+		return err
+	}
+	detour := "bind"
+	if inbound.Tag != "" {
+		detour = inbound.Tag + " -> bind"
+	}
+	log.Record(&log.AccessMessage{
+		From:   inbound.Source,
+		To:     from,
+		Status: log.AccessAccepted,
+		Reason: "",
+		Email:  inbound.User.Email,
+		Detour: detour,
+	})
+
+	var peerConn stat.Connection = peer
+	if inbound.User.Email != "" {
+		counterConn := &stat.CounterConnection{Connection: peer}
+		if sessionPolicy.Stats.UserUplink {
+			counterConn.WriteCounter, _ = stats.GetOrRegisterCounter(s.stats, "user>>>"+inbound.User.Email+">>>traffic>>>uplink")
+		}
+		if sessionPolicy.Stats.UserDownlink {
+			counterConn.ReadCounter, _ = stats.GetOrRegisterCounter(s.stats, "user>>>"+inbound.User.Email+">>>traffic>>>downlink")
+		}
+		peerConn = counterConn
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	timer := signal.CancelAfterInactivity(ctx, cancel, sessionPolicy.Timeouts.ConnectionIdle)
+
+	requestDone := func() error {
+		defer timer.SetTimeout(sessionPolicy.Timeouts.DownlinkOnly)
+		return buf.Copy(reader, buf.NewWriter(peerConn), buf.UpdateActivity(timer))
+	}
+	responseDone := func() error {
+		defer timer.SetTimeout(sessionPolicy.Timeouts.UplinkOnly)
+		return buf.Copy(buf.NewReader(peerConn), buf.NewWriter(conn), buf.UpdateActivity(timer))
+	}
+	if err := task.Run(ctx, task.OnSuccess(requestDone, peer.CloseWrite), responseDone); err != nil {
+		return errors.New("TCP bind ends").Base(err)
+	}
 	return nil
 }
 
@@ -186,9 +361,16 @@ func (*Server) handleUDP(c io.Reader) error {
 }
 
 func (s *Server) handleUDPPayload(ctx context.Context, conn stat.Connection, dispatcher routing.Dispatcher) error {
-	if s.udpFilter != nil && !s.udpFilter.Check(conn.RemoteAddr()) {
-		errors.LogDebug(ctx, "Unauthorized UDP access from ", conn.RemoteAddr().String())
-		return nil
+	inbound := session.InboundFromContext(ctx)
+	if s.udpFilter != nil {
+		user, ok := s.udpFilter.Check(conn.RemoteAddr())
+		if !ok {
+			errors.LogDebug(ctx, "Unauthorized UDP access from ", conn.RemoteAddr().String())
+			return nil
+		}
+		if inbound != nil && user != nil {
+			inbound.User = user
+		}
 	}
 	udpServer := udp.NewDispatcher(dispatcher, func(ctx context.Context, packet *udp_proto.Packet) {
 		payload := packet.Payload
@@ -221,7 +403,6 @@ func (s *Server) handleUDPPayload(ctx context.Context, conn stat.Connection, dis
 	})
 	defer udpServer.RemoveRay()
 
-	inbound := session.InboundFromContext(ctx)
 	if inbound != nil && inbound.Source.IsValid() {
 		errors.LogInfo(ctx, "client UDP connection from ", inbound.Source)
 	}
@@ -3,6 +3,8 @@ package socks
 import (
 	"net"
 	"sync"
+
+	"github.com/xtls/xray-core/common/protocol"
 )
 
 /*
@@ -10,22 +12,28 @@ In the sock implementation of * ray, UDP authentication is flawed and can be byp
 Tracking a UDP connection may be a bit troublesome.
 Here is a simple solution.
 We create a filter, add remote IP to the pool when it try to establish a UDP connection with auth.
-And drop UDP packets from unauthorized IP.
+And drop UDP packets from unauthorized IP. UDP packets from an IP are accounted to the user that
+established the latest UDP connection from it.
 After discussion, we believe it is not necessary to add a timeout mechanism to this filter.
 */
 
 type UDPFilter struct {
-	ips sync.Map
+	ips sync.Map // string -> *protocol.MemoryUser
 }
 
-func (f *UDPFilter) Add(addr net.Addr) bool {
+func (f *UDPFilter) Add(addr net.Addr, user *protocol.MemoryUser) bool {
 	ip, _, _ := net.SplitHostPort(addr.String())
-	f.ips.Store(ip, true)
+	f.ips.Store(ip, user)
 	return true
 }
 
-func (f *UDPFilter) Check(addr net.Addr) bool {
+// Check returns the user that established a UDP connection from the IP of
+// addr, and whether there is one.
+func (f *UDPFilter) Check(addr net.Addr) (*protocol.MemoryUser, bool) {
 	ip, _, _ := net.SplitHostPort(addr.String())
-	_, ok := f.ips.Load(ip)
-	return ok
+	user, ok := f.ips.Load(ip)
+	if !ok {
+		return nil, false
+	}
+	return user.(*protocol.MemoryUser), true
 }
@@ -4,26 +4,66 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"time"
+
 	"google.golang.org/protobuf/proto"
 
 	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/protocol"
 )
 
 // MemoryAccount is an account type converted from Account.
 type MemoryAccount struct {
-	Password string
-	Key      []byte
+	Password    string
+	Key         []byte
+	Credentials []*MemoryCredential
+}
+
+// MemoryCredential is a password of a MemoryAccount with a validity window.
+type MemoryCredential struct {
+	Password  string
+	Key       []byte
+	NotBefore time.Time
+	NotAfter  time.Time
 }
 
 // AsAccount implements protocol.AsAccount.
 func (a *Account) AsAccount() (protocol.Account, error) {
 	password := a.GetPassword()
-	key := hexSha224(password)
-	return &MemoryAccount{
+	account := &MemoryAccount{
 		Password: password,
-		Key:      key,
-	}, nil
+	}
+	if password != "" || len(a.Credentials) == 0 {
+		account.Key = hexSha224(password)
+	}
+	for _, c := range a.Credentials {
+		if c.Password == "" {
+			return nil, errors.New("empty trojan password")
+		}
+		credential := &MemoryCredential{
+			Password: c.Password,
+			Key:      hexSha224(c.Password),
+		}
+		if c.NotBefore != 0 {
+			credential.NotBefore = time.Unix(c.NotBefore, 0)
+		}
+		if c.NotAfter != 0 {
+			credential.NotAfter = time.Unix(c.NotAfter, 0)
+		}
+		account.Credentials = append(account.Credentials, credential)
+	}
+	return account, nil
+}
+
+// ValidAt reports whether the credential is valid at t.
+func (c *MemoryCredential) ValidAt(t time.Time) bool {
+	return (c.NotBefore.IsZero() || !t.Before(c.NotBefore)) && !c.ExpiredAt(t)
+}
+
+// ExpiredAt reports whether the credential is no longer valid at t.
+func (c *MemoryCredential) ExpiredAt(t time.Time) bool {
+	return !c.NotAfter.IsZero() && t.After(c.NotAfter)
 }
 
 // Equals implements protocol.Account.Equals().
@@ -35,9 +75,22 @@ func (a *MemoryAccount) Equals(another protocol.Account) bool {
 }
 
 func (a *MemoryAccount) ToProto() proto.Message {
-	return &Account{
+	account := &Account{
 		Password: a.Password,
 	}
+	for _, c := range a.Credentials {
+		credential := &Credential{
+			Password: c.Password,
+		}
+		if !c.NotBefore.IsZero() {
+			credential.NotBefore = c.NotBefore.Unix()
+		}
+		if !c.NotAfter.IsZero() {
+			credential.NotAfter = c.NotAfter.Unix()
+		}
+		account.Credentials = append(account.Credentials, credential)
+	}
+	return account
 }
 
 func hexSha224(password string) []byte {
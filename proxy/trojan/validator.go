@@ -3,16 +3,30 @@ package trojan
 import (
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/protocol"
 )
 
+// expiredSweepInterval is how often expired credentials are removed.
+const expiredSweepInterval = time.Minute
+
 // Validator stores valid trojan users.
 type Validator struct {
 	// Considering email's usage here, map + sync.Mutex/RWMutex may have better performance.
 	email sync.Map
 	users sync.Map
+
+	sweptAt atomic.Int64
+}
+
+// validatorEntry is a password of a user, with the window it is valid in
+// unless it is the main password.
+type validatorEntry struct {
+	user       *protocol.MemoryUser
+	credential *MemoryCredential
 }
 
 // Add a trojan user, Email must be empty or unique.
@@ -23,7 +37,16 @@ func (v *Validator) Add(u *protocol.MemoryUser) error {
 			return errors.New("User ", u.Email, " already exists.")
 		}
 	}
-	v.users.Store(hexString(u.Account.(*MemoryAccount).Key), u)
+	account := u.Account.(*MemoryAccount)
+	if account.Key != nil {
+		v.users.Store(hexString(account.Key), &validatorEntry{user: u})
+	}
+	now := time.Now()
+	for _, c := range account.Credentials {
+		if !c.ExpiredAt(now) {
+			v.users.Store(hexString(c.Key), &validatorEntry{user: u, credential: c})
+		}
+	}
 	return nil
 }
 
@@ -38,17 +61,56 @@ func (v *Validator) Del(e string) error {
 		return errors.New("User ", e, " not found.")
 	}
 	v.email.Delete(le)
-	v.users.Delete(hexString(u.(*protocol.MemoryUser).Account.(*MemoryAccount).Key))
+	user := u.(*protocol.MemoryUser)
+	account := user.Account.(*MemoryAccount)
+	if account.Key != nil {
+		v.delete(hexString(account.Key), user)
+	}
+	for _, c := range account.Credentials {
+		v.delete(hexString(c.Key), user)
+	}
 	return nil
 }
 
-// Get a trojan user with hashed key, nil if user doesn't exist.
+// delete removes the password hash if it still belongs to user.
+func (v *Validator) delete(hash string, user *protocol.MemoryUser) {
+	if entry, found := v.users.Load(hash); found && entry.(*validatorEntry).user == user {
+		v.users.CompareAndDelete(hash, entry)
+	}
+}
+
+// Get a trojan user with hashed key, nil if user doesn't exist or the
+// password is not valid now.
 func (v *Validator) Get(hash string) *protocol.MemoryUser {
-	u, _ := v.users.Load(hash)
-	if u != nil {
-		return u.(*protocol.MemoryUser)
+	now := time.Now()
+	v.sweepExpired(now)
+	e, _ := v.users.Load(hash)
+	if e == nil {
+		return nil
 	}
-	return nil
+	entry := e.(*validatorEntry)
+	if c := entry.credential; c != nil && !c.ValidAt(now) {
+		if c.ExpiredAt(now) {
+			v.users.CompareAndDelete(hash, e)
+		}
+		return nil
+	}
+	return entry.user
+}
+
+// sweepExpired removes expired credentials, at most once per
+// expiredSweepInterval.
+func (v *Validator) sweepExpired(now time.Time) {
+	last := v.sweptAt.Load()
+	if now.Unix()-last < int64(expiredSweepInterval/time.Second) || !v.sweptAt.CompareAndSwap(last, now.Unix()) {
+		return
+	}
+	v.users.Range(func(key, value interface{}) bool {
+		if c := value.(*validatorEntry).credential; c != nil && c.ExpiredAt(now) {
+			v.users.CompareAndDelete(key, value)
+		}
+		return true
+	})
 }
 
 // Get a trojan user with hashed key, nil if user doesn't exist.
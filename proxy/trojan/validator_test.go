@@ -0,0 +1,89 @@
+package trojan_test
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/protocol"
+	. "github.com/xtls/xray-core/proxy/trojan"
+)
+
+func hashOf(key []byte) string {
+	return hex.EncodeToString(key)
+}
+
+func TestValidatorCredentials(t *testing.T) {
+	now := time.Now()
+	account := toAccount(&Account{
+		Password: "main",
+		Credentials: []*Credential{
+			{Password: "current", NotAfter: now.Add(time.Hour).Unix()},
+			{Password: "next", NotBefore: now.Add(30 * time.Minute).Unix()},
+			{Password: "expired", NotAfter: now.Add(-time.Minute).Unix()},
+			{Password: "expiring", NotAfter: now.Add(time.Second).Unix()},
+		},
+	}).(*MemoryAccount)
+	user := &protocol.MemoryUser{Email: "alice@example.com", Account: account}
+
+	v := new(Validator)
+	common.Must(v.Add(user))
+
+	keys := map[string]string{"main": hashOf(account.Key)}
+	for _, c := range account.Credentials {
+		keys[c.Password] = hashOf(c.Key)
+	}
+
+	for password, valid := range map[string]bool{
+		"main":     true,
+		"current":  true,
+		"next":     false,
+		"expired":  false,
+		"expiring": true,
+	} {
+		if u := v.Get(keys[password]); (u == user) != valid {
+			t.Error("password ", password, " valid: ", u != nil, ", expected ", valid)
+		}
+	}
+
+	time.Sleep(2 * time.Second)
+	if v.Get(keys["expiring"]) != nil {
+		t.Error("expired password accepted")
+	}
+	if v.Get(keys["current"]) != user {
+		t.Error("password rejected after another one expired")
+	}
+
+	common.Must(v.Del(user.Email))
+	for password, key := range keys {
+		if v.Get(key) != nil {
+			t.Error("password ", password, " accepted after the user was removed")
+		}
+	}
+}
+
+func TestCredentialsOnly(t *testing.T) {
+	account := toAccount(&Account{
+		Credentials: []*Credential{{Password: "only"}},
+	}).(*MemoryAccount)
+	if account.Key != nil {
+		t.Error("empty main password gets a key")
+	}
+
+	v := new(Validator)
+	user := &protocol.MemoryUser{Email: "bob@example.com", Account: account}
+	common.Must(v.Add(user))
+	if v.Get(hashOf(account.Credentials[0].Key)) != user {
+		t.Error("password rejected")
+	}
+
+	if _, err := (&Account{Credentials: []*Credential{{}}}).AsAccount(); err == nil {
+		t.Error("accepted an empty password")
+	}
+
+	copied := toAccount(account.ToProto().(*Account)).(*MemoryAccount)
+	if len(copied.Credentials) != 1 || copied.Credentials[0].Password != "only" || copied.Key != nil {
+		t.Error("credentials are not preserved by ToProto")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unsafe"
 
@@ -36,6 +37,7 @@ import (
 	"github.com/xtls/xray-core/proxy/vless"
 	"github.com/xtls/xray-core/proxy/vless/encoding"
 	"github.com/xtls/xray-core/proxy/vless/encryption"
+	"github.com/xtls/xray-core/proxy/vless/resume"
 	"github.com/xtls/xray-core/transport"
 	"github.com/xtls/xray-core/transport/internet/reality"
 	"github.com/xtls/xray-core/transport/internet/stat"
@@ -80,6 +82,10 @@ type Handler struct {
 	ctx                    context.Context
 	fallbacks              map[string]map[string]map[string]*Fallback // or nil
 	// regexps               map[string]*regexp.Regexp       // or nil
+	resumeGrace    time.Duration
+	resumeBuffer   int
+	sessions       map[string]*resume.Session
+	sessionsAccess sync.Mutex
 }
 
 // New creates a new VLess inbound handler.
@@ -92,6 +98,9 @@ func New(ctx context.Context, config *Config, dc dns.Client, validator vless.Val
 		outboundHandlerManager: v.GetFeature(outbound.ManagerType()).(outbound.Manager),
 		defaultDispatcher:      v.GetFeature(routing.DispatcherType()).(*dispatcher.DefaultDispatcher),
 		ctx:                    ctx,
+		resumeGrace:            time.Duration(config.ResumeSeconds) * time.Second,
+		resumeBuffer:           int(config.ResumeBuffer),
+		sessions:               make(map[string]*resume.Session),
 	}
 
 	if config.Decryption != "" && config.Decryption != "none" {
@@ -601,6 +610,15 @@ func (h *Handler) Process(ctx context.Context, network net.Network, connection s
 		ctx = session.ContextWithAllowedNetwork(ctx, net.Network_UDP)
 	}
 
+	if (requestAddons.Resume || len(requestAddons.SessionId) > 0) && h.resumeGrace > 0 && requestAddons.Flow == "" && request.Command == protocol.RequestCommandTCP {
+		return h.processResumable(ctx, request, requestAddons, userSentID, connection, reader, dispatcher)
+	}
+	if requestAddons.Resume {
+		// a response without session ID tells the client to give up
+		encoding.EncodeResponseHeader(connection, request, &encoding.Addons{})
+		return errors.New("session resumption is not enabled").AtInfo()
+	}
+
 	trafficState := proxy.NewTrafficState(userSentID)
 	clientReader := encoding.DecodeBodyAddons(reader, request, requestAddons)
 	if requestAddons.Flow == vless.XRV {
@@ -631,6 +649,67 @@ func (h *Handler) Process(ctx context.Context, network net.Network, connection s
 	return nil
 }
 
+// processResumable serves a session that the client can resume over a new
+// connection. The first connection dispatches the session, and connections
+// that resume it only stay until they break or are replaced.
+func (h *Handler) processResumable(ctx context.Context, request *protocol.RequestHeader, requestAddons *encoding.Addons, userSentID []byte, connection stat.Connection, reader buf.Reader, dispatcher routing.Dispatcher) error {
+	key := string(userSentID) + string(requestAddons.SessionId)
+	responseAddons := &encoding.Addons{
+		SessionId: requestAddons.SessionId,
+	}
+
+	if requestAddons.Resume {
+		h.sessionsAccess.Lock()
+		s := h.sessions[key]
+		h.sessionsAccess.Unlock()
+		if s == nil {
+			// a response without session ID tells the client to give up
+			encoding.EncodeResponseHeader(connection, request, &encoding.Addons{})
+			return errors.New("unknown session to resume").AtInfo()
+		}
+		if err := s.Attach(connection, reader, func(read uint64) (uint64, error) {
+			responseAddons.Received = read
+			return requestAddons.Received, encoding.EncodeResponseHeader(connection, request, responseAddons)
+		}); err != nil {
+			if err == resume.ErrLost {
+				s.Interrupt()
+			}
+			return errors.New("failed to resume session").Base(err).AtInfo()
+		}
+		errors.LogInfo(ctx, "resumed session to ", request.Destination())
+		s.Wait(connection)
+		return nil
+	}
+
+	h.sessionsAccess.Lock()
+	if h.sessions[key] != nil {
+		h.sessionsAccess.Unlock()
+		return errors.New("duplicate session ID").AtWarning()
+	}
+	s := resume.New(h.resumeBuffer, h.resumeGrace, nil)
+	h.sessions[key] = s
+	h.sessionsAccess.Unlock()
+	defer func() {
+		h.sessionsAccess.Lock()
+		delete(h.sessions, key)
+		h.sessionsAccess.Unlock()
+		s.Interrupt()
+	}()
+
+	if err := s.Attach(connection, reader, func(uint64) (uint64, error) {
+		return 0, encoding.EncodeResponseHeader(connection, request, responseAddons)
+	}); err != nil {
+		return errors.New("failed to encode response header").Base(err).AtWarning()
+	}
+	if err := dispatcher.DispatchLink(ctx, request.Destination(), &transport.Link{
+		Reader: s,
+		Writer: s},
+	); err != nil {
+		return errors.New("failed to dispatch request").Base(err)
+	}
+	return nil
+}
+
 type Reverse struct {
 	tag    string
 	picker *reverse.StaticMuxPicker
@@ -646,7 +725,7 @@ func (r *Reverse) NewMux(ctx context.Context, link *transport.Link) error {
 	if err != nil {
 		return errors.New("failed to create mux client worker").Base(err).AtWarning()
 	}
-	worker, err := reverse.NewPortalWorker(muxClient)
+	worker, err := reverse.NewPortalWorker(muxClient, nil)
 	if err != nil {
 		return errors.New("failed to create portal worker").Base(err).AtWarning()
 	}
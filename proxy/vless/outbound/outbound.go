@@ -3,10 +3,12 @@ package outbound
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
 	gotls "crypto/tls"
 	"encoding/base64"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
@@ -31,6 +33,7 @@ import (
 	"github.com/xtls/xray-core/proxy/vless"
 	"github.com/xtls/xray-core/proxy/vless/encoding"
 	"github.com/xtls/xray-core/proxy/vless/encryption"
+	"github.com/xtls/xray-core/proxy/vless/resume"
 	"github.com/xtls/xray-core/transport"
 	"github.com/xtls/xray-core/transport/internet"
 	"github.com/xtls/xray-core/transport/internet/reality"
@@ -47,11 +50,13 @@ func init() {
 
 // Handler is an outbound connection handler for VLess protocol.
 type Handler struct {
-	server        *protocol.ServerSpec
-	policyManager policy.Manager
-	cone          bool
-	encryption    *encryption.ClientInstance
-	reverse       *Reverse
+	server         *protocol.ServerSpec
+	policyManager  policy.Manager
+	cone           bool
+	encryption     *encryption.ClientInstance
+	reverse        *Reverse
+	resumeAttempts int
+	resumeBuffer   int
 }
 
 // New creates a new VLess outbound handler.
@@ -66,9 +71,11 @@ func New(ctx context.Context, config *Config) (*Handler, error) {
 
 	v := core.MustFromContext(ctx)
 	handler := &Handler{
-		server:        server,
-		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
-		cone:          ctx.Value("cone").(bool),
+		server:         server,
+		policyManager:  v.GetFeature(policy.ManagerType()).(policy.Manager),
+		cone:           ctx.Value("cone").(bool),
+		resumeAttempts: int(config.ResumeAttempts),
+		resumeBuffer:   int(config.ResumeBuffer),
 	}
 
 	a := handler.server.User.Account.(*vless.MemoryAccount)
@@ -257,6 +264,14 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 		request.Port = net.Port(666)
 	}
 
+	if h.resumeAttempts > 0 && requestAddons.Flow == "" && request.Command == protocol.RequestCommandTCP {
+		taskCtx := ctx
+		if newCtx != nil {
+			taskCtx = newCtx
+		}
+		return h.processResumable(ctx, taskCtx, conn, dialer, request, link, timer, sessionPolicy)
+	}
+
 	postRequest := func() error {
 		defer timer.SetTimeout(sessionPolicy.Timeouts.DownlinkOnly)
 
@@ -363,6 +378,137 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 	return nil
 }
 
+var errResumeRejected = errors.New("server rejected to resume the session")
+
+// processResumable runs a session that survives broken connections. When the
+// connection to the server breaks, a new one is dialed and both sides replay
+// what the other has not received yet.
+func (h *Handler) processResumable(ctx context.Context, taskCtx context.Context, conn stat.Connection, dialer internet.Dialer, request *protocol.RequestHeader, link *transport.Link, timer *signal.ActivityTimer, sessionPolicy policy.Session) error {
+	sessionId := make([]byte, 16)
+	common.Must2(rand.Read(sessionId))
+
+	var resumable atomic.Bool
+	var s *resume.Session
+	s = resume.New(h.resumeBuffer, 0, func() {
+		if !resumable.Load() || !h.resume(ctx, s, dialer, request, sessionId, sessionPolicy) {
+			s.Interrupt()
+		}
+	})
+	defer s.Interrupt()
+
+	reader := &responseReader{
+		conn:      conn,
+		request:   request,
+		sessionId: sessionId,
+		resumable: &resumable,
+	}
+	if err := s.Attach(conn, reader, func(uint64) (uint64, error) {
+		return 0, encoding.EncodeRequestHeader(conn, request, &encoding.Addons{SessionId: sessionId})
+	}); err != nil {
+		return errors.New("failed to encode request header").Base(err).AtWarning()
+	}
+
+	postRequest := func() error {
+		defer timer.SetTimeout(sessionPolicy.Timeouts.DownlinkOnly)
+		if err := buf.Copy(link.Reader, s, buf.UpdateActivity(timer)); err != nil {
+			return errors.New("failed to transfer request payload").Base(err).AtInfo()
+		}
+		return nil
+	}
+
+	getResponse := func() error {
+		defer timer.SetTimeout(sessionPolicy.Timeouts.UplinkOnly)
+		if err := buf.Copy(s, link.Writer, buf.UpdateActivity(timer)); err != nil {
+			return errors.New("failed to transfer response payload").Base(err).AtInfo()
+		}
+		return nil
+	}
+
+	if err := task.Run(taskCtx, postRequest, task.OnSuccess(getResponse, task.Close(link.Writer))); err != nil {
+		return errors.New("connection ends").Base(err).AtInfo()
+	}
+	return nil
+}
+
+// resume dials the server again until the session is reattached, and reports
+// whether it succeeded.
+func (h *Handler) resume(ctx context.Context, s *resume.Session, dialer internet.Dialer, request *protocol.RequestHeader, sessionId []byte, sessionPolicy policy.Session) bool {
+	for attempt := 1; attempt <= h.resumeAttempts; attempt++ {
+		if s.Closed() || ctx.Err() != nil {
+			return false
+		}
+		err := h.reattach(ctx, s, dialer, request, sessionId, sessionPolicy)
+		if err == nil {
+			errors.LogInfo(ctx, "resumed session to ", request.Destination(), " after ", attempt, " attempt(s)")
+			return true
+		}
+		errors.LogInfoInner(ctx, err, "failed to resume session, attempt ", attempt)
+		if err == errResumeRejected || err == resume.ErrLost {
+			return false
+		}
+		time.Sleep(time.Duration(attempt) * 500 * time.Millisecond)
+	}
+	return false
+}
+
+func (h *Handler) reattach(ctx context.Context, s *resume.Session, dialer internet.Dialer, request *protocol.RequestHeader, sessionId []byte, sessionPolicy policy.Session) error {
+	conn, err := dialer.Dial(ctx, h.server.Destination)
+	if err != nil {
+		return err
+	}
+	if h.encryption != nil {
+		if conn, err = h.encryption.Handshake(conn); err != nil {
+			return errors.New("ML-KEM-768 handshake failed").Base(err)
+		}
+	}
+	err = s.Attach(conn, buf.NewReader(conn), func(read uint64) (uint64, error) {
+		conn.SetDeadline(time.Now().Add(sessionPolicy.Timeouts.Handshake))
+		defer conn.SetDeadline(time.Time{})
+		requestAddons := &encoding.Addons{
+			SessionId: sessionId,
+			Resume:    true,
+			Received:  read,
+		}
+		if err := encoding.EncodeRequestHeader(conn, request, requestAddons); err != nil {
+			return 0, err
+		}
+		responseAddons, err := encoding.DecodeResponseHeader(conn, request)
+		if err != nil {
+			return 0, err
+		}
+		if !bytes.Equal(responseAddons.SessionId, sessionId) {
+			return 0, errResumeRejected
+		}
+		return responseAddons.Received, nil
+	})
+	if err != nil {
+		conn.Close()
+	}
+	return err
+}
+
+// responseReader decodes the response header before the first read, and
+// records whether the server accepted the session as resumable.
+type responseReader struct {
+	conn      net.Conn
+	request   *protocol.RequestHeader
+	sessionId []byte
+	resumable *atomic.Bool
+	reader    buf.Reader
+}
+
+func (r *responseReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	if r.reader == nil {
+		responseAddons, err := encoding.DecodeResponseHeader(r.conn, r.request)
+		if err != nil {
+			return nil, errors.New("failed to decode response header").Base(err)
+		}
+		r.resumable.Store(bytes.Equal(responseAddons.SessionId, r.sessionId))
+		r.reader = buf.NewReader(r.conn)
+	}
+	return r.reader.ReadMultiBuffer()
+}
+
 type Reverse struct {
 	tag         string
 	dispatcher  routing.Dispatcher
@@ -0,0 +1,279 @@
+// Package resume keeps a VLESS session alive across broken connections.
+//
+// Both ends count the bytes they have read and keep the tail of the bytes
+// they have written. When a connection breaks, the client opens a new one
+// with the same session ID, both ends exchange their read counters, and each
+// side replays what the other has not received yet.
+package resume
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+)
+
+// ErrLost is returned by Attach when the peer is missing bytes that are no
+// longer in the replay buffer.
+var ErrLost = errors.New("session can not be resumed, unacknowledged data is lost")
+
+// Session is one end of a resumable VLESS session. It implements buf.Reader
+// and buf.Writer on top of whichever connection is currently attached.
+//
+// A clean EOF from the peer ends the session. Any other error detaches the
+// connection: reads and writes block until a new connection is attached, or
+// until the grace period runs out.
+type Session struct {
+	sync.Mutex
+	cond      *sync.Cond
+	conn      net.Conn
+	attaching net.Conn
+	reader    buf.Reader
+	writer    buf.Writer
+	ring      *buf.Ring
+	read      uint64
+	grace     time.Duration
+	timer     *time.Timer
+	onBreak   func()
+	closing   bool
+	closed    bool
+	eof       bool
+}
+
+// DefaultBufferSize is the size of the replay buffer when none is set.
+const DefaultBufferSize = 512 * 1024
+
+// New creates a Session that keeps the last bufferSize written bytes. If
+// grace is not zero, the session is interrupted when it stays detached for
+// that long. onBreak, if set, is called whenever a connection breaks.
+func New(bufferSize int, grace time.Duration, onBreak func()) *Session {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	s := &Session{
+		ring:    buf.NewRing(bufferSize),
+		grace:   grace,
+		onBreak: onBreak,
+	}
+	s.cond = sync.NewCond(&s.Mutex)
+	return s
+}
+
+// Attach makes conn the current connection of the session, replacing the
+// previous one. reader reads the session data from conn. handshake is called
+// with the number of bytes read so far and returns how many bytes the peer
+// has read; everything after that is replayed before Attach returns. Reads
+// and writes wait while a connection is attaching, and the session is not
+// locked during the handshake and the replay.
+func (s *Session) Attach(conn net.Conn, reader buf.Reader, handshake func(read uint64) (uint64, error)) error {
+	s.Lock()
+	if s.closed {
+		s.Unlock()
+		return errors.New("session is closed")
+	}
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn, s.reader, s.writer = nil, nil, nil
+	}
+	if s.attaching != nil {
+		s.attaching.Close()
+	}
+	s.attaching = conn
+	read := s.read
+	s.Unlock()
+
+	fail := func(err error) error {
+		s.Lock()
+		if s.attaching == conn {
+			s.attaching = nil
+		}
+		s.Unlock()
+		return err
+	}
+
+	peerRead, err := handshake(read)
+	if err != nil {
+		return fail(err)
+	}
+
+	// nothing is read or written while no connection is attached
+	s.Lock()
+	if s.attaching != conn || s.closed {
+		s.Unlock()
+		return errors.New("session is closed or attached to another connection")
+	}
+	pending, ok := s.ring.Since(peerRead)
+	s.Unlock()
+	if !ok {
+		return fail(ErrLost)
+	}
+	if len(pending) > 0 {
+		if _, err := conn.Write(pending); err != nil {
+			return fail(errors.New("failed to replay ", len(pending), " bytes").Base(err))
+		}
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	if s.attaching != conn || s.closed {
+		return errors.New("session is closed or attached to another connection")
+	}
+	s.attaching = nil
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.conn, s.reader, s.writer = conn, reader, buf.NewWriter(conn)
+	s.cond.Broadcast()
+	if s.closing {
+		// everything is delivered now
+		s.closeLocked()
+	}
+	return nil
+}
+
+// Wait blocks until conn is no longer attached to the session.
+func (s *Session) Wait(conn net.Conn) {
+	s.Lock()
+	defer s.Unlock()
+	for s.conn == conn && !s.closed {
+		s.cond.Wait()
+	}
+}
+
+func (s *Session) detachLocked(conn net.Conn) {
+	if s.conn != conn || s.closed {
+		return
+	}
+	s.conn, s.reader, s.writer = nil, nil, nil
+	conn.Close()
+	s.cond.Broadcast()
+	if s.grace > 0 {
+		s.timer = time.AfterFunc(s.grace, func() {
+			s.Lock()
+			defer s.Unlock()
+			if s.conn == nil {
+				s.closeLocked()
+			}
+		})
+	}
+	if s.onBreak != nil {
+		go s.onBreak()
+	}
+}
+
+// waitLocked waits for an attached connection.
+func (s *Session) waitLocked() bool {
+	for s.conn == nil && !s.closed {
+		s.cond.Wait()
+	}
+	return !s.closed
+}
+
+// ReadMultiBuffer implements buf.Reader.
+func (s *Session) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	for {
+		s.Lock()
+		if s.eof || !s.waitLocked() {
+			s.Unlock()
+			return nil, io.EOF
+		}
+		conn, reader := s.conn, s.reader
+		s.Unlock()
+
+		mb, err := reader.ReadMultiBuffer()
+
+		s.Lock()
+		if s.conn != conn {
+			// the peer replays these bytes on the new connection
+			s.Unlock()
+			buf.ReleaseMulti(mb)
+			continue
+		}
+		s.read += uint64(mb.Len())
+		if err != nil {
+			if errors.Cause(err) == io.EOF {
+				s.eof = true
+				s.closeLocked()
+			} else {
+				s.detachLocked(conn)
+			}
+		}
+		s.Unlock()
+		if !mb.IsEmpty() {
+			return mb, nil
+		}
+	}
+}
+
+// WriteMultiBuffer implements buf.Writer.
+func (s *Session) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	s.Lock()
+	if s.closing || !s.waitLocked() {
+		s.Unlock()
+		buf.ReleaseMulti(mb)
+		return io.ErrClosedPipe
+	}
+	for _, b := range mb {
+		s.ring.Write(b.Bytes())
+	}
+	conn, writer := s.conn, s.writer
+	s.Unlock()
+
+	if err := writer.WriteMultiBuffer(mb); err != nil {
+		// the data is in the ring buffer and will be replayed on resume
+		s.Lock()
+		s.detachLocked(conn)
+		s.Unlock()
+	}
+	return nil
+}
+
+func (s *Session) closeLocked() {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	if s.attaching != nil {
+		s.attaching.Close()
+		s.attaching = nil
+	}
+	s.cond.Broadcast()
+}
+
+// Close ends the session once everything written has been delivered over an
+// attached connection.
+func (s *Session) Close() error {
+	s.Lock()
+	defer s.Unlock()
+	s.closing = true
+	if s.conn != nil {
+		s.closeLocked()
+	}
+	return nil
+}
+
+// Interrupt ends the session immediately.
+func (s *Session) Interrupt() {
+	s.Lock()
+	defer s.Unlock()
+	s.closeLocked()
+}
+
+// Closed reports whether the session has ended.
+func (s *Session) Closed() bool {
+	s.Lock()
+	defer s.Unlock()
+	return s.closed
+}
@@ -0,0 +1,184 @@
+package resume_test
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/net"
+	. "github.com/xtls/xray-core/proxy/vless/resume"
+)
+
+func connPair(t *testing.T) (net.Conn, net.Conn) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+	client, err := net.Dial("tcp", listener.Addr().String())
+	common.Must(err)
+	server, err := listener.Accept()
+	common.Must(err)
+	return client, server
+}
+
+// attachBoth attaches a new connection to both ends, exchanging their read
+// counters the way VLESS headers do.
+func attachBoth(t *testing.T, client, server *Session) (net.Conn, net.Conn) {
+	c, s := connPair(t)
+	toServer := make(chan uint64, 1)
+	toClient := make(chan uint64, 1)
+	errs := make(chan error, 2)
+	go func() {
+		errs <- client.Attach(c, buf.NewReader(c), func(read uint64) (uint64, error) {
+			toServer <- read
+			return <-toClient, nil
+		})
+	}()
+	go func() {
+		errs <- server.Attach(s, buf.NewReader(s), func(read uint64) (uint64, error) {
+			toClient <- read
+			return <-toServer, nil
+		})
+	}()
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c, s
+}
+
+func write(t *testing.T, s *Session, data string) {
+	if err := s.WriteMultiBuffer(buf.MergeBytes(nil, []byte(data))); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func read(t *testing.T, s *Session, data string) {
+	var b []byte
+	for len(b) < len(data) {
+		mb, err := s.ReadMultiBuffer()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, x := range mb {
+			b = append(b, x.Bytes()...)
+		}
+		buf.ReleaseMulti(mb)
+	}
+	if string(b) != data {
+		t.Fatalf("expected %q, got %q", data, b)
+	}
+}
+
+func TestSessionResume(t *testing.T) {
+	broken := make(chan struct{}, 2)
+	client := New(0, 0, func() { broken <- struct{}{} })
+	server := New(0, time.Minute, func() { broken <- struct{}{} })
+	defer client.Interrupt()
+	defer server.Interrupt()
+
+	c, s := attachBoth(t, client, server)
+	write(t, client, "ping")
+	read(t, server, "ping")
+	write(t, server, "pong")
+	read(t, client, "pong")
+
+	// errors other than EOF, like timeouts, detach the connection
+	clientRead := make(chan string)
+	go func() {
+		mb, _ := client.ReadMultiBuffer()
+		clientRead <- mb.String()
+	}()
+	serverRead := make(chan string)
+	go func() {
+		mb, _ := server.ReadMultiBuffer()
+		serverRead <- mb.String()
+	}()
+	past := time.Now().Add(-time.Second)
+	c.SetDeadline(past)
+	s.SetDeadline(past)
+	<-broken
+	<-broken
+
+	// writes while detached are replayed after resuming
+	done := make(chan struct{})
+	go func() {
+		write(t, client, "uplink")
+		write(t, server, "downlink")
+		close(done)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	attachBoth(t, client, server)
+	<-done
+
+	if r := <-serverRead; r != "uplink" {
+		t.Fatalf("unexpected uplink %q", r)
+	}
+	if r := <-clientRead; r != "downlink" {
+		t.Fatalf("unexpected downlink %q", r)
+	}
+
+	// a clean close ends the session on the other end
+	common.Must(client.Close())
+	if _, err := server.ReadMultiBuffer(); err != io.EOF {
+		t.Fatal("expected EOF, got ", err)
+	}
+	if !server.Closed() {
+		t.Fatal("session is not closed")
+	}
+}
+
+func TestSessionLost(t *testing.T) {
+	client := New(8, 0, nil)
+	server := New(0, time.Minute, nil)
+	defer client.Interrupt()
+	defer server.Interrupt()
+
+	attachBoth(t, client, server)
+	write(t, client, "0123456789abcdef")
+	read(t, server, "0123456789abcdef")
+
+	c, _ := connPair(t)
+	defer c.Close()
+	err := client.Attach(c, buf.NewReader(c), func(uint64) (uint64, error) {
+		return 4, nil
+	})
+	if err != ErrLost {
+		t.Fatal("expected ErrLost, got ", err)
+	}
+}
+
+func TestSessionNotLockedDuringHandshake(t *testing.T) {
+	s := New(1024, 0, nil)
+	c, _ := connPair(t)
+
+	handshaking := make(chan struct{})
+	release := make(chan struct{})
+	attached := make(chan error)
+	go func() {
+		attached <- s.Attach(c, buf.NewReader(c), func(uint64) (uint64, error) {
+			close(handshaking)
+			<-release
+			return 0, nil
+		})
+	}()
+	<-handshaking
+
+	closed := make(chan bool)
+	go func() {
+		closed <- s.Closed()
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("session is locked during the handshake")
+	}
+
+	s.Interrupt()
+	close(release)
+	if err := <-attached; err == nil {
+		t.Error("attached to an interrupted session")
+	}
+}
@@ -23,6 +23,7 @@ import (
 	core "github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/proxy/blackhole"
 	"github.com/xtls/xray-core/proxy/dokodemo"
+	"github.com/xtls/xray-core/proxy/failover"
 	"github.com/xtls/xray-core/proxy/freedom"
 	v2http "github.com/xtls/xray-core/proxy/http"
 	"github.com/xtls/xray-core/proxy/socks"
@@ -33,6 +34,7 @@ import (
 	"github.com/xtls/xray-core/testing/servers/udp"
 	"github.com/xtls/xray-core/transport/internet"
 	xproxy "golang.org/x/net/proxy"
+	"golang.org/x/sync/errgroup"
 )
 
 func TestPassiveConnection(t *testing.T) {
@@ -479,6 +481,186 @@ func TestForward(t *testing.T) {
 	}
 }
 
+func TestFailover(t *testing.T) {
+	tcpServer := tcp.Server{
+		MsgProcessor: xor,
+	}
+	dest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	serverPort := tcp.PickPort()
+	serverConfig := &core.Config{
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortList: &net.PortList{Range: []*net.PortRange{net.SinglePortRange(serverPort)}},
+					Listen:   net.NewIPOrDomain(net.LocalHostIP),
+					// the failover replays the payload cached while sniffing
+					SniffingSettings: &proxyman.SniffingConfig{
+						Enabled:             true,
+						DestinationOverride: []string{"http"},
+					},
+				}),
+				ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+					Address:  net.NewIPOrDomain(dest.Address),
+					Port:     uint32(dest.Port),
+					Networks: []net.Network{net.Network_TCP},
+				}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&failover.Config{
+					OutboundTags: []string{"dead", "blocked", "alive"},
+				}),
+			},
+			{
+				Tag: "dead",
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{
+					DestinationOverride: &freedom.DestinationOverride{
+						Server: &protocol.ServerEndpoint{
+							Address: net.NewIPOrDomain(net.LocalHostIP),
+							Port:    uint32(tcp.PickPort()),
+						},
+					},
+				}),
+			},
+			{
+				Tag:           "blocked",
+				ProxySettings: serial.ToTypedMessage(&blackhole.Config{}),
+			},
+			{
+				Tag:           "alive",
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	servers, err := InitializeServerConfigs(serverConfig)
+	common.Must(err)
+	defer CloseAllServers(servers)
+
+	var errg errgroup.Group
+	for range 3 {
+		errg.Go(testTCPConn(serverPort, 10240, time.Second*10))
+	}
+	if err := errg.Wait(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFailoverUDP(t *testing.T) {
+	udpServer := udp.Server{
+		MsgProcessor: xor,
+	}
+	dest, err := udpServer.Start()
+	common.Must(err)
+	defer udpServer.Close()
+
+	serverPort := udp.PickPort()
+	serverConfig := &core.Config{
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortList: &net.PortList{Range: []*net.PortRange{net.SinglePortRange(serverPort)}},
+					Listen:   net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+					Address:  net.NewIPOrDomain(dest.Address),
+					Port:     uint32(dest.Port),
+					Networks: []net.Network{net.Network_UDP},
+				}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				// nothing is sniffed, so the failover reads the payload
+				// itself; the alive outbound would stay silent for the whole
+				// timeout without it
+				ProxySettings: serial.ToTypedMessage(&failover.Config{
+					OutboundTags: []string{"blocked", "alive"},
+					Timeout:      30000,
+				}),
+			},
+			{
+				Tag:           "blocked",
+				ProxySettings: serial.ToTypedMessage(&blackhole.Config{}),
+			},
+			{
+				Tag:           "alive",
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	servers, err := InitializeServerConfigs(serverConfig)
+	common.Must(err)
+	defer CloseAllServers(servers)
+
+	if err := testUDPConn(serverPort, 1024, time.Second*5)(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFailoverRejectsCycle(t *testing.T) {
+	for _, outbounds := range [][]*core.OutboundHandlerConfig{
+		{
+			{
+				Tag:           "self",
+				ProxySettings: serial.ToTypedMessage(&failover.Config{OutboundTags: []string{"direct", "self"}}),
+			},
+			{
+				Tag:           "direct",
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+		{
+			{
+				Tag:           "a",
+				ProxySettings: serial.ToTypedMessage(&failover.Config{OutboundTags: []string{"b", "direct"}}),
+			},
+			{
+				Tag:           "b",
+				ProxySettings: serial.ToTypedMessage(&failover.Config{OutboundTags: []string{"direct", "a"}}),
+			},
+			{
+				Tag:           "direct",
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	} {
+		config := &core.Config{
+			App:      []*serial.TypedMessage{serial.ToTypedMessage(&proxyman.OutboundConfig{})},
+			Outbound: outbounds,
+		}
+		if _, err := core.New(config); err == nil {
+			t.Error("accepted a failover that uses itself: ", outbounds[0].Tag)
+		}
+	}
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{serial.ToTypedMessage(&proxyman.OutboundConfig{})},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				Tag:           "a",
+				ProxySettings: serial.ToTypedMessage(&failover.Config{OutboundTags: []string{"b", "direct"}}),
+			},
+			{
+				Tag:           "b",
+				ProxySettings: serial.ToTypedMessage(&failover.Config{OutboundTags: []string{"direct"}}),
+			},
+			{
+				Tag:           "direct",
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+	if _, err := core.New(config); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestUDPConnection(t *testing.T) {
 	udpServer := udp.Server{
 		MsgProcessor: xor,
@@ -4,24 +4,41 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/xtls/xray-core/app/commander"
+	"github.com/xtls/xray-core/app/policy"
 	"github.com/xtls/xray-core/app/proxyman"
+	"github.com/xtls/xray-core/app/proxyman/command"
+	"github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/app/stats"
+	statscmd "github.com/xtls/xray-core/app/stats/command"
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/buf"
 	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/protocol/tls/cert"
 	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/proxy/dokodemo"
 	"github.com/xtls/xray-core/proxy/freedom"
 	v2http "github.com/xtls/xray-core/proxy/http"
 	v2httptest "github.com/xtls/xray-core/testing/servers/http"
 	"github.com/xtls/xray-core/testing/servers/tcp"
+	"github.com/xtls/xray-core/testing/servers/udp"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/tls"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 func TestHttpConformance(t *testing.T) {
@@ -367,3 +384,284 @@ func TestHttpBasicAuth(t *testing.T) {
 		}
 	}
 }
+
+func TestHttpAddRemoveUser(t *testing.T) {
+	httpServerPort := tcp.PickPort()
+	httpServer := &v2httptest.Server{
+		Port:        httpServerPort,
+		PathHandler: make(map[string]http.HandlerFunc),
+	}
+	_, err := httpServer.Start()
+	common.Must(err)
+	defer httpServer.Close()
+
+	tcpServer := tcp.Server{
+		MsgProcessor: xor,
+	}
+	dest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	cmdPort := tcp.PickPort()
+	serverPort := tcp.PickPort()
+	serverConfig := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&stats.Config{}),
+			serial.ToTypedMessage(&commander.Config{
+				Tag: "api",
+				Service: []*serial.TypedMessage{
+					serial.ToTypedMessage(&command.Config{}),
+					serial.ToTypedMessage(&statscmd.Config{}),
+				},
+			}),
+			serial.ToTypedMessage(&router.Config{
+				Rule: []*router.RoutingRule{
+					{
+						InboundTag: []string{"api"},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "api",
+						},
+					},
+				},
+			}),
+			serial.ToTypedMessage(&policy.Config{
+				Level: map[uint32]*policy.Policy{
+					1: {
+						Stats: &policy.Policy_Stats{
+							UserUplink:   true,
+							UserDownlink: true,
+						},
+					},
+				},
+			}),
+		},
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				Tag: "http",
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortList: &net.PortList{Range: []*net.PortRange{net.SinglePortRange(serverPort)}},
+					Listen:   net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&v2http.ServerConfig{
+					Users: []*protocol.User{
+						{
+							Email:   "a@example.com",
+							Account: serial.ToTypedMessage(&v2http.Account{Username: "a", Password: "b"}),
+						},
+					},
+				}),
+			},
+			{
+				Tag: "api",
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortList: &net.PortList{Range: []*net.PortRange{net.SinglePortRange(cmdPort)}},
+					Listen:   net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+					Address:  net.NewIPOrDomain(dest.Address),
+					Port:     uint32(dest.Port),
+					Networks: []net.Network{net.Network_TCP},
+				}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	servers, err := InitializeServerConfigs(serverConfig)
+	common.Must(err)
+	defer CloseAllServers(servers)
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy: func(req *http.Request) (*url.URL, error) {
+				return url.Parse("http://127.0.0.1:" + serverPort.String())
+			},
+			DisableKeepAlives: true,
+		},
+	}
+	get := func(user, pass string) int {
+		req, err := http.NewRequest("GET", "http://127.0.0.1:"+httpServerPort.String(), nil)
+		common.Must(err)
+		setProxyBasicAuth(req, user, pass)
+		resp, err := client.Do(req)
+		common.Must(err)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := get("c", "d"); status != 407 {
+		t.Fatal("status of unknown user: ", status)
+	}
+
+	cmdConn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", cmdPort), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	common.Must(err)
+	defer cmdConn.Close()
+
+	hsClient := command.NewHandlerServiceClient(cmdConn)
+	_, err = hsClient.AlterInbound(context.Background(), &command.AlterInboundRequest{
+		Tag: "http",
+		Operation: serial.ToTypedMessage(&command.AddUserOperation{User: &protocol.User{
+			Email:   "c@example.com",
+			Level:   1,
+			Account: serial.ToTypedMessage(&v2http.Account{Username: "c", Password: "d"}),
+		}}),
+	})
+	common.Must(err)
+
+	if status := get("c", "d"); status != 200 {
+		t.Fatal("status of added user: ", status)
+	}
+
+	sClient := statscmd.NewStatsServiceClient(cmdConn)
+	sresp, err := sClient.GetStats(context.Background(), &statscmd.GetStatsRequest{
+		Name: "user>>>c@example.com>>>traffic>>>downlink",
+	})
+	common.Must(err)
+	if sresp.Stat.Value == 0 {
+		t.Error("no downlink traffic counted for the added user")
+	}
+
+	_, err = hsClient.AlterInbound(context.Background(), &command.AlterInboundRequest{
+		Tag:       "http",
+		Operation: serial.ToTypedMessage(&command.RemoveUserOperation{Email: "c@example.com"}),
+	})
+	common.Must(err)
+
+	if status := get("c", "d"); status != 407 {
+		t.Fatal("status of removed user: ", status)
+	}
+	if status := get("a", "b"); status != 200 {
+		t.Fatal("status of remaining user: ", status)
+	}
+}
+
+func TestHttpConnectUDP(t *testing.T) {
+	testHttpConnectUDP(t, tcp.PickPort(), nil, nil)
+}
+
+func TestHttpConnectUDPOverHTTP2(t *testing.T) {
+	if !strings.Contains(os.Getenv("GODEBUG"), "http2xconnect=1") {
+		t.Skip("extended CONNECT over HTTP/2 needs GODEBUG=http2xconnect=1")
+	}
+	testHttpConnectUDP(t, tcp.PickPort(), &internet.StreamConfig{
+		SecurityType: serial.GetMessageType(&tls.Config{}),
+		SecuritySettings: []*serial.TypedMessage{
+			serial.ToTypedMessage(&tls.Config{
+				Certificate:  []*tls.Certificate{tls.ParseCertificate(cert.MustGenerate(nil))},
+				NextProtocol: []string{"h2"},
+			}),
+		},
+	}, &internet.StreamConfig{
+		SecurityType: serial.GetMessageType(&tls.Config{}),
+		SecuritySettings: []*serial.TypedMessage{
+			serial.ToTypedMessage(&tls.Config{
+				AllowInsecure: true,
+				NextProtocol:  []string{"h2"},
+			}),
+		},
+	})
+}
+
+func TestHttpConnectUDPOverHTTP3(t *testing.T) {
+	testHttpConnectUDP(t, udp.PickPort(), &internet.StreamConfig{
+		SecurityType: serial.GetMessageType(&tls.Config{}),
+		SecuritySettings: []*serial.TypedMessage{
+			serial.ToTypedMessage(&tls.Config{
+				Certificate:  []*tls.Certificate{tls.ParseCertificate(cert.MustGenerate(nil))},
+				NextProtocol: []string{"h3"},
+			}),
+		},
+	}, &internet.StreamConfig{
+		SecurityType: serial.GetMessageType(&tls.Config{}),
+		SecuritySettings: []*serial.TypedMessage{
+			serial.ToTypedMessage(&tls.Config{
+				AllowInsecure: true,
+				NextProtocol:  []string{"h3"},
+			}),
+		},
+	})
+}
+
+func testHttpConnectUDP(t *testing.T, serverPort net.Port, serverStream, clientStream *internet.StreamConfig) {
+	udpServer := udp.Server{
+		MsgProcessor: xor,
+	}
+	dest, err := udpServer.Start()
+	common.Must(err)
+	defer udpServer.Close()
+
+	serverConfig := &core.Config{
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortList:       &net.PortList{Range: []*net.PortRange{net.SinglePortRange(serverPort)}},
+					Listen:         net.NewIPOrDomain(net.LocalHostIP),
+					StreamSettings: serverStream,
+				}),
+				ProxySettings: serial.ToTypedMessage(&v2http.ServerConfig{
+					Accounts: map[string]string{
+						"a": "b",
+					},
+				}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	clientPort := udp.PickPort()
+	clientConfig := &core.Config{
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortList: &net.PortList{Range: []*net.PortRange{net.SinglePortRange(clientPort)}},
+					Listen:   net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+					Address:  net.NewIPOrDomain(dest.Address),
+					Port:     uint32(dest.Port),
+					Networks: []net.Network{net.Network_UDP},
+				}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				SenderSettings: serial.ToTypedMessage(&proxyman.SenderConfig{
+					StreamSettings: clientStream,
+				}),
+				ProxySettings: serial.ToTypedMessage(&v2http.ClientConfig{
+					Server: &protocol.ServerEndpoint{
+						Address: net.NewIPOrDomain(net.LocalHostIP),
+						Port:    uint32(serverPort),
+						User: &protocol.User{
+							Account: serial.ToTypedMessage(&v2http.Account{
+								Username: "a",
+								Password: "b",
+							}),
+						},
+					},
+				}),
+			},
+		},
+	}
+
+	servers, err := InitializeServerConfigs(serverConfig, clientConfig)
+	common.Must(err)
+	defer CloseAllServers(servers)
+
+	var errg errgroup.Group
+	for range 3 {
+		errg.Go(testUDPConn(clientPort, 1024, time.Second*5))
+	}
+	if err := errg.Wait(); err != nil {
+		t.Error(err)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/xtls/xray-core/proxy/vmess/inbound"
 	"github.com/xtls/xray-core/proxy/vmess/outbound"
 	"github.com/xtls/xray-core/testing/servers/tcp"
+	"github.com/xtls/xray-core/testing/servers/udp"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -372,3 +373,184 @@ func TestReverseProxyLongRunning(t *testing.T) {
 		}
 	}
 }
+
+// reverseUDPConfigs returns the configs of a portal and a bridge, where the
+// portal publishes the UDP service at dest either with its own UDP listener
+// or through a dokodemo inbound.
+func reverseUDPConfigs(dest net.Destination, externalPort net.Port, listener bool) (*core.Config, *core.Config) {
+	userID := protocol.NewID(uuid.New())
+	reversePort := tcp.PickPort()
+
+	portal := &reverse.PortalConfig{
+		Tag:    "portal",
+		Domain: "test.example.com",
+		// the bridge has to connect as the user to register its id
+		Bridges: map[string]string{"office": "office@example.com"},
+	}
+	var externalInbound []*core.InboundHandlerConfig
+	if listener {
+		portal.UdpListener = []*reverse.UDPListener{
+			{
+				Listen:     net.NewIPOrDomain(net.LocalHostIP),
+				Port:       uint32(externalPort),
+				Address:    net.NewIPOrDomain(dest.Address),
+				TargetPort: uint32(dest.Port),
+				Bridge:     "office",
+			},
+		}
+	} else {
+		externalInbound = append(externalInbound, &core.InboundHandlerConfig{
+			Tag: "external",
+			ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+				PortList: &net.PortList{Range: []*net.PortRange{net.SinglePortRange(externalPort)}},
+				Listen:   net.NewIPOrDomain(net.LocalHostIP),
+			}),
+			ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+				Address:  net.NewIPOrDomain(dest.Address),
+				Port:     uint32(dest.Port),
+				Networks: []net.Network{net.Network_UDP},
+			}),
+		})
+	}
+
+	serverConfig := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&reverse.Config{
+				PortalConfig: []*reverse.PortalConfig{portal},
+			}),
+			serial.ToTypedMessage(&router.Config{
+				Rule: []*router.RoutingRule{
+					{
+						Domain: []*router.Domain{
+							{Type: router.Domain_Full, Value: "test.example.com"},
+						},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "portal",
+						},
+					},
+					{
+						InboundTag: []string{"external"},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "portal",
+						},
+					},
+				},
+			}),
+		},
+		Inbound: append(externalInbound, &core.InboundHandlerConfig{
+			ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+				PortList: &net.PortList{Range: []*net.PortRange{net.SinglePortRange(reversePort)}},
+				Listen:   net.NewIPOrDomain(net.LocalHostIP),
+			}),
+			ProxySettings: serial.ToTypedMessage(&inbound.Config{
+				User: []*protocol.User{
+					{
+						Email: "office@example.com",
+						Account: serial.ToTypedMessage(&vmess.Account{
+							Id: userID.String(),
+						}),
+					},
+				},
+			}),
+		}),
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&blackhole.Config{}),
+			},
+		},
+	}
+
+	clientConfig := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&reverse.Config{
+				BridgeConfig: []*reverse.BridgeConfig{
+					{
+						Tag:    "bridge",
+						Domain: "test.example.com",
+						Id:     "office",
+					},
+				},
+			}),
+			serial.ToTypedMessage(&router.Config{
+				Rule: []*router.RoutingRule{
+					{
+						Domain: []*router.Domain{
+							{Type: router.Domain_Full, Value: "test.example.com"},
+						},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "reverse",
+						},
+					},
+					{
+						InboundTag: []string{"bridge"},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "freedom",
+						},
+					},
+				},
+			}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				Tag:           "freedom",
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+			{
+				Tag: "reverse",
+				ProxySettings: serial.ToTypedMessage(&outbound.Config{
+					Receiver: &protocol.ServerEndpoint{
+						Address: net.NewIPOrDomain(net.LocalHostIP),
+						Port:    uint32(reversePort),
+						User: &protocol.User{
+							Account: serial.ToTypedMessage(&vmess.Account{
+								Id: userID.String(),
+								SecuritySettings: &protocol.SecurityConfig{
+									Type: protocol.SecurityType_AES128_GCM,
+								},
+							}),
+						},
+					},
+				}),
+			},
+		},
+	}
+	return serverConfig, clientConfig
+}
+
+func testReverseProxyUDP(t *testing.T, listener bool) {
+	udpServer := udp.Server{
+		MsgProcessor: xor,
+	}
+	dest, err := udpServer.Start()
+	common.Must(err)
+	defer udpServer.Close()
+
+	externalPort := udp.PickPort()
+	servers, err := InitializeServerConfigs(reverseUDPConfigs(dest, externalPort, listener))
+	common.Must(err)
+	defer CloseAllServers(servers)
+
+	// waits for the bridge to connect
+	deadline := time.Now().Add(10 * time.Second)
+	for testUDPConn(externalPort, 1024, time.Second)() != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("bridge is not available")
+		}
+	}
+
+	var errg errgroup.Group
+	for range 8 {
+		errg.Go(testUDPConn(externalPort, 1024, time.Second*5))
+	}
+	if err := errg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReverseProxyUDPListener(t *testing.T) {
+	testReverseProxyUDP(t, true)
+}
+
+func TestReverseProxyUDPDokodemo(t *testing.T) {
+	testReverseProxyUDP(t, false)
+}
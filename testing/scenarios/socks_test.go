@@ -1,6 +1,10 @@
 package scenarios
 
 import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strconv"
 	"testing"
 	"time"
 
@@ -479,3 +483,124 @@ func TestSocksConformanceMod(t *testing.T) {
 		}
 	}
 }
+
+func TestSocksBind(t *testing.T) {
+	serverPort := tcp.PickPort()
+	serverConfig := &core.Config{
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortList: &net.PortList{Range: []*net.PortRange{net.SinglePortRange(serverPort)}},
+					Listen:   net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&socks.ServerConfig{
+					AuthType: socks.AuthType_PASSWORD,
+					Users: []*protocol.User{
+						{
+							Email:   "a@example.com",
+							Account: serial.ToTypedMessage(&socks.Account{Username: "a", Password: "b"}),
+						},
+					},
+					BindEnabled: true,
+				}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	servers, err := InitializeServerConfigs(serverConfig)
+	common.Must(err)
+	defer CloseAllServers(servers)
+
+	conn, err := net.Dial("tcp", "127.0.0.1:"+serverPort.String())
+	common.Must(err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	expect := func(expected []byte) []byte {
+		t.Helper()
+		b := make([]byte, len(expected))
+		if _, err := io.ReadFull(conn, b); err != nil {
+			t.Fatal(err)
+		}
+		if n := min(len(b), 4); !bytes.Equal(b[:n], expected[:n]) {
+			t.Fatal("unexpected response: ", b)
+		}
+		return b
+	}
+	common.Must2(conn.Write([]byte{5, 1, 2}))
+	expect([]byte{5, 2})
+	common.Must2(conn.Write([]byte{1, 1, 'a', 1, 'b'}))
+	expect([]byte{1, 0})
+	common.Must2(conn.Write([]byte{5, 2, 0, 1, 127, 0, 0, 1, 0, 0}))
+	bound := expect([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	bindPort := binary.BigEndian.Uint16(bound[8:])
+
+	peer, err := net.Dial("tcp", "127.0.0.1:"+strconv.Itoa(int(bindPort)))
+	common.Must(err)
+	defer peer.Close()
+	peer.SetDeadline(time.Now().Add(10 * time.Second))
+
+	accepted := expect([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	if port := binary.BigEndian.Uint16(accepted[8:]); int(port) != peer.LocalAddr().(*net.TCPAddr).Port {
+		t.Error("reported peer port ", port, " instead of ", peer.LocalAddr())
+	}
+
+	common.Must2(conn.Write([]byte("ping")))
+	b := make([]byte, 4)
+	common.Must2(io.ReadFull(peer, b))
+	if string(b) != "ping" {
+		t.Error("peer received ", string(b))
+	}
+	common.Must2(peer.Write([]byte("pong")))
+	common.Must2(io.ReadFull(conn, b))
+	if string(b) != "pong" {
+		t.Error("client received ", string(b))
+	}
+}
+
+func TestSocksBindNotDirect(t *testing.T) {
+	serverPort := tcp.PickPort()
+	serverConfig := &core.Config{
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortList: &net.PortList{Range: []*net.PortRange{net.SinglePortRange(serverPort)}},
+					Listen:   net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&socks.ServerConfig{
+					AuthType:    socks.AuthType_NO_AUTH,
+					BindEnabled: true,
+				}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&blackhole.Config{}),
+			},
+		},
+	}
+
+	servers, err := InitializeServerConfigs(serverConfig)
+	common.Must(err)
+	defer CloseAllServers(servers)
+
+	conn, err := net.Dial("tcp", "127.0.0.1:"+serverPort.String())
+	common.Must(err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	common.Must2(conn.Write([]byte{5, 1, 0}))
+	b := make([]byte, 2)
+	common.Must2(io.ReadFull(conn, b))
+	common.Must2(conn.Write([]byte{5, 2, 0, 1, 127, 0, 0, 1, 0, 0}))
+	b = make([]byte, 10)
+	common.Must2(io.ReadFull(conn, b))
+	if b[1] != 0x02 {
+		t.Error("bind not routed to a direct outbound answered with ", b[1])
+	}
+}
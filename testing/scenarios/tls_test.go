@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/xtls/xray-core/app/proxyman"
+	"github.com/xtls/xray-core/app/router"
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/protocol"
@@ -14,8 +15,10 @@ import (
 	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/common/uuid"
 	core "github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/proxy/blackhole"
 	"github.com/xtls/xray-core/proxy/dokodemo"
 	"github.com/xtls/xray-core/proxy/freedom"
+	"github.com/xtls/xray-core/proxy/socks"
 	"github.com/xtls/xray-core/proxy/vmess"
 	"github.com/xtls/xray-core/proxy/vmess/inbound"
 	"github.com/xtls/xray-core/proxy/vmess/outbound"
@@ -1056,3 +1059,132 @@ func TestUTLSConnectionPinnedWrongCert(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestTLSClientCertificateUser(t *testing.T) {
+	tcpServer := tcp.Server{
+		MsgProcessor: xor,
+	}
+	dest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	clientAuth := func(c *x509.Certificate) {
+		c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+	clientCA := cert.MustGenerate(nil, cert.Authority(true), cert.KeyUsage(x509.KeyUsageCertSign), clientAuth)
+	caCertificate := tls.ParseCertificate(clientCA)
+	caCertificate.Key = nil
+	caCertificate.Usage = tls.Certificate_AUTHORITY_VERIFY
+
+	serverPort := tcp.PickPort()
+	serverConfig := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&router.Config{
+				Rule: []*router.RoutingRule{
+					{
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "direct",
+						},
+						UserEmail: []string{"alice@example.com"},
+					},
+				},
+			}),
+		},
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortList: &net.PortList{Range: []*net.PortRange{net.SinglePortRange(serverPort)}},
+					Listen:   net.NewIPOrDomain(net.LocalHostIP),
+					StreamSettings: &internet.StreamConfig{
+						SecurityType: serial.GetMessageType(&tls.Config{}),
+						SecuritySettings: []*serial.TypedMessage{
+							serial.ToTypedMessage(&tls.Config{
+								Certificate: []*tls.Certificate{
+									tls.ParseCertificate(cert.MustGenerate(nil)),
+									caCertificate,
+								},
+								ClientAuth: &tls.ClientAuth{
+									Required: true,
+									User: []*tls.ClientUser{
+										{
+											Subject: "alice",
+											User:    &protocol.User{Email: "alice@example.com"},
+										},
+									},
+								},
+							}),
+						},
+					},
+				}),
+				ProxySettings: serial.ToTypedMessage(&socks.ServerConfig{
+					AuthType: socks.AuthType_NO_AUTH,
+					Address:  net.NewIPOrDomain(net.LocalHostIP),
+				}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&blackhole.Config{}),
+			},
+			{
+				Tag:           "direct",
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	clientConfig := func(clientPort net.Port, commonName string) *core.Config {
+		return &core.Config{
+			Inbound: []*core.InboundHandlerConfig{
+				{
+					ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+						PortList: &net.PortList{Range: []*net.PortRange{net.SinglePortRange(clientPort)}},
+						Listen:   net.NewIPOrDomain(net.LocalHostIP),
+					}),
+					ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+						Address:  net.NewIPOrDomain(dest.Address),
+						Port:     uint32(dest.Port),
+						Networks: []net.Network{net.Network_TCP},
+					}),
+				},
+			},
+			Outbound: []*core.OutboundHandlerConfig{
+				{
+					ProxySettings: serial.ToTypedMessage(&socks.ClientConfig{
+						Server: &protocol.ServerEndpoint{
+							Address: net.NewIPOrDomain(net.LocalHostIP),
+							Port:    uint32(serverPort),
+						},
+					}),
+					SenderSettings: serial.ToTypedMessage(&proxyman.SenderConfig{
+						StreamSettings: &internet.StreamConfig{
+							SecurityType: serial.GetMessageType(&tls.Config{}),
+							SecuritySettings: []*serial.TypedMessage{
+								serial.ToTypedMessage(&tls.Config{
+									AllowInsecure: true,
+									Certificate: []*tls.Certificate{
+										tls.ParseCertificate(cert.MustGenerate(clientCA, cert.CommonName(commonName), clientAuth)),
+									},
+									PresentClientCertificate: true,
+								}),
+							},
+						},
+					}),
+				},
+			},
+		}
+	}
+
+	aliceClientPort := tcp.PickPort()
+	malloryClientPort := tcp.PickPort()
+	servers, err := InitializeServerConfigs(serverConfig, clientConfig(aliceClientPort, "alice"), clientConfig(malloryClientPort, "mallory"))
+	common.Must(err)
+	defer CloseAllServers(servers)
+
+	if err := testTCPConn(aliceClientPort, 1024, time.Second*20)(); err != nil {
+		t.Fatal(err)
+	}
+	if err := testTCPConn(malloryClientPort, 1024, time.Second*2)(); err == nil {
+		t.Fatal("a client certificate mapping to no user is routed as alice")
+	}
+}
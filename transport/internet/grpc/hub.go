@@ -2,12 +2,13 @@ package grpc
 
 import (
 	"context"
+	gotls "crypto/tls"
 	"time"
 
-	goreality "github.com/xtls/reality"
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
 	"github.com/xtls/xray-core/transport/internet"
 	"github.com/xtls/xray-core/transport/internet/grpc/encoding"
 	"github.com/xtls/xray-core/transport/internet/reality"
@@ -15,6 +16,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/peer"
 )
 
 type Listener struct {
@@ -24,25 +26,58 @@ type Listener struct {
 	local   net.Addr
 	config  *Config
 
-	s *grpc.Server
+	s         *grpc.Server
+	tlsConfig *gotls.Config
 }
 
 func (l Listener) Tun(server encoding.GRPCService_TunServer) error {
 	tunCtx, cancel := context.WithCancel(l.ctx)
-	l.handler(encoding.NewHunkConn(server, cancel))
+	l.handler(l.withHandshake(server.Context(), encoding.NewHunkConn(server, cancel)))
 	<-tunCtx.Done()
 	return nil
 }
 
 func (l Listener) TunMulti(server encoding.GRPCService_TunMultiServer) error {
 	tunCtx, cancel := context.WithCancel(l.ctx)
-	l.handler(encoding.NewMultiHunkConn(server, cancel))
+	l.handler(l.withHandshake(server.Context(), encoding.NewMultiHunkConn(server, cancel)))
 	<-tunCtx.Done()
 	return nil
 }
 
+// tunConn is a tunnel whose gRPC connection reports its TLS handshake.
+type tunConn struct {
+	net.Conn
+	handshake *tls.Handshake
+}
+
+// SNIProfile returns the name of the TLS SNI profile the connection matched, if any.
+func (c *tunConn) SNIProfile() string {
+	return c.handshake.SNIProfile()
+}
+
+// ClientUser returns the user the TLS client certificate of the connection maps to, if any.
+func (c *tunConn) ClientUser() *protocol.MemoryUser {
+	return c.handshake.ClientUser()
+}
+
+func (l Listener) withHandshake(ctx context.Context, conn net.Conn) net.Conn {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return conn
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return conn
+	}
+	if handshake := tls.NewHandshake(l.tlsConfig, &info.State); handshake != nil {
+		return &tunConn{Conn: conn, handshake: handshake}
+	}
+	return conn
+}
+
 func (l Listener) Close() error {
 	l.s.Stop()
+	tls.Release(l.tlsConfig)
 	return nil
 }
 
@@ -81,7 +116,8 @@ func Listen(ctx context.Context, address net.Address, port net.Port, settings *i
 	var s *grpc.Server
 	if config != nil {
 		// gRPC server may silently ignore TLS errors
-		options = append(options, grpc.Creds(credentials.NewTLS(config.GetTLSConfig(tls.WithNextProto("h2")))))
+		listener.tlsConfig = config.GetTLSConfig(tls.WithNextProto("h2"))
+		options = append(options, grpc.Creds(credentials.NewTLS(listener.tlsConfig)))
 	}
 	if grpcSettings.IdleTimeout > 0 || grpcSettings.HealthCheckTimeout > 0 {
 		options = append(options, grpc.KeepaliveParams(keepalive.ServerParameters{
@@ -124,7 +160,9 @@ func Listen(ctx context.Context, address net.Address, port net.Port, settings *i
 		encoding.RegisterGRPCServiceServerX(s, listener, grpcSettings.getServiceName(), grpcSettings.getTunStreamName(), grpcSettings.getTunMultiStreamName())
 
 		if config := reality.ConfigFromStreamSettings(settings); config != nil {
-			streamListener = goreality.NewListener(streamListener, config.GetREALITYConfig())
+			realityConfig := config.GetREALITYConfig()
+			defer reality.Release(realityConfig)
+			streamListener = reality.NewListener(streamListener, realityConfig)
 		}
 		if err = s.Serve(streamListener); err != nil {
 			errors.LogInfoInner(ctx, err, "Listener for gRPC ended")
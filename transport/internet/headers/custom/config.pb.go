@@ -0,0 +1,723 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.35.1
+// 	protoc        v5.28.2
+// source: transport/internet/headers/custom/config.proto
+
+package custom
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Constant bytes copied verbatim.
+type ConstantSegment struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *ConstantSegment) Reset() {
+	*x = ConstantSegment{}
+	mi := &file_transport_internet_headers_custom_config_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConstantSegment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConstantSegment) ProtoMessage() {}
+
+func (x *ConstantSegment) ProtoReflect() protoreflect.Message {
+	mi := &file_transport_internet_headers_custom_config_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConstantSegment.ProtoReflect.Descriptor instead.
+func (*ConstantSegment) Descriptor() ([]byte, []int) {
+	return file_transport_internet_headers_custom_config_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ConstantSegment) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// Random bytes, each in the inclusive range [min, max].
+// A zero max is treated as 255.
+type RandomSegment struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Length uint32 `protobuf:"varint,1,opt,name=length,proto3" json:"length,omitempty"`
+	Min    uint32 `protobuf:"varint,2,opt,name=min,proto3" json:"min,omitempty"`
+	Max    uint32 `protobuf:"varint,3,opt,name=max,proto3" json:"max,omitempty"`
+}
+
+func (x *RandomSegment) Reset() {
+	*x = RandomSegment{}
+	mi := &file_transport_internet_headers_custom_config_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RandomSegment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RandomSegment) ProtoMessage() {}
+
+func (x *RandomSegment) ProtoReflect() protoreflect.Message {
+	mi := &file_transport_internet_headers_custom_config_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RandomSegment.ProtoReflect.Descriptor instead.
+func (*RandomSegment) Descriptor() ([]byte, []int) {
+	return file_transport_internet_headers_custom_config_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RandomSegment) GetLength() uint32 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+func (x *RandomSegment) GetMin() uint32 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *RandomSegment) GetMax() uint32 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+// A counter of 1, 2, 4 or 8 bytes, increased by step on every header.
+type CounterSegment struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Size         uint32 `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	Start        uint64 `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
+	RandomStart  bool   `protobuf:"varint,3,opt,name=random_start,json=randomStart,proto3" json:"random_start,omitempty"`
+	Step         uint64 `protobuf:"varint,4,opt,name=step,proto3" json:"step,omitempty"`
+	LittleEndian bool   `protobuf:"varint,5,opt,name=little_endian,json=littleEndian,proto3" json:"little_endian,omitempty"`
+}
+
+func (x *CounterSegment) Reset() {
+	*x = CounterSegment{}
+	mi := &file_transport_internet_headers_custom_config_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CounterSegment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CounterSegment) ProtoMessage() {}
+
+func (x *CounterSegment) ProtoReflect() protoreflect.Message {
+	mi := &file_transport_internet_headers_custom_config_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CounterSegment.ProtoReflect.Descriptor instead.
+func (*CounterSegment) Descriptor() ([]byte, []int) {
+	return file_transport_internet_headers_custom_config_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CounterSegment) GetSize() uint32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *CounterSegment) GetStart() uint64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *CounterSegment) GetRandomStart() bool {
+	if x != nil {
+		return x.RandomStart
+	}
+	return false
+}
+
+func (x *CounterSegment) GetStep() uint64 {
+	if x != nil {
+		return x.Step
+	}
+	return 0
+}
+
+func (x *CounterSegment) GetLittleEndian() bool {
+	if x != nil {
+		return x.LittleEndian
+	}
+	return false
+}
+
+// The current unix time in 4 or 8 bytes.
+type TimestampSegment struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Size         uint32 `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	Milliseconds bool   `protobuf:"varint,2,opt,name=milliseconds,proto3" json:"milliseconds,omitempty"`
+	LittleEndian bool   `protobuf:"varint,3,opt,name=little_endian,json=littleEndian,proto3" json:"little_endian,omitempty"`
+}
+
+func (x *TimestampSegment) Reset() {
+	*x = TimestampSegment{}
+	mi := &file_transport_internet_headers_custom_config_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TimestampSegment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimestampSegment) ProtoMessage() {}
+
+func (x *TimestampSegment) ProtoReflect() protoreflect.Message {
+	mi := &file_transport_internet_headers_custom_config_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimestampSegment.ProtoReflect.Descriptor instead.
+func (*TimestampSegment) Descriptor() ([]byte, []int) {
+	return file_transport_internet_headers_custom_config_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *TimestampSegment) GetSize() uint32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *TimestampSegment) GetMilliseconds() bool {
+	if x != nil {
+		return x.Milliseconds
+	}
+	return false
+}
+
+func (x *TimestampSegment) GetLittleEndian() bool {
+	if x != nil {
+		return x.LittleEndian
+	}
+	return false
+}
+
+// A length field of 1, 2, 4 or 8 bytes. For packet headers it holds the
+// header size, for connection headers the header size plus the length of
+// the first payload written with it. adjust is added to the value.
+type LengthSegment struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Size         uint32 `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	Adjust       int32  `protobuf:"varint,2,opt,name=adjust,proto3" json:"adjust,omitempty"`
+	LittleEndian bool   `protobuf:"varint,3,opt,name=little_endian,json=littleEndian,proto3" json:"little_endian,omitempty"`
+}
+
+func (x *LengthSegment) Reset() {
+	*x = LengthSegment{}
+	mi := &file_transport_internet_headers_custom_config_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LengthSegment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LengthSegment) ProtoMessage() {}
+
+func (x *LengthSegment) ProtoReflect() protoreflect.Message {
+	mi := &file_transport_internet_headers_custom_config_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LengthSegment.ProtoReflect.Descriptor instead.
+func (*LengthSegment) Descriptor() ([]byte, []int) {
+	return file_transport_internet_headers_custom_config_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *LengthSegment) GetSize() uint32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *LengthSegment) GetAdjust() int32 {
+	if x != nil {
+		return x.Adjust
+	}
+	return 0
+}
+
+func (x *LengthSegment) GetLittleEndian() bool {
+	if x != nil {
+		return x.LittleEndian
+	}
+	return false
+}
+
+type Segment struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Value:
+	//
+	//	*Segment_Constant
+	//	*Segment_Random
+	//	*Segment_Counter
+	//	*Segment_Timestamp
+	//	*Segment_Length
+	Value isSegment_Value `protobuf_oneof:"value"`
+}
+
+func (x *Segment) Reset() {
+	*x = Segment{}
+	mi := &file_transport_internet_headers_custom_config_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Segment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Segment) ProtoMessage() {}
+
+func (x *Segment) ProtoReflect() protoreflect.Message {
+	mi := &file_transport_internet_headers_custom_config_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Segment.ProtoReflect.Descriptor instead.
+func (*Segment) Descriptor() ([]byte, []int) {
+	return file_transport_internet_headers_custom_config_proto_rawDescGZIP(), []int{5}
+}
+
+func (m *Segment) GetValue() isSegment_Value {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (x *Segment) GetConstant() *ConstantSegment {
+	if x, ok := x.GetValue().(*Segment_Constant); ok {
+		return x.Constant
+	}
+	return nil
+}
+
+func (x *Segment) GetRandom() *RandomSegment {
+	if x, ok := x.GetValue().(*Segment_Random); ok {
+		return x.Random
+	}
+	return nil
+}
+
+func (x *Segment) GetCounter() *CounterSegment {
+	if x, ok := x.GetValue().(*Segment_Counter); ok {
+		return x.Counter
+	}
+	return nil
+}
+
+func (x *Segment) GetTimestamp() *TimestampSegment {
+	if x, ok := x.GetValue().(*Segment_Timestamp); ok {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Segment) GetLength() *LengthSegment {
+	if x, ok := x.GetValue().(*Segment_Length); ok {
+		return x.Length
+	}
+	return nil
+}
+
+type isSegment_Value interface {
+	isSegment_Value()
+}
+
+type Segment_Constant struct {
+	Constant *ConstantSegment `protobuf:"bytes,1,opt,name=constant,proto3,oneof"`
+}
+
+type Segment_Random struct {
+	Random *RandomSegment `protobuf:"bytes,2,opt,name=random,proto3,oneof"`
+}
+
+type Segment_Counter struct {
+	Counter *CounterSegment `protobuf:"bytes,3,opt,name=counter,proto3,oneof"`
+}
+
+type Segment_Timestamp struct {
+	Timestamp *TimestampSegment `protobuf:"bytes,4,opt,name=timestamp,proto3,oneof"`
+}
+
+type Segment_Length struct {
+	Length *LengthSegment `protobuf:"bytes,5,opt,name=length,proto3,oneof"`
+}
+
+func (*Segment_Constant) isSegment_Value() {}
+
+func (*Segment_Random) isSegment_Value() {}
+
+func (*Segment_Counter) isSegment_Value() {}
+
+func (*Segment_Timestamp) isSegment_Value() {}
+
+func (*Segment_Length) isSegment_Value() {}
+
+// Config is a packet header for mKCP.
+type Config struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Segments []*Segment `protobuf:"bytes,1,rep,name=segments,proto3" json:"segments,omitempty"`
+}
+
+func (x *Config) Reset() {
+	*x = Config{}
+	mi := &file_transport_internet_headers_custom_config_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Config) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Config) ProtoMessage() {}
+
+func (x *Config) ProtoReflect() protoreflect.Message {
+	mi := &file_transport_internet_headers_custom_config_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Config.ProtoReflect.Descriptor instead.
+func (*Config) Descriptor() ([]byte, []int) {
+	return file_transport_internet_headers_custom_config_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Config) GetSegments() []*Segment {
+	if x != nil {
+		return x.Segments
+	}
+	return nil
+}
+
+// ConnectionConfig is a connection header for raw TCP. The client prefix is
+// sent before the first client write, the server prefix before the first
+// server write.
+type ConnectionConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Client []*Segment `protobuf:"bytes,1,rep,name=client,proto3" json:"client,omitempty"`
+	Server []*Segment `protobuf:"bytes,2,rep,name=server,proto3" json:"server,omitempty"`
+}
+
+func (x *ConnectionConfig) Reset() {
+	*x = ConnectionConfig{}
+	mi := &file_transport_internet_headers_custom_config_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectionConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectionConfig) ProtoMessage() {}
+
+func (x *ConnectionConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_transport_internet_headers_custom_config_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectionConfig.ProtoReflect.Descriptor instead.
+func (*ConnectionConfig) Descriptor() ([]byte, []int) {
+	return file_transport_internet_headers_custom_config_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ConnectionConfig) GetClient() []*Segment {
+	if x != nil {
+		return x.Client
+	}
+	return nil
+}
+
+func (x *ConnectionConfig) GetServer() []*Segment {
+	if x != nil {
+		return x.Server
+	}
+	return nil
+}
+
+var File_transport_internet_headers_custom_config_proto protoreflect.FileDescriptor
+
+var file_transport_internet_headers_custom_config_proto_rawDesc = []byte{
+	0x0a, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2f, 0x69, 0x6e, 0x74, 0x65,
+	0x72, 0x6e, 0x65, 0x74, 0x2f, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x2f, 0x63, 0x75, 0x73,
+	0x74, 0x6f, 0x6d, 0x2f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x12, 0x26, 0x78, 0x72, 0x61, 0x79, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74,
+	0x2e, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x2e, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72,
+	0x73, 0x2e, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x22, 0x25, 0x0a, 0x0f, 0x43, 0x6f, 0x6e, 0x73,
+	0x74, 0x61, 0x6e, 0x74, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64,
+	0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22,
+	0x4b, 0x0a, 0x0d, 0x52, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74,
+	0x12, 0x16, 0x0a, 0x06, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x06, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x69, 0x6e, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x6d, 0x69, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x61,
+	0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x6d, 0x61, 0x78, 0x22, 0x96, 0x01, 0x0a,
+	0x0e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x12,
+	0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x73,
+	0x69, 0x7a, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x72, 0x61, 0x6e,
+	0x64, 0x6f, 0x6d, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0b, 0x72, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x73, 0x74, 0x65, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x73, 0x74, 0x65, 0x70,
+	0x12, 0x23, 0x0a, 0x0d, 0x6c, 0x69, 0x74, 0x74, 0x6c, 0x65, 0x5f, 0x65, 0x6e, 0x64, 0x69, 0x61,
+	0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x6c, 0x69, 0x74, 0x74, 0x6c, 0x65, 0x45,
+	0x6e, 0x64, 0x69, 0x61, 0x6e, 0x22, 0x6f, 0x0a, 0x10, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x22, 0x0a,
+	0x0c, 0x6d, 0x69, 0x6c, 0x6c, 0x69, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x0c, 0x6d, 0x69, 0x6c, 0x6c, 0x69, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64,
+	0x73, 0x12, 0x23, 0x0a, 0x0d, 0x6c, 0x69, 0x74, 0x74, 0x6c, 0x65, 0x5f, 0x65, 0x6e, 0x64, 0x69,
+	0x61, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x6c, 0x69, 0x74, 0x74, 0x6c, 0x65,
+	0x45, 0x6e, 0x64, 0x69, 0x61, 0x6e, 0x22, 0x60, 0x0a, 0x0d, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68,
+	0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x61,
+	0x64, 0x6a, 0x75, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x61, 0x64, 0x6a,
+	0x75, 0x73, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x6c, 0x69, 0x74, 0x74, 0x6c, 0x65, 0x5f, 0x65, 0x6e,
+	0x64, 0x69, 0x61, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x6c, 0x69, 0x74, 0x74,
+	0x6c, 0x65, 0x45, 0x6e, 0x64, 0x69, 0x61, 0x6e, 0x22, 0xb9, 0x03, 0x0a, 0x07, 0x53, 0x65, 0x67,
+	0x6d, 0x65, 0x6e, 0x74, 0x12, 0x55, 0x0a, 0x08, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x37, 0x2e, 0x78, 0x72, 0x61, 0x79, 0x2e, 0x74, 0x72,
+	0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74,
+	0x2e, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x2e, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x2e,
+	0x43, 0x6f, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x74, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x48,
+	0x00, 0x52, 0x08, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x74, 0x12, 0x4f, 0x0a, 0x06, 0x72,
+	0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x35, 0x2e, 0x78, 0x72,
+	0x61, 0x79, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x69, 0x6e, 0x74,
+	0x65, 0x72, 0x6e, 0x65, 0x74, 0x2e, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x2e, 0x63, 0x75,
+	0x73, 0x74, 0x6f, 0x6d, 0x2e, 0x52, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x53, 0x65, 0x67, 0x6d, 0x65,
+	0x6e, 0x74, 0x48, 0x00, 0x52, 0x06, 0x72, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x12, 0x52, 0x0a, 0x07,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x36, 0x2e,
+	0x78, 0x72, 0x61, 0x79, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x69,
+	0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x2e, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x2e,
+	0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x53, 0x65,
+	0x67, 0x6d, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x07, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72,
+	0x12, 0x58, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x38, 0x2e, 0x78, 0x72, 0x61, 0x79, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73,
+	0x70, 0x6f, 0x72, 0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x2e, 0x68, 0x65,
+	0x61, 0x64, 0x65, 0x72, 0x73, 0x2e, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x2e, 0x54, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52,
+	0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x4f, 0x0a, 0x06, 0x6c, 0x65,
+	0x6e, 0x67, 0x74, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x35, 0x2e, 0x78, 0x72, 0x61,
+	0x79, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65,
+	0x72, 0x6e, 0x65, 0x74, 0x2e, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x2e, 0x63, 0x75, 0x73,
+	0x74, 0x6f, 0x6d, 0x2e, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e,
+	0x74, 0x48, 0x00, 0x52, 0x06, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x42, 0x07, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x22, 0x55, 0x0a, 0x06, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x4b,
+	0x0a, 0x08, 0x73, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x2f, 0x2e, 0x78, 0x72, 0x61, 0x79, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72,
+	0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x2e, 0x68, 0x65, 0x61, 0x64, 0x65,
+	0x72, 0x73, 0x2e, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x2e, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e,
+	0x74, 0x52, 0x08, 0x73, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x22, 0xa4, 0x01, 0x0a, 0x10,
+	0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x12, 0x47, 0x0a, 0x06, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x2f, 0x2e, 0x78, 0x72, 0x61, 0x79, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72,
+	0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x2e, 0x68, 0x65, 0x61, 0x64, 0x65,
+	0x72, 0x73, 0x2e, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x2e, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e,
+	0x74, 0x52, 0x06, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x12, 0x47, 0x0a, 0x06, 0x73, 0x65, 0x72,
+	0x76, 0x65, 0x72, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2f, 0x2e, 0x78, 0x72, 0x61, 0x79,
+	0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65, 0x72,
+	0x6e, 0x65, 0x74, 0x2e, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x2e, 0x63, 0x75, 0x73, 0x74,
+	0x6f, 0x6d, 0x2e, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x06, 0x73, 0x65, 0x72, 0x76,
+	0x65, 0x72, 0x42, 0x94, 0x01, 0x0a, 0x2a, 0x63, 0x6f, 0x6d, 0x2e, 0x78, 0x72, 0x61, 0x79, 0x2e,
+	0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e,
+	0x65, 0x74, 0x2e, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x2e, 0x63, 0x75, 0x73, 0x74, 0x6f,
+	0x6d, 0x50, 0x01, 0x5a, 0x3b, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x78, 0x74, 0x6c, 0x73, 0x2f, 0x78, 0x72, 0x61, 0x79, 0x2d, 0x63, 0x6f, 0x72, 0x65, 0x2f, 0x74,
+	0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65,
+	0x74, 0x2f, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x2f, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d,
+	0xaa, 0x02, 0x26, 0x58, 0x72, 0x61, 0x79, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72,
+	0x74, 0x2e, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x65,
+	0x72, 0x73, 0x2e, 0x43, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
+}
+
+var (
+	file_transport_internet_headers_custom_config_proto_rawDescOnce sync.Once
+	file_transport_internet_headers_custom_config_proto_rawDescData = file_transport_internet_headers_custom_config_proto_rawDesc
+)
+
+func file_transport_internet_headers_custom_config_proto_rawDescGZIP() []byte {
+	file_transport_internet_headers_custom_config_proto_rawDescOnce.Do(func() {
+		file_transport_internet_headers_custom_config_proto_rawDescData = protoimpl.X.CompressGZIP(file_transport_internet_headers_custom_config_proto_rawDescData)
+	})
+	return file_transport_internet_headers_custom_config_proto_rawDescData
+}
+
+var file_transport_internet_headers_custom_config_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_transport_internet_headers_custom_config_proto_goTypes = []any{
+	(*ConstantSegment)(nil),  // 0: xray.transport.internet.headers.custom.ConstantSegment
+	(*RandomSegment)(nil),    // 1: xray.transport.internet.headers.custom.RandomSegment
+	(*CounterSegment)(nil),   // 2: xray.transport.internet.headers.custom.CounterSegment
+	(*TimestampSegment)(nil), // 3: xray.transport.internet.headers.custom.TimestampSegment
+	(*LengthSegment)(nil),    // 4: xray.transport.internet.headers.custom.LengthSegment
+	(*Segment)(nil),          // 5: xray.transport.internet.headers.custom.Segment
+	(*Config)(nil),           // 6: xray.transport.internet.headers.custom.Config
+	(*ConnectionConfig)(nil), // 7: xray.transport.internet.headers.custom.ConnectionConfig
+}
+var file_transport_internet_headers_custom_config_proto_depIdxs = []int32{
+	0, // 0: xray.transport.internet.headers.custom.Segment.constant:type_name -> xray.transport.internet.headers.custom.ConstantSegment
+	1, // 1: xray.transport.internet.headers.custom.Segment.random:type_name -> xray.transport.internet.headers.custom.RandomSegment
+	2, // 2: xray.transport.internet.headers.custom.Segment.counter:type_name -> xray.transport.internet.headers.custom.CounterSegment
+	3, // 3: xray.transport.internet.headers.custom.Segment.timestamp:type_name -> xray.transport.internet.headers.custom.TimestampSegment
+	4, // 4: xray.transport.internet.headers.custom.Segment.length:type_name -> xray.transport.internet.headers.custom.LengthSegment
+	5, // 5: xray.transport.internet.headers.custom.Config.segments:type_name -> xray.transport.internet.headers.custom.Segment
+	5, // 6: xray.transport.internet.headers.custom.ConnectionConfig.client:type_name -> xray.transport.internet.headers.custom.Segment
+	5, // 7: xray.transport.internet.headers.custom.ConnectionConfig.server:type_name -> xray.transport.internet.headers.custom.Segment
+	8, // [8:8] is the sub-list for method output_type
+	8, // [8:8] is the sub-list for method input_type
+	8, // [8:8] is the sub-list for extension type_name
+	8, // [8:8] is the sub-list for extension extendee
+	0, // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_transport_internet_headers_custom_config_proto_init() }
+func file_transport_internet_headers_custom_config_proto_init() {
+	if File_transport_internet_headers_custom_config_proto != nil {
+		return
+	}
+	file_transport_internet_headers_custom_config_proto_msgTypes[5].OneofWrappers = []any{
+		(*Segment_Constant)(nil),
+		(*Segment_Random)(nil),
+		(*Segment_Counter)(nil),
+		(*Segment_Timestamp)(nil),
+		(*Segment_Length)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_transport_internet_headers_custom_config_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_transport_internet_headers_custom_config_proto_goTypes,
+		DependencyIndexes: file_transport_internet_headers_custom_config_proto_depIdxs,
+		MessageInfos:      file_transport_internet_headers_custom_config_proto_msgTypes,
+	}.Build()
+	File_transport_internet_headers_custom_config_proto = out.File
+	file_transport_internet_headers_custom_config_proto_rawDesc = nil
+	file_transport_internet_headers_custom_config_proto_goTypes = nil
+	file_transport_internet_headers_custom_config_proto_depIdxs = nil
+}
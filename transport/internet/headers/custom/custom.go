@@ -0,0 +1,120 @@
+package custom
+
+import (
+	"context"
+	"io"
+	"net"
+
+	"github.com/xtls/xray-core/common"
+)
+
+// PacketHeader is a template-based packet header for mKCP.
+type PacketHeader struct {
+	template *Template
+}
+
+func (h *PacketHeader) Size() int32 {
+	return int32(h.template.Size())
+}
+
+// Serialize implements PacketHeader.
+func (h *PacketHeader) Serialize(b []byte) {
+	h.template.Render(b, 0)
+}
+
+// NewPacketHeader returns a new PacketHeader instance based on the given config.
+func NewPacketHeader(ctx context.Context, config interface{}) (interface{}, error) {
+	template, err := NewTemplate(config.(*Config).Segments)
+	if err != nil {
+		return nil, err
+	}
+	return &PacketHeader{
+		template: template,
+	}, nil
+}
+
+// Conn sends a rendered prefix before the first write, and drops a prefix of
+// fixed size before the first read.
+type Conn struct {
+	net.Conn
+
+	writeTemplate *Template
+	readSize      int
+}
+
+// Read implements io.Reader.
+func (c *Conn) Read(b []byte) (int, error) {
+	if c.readSize > 0 {
+		if _, err := io.CopyN(io.Discard, c.Conn, int64(c.readSize)); err != nil {
+			return 0, err
+		}
+		c.readSize = 0
+	}
+	return c.Conn.Read(b)
+}
+
+// Write implements io.Writer.
+func (c *Conn) Write(b []byte) (int, error) {
+	if c.writeTemplate != nil {
+		size := c.writeTemplate.Size()
+		data := make([]byte, size+len(b))
+		c.writeTemplate.Render(data, len(b))
+		copy(data[size:], b)
+		c.writeTemplate = nil
+		if _, err := c.Conn.Write(data); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	return c.Conn.Write(b)
+}
+
+// ConnectionAuthenticator is a template-based connection header for raw TCP.
+type ConnectionAuthenticator struct {
+	client *Template
+	server *Template
+}
+
+func newConn(conn net.Conn, write *Template, read *Template) net.Conn {
+	if write.Size() == 0 && read.Size() == 0 {
+		return conn
+	}
+	c := &Conn{
+		Conn:     conn,
+		readSize: read.Size(),
+	}
+	if write.Size() > 0 {
+		c.writeTemplate = write
+	}
+	return c
+}
+
+func (a *ConnectionAuthenticator) Client(conn net.Conn) net.Conn {
+	return newConn(conn, a.client, a.server)
+}
+
+func (a *ConnectionAuthenticator) Server(conn net.Conn) net.Conn {
+	return newConn(conn, a.server, a.client)
+}
+
+// NewConnectionAuthenticator returns a new ConnectionAuthenticator instance based on the given config.
+func NewConnectionAuthenticator(ctx context.Context, config interface{}) (interface{}, error) {
+	c := config.(*ConnectionConfig)
+	client, err := NewTemplate(c.Client)
+	if err != nil {
+		return nil, err
+	}
+	server, err := NewTemplate(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	return &ConnectionAuthenticator{
+		client: client,
+		server: server,
+	}, nil
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), NewPacketHeader))
+	common.Must(common.RegisterConfig((*ConnectionConfig)(nil), NewConnectionAuthenticator))
+}
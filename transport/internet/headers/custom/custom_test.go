@@ -0,0 +1,121 @@
+package custom_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	. "github.com/xtls/xray-core/transport/internet/headers/custom"
+)
+
+func TestPacketHeader(t *testing.T) {
+	config := &Config{
+		Segments: []*Segment{
+			{Value: &Segment_Constant{Constant: &ConstantSegment{Data: []byte{0xde, 0xad}}}},
+			{Value: &Segment_Random{Random: &RandomSegment{Length: 3, Min: 'a', Max: 'c'}}},
+			{Value: &Segment_Counter{Counter: &CounterSegment{Size: 2, Start: 7, Step: 2}}},
+			{Value: &Segment_Length{Length: &LengthSegment{Size: 1, Adjust: 1}}},
+			{Value: &Segment_Timestamp{Timestamp: &TimestampSegment{Size: 4}}},
+		},
+	}
+	headerRaw, err := NewPacketHeader(context.Background(), config)
+	common.Must(err)
+	header := headerRaw.(*PacketHeader)
+
+	if header.Size() != 12 {
+		t.Fatal("expected size 12, but got ", header.Size())
+	}
+
+	for i := 0; i < 2; i++ {
+		b := make([]byte, header.Size())
+		header.Serialize(b)
+		if !bytes.Equal(b[:2], []byte{0xde, 0xad}) {
+			t.Error("unexpected constant: ", b[:2])
+		}
+		for _, c := range b[2:5] {
+			if c < 'a' || c > 'c' {
+				t.Error("random byte out of range: ", c)
+			}
+		}
+		if counter := binary.BigEndian.Uint16(b[5:]); counter != uint16(7+2*i) {
+			t.Error("unexpected counter: ", counter)
+		}
+		if b[7] != 13 {
+			t.Error("unexpected length: ", b[7])
+		}
+		if binary.BigEndian.Uint32(b[8:]) == 0 {
+			t.Error("empty timestamp")
+		}
+	}
+}
+
+func TestInvalidSegment(t *testing.T) {
+	_, err := NewPacketHeader(context.Background(), &Config{
+		Segments: []*Segment{
+			{Value: &Segment_Counter{Counter: &CounterSegment{Size: 3}}},
+		},
+	})
+	if err == nil {
+		t.Error("expected error for 3-byte counter")
+	}
+}
+
+func TestConnectionHeader(t *testing.T) {
+	authRaw, err := NewConnectionAuthenticator(context.Background(), &ConnectionConfig{
+		Client: []*Segment{
+			{Value: &Segment_Constant{Constant: &ConstantSegment{Data: []byte("GET")}}},
+			{Value: &Segment_Length{Length: &LengthSegment{Size: 2}}},
+		},
+		Server: []*Segment{
+			{Value: &Segment_Random{Random: &RandomSegment{Length: 4}}},
+		},
+	})
+	common.Must(err)
+	auth := authRaw.(*ConnectionAuthenticator)
+
+	clientRaw, serverRaw := net.Pipe()
+	client := auth.Client(clientRaw)
+	server := auth.Server(serverRaw)
+
+	go func() {
+		common.Must2(client.Write([]byte("ping")))
+	}()
+	b := make([]byte, 4)
+	common.Must2(io.ReadFull(server, b))
+	if string(b) != "ping" {
+		t.Error("unexpected payload: ", string(b))
+	}
+
+	go func() {
+		common.Must2(server.Write([]byte("pong")))
+	}()
+	common.Must2(io.ReadFull(client, b))
+	if string(b) != "pong" {
+		t.Error("unexpected payload: ", string(b))
+	}
+}
+
+func TestConnectionHeaderLength(t *testing.T) {
+	authRaw, err := NewConnectionAuthenticator(context.Background(), &ConnectionConfig{
+		Client: []*Segment{
+			{Value: &Segment_Length{Length: &LengthSegment{Size: 2, Adjust: -2}}},
+		},
+	})
+	common.Must(err)
+	auth := authRaw.(*ConnectionAuthenticator)
+
+	clientRaw, serverRaw := net.Pipe()
+	client := auth.Client(clientRaw)
+	go func() {
+		common.Must2(client.Write([]byte("hello")))
+	}()
+	b := make([]byte, 7)
+	common.Must2(io.ReadFull(serverRaw, b))
+	if binary.BigEndian.Uint16(b) != 5 || string(b[2:]) != "hello" {
+		t.Error("unexpected data: ", b)
+	}
+}
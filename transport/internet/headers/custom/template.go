@@ -0,0 +1,209 @@
+package custom
+
+import (
+	"encoding/binary"
+	"sync/atomic"
+	"time"
+
+	"github.com/xtls/xray-core/common/dice"
+	"github.com/xtls/xray-core/common/errors"
+)
+
+type segment interface {
+	size() int
+	// render writes the segment into b. total is the value of length fields.
+	render(b []byte, total int)
+}
+
+func putUint(b []byte, v uint64, littleEndian bool) {
+	var order binary.ByteOrder = binary.BigEndian
+	if littleEndian {
+		order = binary.LittleEndian
+	}
+	switch len(b) {
+	case 1:
+		b[0] = byte(v)
+	case 2:
+		order.PutUint16(b, uint16(v))
+	case 4:
+		order.PutUint32(b, uint32(v))
+	case 8:
+		order.PutUint64(b, v)
+	}
+}
+
+func checkWidth(name string, size uint32, allowed ...uint32) error {
+	for _, s := range allowed {
+		if size == s {
+			return nil
+		}
+	}
+	return errors.New("invalid ", name, " size: ", size)
+}
+
+type constantSegment []byte
+
+func (s constantSegment) size() int {
+	return len(s)
+}
+
+func (s constantSegment) render(b []byte, _ int) {
+	copy(b, s)
+}
+
+type randomSegment struct {
+	length int
+	min    int
+	max    int
+}
+
+func (s *randomSegment) size() int {
+	return s.length
+}
+
+func (s *randomSegment) render(b []byte, _ int) {
+	for i := 0; i < s.length; i++ {
+		b[i] = byte(s.min + dice.Roll(s.max-s.min+1))
+	}
+}
+
+type counterSegment struct {
+	width        int
+	value        atomic.Uint64
+	step         uint64
+	littleEndian bool
+}
+
+func (s *counterSegment) size() int {
+	return s.width
+}
+
+func (s *counterSegment) render(b []byte, _ int) {
+	putUint(b[:s.width], s.value.Add(s.step)-s.step, s.littleEndian)
+}
+
+type timestampSegment struct {
+	width        int
+	milliseconds bool
+	littleEndian bool
+}
+
+func (s *timestampSegment) size() int {
+	return s.width
+}
+
+func (s *timestampSegment) render(b []byte, _ int) {
+	now := time.Now()
+	v := uint64(now.Unix())
+	if s.milliseconds {
+		v = uint64(now.UnixMilli())
+	}
+	putUint(b[:s.width], v, s.littleEndian)
+}
+
+type lengthSegment struct {
+	width        int
+	adjust       int
+	littleEndian bool
+}
+
+func (s *lengthSegment) size() int {
+	return s.width
+}
+
+func (s *lengthSegment) render(b []byte, total int) {
+	putUint(b[:s.width], uint64(total+s.adjust), s.littleEndian)
+}
+
+func newSegment(config *Segment) (segment, error) {
+	switch v := config.Value.(type) {
+	case *Segment_Constant:
+		return constantSegment(v.Constant.Data), nil
+	case *Segment_Random:
+		s := &randomSegment{
+			length: int(v.Random.Length),
+			min:    int(v.Random.Min),
+			max:    int(v.Random.Max),
+		}
+		if s.max == 0 {
+			s.max = 255
+		}
+		if s.max > 255 || s.min > s.max {
+			return nil, errors.New("invalid random range: ", s.min, "-", s.max)
+		}
+		return s, nil
+	case *Segment_Counter:
+		if err := checkWidth("counter", v.Counter.Size, 1, 2, 4, 8); err != nil {
+			return nil, err
+		}
+		s := &counterSegment{
+			width:        int(v.Counter.Size),
+			step:         v.Counter.Step,
+			littleEndian: v.Counter.LittleEndian,
+		}
+		if s.step == 0 {
+			s.step = 1
+		}
+		start := v.Counter.Start
+		if v.Counter.RandomStart {
+			start = dice.RollUint64()
+		}
+		s.value.Store(start)
+		return s, nil
+	case *Segment_Timestamp:
+		if err := checkWidth("timestamp", v.Timestamp.Size, 4, 8); err != nil {
+			return nil, err
+		}
+		return &timestampSegment{
+			width:        int(v.Timestamp.Size),
+			milliseconds: v.Timestamp.Milliseconds,
+			littleEndian: v.Timestamp.LittleEndian,
+		}, nil
+	case *Segment_Length:
+		if err := checkWidth("length", v.Length.Size, 1, 2, 4, 8); err != nil {
+			return nil, err
+		}
+		return &lengthSegment{
+			width:        int(v.Length.Size),
+			adjust:       int(v.Length.Adjust),
+			littleEndian: v.Length.LittleEndian,
+		}, nil
+	default:
+		return nil, errors.New("empty header segment")
+	}
+}
+
+// Template renders a fixed-size header from a list of segments.
+type Template struct {
+	segments []segment
+	size     int
+}
+
+// NewTemplate creates a Template from the given segment configs.
+func NewTemplate(configs []*Segment) (*Template, error) {
+	t := &Template{}
+	for i, config := range configs {
+		s, err := newSegment(config)
+		if err != nil {
+			return nil, errors.New("invalid header segment ", i).Base(err)
+		}
+		t.segments = append(t.segments, s)
+		t.size += s.size()
+	}
+	return t, nil
+}
+
+// Size returns the number of bytes rendered by the template.
+func (t *Template) Size() int {
+	return t.size
+}
+
+// Render writes the header into b, which must be at least Size() bytes long.
+// payloadLen is added to the value of length fields.
+func (t *Template) Render(b []byte, payloadLen int) {
+	offset := 0
+	for _, s := range t.segments {
+		s.render(b[offset:], t.size+payloadLen)
+		offset += s.size()
+	}
+}
@@ -1,10 +1,16 @@
 package httpupgrade
 
-import "net"
+import (
+	"net"
+
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/transport/internet/tls"
+)
 
 type connection struct {
 	net.Conn
 	remoteAddr net.Addr
+	handshake  *tls.Handshake
 }
 
 func newConnection(conn net.Conn, remoteAddr net.Addr) *connection {
@@ -17,3 +23,13 @@ func newConnection(conn net.Conn, remoteAddr net.Addr) *connection {
 func (c *connection) RemoteAddr() net.Addr {
 	return c.remoteAddr
 }
+
+// SNIProfile returns the name of the TLS SNI profile the connection matched, if any.
+func (c *connection) SNIProfile() string {
+	return c.handshake.SNIProfile()
+}
+
+// ClientUser returns the user the TLS client certificate of the connection maps to, if any.
+func (c *connection) ClientUser() *protocol.MemoryUser {
+	return c.handshake.ClientUser()
+}
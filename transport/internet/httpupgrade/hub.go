@@ -20,9 +20,11 @@ type server struct {
 	config         *Config
 	addConn        internet.ConnHandler
 	innnerListener net.Listener
+	tlsConfig      *tls.Config
 }
 
 func (s *server) Close() error {
+	v2tls.Release(s.tlsConfig)
 	return s.innnerListener.Close()
 }
 
@@ -79,7 +81,12 @@ func (s *server) Handle(conn net.Conn) (stat.Connection, error) {
 		}
 	}
 
-	return stat.Connection(newConnection(conn, remoteAddr)), nil
+	upgradedConn := newConnection(conn, remoteAddr)
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		state := tlsConn.ConnectionState()
+		upgradedConn.handshake = v2tls.NewHandshake(s.tlsConfig, &state)
+	}
+	return stat.Connection(upgradedConn), nil
 }
 
 func (s *server) keepAccepting() {
@@ -131,8 +138,9 @@ func ListenHTTPUpgrade(ctx context.Context, address net.Address, port net.Port,
 		errors.LogWarning(ctx, "accepting PROXY protocol")
 	}
 
+	var tlsConfig *tls.Config
 	if config := v2tls.ConfigFromStreamSettings(streamSettings); config != nil {
-		if tlsConfig := config.GetTLSConfig(); tlsConfig != nil {
+		if tlsConfig = config.GetTLSConfig(); tlsConfig != nil {
 			listener = tls.NewListener(listener, tlsConfig)
 		}
 	}
@@ -141,6 +149,7 @@ func ListenHTTPUpgrade(ctx context.Context, address net.Address, port net.Port,
 		config:         transportConfiguration,
 		addConn:        addConn,
 		innnerListener: listener,
+		tlsConfig:      tlsConfig,
 	}
 	go serverInstance.keepAccepting()
 	return serverInstance, nil
@@ -148,6 +148,7 @@ func (l *Listener) Remove(id ConnectionID) {
 
 // Close stops listening on the UDP address. Already Accepted connections are not closed.
 func (l *Listener) Close() error {
+	tls.Release(l.tlsConfig)
 	l.hub.Close()
 
 	l.Lock()
@@ -1,6 +1,10 @@
 package internet
 
-import "github.com/xtls/xray-core/common/net"
+import (
+	"context"
+
+	"github.com/xtls/xray-core/common/net"
+)
 
 // MemoryStreamConfig is a parsed form of StreamConfig. It is used to reduce the number of Protobuf parses.
 type MemoryStreamConfig struct {
@@ -11,6 +15,8 @@ type MemoryStreamConfig struct {
 	SecuritySettings interface{}
 	SocketSettings   *SocketConfig
 	DownloadSettings *MemoryStreamConfig
+	// AlternativeSettings are the parsed alternative endpoints of XHTTP.
+	AlternativeSettings []*MemoryStreamConfig
 }
 
 // ToMemoryStreamConfig converts a StreamConfig to MemoryStreamConfig. It returns a default non-nil MemoryStreamConfig for nil input.
@@ -47,3 +53,18 @@ func ToMemoryStreamConfig(s *StreamConfig) (*MemoryStreamConfig, error) {
 
 	return mss, nil
 }
+
+type streamSettingsKey struct{}
+
+// ContextWithStreamSettings returns a context carrying the stream settings of
+// the handler whose proxy is created with it.
+func ContextWithStreamSettings(ctx context.Context, s *MemoryStreamConfig) context.Context {
+	return context.WithValue(ctx, streamSettingsKey{}, s)
+}
+
+// StreamSettingsFromContext returns the stream settings of the handler of a
+// proxy being created, or nil if there are none.
+func StreamSettingsFromContext(ctx context.Context) *MemoryStreamConfig {
+	s, _ := ctx.Value(streamSettingsKey{}).(*MemoryStreamConfig)
+	return s
+}
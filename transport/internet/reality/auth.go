@@ -0,0 +1,287 @@
+package reality
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xtls/reality"
+	"github.com/xtls/xray-core/common/antireplay"
+	"github.com/xtls/xray-core/common/errors"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+const mlkem768KeySize = 1184
+
+// DeriveShortId returns the short ID derived from seed for the epoch of
+// period that contains t.
+func DeriveShortId(seed []byte, period time.Duration, t time.Time) []byte {
+	return deriveShortId(seed, t.Unix()/int64(period/time.Second))
+}
+
+// ShortIdEpoch returns the start and end of the epoch of period that
+// contains t.
+func ShortIdEpoch(period time.Duration, t time.Time) (time.Time, time.Time) {
+	seconds := int64(period / time.Second)
+	start := time.Unix(t.Unix()/seconds*seconds, 0)
+	return start, start.Add(period)
+}
+
+func deriveShortId(seed []byte, epoch int64) []byte {
+	mac := hmac.New(sha256.New, seed)
+	mac.Write([]byte("REALITY short ID"))
+	binary.Write(mac, binary.BigEndian, epoch)
+	return mac.Sum(nil)[:8]
+}
+
+// serverAuth checks the ClientHellos of a REALITY server against rotating
+// short IDs and a replay cache before REALITY authenticates them itself.
+type serverAuth struct {
+	base   *reality.Config
+	reject *reality.Config
+
+	seed    []byte
+	period  int64
+	overlap int64
+	epoch   atomic.Pointer[epochConfig]
+
+	replay *antireplay.ReplayFilter
+}
+
+type epochConfig struct {
+	key    string
+	config *reality.Config
+}
+
+var serverAuths sync.Map // *reality.Config -> *serverAuth
+
+func newServerAuth(c *Config, base *reality.Config) *serverAuth {
+	if len(c.ShortIdSeed) == 0 && !c.ReplayProtection {
+		return nil
+	}
+	a := &serverAuth{
+		base:   base,
+		reject: cloneConfig(base),
+	}
+	a.reject.ShortIds = make(map[[8]byte]bool)
+	if len(c.ShortIdSeed) > 0 && c.ShortIdPeriod > 0 {
+		a.seed = c.ShortIdSeed
+		a.period = int64(c.ShortIdPeriod)
+		a.overlap = min(int64(c.ShortIdOverlap), a.period/2)
+	}
+	if c.ReplayProtection {
+		if base.MaxTimeDiff > 0 {
+			// A ClientHello is accepted while the server clock is within
+			// MaxTimeDiff of its timestamp either way, and the filter keeps
+			// it for at least its interval.
+			interval := 2 * base.MaxTimeDiff
+			a.replay = antireplay.NewReplayFilter(int64((interval + time.Second - 1) / time.Second))
+		} else {
+			errors.LogError(context.Background(), "REALITY: replay protection requires a maxTimeDiff, disabling it")
+		}
+	}
+	return a
+}
+
+// cloneConfig clones config with all the fields REALITY uses on the server.
+func cloneConfig(config *reality.Config) *reality.Config {
+	clone := config.Clone()
+	clone.Mldsa65Key = config.Mldsa65Key
+	return clone
+}
+
+// epochConfig returns the config accepting the static short IDs and the
+// short IDs derived for now.
+func (a *serverAuth) epochConfig(now int64) *reality.Config {
+	if a.seed == nil {
+		return a.base
+	}
+	epoch := now / a.period
+	epochs := []int64{epoch}
+	if now-epoch*a.period < a.overlap {
+		epochs = append(epochs, epoch-1)
+	}
+	if (epoch+1)*a.period-now <= a.overlap {
+		epochs = append(epochs, epoch+1)
+	}
+	key := ""
+	for _, e := range epochs {
+		key += strconv.FormatInt(e, 10) + " "
+	}
+	if current := a.epoch.Load(); current != nil && current.key == key {
+		return current.config
+	}
+
+	config := cloneConfig(a.base)
+	config.ShortIds = make(map[[8]byte]bool, len(a.base.ShortIds)+len(epochs))
+	for id := range a.base.ShortIds {
+		config.ShortIds[id] = true
+	}
+	for _, e := range epochs {
+		config.ShortIds[[8]byte(deriveShortId(a.seed, e))] = true
+	}
+	a.epoch.Store(&epochConfig{key: key, config: config})
+	return config
+}
+
+// configFor returns the config REALITY handles a ClientHello with.
+func (a *serverAuth) configFor(record []byte) *reality.Config {
+	hello := parseClientHello(record)
+	if hello == nil || !hello.open(a.base.PrivateKey) {
+		return a.base
+	}
+	if a.replay != nil && !a.replay.Check(hello.random) {
+		errors.LogWarning(context.Background(), "REALITY: rejected replayed ClientHello")
+		return a.reject
+	}
+	return a.epochConfig(time.Now().Unix())
+}
+
+type clientHello struct {
+	raw       []byte
+	random    []byte
+	sessionId []byte
+	peerPub   []byte
+}
+
+// parseClientHello parses the ClientHello in a TLS record, if it is not
+// fragmented across several records.
+func parseClientHello(record []byte) *clientHello {
+	if len(record) < 5+4 || record[0] != 22 || record[5] != 1 {
+		return nil
+	}
+	raw := record[5:]
+	length := int(raw[1])<<16 | int(raw[2])<<8 | int(raw[3])
+	if len(raw) < 4+length {
+		return nil
+	}
+	raw = raw[:4+length]
+	hello := &clientHello{raw: raw}
+	b := raw[4:]
+	if len(b) < 2+32+1+32 || b[34] != 32 {
+		return nil
+	}
+	hello.random = b[2:34]
+	hello.sessionId = b[35:67]
+	b = b[67:]
+
+	skip := func(lengthSize int) bool {
+		if len(b) < lengthSize {
+			return false
+		}
+		n := 0
+		for _, v := range b[:lengthSize] {
+			n = n<<8 | int(v)
+		}
+		if len(b) < lengthSize+n {
+			return false
+		}
+		b = b[lengthSize+n:]
+		return true
+	}
+	if !skip(2) || !skip(1) || len(b) < 2 {
+		return nil
+	}
+	extensions := b[2:]
+	for len(extensions) >= 4 {
+		extType := binary.BigEndian.Uint16(extensions)
+		extLength := int(binary.BigEndian.Uint16(extensions[2:]))
+		if len(extensions) < 4+extLength {
+			return nil
+		}
+		data := extensions[4 : 4+extLength]
+		extensions = extensions[4+extLength:]
+		if extType != 51 || len(data) < 2 { // key_share
+			continue
+		}
+		data = data[2:]
+		var mlkemPub []byte
+		for len(data) >= 4 {
+			group := reality.CurveID(binary.BigEndian.Uint16(data))
+			keyLength := int(binary.BigEndian.Uint16(data[2:]))
+			if len(data) < 4+keyLength {
+				return nil
+			}
+			key := data[4 : 4+keyLength]
+			data = data[4+keyLength:]
+			if group == reality.X25519 && keyLength == 32 && hello.peerPub == nil {
+				hello.peerPub = key
+			}
+			if group == reality.X25519MLKEM768 && keyLength == mlkem768KeySize+32 && mlkemPub == nil {
+				mlkemPub = key[mlkem768KeySize:]
+			}
+		}
+		if hello.peerPub == nil {
+			hello.peerPub = mlkemPub
+		}
+	}
+	if hello.peerPub == nil {
+		return nil
+	}
+	return hello
+}
+
+// open decrypts the session ID the way REALITY does, and reports whether it
+// was sealed for privateKey.
+func (h *clientHello) open(privateKey []byte) bool {
+	authKey, err := curve25519.X25519(privateKey, h.peerPub)
+	if err != nil {
+		return false
+	}
+	if _, err := io.ReadFull(hkdf.New(sha256.New, authKey, h.random[:20], []byte("REALITY")), authKey); err != nil {
+		return false
+	}
+	block, _ := aes.NewCipher(authKey)
+	aead, _ := cipher.NewGCM(block)
+	aad := append([]byte(nil), h.raw...)
+	clear(aad[39 : 39+32])
+	_, err = aead.Open(nil, h.random[20:], h.sessionId, aad)
+	return err == nil
+}
+
+// readRecord reads the first TLS record of r.
+func readRecord(r io.Reader) ([]byte, error) {
+	record := make([]byte, 5)
+	if n, err := io.ReadFull(r, record); err != nil {
+		return record[:n], err
+	}
+	length := int(binary.BigEndian.Uint16(record[3:]))
+	if record[0] != 22 || length > 1<<14 {
+		return record, nil
+	}
+	record = append(record, make([]byte, length)...)
+	n, err := io.ReadFull(r, record[5:])
+	return record[:5+n], err
+}
+
+// peekedConn replays the bytes peeked from Conn before reading from it again.
+type peekedConn struct {
+	net.Conn
+	peeked []byte
+}
+
+func (c *peekedConn) Read(b []byte) (int, error) {
+	if len(c.peeked) > 0 {
+		n := copy(b, c.peeked)
+		c.peeked = c.peeked[n:]
+		return n, nil
+	}
+	return c.Conn.Read(b)
+}
+
+func (c *peekedConn) CloseWrite() error {
+	if conn, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return conn.CloseWrite()
+	}
+	return c.Conn.Close()
+}
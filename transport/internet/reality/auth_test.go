@@ -0,0 +1,132 @@
+package reality
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	gotls "crypto/tls"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/xtls/reality"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol/tls/cert"
+)
+
+type recordingConn struct {
+	net.Conn
+	written bytes.Buffer
+}
+
+func (c *recordingConn) Write(b []byte) (int, error) {
+	c.written.Write(b)
+	return c.Conn.Write(b)
+}
+
+func TestShortIdRotationAndReplay(t *testing.T) {
+	certPEM, keyPEM := cert.MustGenerate(nil, cert.DNSNames("example.com")).ToPEM()
+	keyPair, err := gotls.X509KeyPair(certPEM, keyPEM)
+	common.Must(err)
+	target, err := gotls.Listen("tcp", "127.0.0.1:0", &gotls.Config{
+		Certificates: []gotls.Certificate{keyPair},
+		NextProtos:   []string{"h2"},
+	})
+	common.Must(err)
+	defer target.Close()
+	go func() {
+		for {
+			conn, err := target.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(io.Discard, conn)
+				conn.Close()
+			}()
+		}
+	}()
+
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	common.Must(err)
+	seed := []byte("short id seed")
+	period := time.Hour
+	config := &Config{
+		Dest:             target.Addr().String(),
+		Type:             "tcp",
+		ServerNames:      []string{"example.com"},
+		PrivateKey:       privateKey.Bytes(),
+		ShortIdSeed:      seed,
+		ShortIdPeriod:    uint64(period / time.Second),
+		MaxTimeDiff:      uint64(time.Minute / time.Millisecond),
+		ReplayProtection: true,
+	}
+	serverConfig := config.GetREALITYConfig()
+	reality.DetectPostHandshakeRecordsLens(serverConfig)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				if conn, err := Server(conn, serverConfig); err == nil {
+					conn.Write([]byte("ok"))
+					conn.Close()
+				}
+			}()
+		}
+	}()
+
+	dial := func(shortId []byte) (*recordingConn, error) {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		common.Must(err)
+		recording := &recordingConn{Conn: conn}
+		_, err = UClient(recording, &Config{
+			Fingerprint: "chrome",
+			ServerName:  "example.com",
+			PublicKey:   privateKey.PublicKey().Bytes(),
+			ShortId:     shortId,
+			SpiderY:     make([]int64, 10),
+		}, context.Background(), net.TCPDestination(net.DomainAddress("example.com"), 443))
+		conn.Close()
+		return recording, err
+	}
+
+	recording, err := dial(DeriveShortId(seed, period, time.Now()))
+	if err != nil {
+		t.Fatal("current short ID rejected: ", err)
+	}
+	if _, err := dial(DeriveShortId(seed, period, time.Now().Add(-2*period))); err == nil {
+		t.Error("expired short ID accepted")
+	}
+
+	auth, _ := serverAuths.Load(serverConfig)
+	record, err := readRecord(&recording.written)
+	common.Must(err)
+	if auth.(*serverAuth).configFor(record) != auth.(*serverAuth).reject {
+		t.Error("replayed ClientHello accepted")
+	}
+}
+
+func TestReplayFilterCoversMaxTimeDiff(t *testing.T) {
+	config := &Config{
+		ReplayProtection: true,
+		MaxTimeDiff:      uint64(90 * time.Second / time.Millisecond),
+	}
+	a := newServerAuth(config, &reality.Config{MaxTimeDiff: 90 * time.Second})
+	if a.replay == nil || a.replay.Interval() != 180 {
+		t.Error("replay filter does not cover the accepted time difference")
+	}
+
+	config.MaxTimeDiff = 0
+	if a := newServerAuth(config, &reality.Config{}); a != nil && a.replay != nil {
+		t.Error("replay filter without maxTimeDiff")
+	}
+}
@@ -47,6 +47,10 @@ func (c *Config) GetREALITYConfig() *reality.Config {
 		config.LimitFallbackDownload.BytesPerSec = c.LimitFallbackDownload.BytesPerSec
 		config.LimitFallbackDownload.BurstBytesPerSec = c.LimitFallbackDownload.BurstBytesPerSec
 	}
+	if monitor := acquireTargetMonitor(c); monitor != nil {
+		config.DialContext = monitor.dialContext(dialer.DialContext)
+		targetListeners.Store(config, monitor)
+	}
 	config.ServerNames = make(map[string]bool)
 	for _, serverName := range c.ServerNames {
 		config.ServerNames[serverName] = true
@@ -55,9 +59,25 @@ func (c *Config) GetREALITYConfig() *reality.Config {
 	for _, shortId := range c.ShortIds {
 		config.ShortIds[*(*[8]byte)(shortId)] = true
 	}
+	if auth := newServerAuth(c, config); auth != nil {
+		serverAuths.Store(config, auth)
+	}
 	return config
 }
 
+// Release stops what GetREALITYConfig started for config, once the listener
+// that used it is closed: the monitor of its targets stops when no other
+// listener uses it.
+func Release(config *reality.Config) {
+	if config == nil {
+		return
+	}
+	serverAuths.Delete(config)
+	if monitor, found := targetListeners.LoadAndDelete(config); found {
+		monitor.(*targetMonitor).release()
+	}
+}
+
 func KeyLogWriterFromConfig(c *Config) io.Writer {
 	if len(c.MasterKeyLog) <= 0 || c.MasterKeyLog == "none" {
 		return nil
@@ -49,10 +49,62 @@ func (c *Conn) HandshakeAddress() net.Address {
 }
 
 func Server(c net.Conn, config *reality.Config) (net.Conn, error) {
+	if auth, found := serverAuths.Load(config); found {
+		record, err := readRecord(c)
+		if err == nil {
+			config = auth.(*serverAuth).configFor(record)
+		}
+		c = &peekedConn{Conn: c, peeked: record}
+	}
 	realityConn, err := reality.Server(context.Background(), c, config)
 	return &Conn{Conn: realityConn}, err
 }
 
+type listener struct {
+	net.Listener
+	config *reality.Config
+	conns  chan net.Conn
+	err    error
+}
+
+// NewListener is like reality.NewListener, but checks ClientHellos the way
+// Server does.
+func NewListener(inner net.Listener, config *reality.Config) net.Listener {
+	if _, found := serverAuths.Load(config); !found {
+		return reality.NewListener(inner, config)
+	}
+	go reality.DetectPostHandshakeRecordsLens(config)
+	l := &listener{
+		Listener: inner,
+		config:   config,
+		conns:    make(chan net.Conn),
+	}
+	go func() {
+		for {
+			c, err := l.Listener.Accept()
+			if err != nil {
+				l.err = err
+				close(l.conns)
+				return
+			}
+			go func() {
+				defer func() { recover() }()
+				if conn, err := Server(c, l.config); err == nil {
+					l.conns <- conn
+				}
+			}()
+		}
+	}()
+	return l
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	if c, ok := <-l.conns; ok {
+		return c, nil
+	}
+	return nil, l.err
+}
+
 type UConn struct {
 	*utls.UConn
 	Config     *Config
@@ -0,0 +1,288 @@
+package reality
+
+import (
+	"context"
+	gotls "crypto/tls"
+	"crypto/x509"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+)
+
+const (
+	defaultTargetCheckInterval = 5 * time.Minute
+	targetCheckTimeout         = 10 * time.Second
+)
+
+// TargetStatus is the health of a REALITY target, as of its last check.
+type TargetStatus struct {
+	Dest        string
+	ServerNames []string
+	Alive       bool
+	Active      bool
+	Delay       time.Duration
+	LastError   string
+	LastTry     time.Time
+	LastSeen    time.Time
+}
+
+type target struct {
+	dest        string
+	serverNames []string
+
+	access sync.Mutex
+	status TargetStatus
+}
+
+// targetMonitor checks the candidate targets of a REALITY server in the
+// background, and has fallback traffic go to the first healthy one. Listeners
+// with the same targets share a monitor, which stops once all of them are
+// closed.
+type targetMonitor struct {
+	key      string
+	network  string
+	targets  []*target
+	interval time.Duration
+	active   atomic.Pointer[target]
+
+	// guarded by targetMonitors
+	refs   int
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+var targetMonitors = struct {
+	sync.Mutex
+	m map[string]*targetMonitor
+}{m: make(map[string]*targetMonitor)}
+
+// targetListeners holds the monitor of each REALITY config returned by
+// GetREALITYConfig, until Release.
+var targetListeners sync.Map // *reality.Config -> *targetMonitor
+
+// acquireTargetMonitor returns the running monitor of the targets of c,
+// starting it if no listener uses it yet, or nil if c has no candidate
+// targets. Since the server accepts the same server names whichever target
+// is active, only candidates that serve all of them are failed over to.
+func acquireTargetMonitor(c *Config) *targetMonitor {
+	targets := []*target{{dest: c.Dest, serverNames: c.ServerNames}}
+	for _, t := range c.Targets {
+		if !servesAll(t.ServerNames, c.ServerNames) {
+			errors.LogError(context.Background(), "REALITY: ignoring target ", t.Dest, " that does not serve all server names")
+			continue
+		}
+		targets = append(targets, &target{dest: t.Dest, serverNames: t.ServerNames})
+	}
+	if len(targets) == 1 {
+		return nil
+	}
+	interval := time.Duration(c.TargetCheckInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultTargetCheckInterval
+	}
+	keys := make([]string, len(targets))
+	for i, t := range targets {
+		keys[i] = t.dest + "=" + strings.Join(t.serverNames, ",")
+	}
+	key := c.Type + "|" + strings.Join(keys, "|")
+
+	targetMonitors.Lock()
+	defer targetMonitors.Unlock()
+	m, found := targetMonitors.m[key]
+	if !found {
+		m = &targetMonitor{
+			key:      key,
+			network:  c.Type,
+			targets:  targets,
+			interval: interval,
+		}
+		m.ctx, m.cancel = context.WithCancel(context.Background())
+		m.active.Store(targets[0])
+		targetMonitors.m[key] = m
+		go m.run()
+	}
+	m.refs++
+	return m
+}
+
+func (m *targetMonitor) release() {
+	targetMonitors.Lock()
+	defer targetMonitors.Unlock()
+
+	if m.refs--; m.refs > 0 {
+		return
+	}
+	delete(targetMonitors.m, m.key)
+	m.cancel()
+}
+
+func (m *targetMonitor) run() {
+	for {
+		m.checkAll(nil)
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-time.After(m.interval):
+		}
+	}
+}
+
+// checkAll checks the targets, against the system roots if roots is nil,
+// and switches to the first healthy one.
+func (m *targetMonitor) checkAll(roots *x509.CertPool) {
+	var wg sync.WaitGroup
+	for _, t := range m.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := m.check(t, roots)
+			t.access.Lock()
+			t.status.LastTry = start
+			t.status.Alive = err == nil
+			if err == nil {
+				t.status.Delay = time.Since(start)
+				t.status.LastSeen = start
+				t.status.LastError = ""
+			} else {
+				t.status.LastError = err.Error()
+			}
+			t.access.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	current := m.active.Load()
+	for _, t := range m.targets {
+		if !t.alive() {
+			continue
+		}
+		if t != current {
+			errors.LogWarning(context.Background(), "REALITY: switching target from ", current.dest, " to ", t.dest)
+			m.active.Store(t)
+		}
+		return
+	}
+	errors.LogError(context.Background(), "REALITY: no healthy target, keeping ", current.dest)
+}
+
+// check connects to the target the way a REALITY client would expect it to
+// behave: TLS 1.3 with X25519 and H2, and a valid certificate for every
+// server name.
+func (m *targetMonitor) check(t *target, roots *x509.CertPool) error {
+	serverName := ""
+	for _, name := range t.serverNames {
+		if name != "" {
+			serverName = name
+			break
+		}
+	}
+	if serverName == "" {
+		host, _, err := net.SplitHostPort(t.dest)
+		if err != nil {
+			return errors.New("no server name to check ", t.dest)
+		}
+		serverName = host
+	}
+
+	ctx, cancel := context.WithTimeout(m.ctx, targetCheckTimeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, m.network, t.dest)
+	if err != nil {
+		return errors.New("failed to dial ", t.dest).Base(err)
+	}
+	defer conn.Close()
+	tlsConn := gotls.Client(conn, &gotls.Config{
+		ServerName:       serverName,
+		RootCAs:          roots,
+		MinVersion:       gotls.VersionTLS13,
+		CurvePreferences: []gotls.CurveID{gotls.X25519},
+		NextProtos:       []string{"h2"},
+	})
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return errors.New("TLS 1.3 handshake with X25519 failed with ", t.dest).Base(err)
+	}
+	state := tlsConn.ConnectionState()
+	if state.NegotiatedProtocol != "h2" {
+		return errors.New(t.dest, " does not support H2")
+	}
+	leaf := state.PeerCertificates[0]
+	for _, name := range t.serverNames {
+		if name == "" {
+			continue
+		}
+		if err := leaf.VerifyHostname(name); err != nil {
+			return errors.New("certificate of ", t.dest, " is invalid for ", name).Base(err)
+		}
+	}
+	if time.Until(leaf.NotAfter) < m.interval {
+		return errors.New("certificate of ", t.dest, " expires at ", leaf.NotAfter)
+	}
+	return nil
+}
+
+func (t *target) alive() bool {
+	t.access.Lock()
+	defer t.access.Unlock()
+	return t.status.Alive
+}
+
+// dialContext dials the active target, whatever address REALITY asks for.
+func (m *targetMonitor) dialContext(dial func(ctx context.Context, network, address string) (net.Conn, error)) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		return dial(ctx, network, m.active.Load().dest)
+	}
+}
+
+// servesAll reports whether a target with the server names serves all the
+// server names the REALITY server accepts.
+func servesAll(serverNames, accepted []string) bool {
+	for _, name := range accepted {
+		if !slices.Contains(serverNames, name) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *targetMonitor) statuses() []TargetStatus {
+	active := m.active.Load()
+	statuses := make([]TargetStatus, len(m.targets))
+	for i, t := range m.targets {
+		t.access.Lock()
+		statuses[i] = t.status
+		t.access.Unlock()
+		statuses[i].Dest = t.dest
+		statuses[i].ServerNames = t.serverNames
+		statuses[i].Active = t == active
+	}
+	return statuses
+}
+
+// TargetStatuses returns the health of the targets of all REALITY servers
+// with candidate targets.
+func TargetStatuses() []TargetStatus {
+	targetMonitors.Lock()
+	keys := make([]string, 0, len(targetMonitors.m))
+	for key := range targetMonitors.m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	monitors := make([]*targetMonitor, len(keys))
+	for i, key := range keys {
+		monitors[i] = targetMonitors.m[key]
+	}
+	targetMonitors.Unlock()
+
+	var statuses []TargetStatus
+	for _, m := range monitors {
+		statuses = append(statuses, m.statuses()...)
+	}
+	return statuses
+}
@@ -0,0 +1,135 @@
+package reality
+
+import (
+	"context"
+	gotls "crypto/tls"
+	"crypto/x509"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/protocol/tls/cert"
+)
+
+func TestTargetMonitor(t *testing.T) {
+	ca := cert.MustGenerate(nil, cert.Authority(true), cert.CommonName("Test CA"), cert.KeyUsage(x509.KeyUsageCertSign))
+	caCert, err := x509.ParseCertificate(ca.Certificate)
+	common.Must(err)
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+
+	serve := func(serverName string, nextProtos ...string) string {
+		certPEM, keyPEM := cert.MustGenerate(ca, cert.DNSNames(serverName)).ToPEM()
+		keyPair, err := gotls.X509KeyPair(certPEM, keyPEM)
+		common.Must(err)
+		listener, err := gotls.Listen("tcp", "127.0.0.1:0", &gotls.Config{
+			Certificates: []gotls.Certificate{keyPair},
+			NextProtos:   nextProtos,
+		})
+		common.Must(err)
+		t.Cleanup(func() { listener.Close() })
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				conn.(*gotls.Conn).Handshake()
+				conn.Close()
+			}
+		}()
+		return listener.Addr().String()
+	}
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	closed.Close()
+
+	m := &targetMonitor{
+		network: "tcp",
+		targets: []*target{
+			{dest: closed.Addr().String(), serverNames: []string{"down.example.com"}},
+			{dest: serve("http.example.com"), serverNames: []string{"http.example.com"}},
+			{dest: serve("wrong.example.com", "h2"), serverNames: []string{"other.example.com"}},
+			{dest: serve("good.example.com", "h2"), serverNames: []string{"good.example.com"}},
+		},
+		interval: defaultTargetCheckInterval,
+		ctx:      context.Background(),
+	}
+	m.active.Store(m.targets[0])
+	m.checkAll(roots)
+
+	statuses := m.statuses()
+	for i, reason := range []string{"failed to dial", "does not support H2", "handshake", ""} {
+		if !strings.Contains(statuses[i].LastError, reason) || (reason == "") != statuses[i].Alive {
+			t.Error("unexpected status of target ", i, ": ", statuses[i].Alive, " ", statuses[i].LastError)
+		}
+	}
+	if !statuses[3].Active {
+		t.Error("healthy target is not active")
+	}
+
+	var dialed string
+	m.dialContext(func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialed = address
+		return nil, nil
+	})(context.Background(), "tcp", m.targets[0].dest)
+	if dialed != m.targets[3].dest {
+		t.Error("dialed ", dialed, " instead of the active target")
+	}
+}
+
+func TestTargetsShareServerNames(t *testing.T) {
+	m := acquireTargetMonitor(&Config{
+		Type:        "tcp",
+		Dest:        "127.0.0.1:1",
+		ServerNames: []string{"example.com", "www.example.com"},
+		Targets: []*Target{
+			{Dest: "127.0.0.1:2", ServerNames: []string{"www.example.com", "example.com", "cdn.example.com"}},
+			{Dest: "127.0.0.1:3", ServerNames: []string{"example.com"}},
+			{Dest: "127.0.0.1:4", ServerNames: []string{"other.example.com"}},
+		},
+	})
+	if m == nil || len(m.targets) != 2 || m.targets[1].dest != "127.0.0.1:2" {
+		t.Fatal("targets not serving all server names are failed over to")
+	}
+	m.release()
+
+	if acquireTargetMonitor(&Config{
+		Type:        "tcp",
+		Dest:        "127.0.0.1:5",
+		ServerNames: []string{"example.com"},
+		Targets:     []*Target{{Dest: "127.0.0.1:6", ServerNames: []string{"other.example.com"}}},
+	}) != nil {
+		t.Error("monitor without candidate targets")
+	}
+}
+
+func TestTargetMonitorRelease(t *testing.T) {
+	config := &Config{
+		Type:        "tcp",
+		Dest:        "127.0.0.1:1",
+		ServerNames: []string{"example.com"},
+		Targets:     []*Target{{Dest: "127.0.0.1:2", ServerNames: []string{"example.com"}}},
+	}
+	first := config.GetREALITYConfig()
+	second := config.GetREALITYConfig()
+	m, _ := targetListeners.Load(first)
+	monitor := m.(*targetMonitor)
+	if m, _ := targetListeners.Load(second); m != monitor {
+		t.Fatal("listeners with the same targets do not share a monitor")
+	}
+
+	Release(first)
+	if monitor.ctx.Err() != nil {
+		t.Error("monitor stopped while a listener still uses it")
+	}
+	Release(second)
+	if monitor.ctx.Err() == nil {
+		t.Error("monitor still running after all its listeners are closed")
+	}
+	if len(TargetStatuses()) != 0 {
+		t.Error("statuses of a stopped monitor reported")
+	}
+}
@@ -72,6 +72,8 @@ func (c *DefaultDialerClient) OpenStream(ctx context.Context, url string, body i
 			if !uploadOnly { // stream-down is enough
 				c.closed = true
 				errors.LogInfoInner(ctx, err, "failed to "+method+" "+url)
+			} else {
+				wrc.(*WaitReadCloser).Err = err
 			}
 			gotConn.Close()
 			wrc.Close()
@@ -79,9 +81,12 @@ func (c *DefaultDialerClient) OpenStream(ctx context.Context, url string, body i
 		}
 		if resp.StatusCode != 200 && !uploadOnly {
 			errors.LogInfo(ctx, "unexpected status ", resp.StatusCode)
+			wrc.(*WaitReadCloser).Err = errors.New("unexpected status ", resp.StatusCode)
 		}
 		if resp.StatusCode != 200 || uploadOnly { // stream-up
-			io.Copy(io.Discard, resp.Body)
+			if _, err := io.Copy(io.Discard, resp.Body); err != nil && uploadOnly {
+				wrc.(*WaitReadCloser).Err = err
+			}
 			resp.Body.Close() // if it is called immediately, the upload will be interrupted also
 			wrc.Close()
 			return
@@ -175,6 +180,9 @@ func (c *DefaultDialerClient) PostPacket(ctx context.Context, url string, body i
 type WaitReadCloser struct {
 	Wait chan struct{}
 	io.ReadCloser
+	// Err is returned by Read if the server rejected the request. For
+	// stream-up requests, it is the error that broke the request.
+	Err error
 }
 
 func (w *WaitReadCloser) Set(rc io.ReadCloser) {
@@ -190,6 +198,9 @@ func (w *WaitReadCloser) Set(rc io.ReadCloser) {
 func (w *WaitReadCloser) Read(b []byte) (int, error) {
 	if w.ReadCloser == nil {
 		if <-w.Wait; w.ReadCloser == nil {
+			if w.Err != nil {
+				return 0, w.Err
+			}
 			return 0, io.ErrClosedPipe
 		}
 	}
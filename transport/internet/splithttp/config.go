@@ -121,6 +121,14 @@ func (c *Config) GetNormalizedScStreamUpServerSecs() RangeConfig {
 	return *c.ScMinPostsIntervalMs
 }
 
+func (c *Config) GetNormalizedScResumeBufferBytes() int {
+	if c.ScResumeBufferBytes <= 0 {
+		return 1 << 20
+	}
+
+	return int(c.ScResumeBufferBytes)
+}
+
 func (m *XmuxConfig) GetNormalizedMaxConcurrency() RangeConfig {
 	if m.MaxConcurrency == nil {
 		return RangeConfig{
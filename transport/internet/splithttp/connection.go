@@ -4,6 +4,9 @@ import (
 	"io"
 	"net"
 	"time"
+
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/transport/internet/tls"
 )
 
 type splitConn struct {
@@ -12,6 +15,7 @@ type splitConn struct {
 	remoteAddr net.Addr
 	localAddr  net.Addr
 	onClose    func()
+	handshake  *tls.Handshake
 }
 
 func (c *splitConn) Write(b []byte) (int, error) {
@@ -62,3 +66,13 @@ func (c *splitConn) SetWriteDeadline(t time.Time) error {
 	// TODO cannot do anything useful
 	return nil
 }
+
+// SNIProfile returns the name of the TLS SNI profile the connection matched, if any.
+func (c *splitConn) SNIProfile() string {
+	return c.handshake.SNIProfile()
+}
+
+// ClientUser returns the user the TLS client certificate of the connection maps to, if any.
+func (c *splitConn) ClientUser() *protocol.MemoryUser {
+	return c.handshake.ClientUser()
+}
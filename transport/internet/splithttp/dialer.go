@@ -1,6 +1,7 @@
 package splithttp
 
 import (
+	"bytes"
 	"context"
 	gotls "crypto/tls"
 	"fmt"
@@ -294,6 +295,7 @@ func Dial(ctx context.Context, dest net.Destination, streamSettings *internet.Me
 	requestURL2 := requestURL
 	httpClient2 := httpClient
 	xmuxClient2 := xmuxClient
+	download := &xhttpEndpoint{dest: dest, streamSettings: streamSettings, requestURL: requestURL}
 	if transportConfiguration.DownloadSettings != nil {
 		globalDialerAccess.Lock()
 		if streamSettings.DownloadSettings == nil {
@@ -304,33 +306,39 @@ func Dial(ctx context.Context, dest net.Destination, streamSettings *internet.Me
 		}
 		globalDialerAccess.Unlock()
 		memory2 := streamSettings.DownloadSettings
-		dest2 := *memory2.Destination // just panic
-		tlsConfig2 := tls.ConfigFromStreamSettings(memory2)
-		realityConfig2 := reality.ConfigFromStreamSettings(memory2)
-		httpVersion2 := decideHTTPVersion(tlsConfig2, realityConfig2)
-		if httpVersion2 == "3" {
-			dest2.Network = net.Network_UDP
-		}
-		if tlsConfig2 != nil || realityConfig2 != nil {
-			requestURL2.Scheme = "https"
-		} else {
-			requestURL2.Scheme = "http"
-		}
-		config2 := memory2.ProtocolSettings.(*Config)
-		requestURL2.Host = config2.Host
-		if requestURL2.Host == "" && tlsConfig2 != nil {
-			requestURL2.Host = tlsConfig2.ServerName
-		}
-		if requestURL2.Host == "" && realityConfig2 != nil {
-			requestURL2.Host = realityConfig2.ServerName
+		var httpVersion2 string
+		download, httpVersion2 = newXHTTPEndpoint(*memory2.Destination, memory2, sessionIdUuid.String()) // just panic
+		requestURL2 = download.requestURL
+		httpClient2, xmuxClient2 = getHTTPClient(ctx, download.dest, memory2)
+		errors.LogInfo(ctx, fmt.Sprintf("XHTTP is downloading from %s, mode %s, HTTP version %s, host %s", download.dest, "stream-down", httpVersion2, requestURL2.Host))
+	}
+
+	// alternative endpoints to resume broken legs with, after the original ones
+	upload := &xhttpEndpoint{dest: dest, streamSettings: streamSettings, requestURL: requestURL}
+	downloads := &endpointList{endpoints: []*xhttpEndpoint{download}}
+	uploads := &endpointList{endpoints: []*xhttpEndpoint{upload}}
+	resumeAttempts := int(transportConfiguration.ResumeAttempts)
+	if _, ok := httpClient2.(*DefaultDialerClient); !ok || mode == "stream-one" {
+		resumeAttempts = 0
+	}
+	if resumeAttempts > 0 {
+		globalDialerAccess.Lock()
+		if streamSettings.AlternativeSettings == nil {
+			for _, alternative := range transportConfiguration.AlternativeSettings {
+				memory := common.Must2(internet.ToMemoryStreamConfig(alternative))
+				if streamSettings.SocketSettings != nil && streamSettings.SocketSettings.Penetrate {
+					memory.SocketSettings = streamSettings.SocketSettings
+				}
+				streamSettings.AlternativeSettings = append(streamSettings.AlternativeSettings, memory)
+			}
 		}
-		if requestURL2.Host == "" {
-			requestURL2.Host = dest2.Address.String()
+		globalDialerAccess.Unlock()
+		for _, memory := range streamSettings.AlternativeSettings {
+			endpoint, _ := newXHTTPEndpoint(*memory.Destination, memory, sessionIdUuid.String())
+			downloads.endpoints = append(downloads.endpoints, endpoint)
+			uploads.endpoints = append(uploads.endpoints, endpoint)
 		}
-		requestURL2.Path = config2.GetNormalizedPath() + sessionIdUuid.String()
-		requestURL2.RawQuery = config2.GetNormalizedQuery()
-		httpClient2, xmuxClient2 = getHTTPClient(ctx, dest2, memory2)
-		errors.LogInfo(ctx, fmt.Sprintf("XHTTP is downloading from %s, mode %s, HTTP version %s, host %s", dest2, "stream-down", httpVersion2, requestURL2.Host))
+		requestURL2.RawQuery += "&x_resumable=1"
 	}
 
 	if xmuxClient != nil {
@@ -376,8 +384,68 @@ func Dial(ctx context.Context, dest net.Destination, streamSettings *internet.Me
 		if err != nil { // browser dialer only
 			return nil, err
 		}
+		if resumeAttempts > 0 {
+			var picked int
+			conn.reader = &resumingReader{
+				current:  conn.reader,
+				attempts: resumeAttempts,
+				open: func(offset uint64, attempt int) io.ReadCloser {
+					var endpoint *xhttpEndpoint
+					picked, endpoint = downloads.get(attempt - 1)
+					url := endpoint.requestURL
+					url.RawQuery += "&x_resume=" + strconv.FormatUint(offset, 10)
+					httpClient, xmuxClient := getHTTPClient(ctx, endpoint.dest, endpoint.streamSettings)
+					if xmuxClient != nil {
+						xmuxClient.LeftRequests.Add(-1)
+					}
+					errors.LogInfo(ctx, "XHTTP is resuming download from ", endpoint.dest, " at ", offset)
+					reader, _, _, _ := httpClient.OpenStream(ctx, url.String(), nil, false)
+					return reader
+				},
+				resumed: func() {
+					downloads.use(picked)
+				},
+			}
+		}
 	}
 	if mode == "stream-up" {
+		if resumeAttempts > 0 {
+			requestURL.RawQuery += "&x_resumable=1"
+			var picked int
+			var w *resumingWriter
+			w = newResumingWriter(transportConfiguration.GetNormalizedScResumeBufferBytes(), resumeAttempts, func(offset uint64, attempt int) io.WriteCloser {
+				endpoint, httpClient, xmuxClient := upload, httpClient, xmuxClient
+				url := requestURL
+				if attempt > 0 {
+					picked, endpoint = uploads.get(attempt - 1)
+					url = endpoint.requestURL
+					url.RawQuery += "&x_resume=" + strconv.FormatUint(offset, 10)
+					httpClient, xmuxClient = getHTTPClient(ctx, endpoint.dest, endpoint.streamSettings)
+					errors.LogInfo(ctx, "XHTTP is resuming upload to ", endpoint.dest, " at ", offset)
+				}
+				if xmuxClient != nil {
+					xmuxClient.LeftRequests.Add(-1)
+				}
+				reader, writer := io.Pipe()
+				wrc, _, _, _ := httpClient.OpenStream(ctx, url.String(), reader, true)
+				// resume as soon as the request breaks, the data it took may
+				// never have arrived
+				go func() {
+					wrc := wrc.(*WaitReadCloser)
+					<-wrc.Wait
+					if wrc.Err != nil {
+						reader.CloseWithError(wrc.Err)
+						w.resume(writer, wrc.Err)
+					}
+				}()
+				return writer
+			}, func() {
+				uploads.use(picked)
+			})
+			w.current = w.open(0, 0)
+			conn.writer = w
+			return stat.Connection(&conn), nil
+		}
 		if xmuxClient != nil {
 			xmuxClient.LeftRequests.Add(-1)
 		}
@@ -420,10 +488,19 @@ func Dial(ctx context.Context, dest net.Destination, streamSettings *internet.Me
 				},
 			})
 
+			if resumeAttempts > 0 {
+				// keep using the endpoint that the last upload went through
+				if _, endpoint := uploads.get(0); endpoint != upload {
+					upload = endpoint
+					httpClient, xmuxClient = getHTTPClient(ctx, upload.dest, upload.streamSettings)
+				}
+			}
+
 			// this intentionally makes a shallow-copy of the struct so we
 			// can reassign Path (potentially concurrently)
-			url := requestURL
-			url.Path += "/" + strconv.FormatInt(seq, 10)
+			seqPath := "/" + strconv.FormatInt(seq, 10)
+			url := upload.requestURL
+			url.Path += seqPath
 
 			seq += 1
 
@@ -443,10 +520,15 @@ func Dial(ctx context.Context, dest net.Destination, streamSettings *internet.Me
 
 			if xmuxClient != nil && (xmuxClient.LeftRequests.Add(-1) <= 0 ||
 				(xmuxClient.UnreusableAt != time.Time{} && lastWrite.After(xmuxClient.UnreusableAt))) {
-				httpClient, xmuxClient = getHTTPClient(ctx, dest, streamSettings)
+				httpClient, xmuxClient = getHTTPClient(ctx, upload.dest, upload.streamSettings)
 			}
 
 			go func() {
+				var payload []byte
+				if resumeAttempts > 0 {
+					payload = make([]byte, chunk.Len())
+					chunk.Copy(payload)
+				}
 				err := httpClient.PostPacket(
 					ctx,
 					url.String(),
@@ -454,6 +536,19 @@ func Dial(ctx context.Context, dest net.Destination, streamSettings *internet.Me
 					int64(chunk.Len()),
 				)
 				wroteRequest.Close()
+				for attempt := 1; err != nil && attempt <= resumeAttempts; attempt++ {
+					errors.LogInfoInner(ctx, err, "failed to send upload, retrying")
+					i, endpoint := uploads.get(attempt - 1)
+					url := endpoint.requestURL
+					url.Path += seqPath
+					httpClient, xmuxClient := getHTTPClient(ctx, endpoint.dest, endpoint.streamSettings)
+					if xmuxClient != nil {
+						xmuxClient.LeftRequests.Add(-1)
+					}
+					if err = httpClient.PostPacket(ctx, url.String(), bytes.NewReader(payload), int64(len(payload))); err == nil {
+						uploads.use(i)
+					}
+				}
 				if err != nil {
 					errors.LogInfoInner(ctx, err, "failed to send upload")
 					uploadPipeReader.Interrupt()
@@ -469,6 +564,45 @@ func Dial(ctx context.Context, dest net.Destination, streamSettings *internet.Me
 	return stat.Connection(&conn), nil
 }
 
+// xhttpEndpoint is the server one leg of an XHTTP session is sent to.
+type xhttpEndpoint struct {
+	dest           net.Destination
+	streamSettings *internet.MemoryStreamConfig
+	requestURL     url.URL
+}
+
+func newXHTTPEndpoint(dest net.Destination, streamSettings *internet.MemoryStreamConfig, sessionId string) (*xhttpEndpoint, string) {
+	tlsConfig := tls.ConfigFromStreamSettings(streamSettings)
+	realityConfig := reality.ConfigFromStreamSettings(streamSettings)
+	httpVersion := decideHTTPVersion(tlsConfig, realityConfig)
+	if httpVersion == "3" {
+		dest.Network = net.Network_UDP
+	}
+	endpoint := &xhttpEndpoint{
+		dest:           dest,
+		streamSettings: streamSettings,
+	}
+	if tlsConfig != nil || realityConfig != nil {
+		endpoint.requestURL.Scheme = "https"
+	} else {
+		endpoint.requestURL.Scheme = "http"
+	}
+	config := streamSettings.ProtocolSettings.(*Config)
+	endpoint.requestURL.Host = config.Host
+	if endpoint.requestURL.Host == "" && tlsConfig != nil {
+		endpoint.requestURL.Host = tlsConfig.ServerName
+	}
+	if endpoint.requestURL.Host == "" && realityConfig != nil {
+		endpoint.requestURL.Host = realityConfig.ServerName
+	}
+	if endpoint.requestURL.Host == "" {
+		endpoint.requestURL.Host = dest.Address.String()
+	}
+	endpoint.requestURL.Path = config.GetNormalizedPath() + sessionId
+	endpoint.requestURL.RawQuery = config.GetNormalizedQuery()
+	return endpoint, httpVersion
+}
+
 // A wrapper around pipe that ensures the size limit is exactly honored.
 //
 // The MultiBuffer pipe accepts any single WriteMultiBuffer call even if that
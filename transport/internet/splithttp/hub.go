@@ -43,6 +43,10 @@ type httpSession struct {
 	// after the client connects, this becomes "done" and the session lives as
 	// long as the GET request.
 	isFullyConnected *done.Instance
+	// set by the first download request of a resumable session
+	download *resumableWriter
+	// set by the first stream-up request of a resumable session
+	upload *resumableReader
 }
 
 func (h *requestHandler) upsertSession(sessionId string) *httpSession {
@@ -162,6 +166,17 @@ func (h *requestHandler) ServeHTTP(writer http.ResponseWriter, request *http.Req
 		}
 	}
 
+	if resume := request.URL.Query().Get("x_resume"); resume != "" && sessionId != "" {
+		switch request.Method {
+		case "GET":
+			h.resumeDownload(writer, request, sessionId, resume)
+			return
+		case "POST":
+			h.resumeUpload(writer, request, sessionId, resume, referrer != "")
+			return
+		}
+	}
+
 	var currentSession *httpSession
 	if sessionId != "" {
 		currentSession = h.upsertSession(sessionId)
@@ -180,6 +195,10 @@ func (h *requestHandler) ServeHTTP(writer http.ResponseWriter, request *http.Req
 				writer.WriteHeader(http.StatusBadRequest)
 				return
 			}
+			if h.config.ScSessionGraceSecs > 0 && request.URL.Query().Get("x_resumable") != "" {
+				h.serveResumableUpload(writer, request, sessionId, currentSession, referrer != "")
+				return
+			}
 			httpSC := &httpServerConn{
 				Instance:       done.New(),
 				Reader:         request.Body,
@@ -255,6 +274,8 @@ func (h *requestHandler) ServeHTTP(writer http.ResponseWriter, request *http.Req
 		}
 
 		writer.WriteHeader(http.StatusOK)
+	} else if request.Method == "GET" && sessionId != "" && h.config.ScSessionGraceSecs > 0 && request.URL.Query().Get("x_resumable") != "" { // resumable stream-down
+		h.serveResumableDownload(writer, request, sessionId, currentSession, remoteAddr)
 	} else if request.Method == "GET" || sessionId == "" { // stream-down, stream-one
 		if sessionId != "" {
 			// after GET is done, the connection is finished. disable automatic
@@ -288,6 +309,7 @@ func (h *requestHandler) ServeHTTP(writer http.ResponseWriter, request *http.Req
 			reader:     httpSC,
 			remoteAddr: remoteAddr,
 			localAddr:  h.localAddr,
+			handshake:  tls.NewHandshake(h.ln.tlsConfig, request.TLS),
 		}
 		if sessionId != "" { // if not stream-one
 			conn.reader = currentSession.uploadQueue
@@ -308,6 +330,194 @@ func (h *requestHandler) ServeHTTP(writer http.ResponseWriter, request *http.Req
 	}
 }
 
+// setDownloadHeader sets the same headers as a regular stream-down response.
+// The status is sent with the first write of the resumable writer.
+func setDownloadHeader(writer http.ResponseWriter, config *Config) {
+	writer.Header().Set("X-Accel-Buffering", "no")
+	writer.Header().Set("Cache-Control", "no-store")
+	if !config.NoSSEHeader {
+		writer.Header().Set("Content-Type", "text/event-stream")
+	}
+}
+
+// serveResumableDownload serves the first download request of a resumable
+// session. The connection handed to the inbound outlives this request, and
+// is only closed by the inbound or when no download request is attached
+// within the grace period.
+func (h *requestHandler) serveResumableDownload(writer http.ResponseWriter, request *http.Request, sessionId string, session *httpSession, remoteAddr net.Addr) {
+	h.sessionMu.Lock()
+	if session.download != nil {
+		h.sessionMu.Unlock()
+		errors.LogInfo(context.Background(), "resumable session already has a download request")
+		writer.WriteHeader(http.StatusConflict)
+		return
+	}
+	conn := &splitConn{
+		reader:     session.uploadQueue,
+		remoteAddr: remoteAddr,
+		localAddr:  h.localAddr,
+		onClose: func() {
+			h.sessions.Delete(sessionId)
+		},
+		handshake: tls.NewHandshake(h.ln.tlsConfig, request.TLS),
+	}
+	session.download = newResumableWriter(h.config.GetNormalizedScResumeBufferBytes(), time.Duration(h.config.ScSessionGraceSecs)*time.Second, func() {
+		errors.LogInfo(context.Background(), "XHTTP session ", sessionId, " was not resumed in time")
+		conn.Close()
+	})
+	conn.writer = session.download
+	h.sessionMu.Unlock()
+
+	// the session now lives as long as the resumable writer
+	session.isFullyConnected.Close()
+
+	setDownloadHeader(writer, h.config)
+	httpSC := &httpServerConn{
+		Instance:       done.New(),
+		Reader:         request.Body,
+		ResponseWriter: writer,
+	}
+	if err := session.download.attach(httpSC, 0); err != nil {
+		errors.LogInfoInner(context.Background(), err, "failed to start resumable XHTTP session ", sessionId)
+		conn.Close()
+		return
+	}
+
+	h.ln.addConn(stat.Connection(conn))
+
+	select {
+	case <-request.Context().Done():
+	case <-httpSC.Wait():
+	}
+	session.download.detach(httpSC)
+}
+
+// resumeDownload attaches a new download request to a resumable session
+// whose previous download request broke, starting from the given offset.
+func (h *requestHandler) resumeDownload(writer http.ResponseWriter, request *http.Request, sessionId string, resume string) {
+	offset, err := strconv.ParseUint(resume, 10, 64)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var download *resumableWriter
+	if sessionAny, ok := h.sessions.Load(sessionId); ok {
+		h.sessionMu.Lock()
+		download = sessionAny.(*httpSession).download
+		h.sessionMu.Unlock()
+	}
+	if download == nil {
+		errors.LogInfo(context.Background(), "failed to resume unknown XHTTP session ", sessionId)
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	setDownloadHeader(writer, h.config)
+	httpSC := &httpServerConn{
+		Instance:       done.New(),
+		Reader:         request.Body,
+		ResponseWriter: writer,
+	}
+	if err := download.attach(httpSC, offset); err != nil {
+		errors.LogInfoInner(context.Background(), err, "failed to resume XHTTP session ", sessionId, " from ", offset)
+		if err == errResumeRejected {
+			writer.WriteHeader(http.StatusGone)
+		}
+		return
+	}
+	errors.LogDebug(context.Background(), "resumed XHTTP session ", sessionId, " from ", offset)
+
+	select {
+	case <-request.Context().Done():
+	case <-httpSC.Wait():
+	}
+	download.detach(httpSC)
+}
+
+// serveResumableUpload serves the first stream-up request of a resumable
+// session. The upload continues with the requests that resume it, until the
+// client closes it or no request is attached within the grace period.
+func (h *requestHandler) serveResumableUpload(writer http.ResponseWriter, request *http.Request, sessionId string, session *httpSession, padding bool) {
+	h.sessionMu.Lock()
+	if session.upload != nil {
+		h.sessionMu.Unlock()
+		errors.LogInfo(context.Background(), "resumable session already has an upload request")
+		writer.WriteHeader(http.StatusConflict)
+		return
+	}
+	upload := newResumableReader(time.Duration(h.config.ScSessionGraceSecs) * time.Second)
+	if err := session.uploadQueue.Push(Packet{Reader: upload}); err != nil {
+		h.sessionMu.Unlock()
+		errors.LogInfoInner(context.Background(), err, "failed to upload (PushReader)")
+		writer.WriteHeader(http.StatusConflict)
+		return
+	}
+	session.upload = upload
+	h.sessionMu.Unlock()
+
+	h.serveUpload(writer, request, upload, 0, padding)
+}
+
+// resumeUpload attaches a new stream-up request to a resumable session whose
+// previous upload request broke. The request starts at the given offset.
+func (h *requestHandler) resumeUpload(writer http.ResponseWriter, request *http.Request, sessionId string, resume string, padding bool) {
+	offset, err := strconv.ParseUint(resume, 10, 64)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var upload *resumableReader
+	if sessionAny, ok := h.sessions.Load(sessionId); ok {
+		h.sessionMu.Lock()
+		upload = sessionAny.(*httpSession).upload
+		h.sessionMu.Unlock()
+	}
+	if upload == nil {
+		errors.LogInfo(context.Background(), "failed to resume unknown XHTTP session ", sessionId)
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+	errors.LogDebug(context.Background(), "resuming upload of XHTTP session ", sessionId, " from ", offset)
+	h.serveUpload(writer, request, upload, offset, padding)
+}
+
+// serveUpload reads a stream-up request into upload, like a regular stream-up
+// request is read into the upload queue.
+func (h *requestHandler) serveUpload(writer http.ResponseWriter, request *http.Request, upload *resumableReader, offset uint64, padding bool) {
+	attachment, err := upload.attach(request.Body, offset)
+	if err != nil {
+		errors.LogInfoInner(context.Background(), err, "failed to attach XHTTP upload at ", offset)
+		writer.WriteHeader(http.StatusGone)
+		return
+	}
+	httpSC := &httpServerConn{
+		Instance:       done.New(),
+		Reader:         request.Body,
+		ResponseWriter: writer,
+	}
+	writer.Header().Set("X-Accel-Buffering", "no")
+	writer.Header().Set("Cache-Control", "no-store")
+	writer.WriteHeader(http.StatusOK)
+	scStreamUpServerSecs := h.config.GetNormalizedScStreamUpServerSecs()
+	if padding && scStreamUpServerSecs.To > 0 {
+		go func() {
+			for {
+				_, err := httpSC.Write(bytes.Repeat([]byte{'X'}, int(h.config.GetNormalizedXPaddingBytes().rand())))
+				if err != nil {
+					break
+				}
+				time.Sleep(time.Duration(scStreamUpServerSecs.rand()) * time.Second)
+			}
+		}()
+	}
+	select {
+	case <-request.Context().Done():
+	case <-attachment.done.Wait():
+	}
+	httpSC.Close()
+	upload.detach(attachment)
+}
+
 type httpServerConn struct {
 	sync.Mutex
 	*done.Instance
@@ -336,13 +546,15 @@ func (c *httpServerConn) Close() error {
 
 type Listener struct {
 	sync.Mutex
-	server     http.Server
-	h3server   *http3.Server
-	listener   net.Listener
-	h3listener *quic.EarlyListener
-	config     *Config
-	addConn    internet.ConnHandler
-	isH3       bool
+	server        http.Server
+	h3server      *http3.Server
+	listener      net.Listener
+	h3listener    *quic.EarlyListener
+	tlsConfig     *gotls.Config
+	realityConfig *goreality.Config
+	config        *Config
+	addConn       internet.ConnHandler
+	isH3          bool
 }
 
 func ListenXH(ctx context.Context, address net.Address, port net.Port, streamSettings *internet.MemoryStreamConfig, addConn internet.ConnHandler) (internet.Listener, error) {
@@ -364,6 +576,7 @@ func ListenXH(ctx context.Context, address net.Address, port net.Port, streamSet
 		sessions:  sync.Map{},
 	}
 	tlsConfig := getTLSConfig(streamSettings)
+	l.tlsConfig = tlsConfig
 	l.isH3 = len(tlsConfig.NextProtos) == 1 && tlsConfig.NextProtos[0] == "h3"
 
 	var err error
@@ -384,7 +597,14 @@ func ListenXH(ctx context.Context, address net.Address, port net.Port, streamSet
 		if err != nil {
 			return nil, errors.New("failed to listen UDP for XHTTP/3 on ", address, ":", port).Base(err)
 		}
-		l.h3listener, err = quic.ListenEarly(Conn, tlsConfig, nil)
+		quicConfig := &quic.Config{
+			MaxIdleTimeout: net.ConnIdleTimeout,
+			// the default of quic-go is 100, which is lower than what
+			// net/http allows for h2 and can stall xmux clients that
+			// multiplex many packet-up requests on the same connection.
+			MaxIncomingStreams: 250,
+		}
+		l.h3listener, err = quic.ListenEarly(Conn, tlsConfig, quicConfig)
 		if err != nil {
 			return nil, errors.New("failed to listen QUIC for XHTTP/3 on ", address, ":", port).Base(err)
 		}
@@ -413,13 +633,12 @@ func ListenXH(ctx context.Context, address net.Address, port net.Port, streamSet
 
 	// tcp/unix (h1/h2)
 	if l.listener != nil {
-		if config := tls.ConfigFromStreamSettings(streamSettings); config != nil {
-			if tlsConfig := config.GetTLSConfig(); tlsConfig != nil {
-				l.listener = gotls.NewListener(l.listener, tlsConfig)
-			}
+		if tls.ConfigFromStreamSettings(streamSettings) != nil {
+			l.listener = gotls.NewListener(l.listener, tlsConfig)
 		}
 		if config := reality.ConfigFromStreamSettings(streamSettings); config != nil {
-			l.listener = goreality.NewListener(l.listener, config.GetREALITYConfig())
+			l.realityConfig = config.GetREALITYConfig()
+			l.listener = reality.NewListener(l.listener, l.realityConfig)
 		}
 
 		handler.localAddr = l.listener.Addr()
@@ -457,10 +676,10 @@ func (ln *Listener) Addr() net.Addr {
 
 // Close implements net.Listener.Close().
 func (ln *Listener) Close() error {
+	tls.Release(ln.tlsConfig)
+	reality.Release(ln.realityConfig)
 	if ln.h3server != nil {
-		if err := ln.h3server.Close(); err != nil {
-			return err
-		}
+		return ln.h3server.Close()
 	} else if ln.listener != nil {
 		return ln.listener.Close()
 	}
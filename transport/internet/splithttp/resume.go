@@ -0,0 +1,524 @@
+package splithttp
+
+// resume.go keeps a logical XHTTP session alive across broken requests. The
+// sender of each stream-down and stream-up leg remembers the tail of the
+// stream, and a new request continues it from the last byte the receiver
+// got.
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/signal/done"
+)
+
+var errResumeRejected = errors.New("session can not be resumed")
+
+// resumableWriter is the download side of a resumable session on the server.
+// Writes go to the currently attached download request, and the last bytes
+// are kept in a ring buffer so that a new request can replay them. While no
+// request is attached, writes block, and the session expires after the grace
+// period.
+type resumableWriter struct {
+	sync.Mutex
+	cond      *sync.Cond
+	buffer    *buf.Ring
+	current   *httpServerConn
+	attaching *httpServerConn
+	grace     time.Duration
+	timer     *time.Timer
+	closed    *done.Instance
+	onExpire  func()
+}
+
+func newResumableWriter(bufferSize int, grace time.Duration, onExpire func()) *resumableWriter {
+	w := &resumableWriter{
+		buffer:   buf.NewRing(bufferSize),
+		grace:    grace,
+		closed:   done.New(),
+		onExpire: onExpire,
+	}
+	w.cond = sync.NewCond(&w.Mutex)
+	return w
+}
+
+func (w *resumableWriter) Write(b []byte) (int, error) {
+	w.Lock()
+	for w.current == nil && !w.closed.Done() {
+		w.cond.Wait()
+	}
+	if w.closed.Done() {
+		w.Unlock()
+		return 0, io.ErrClosedPipe
+	}
+	w.buffer.Write(b)
+	current := w.current
+	w.Unlock()
+
+	// not holding the lock, so that a stuck request does not block a new one
+	// from attaching
+	if _, err := current.Write(b); err != nil {
+		// the data is in the ring buffer and will be replayed on resume
+		w.detach(current)
+	}
+	return len(b), nil
+}
+
+// attach makes c the current download request, replaying everything the
+// client has not received yet.
+func (w *resumableWriter) attach(c *httpServerConn, offset uint64) error {
+	w.Lock()
+	if w.closed.Done() {
+		w.Unlock()
+		return errResumeRejected
+	}
+	if _, ok := w.buffer.Since(offset); !ok {
+		w.Unlock()
+		return errResumeRejected
+	}
+	previous := w.current
+	w.current = nil
+	w.attaching = c
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.Unlock()
+	if previous != nil {
+		// closing waits for a write in progress, which may be stuck
+		go previous.Close()
+	}
+
+	// writes wait while the replay catches up, and c becomes current once
+	// nothing is left to replay. An empty write still sends the response
+	// header.
+	for first := true; ; first = false {
+		w.Lock()
+		if w.attaching != c || w.closed.Done() {
+			w.Unlock()
+			c.Close()
+			return errResumeRejected
+		}
+		pending, ok := w.buffer.Since(offset)
+		if !ok {
+			w.attaching = nil
+			w.expireLater()
+			w.Unlock()
+			return errResumeRejected
+		}
+		if len(pending) == 0 && !first {
+			w.attaching = nil
+			w.current = c
+			w.cond.Broadcast()
+			w.Unlock()
+			return nil
+		}
+		w.Unlock()
+
+		if _, err := c.Write(pending); err != nil {
+			w.Lock()
+			if w.attaching == c {
+				w.attaching = nil
+				w.expireLater()
+			}
+			w.Unlock()
+			c.Close()
+			return err
+		}
+		offset += uint64(len(pending))
+	}
+}
+
+func (w *resumableWriter) detach(c *httpServerConn) {
+	w.Lock()
+	defer w.Unlock()
+	if w.current != c || w.closed.Done() {
+		return
+	}
+	w.current = nil
+	c.Close()
+	w.expireLater()
+}
+
+// expireLater expires the session unless a request attaches within the
+// grace period.
+func (w *resumableWriter) expireLater() {
+	if w.closed.Done() {
+		return
+	}
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(w.grace, func() {
+		w.Lock()
+		expired := w.current == nil && w.attaching == nil && !w.closed.Done()
+		w.Unlock()
+		if expired {
+			w.onExpire()
+		}
+	})
+}
+
+func (w *resumableWriter) Close() error {
+	w.Lock()
+	if w.closed.Done() {
+		w.Unlock()
+		return nil
+	}
+	w.closed.Close()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	current := w.current
+	w.current = nil
+	w.cond.Broadcast()
+	w.Unlock()
+
+	if current != nil {
+		current.Close()
+	}
+	return nil
+}
+
+// uploadAttachment is a stream-up request attached to a resumableReader.
+type uploadAttachment struct {
+	body   io.ReadCloser
+	offset uint64
+	done   *done.Instance
+}
+
+// resumableReader is the upload side of a resumable stream-up session on the
+// server. It reads from the currently attached upload request and counts
+// the bytes received. When the request breaks, reads block until a new one
+// attaches, and the session expires after the grace period. A new request
+// starts at an offset the client still has, and what the server already
+// received is skipped.
+type resumableReader struct {
+	sync.Mutex
+	cond     *sync.Cond
+	received uint64
+	current  *uploadAttachment
+	grace    time.Duration
+	timer    *time.Timer
+	expired  bool
+	closed   *done.Instance
+}
+
+func newResumableReader(grace time.Duration) *resumableReader {
+	r := &resumableReader{
+		grace:  grace,
+		closed: done.New(),
+	}
+	r.cond = sync.NewCond(&r.Mutex)
+	return r
+}
+
+func (r *resumableReader) Read(b []byte) (int, error) {
+	for {
+		r.Lock()
+		for r.current == nil && !r.expired && !r.closed.Done() {
+			r.cond.Wait()
+		}
+		if r.closed.Done() {
+			r.Unlock()
+			return 0, io.EOF
+		}
+		if r.expired {
+			r.Unlock()
+			return 0, errors.New("XHTTP upload was not resumed in time")
+		}
+		a := r.current
+		r.Unlock()
+
+		n, err := a.body.Read(b)
+
+		r.Lock()
+		// a request may repeat what an earlier one delivered
+		if skip := r.received - min(r.received, a.offset); skip > 0 {
+			skipped := int(min(skip, uint64(n)))
+			n = copy(b, b[skipped:n])
+			a.offset += uint64(skipped)
+		}
+		a.offset += uint64(n)
+		r.received += uint64(n)
+		if err != nil && r.current == a {
+			if err == io.EOF {
+				// the client closed the upload
+				r.Unlock()
+				if n > 0 {
+					return n, nil
+				}
+				return 0, io.EOF
+			}
+			r.current = nil
+			a.done.Close()
+			r.expireLater()
+		}
+		r.Unlock()
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// attach makes body the current upload request, which starts at offset.
+func (r *resumableReader) attach(body io.ReadCloser, offset uint64) (*uploadAttachment, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.closed.Done() || r.expired || offset > r.received {
+		return nil, errResumeRejected
+	}
+	if r.current != nil {
+		r.current.body.Close()
+		r.current.done.Close()
+	}
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	r.current = &uploadAttachment{
+		body:   body,
+		offset: offset,
+		done:   done.New(),
+	}
+	r.cond.Broadcast()
+	return r.current, nil
+}
+
+func (r *resumableReader) detach(a *uploadAttachment) {
+	r.Lock()
+	defer r.Unlock()
+	if r.current != a || r.closed.Done() {
+		return
+	}
+	r.current = nil
+	a.body.Close()
+	a.done.Close()
+	r.expireLater()
+}
+
+func (r *resumableReader) expireLater() {
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.timer = time.AfterFunc(r.grace, func() {
+		r.Lock()
+		defer r.Unlock()
+		if r.current == nil {
+			r.expired = true
+			r.cond.Broadcast()
+		}
+	})
+}
+
+func (r *resumableReader) Close() error {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.closed.Done() {
+		return nil
+	}
+	r.closed.Close()
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	if r.current != nil {
+		r.current.body.Close()
+		r.current.done.Close()
+		r.current = nil
+	}
+	r.cond.Broadcast()
+	return nil
+}
+
+// resumingReader is the download side of a resumable session on the client.
+// When the current download request breaks, it opens a new one from the
+// last received byte, trying the next endpoint on each failed attempt.
+type resumingReader struct {
+	sync.Mutex
+	current  io.ReadCloser
+	offset   uint64
+	attempts int
+	open     func(offset uint64, attempt int) io.ReadCloser
+	resumed  func()
+	closed   bool
+}
+
+func (r *resumingReader) Read(b []byte) (int, error) {
+	n, err := r.current.Read(b)
+	r.offset += uint64(n)
+	if n > 0 || err == nil {
+		return n, nil
+	}
+
+	broken := err
+	for attempt := 1; attempt <= r.attempts; attempt++ {
+		r.Lock()
+		if r.closed {
+			r.Unlock()
+			return 0, broken
+		}
+		r.current.Close()
+		r.current = r.open(r.offset, attempt)
+		r.Unlock()
+
+		n, err = r.current.Read(b)
+		r.offset += uint64(n)
+		if n > 0 || err == nil {
+			r.resumed()
+			return n, nil
+		}
+		if wrc, ok := r.current.(*WaitReadCloser); ok && wrc.Err != nil {
+			// the server does not know the session anymore, which is how
+			// sessions that ended look as well
+			return 0, broken
+		}
+		time.Sleep(time.Duration(attempt) * 200 * time.Millisecond)
+	}
+	return 0, errors.New("failed to resume XHTTP download after ", r.attempts, " attempts").Base(broken)
+}
+
+func (r *resumingReader) Close() error {
+	r.Lock()
+	defer r.Unlock()
+	r.closed = true
+	return r.current.Close()
+}
+
+// resumingWriter is the upload side of a resumable stream-up session on the
+// client. It keeps the last bytes written, and when the current upload
+// request breaks, it opens a new one from the oldest byte it still has,
+// trying the next endpoint on each failed attempt. The server skips what it
+// already received. Writes wait while a request is being resumed.
+type resumingWriter struct {
+	sync.Mutex
+	cond     *sync.Cond
+	buffer   *buf.Ring
+	current  io.WriteCloser
+	resuming bool
+	err      error
+	closed   bool
+	attempts int
+	// open starts an upload request, attempt 0 being the first one
+	open    func(offset uint64, attempt int) io.WriteCloser
+	resumed func()
+}
+
+func newResumingWriter(bufferSize int, attempts int, open func(offset uint64, attempt int) io.WriteCloser, resumed func()) *resumingWriter {
+	w := &resumingWriter{
+		buffer:   buf.NewRing(bufferSize),
+		attempts: attempts,
+		open:     open,
+		resumed:  resumed,
+	}
+	w.cond = sync.NewCond(&w.Mutex)
+	return w
+}
+
+func (w *resumingWriter) Write(b []byte) (int, error) {
+	w.Lock()
+	for w.resuming {
+		w.cond.Wait()
+	}
+	if w.closed {
+		w.Unlock()
+		return 0, io.ErrClosedPipe
+	}
+	if w.err != nil {
+		w.Unlock()
+		return 0, w.err
+	}
+	w.buffer.Write(b)
+	current := w.current
+	w.Unlock()
+
+	if _, err := current.Write(b); err != nil {
+		if err := w.resume(current, err); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// resume replaces the broken upload request with a new one, unless that
+// happened already.
+func (w *resumingWriter) resume(broken io.WriteCloser, cause error) error {
+	w.Lock()
+	for w.resuming {
+		w.cond.Wait()
+	}
+	if w.current != broken || w.closed || w.err != nil {
+		err := w.err
+		w.Unlock()
+		return err
+	}
+	w.resuming = true
+	w.Unlock()
+	broken.Close()
+
+	var current io.WriteCloser
+	for attempt := 1; attempt <= w.attempts; attempt++ {
+		w.Lock()
+		if w.closed {
+			w.Unlock()
+			break
+		}
+		offset := w.buffer.Start()
+		pending, _ := w.buffer.Since(offset)
+		w.Unlock()
+
+		current = w.open(offset, attempt)
+		if _, err := current.Write(pending); err == nil {
+			break
+		}
+		current.Close()
+		current = nil
+		time.Sleep(time.Duration(attempt) * 200 * time.Millisecond)
+	}
+
+	w.Lock()
+	defer w.Unlock()
+	w.resuming = false
+	w.cond.Broadcast()
+	if w.closed {
+		if current != nil {
+			current.Close()
+		}
+		return io.ErrClosedPipe
+	}
+	if current == nil {
+		w.err = errors.New("failed to resume XHTTP upload after ", w.attempts, " attempts").Base(cause)
+		return w.err
+	}
+	w.current = current
+	w.resumed()
+	return nil
+}
+
+func (w *resumingWriter) Close() error {
+	w.Lock()
+	defer w.Unlock()
+	w.closed = true
+	return w.current.Close()
+}
+
+// endpointList holds the endpoints one leg of a resumable session can use.
+// Attempts start from the endpoint that worked last.
+type endpointList struct {
+	endpoints []*xhttpEndpoint
+	current   atomic.Int32
+}
+
+func (l *endpointList) get(n int) (int, *xhttpEndpoint) {
+	i := (int(l.current.Load()) + n) % len(l.endpoints)
+	return i, l.endpoints[i]
+}
+
+func (l *endpointList) use(i int) {
+	l.current.Store(int32(i))
+}
@@ -0,0 +1,125 @@
+package splithttp
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/signal/done"
+)
+
+// stuckResponseWriter blocks writes until released, like a request whose
+// connection stopped reading.
+type stuckResponseWriter struct {
+	http.ResponseWriter
+	release chan struct{}
+}
+
+func (w *stuckResponseWriter) Write(b []byte) (int, error) {
+	<-w.release
+	return len(b), nil
+}
+
+func (w *stuckResponseWriter) Flush() {}
+
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	access sync.Mutex
+	data   bytes.Buffer
+}
+
+func (w *recordingResponseWriter) Write(b []byte) (int, error) {
+	w.access.Lock()
+	defer w.access.Unlock()
+	return w.data.Write(b)
+}
+
+func (w *recordingResponseWriter) Flush() {}
+
+func TestResumableWriterAttachDuringStuckWrite(t *testing.T) {
+	w := newResumableWriter(1024, time.Minute, func() {})
+	defer w.Close()
+
+	stuck := &stuckResponseWriter{release: make(chan struct{})}
+	go func() {
+		// the first write sends the header, the following one gets stuck
+		stuck.release <- struct{}{}
+	}()
+	common.Must(w.attach(&httpServerConn{Instance: done.New(), ResponseWriter: stuck}, 0))
+
+	written := make(chan struct{})
+	go func() {
+		w.Write([]byte("hello"))
+		close(written)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	next := &recordingResponseWriter{}
+	attached := make(chan error)
+	go func() {
+		attached <- w.attach(&httpServerConn{Instance: done.New(), ResponseWriter: next}, 0)
+	}()
+	select {
+	case err := <-attached:
+		common.Must(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("attach is blocked by a stuck write")
+	}
+	close(stuck.release)
+	<-written
+
+	common.Must2(w.Write([]byte(" world")))
+	next.access.Lock()
+	defer next.access.Unlock()
+	if s := next.data.String(); s != "hello world" {
+		t.Error("replayed ", s)
+	}
+}
+
+func TestResumableReaderSkipsReceived(t *testing.T) {
+	r := newResumableReader(time.Minute)
+	defer r.Close()
+
+	first, err := r.attach(&brokenBody{data: []byte("hello")}, 0)
+	common.Must(err)
+	b := make([]byte, 16)
+	n, err := r.Read(b)
+	common.Must(err)
+	if string(b[:n]) != "hello" {
+		t.Fatal("read ", string(b[:n]))
+	}
+
+	// the client resends from an offset it still has
+	go func() {
+		<-first.done.Wait()
+		common.Must2(r.attach(&brokenBody{data: []byte("llo world")}, 2))
+	}()
+	n, err = r.Read(b)
+	common.Must(err)
+	if string(b[:n]) != " world" {
+		t.Error("read ", string(b[:n]))
+	}
+
+	if _, err := r.attach(&brokenBody{}, 100); err != errResumeRejected {
+		t.Error("accepted a gap in the upload")
+	}
+}
+
+// brokenBody returns data, then fails like a broken request.
+type brokenBody struct {
+	data []byte
+}
+
+func (b *brokenBody) Read(p []byte) (int, error) {
+	if len(b.data) == 0 {
+		return 0, http.ErrBodyReadAfterClose
+	}
+	n := copy(p, b.data)
+	b.data = b.data[n:]
+	return n, nil
+}
+
+func (b *brokenBody) Close() error { return nil }
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"runtime"
+	"sync"
 	"testing"
 	"time"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/xtls/xray-core/common/buf"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/protocol/tls/cert"
+	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/testing/servers/tcp"
 	"github.com/xtls/xray-core/testing/servers/udp"
 	"github.com/xtls/xray-core/transport/internet"
@@ -458,3 +460,231 @@ func Test_maxUpload(t *testing.T) {
 
 	common.Must(listen.Close())
 }
+
+func Test_ListenXHAndDial_QUIC_Modes(t *testing.T) {
+	if runtime.GOARCH == "arm64" {
+		return
+	}
+
+	for _, mode := range []string{"packet-up", "stream-up", "stream-one"} {
+		t.Run(mode, func(t *testing.T) {
+			listenPort := udp.PickPort()
+			streamSettings := &internet.MemoryStreamConfig{
+				ProtocolName: "splithttp",
+				ProtocolSettings: &Config{
+					Path: "shs",
+					Mode: mode,
+					Xmux: &XmuxConfig{
+						MaxConcurrency: &RangeConfig{From: 4, To: 4},
+					},
+				},
+				SecurityType: "tls",
+				SecuritySettings: &tls.Config{
+					AllowInsecure: true,
+					Certificate:   []*tls.Certificate{tls.ParseCertificate(cert.MustGenerate(nil, cert.CommonName("localhost")))},
+					NextProtocol:  []string{"h3"},
+				},
+			}
+
+			listen, err := ListenXH(context.Background(), net.LocalHostIP, listenPort, streamSettings, func(conn stat.Connection) {
+				go func() {
+					defer conn.Close()
+					io.Copy(conn, conn)
+				}()
+			})
+			common.Must(err)
+
+			// several connections share one QUIC connection through xmux
+			const N = 4
+			errs := make(chan error, N)
+			for i := 0; i < N; i++ {
+				go func() {
+					conn, err := Dial(context.Background(), net.UDPDestination(net.DomainAddress("localhost"), listenPort), streamSettings)
+					if err != nil {
+						errs <- err
+						return
+					}
+					defer conn.Close()
+
+					b1 := make([]byte, 4096)
+					common.Must2(rand.Read(b1))
+					for j := 0; j < 2; j++ {
+						if _, err := conn.Write(b1); err != nil {
+							errs <- err
+							return
+						}
+						b2 := make([]byte, len(b1))
+						if _, err := io.ReadFull(conn, b2); err != nil {
+							errs <- err
+							return
+						}
+						if !bytes.Equal(b1, b2) {
+							errs <- fmt.Errorf("unexpected echo in %s", mode)
+							return
+						}
+					}
+					errs <- nil
+				}()
+			}
+			for i := 0; i < N; i++ {
+				select {
+				case err := <-errs:
+					if err != nil {
+						t.Error(err)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatal("timeout")
+				}
+			}
+
+			common.Must(listen.Close())
+
+			// the UDP socket must be released on close
+			listen, err = ListenXH(context.Background(), net.LocalHostIP, listenPort, streamSettings, func(conn stat.Connection) {
+				conn.Close()
+			})
+			common.Must(err)
+			common.Must(listen.Close())
+		})
+	}
+}
+
+// breakableProxy forwards TCP connections to target and can cut all of them.
+type breakableProxy struct {
+	listener net.Listener
+	access   sync.Mutex
+	conns    []net.Conn
+}
+
+func newBreakableProxy(t *testing.T, target net.Port) *breakableProxy {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	p := &breakableProxy{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			upstream, err := net.Dial("tcp", net.TCPDestination(net.LocalHostIP, target).NetAddr())
+			if err != nil {
+				conn.Close()
+				continue
+			}
+			p.access.Lock()
+			p.conns = append(p.conns, conn, upstream)
+			p.access.Unlock()
+			go io.Copy(conn, upstream)
+			go io.Copy(upstream, conn)
+		}
+	}()
+	return p
+}
+
+func (p *breakableProxy) port() net.Port {
+	return net.Port(p.listener.Addr().(*net.TCPAddr).Port)
+}
+
+func (p *breakableProxy) cut() {
+	p.access.Lock()
+	defer p.access.Unlock()
+	for _, conn := range p.conns {
+		conn.Close()
+	}
+	p.conns = nil
+}
+
+func Test_ResumeSession(t *testing.T) {
+	for _, mode := range []string{"packet-up", "stream-up"} {
+		t.Run(mode, func(t *testing.T) {
+			testResumeSession(t, mode)
+		})
+	}
+}
+
+func testResumeSession(t *testing.T, mode string) {
+	// HTTP/2, since failed uploads over pooled HTTP/1.1 connections go unnoticed
+	tlsConfig := &tls.Config{
+		AllowInsecure: true,
+		Certificate:   []*tls.Certificate{tls.ParseCertificate(cert.MustGenerate(nil, cert.CommonName("localhost")))},
+	}
+	listenPort := tcp.PickPort()
+	listen, err := ListenXH(context.Background(), net.LocalHostIP, listenPort, &internet.MemoryStreamConfig{
+		ProtocolName: "splithttp",
+		ProtocolSettings: &Config{
+			Path:               "/sh",
+			ScSessionGraceSecs: 5,
+		},
+		SecurityType:     "tls",
+		SecuritySettings: tlsConfig,
+	}, func(conn stat.Connection) {
+		go func() {
+			defer conn.Close()
+			io.Copy(conn, conn)
+		}()
+	})
+	common.Must(err)
+	defer listen.Close()
+
+	primary := newBreakableProxy(t, listenPort)
+	alternative := newBreakableProxy(t, listenPort)
+	defer alternative.listener.Close()
+
+	streamSettings := &internet.MemoryStreamConfig{
+		ProtocolName: "splithttp",
+		ProtocolSettings: &Config{
+			Path:           "/sh",
+			Mode:           mode,
+			ResumeAttempts: 3,
+			AlternativeSettings: []*internet.StreamConfig{
+				{
+					Address:      net.NewIPOrDomain(net.LocalHostIP),
+					Port:         uint32(alternative.port()),
+					ProtocolName: "splithttp",
+					TransportSettings: []*internet.TransportConfig{
+						{
+							ProtocolName: "splithttp",
+							Settings:     serial.ToTypedMessage(&Config{Path: "/sh"}),
+						},
+					},
+					SecurityType:     serial.GetMessageType(tlsConfig),
+					SecuritySettings: []*serial.TypedMessage{serial.ToTypedMessage(tlsConfig)},
+				},
+			},
+		},
+		SecurityType:     "tls",
+		SecuritySettings: tlsConfig,
+	}
+	conn, err := Dial(context.Background(), net.TCPDestination(net.LocalHostIP, primary.port()), streamSettings)
+	common.Must(err)
+	defer conn.Close()
+
+	echo := func(size int) {
+		b1 := make([]byte, size)
+		common.Must2(rand.Read(b1))
+		common.Must2(conn.Write(b1))
+		b2 := make([]byte, size)
+		common.Must2(io.ReadFull(conn, b2))
+		if !bytes.Equal(b1, b2) {
+			t.Fatal("unexpected echo")
+		}
+	}
+
+	echo(1024)
+
+	// the primary endpoint goes away, the session continues on the alternative one
+	primary.listener.Close()
+	primary.cut()
+
+	echo(1024)
+	echo(64 * 1024)
+
+	// the session ends with an error once no endpoint is left
+	alternative.listener.Close()
+	alternative.cut()
+	listen.Close()
+	conn.Write(make([]byte, 1024))
+	if _, err := io.ReadFull(conn, make([]byte, 1024)); err == nil || err == io.EOF {
+		t.Error("expected the failure to resume, got ", err)
+	}
+}
@@ -132,6 +132,8 @@ func (v *Listener) Addr() net.Addr {
 
 // Close implements internet.Listener.Close.
 func (v *Listener) Close() error {
+	tls.Release(v.tlsConfig)
+	reality.Release(v.realityConfig)
 	return v.listener.Close()
 }
 
@@ -0,0 +1,449 @@
+package tls
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"golang.org/x/crypto/acme"
+)
+
+const (
+	acmeDefaultRenewBefore = 30 * 24 * time.Hour
+	acmeRetryMin           = time.Minute
+	acmeRetryMax           = 6 * time.Hour
+	acmeObtainTimeout      = 10 * time.Minute
+)
+
+var (
+	acmeAccess   sync.Mutex
+	acmeManagers = make(map[string]*acmeManager)
+
+	// acmeListeners holds the managers acquired for each tls.Config built by
+	// GetTLSConfig, until the listener using it calls Release.
+	acmeListeners sync.Map // *tls.Config -> []*acmeManager
+)
+
+// acmeManager obtains and renews the certificate of one Acme config. It is
+// shared by every listener that uses the same config, and stops once the
+// last of them is closed.
+type acmeManager struct {
+	key    string
+	config *Acme
+	cert   atomic.Pointer[tls.Certificate]
+	refs   int // guarded by acmeAccess
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// challenges holds TLS-ALPN-01 certificates by domain while authorizing.
+	challenges sync.Map
+	// httpTokens holds HTTP-01 key authorizations by request path while
+	// authorizing.
+	httpTokens sync.Map
+}
+
+// acquireAcmeManager returns the running manager of config, starting it if
+// no listener uses it yet.
+func acquireAcmeManager(config *Acme) *acmeManager {
+	key := strings.Join([]string{config.DirectoryUrl, config.StoragePath, strings.Join(config.Domains, ",")}, "|")
+
+	acmeAccess.Lock()
+	defer acmeAccess.Unlock()
+
+	m, found := acmeManagers[key]
+	if !found {
+		m = &acmeManager{key: key, config: config}
+		m.ctx, m.cancel = context.WithCancel(context.Background())
+		acmeManagers[key] = m
+		m.start()
+	}
+	m.refs++
+	return m
+}
+
+func (m *acmeManager) release() {
+	acmeAccess.Lock()
+	defer acmeAccess.Unlock()
+
+	if m.refs--; m.refs > 0 {
+		return
+	}
+	delete(acmeManagers, m.key)
+	m.cancel()
+}
+
+// acquireAcmeManagers returns the managers of the ACME certificates of the
+// config. Each of them has to be released.
+func (c *Config) acquireAcmeManagers() []*acmeManager {
+	var managers []*acmeManager
+	for _, entry := range c.Certificate {
+		if entry.Acme == nil || entry.Usage != Certificate_ENCIPHERMENT {
+			continue
+		}
+		if len(entry.Acme.Domains) == 0 {
+			errors.LogWarning(context.Background(), "ignoring ACME certificate without domains")
+			continue
+		}
+		managers = append(managers, acquireAcmeManager(entry.Acme))
+	}
+	return managers
+}
+
+// Release stops what GetTLSConfig started for config, once the listener
+// that used it is closed: the ACME managers of its certificates stop when
+// no other listener uses them.
+func Release(config *tls.Config) {
+	if config == nil {
+		return
+	}
+	serverConfigs.Delete(config)
+	if managers, found := acmeListeners.LoadAndDelete(config); found {
+		for _, m := range managers.([]*acmeManager) {
+			m.release()
+		}
+	}
+}
+
+// AcmeHTTP01Response returns the key authorization to answer an HTTP-01
+// challenge request for path with, if an ACME config that uses the inbound
+// with the tag is authorizing.
+func AcmeHTTP01Response(inboundTag, path string) (string, bool) {
+	if inboundTag == "" {
+		return "", false
+	}
+	acmeAccess.Lock()
+	defer acmeAccess.Unlock()
+
+	for _, m := range acmeManagers {
+		if m.config.HttpInbound != inboundTag {
+			continue
+		}
+		if response, found := m.httpTokens.Load(path); found {
+			return response.(string), true
+		}
+	}
+	return "", false
+}
+
+func (m *acmeManager) start() {
+	if m.config.StoragePath == "" {
+		errors.LogWarning(context.Background(), "ACME storage path is not set, certificates for ", m.config.Domains[0], " will not persist")
+	} else if cert, err := m.load(); err != nil {
+		if !os.IsNotExist(errors.Cause(err)) {
+			errors.LogWarningInner(context.Background(), err, "failed to load stored ACME certificate")
+		}
+	} else {
+		m.cert.Store(cert)
+	}
+	go m.renewLoop()
+}
+
+func (m *acmeManager) renewBefore() time.Duration {
+	if m.config.RenewBefore > 0 {
+		return time.Duration(m.config.RenewBefore) * time.Second
+	}
+	return acmeDefaultRenewBefore
+}
+
+// sleep waits for d, and returns false if the manager stopped meanwhile.
+func (m *acmeManager) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-m.ctx.Done():
+		return false
+	}
+}
+
+func (m *acmeManager) renewLoop() {
+	retry := acmeRetryMin
+	for {
+		if cert := m.cert.Load(); cert != nil {
+			renewBefore := m.renewBefore()
+			// Short-lived certificates are renewed after two thirds of their lifetime.
+			if lifetime := cert.Leaf.NotAfter.Sub(cert.Leaf.NotBefore); renewBefore >= lifetime {
+				renewBefore = lifetime / 3
+			}
+			if wait := time.Until(cert.Leaf.NotAfter.Add(-renewBefore)); wait > 0 && !m.sleep(wait) {
+				return
+			}
+		}
+		ctx, cancel := context.WithTimeout(m.ctx, acmeObtainTimeout)
+		cert, err := m.obtain(ctx)
+		cancel()
+		if m.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			errors.LogWarningInner(context.Background(), err, "failed to obtain ACME certificate for ", m.config.Domains[0], ", retrying in ", retry)
+			if !m.sleep(retry) {
+				return
+			}
+			retry = min(retry*2, acmeRetryMax)
+			continue
+		}
+		retry = acmeRetryMin
+		m.cert.Store(cert)
+		errors.LogInfo(context.Background(), "obtained ACME certificate for ", strings.Join(m.config.Domains, ", "), ", valid until ", cert.Leaf.NotAfter)
+		if m.config.StoragePath != "" {
+			if err := m.save(cert); err != nil {
+				errors.LogWarningInner(context.Background(), err, "failed to store ACME certificate")
+			}
+		}
+	}
+}
+
+func (m *acmeManager) path(name string) string {
+	return filepath.Join(m.config.StoragePath, name)
+}
+
+func (m *acmeManager) load() (*tls.Certificate, error) {
+	certPEM, err := os.ReadFile(m.path(m.config.Domains[0] + ".crt"))
+	if err != nil {
+		return nil, errors.New("failed to read certificate").Base(err)
+	}
+	keyPEM, err := os.ReadFile(m.path(m.config.Domains[0] + ".key"))
+	if err != nil {
+		return nil, errors.New("failed to read key").Base(err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, errors.New("invalid key pair").Base(err)
+	}
+	cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, errors.New("invalid certificate").Base(err)
+	}
+	for _, domain := range m.config.Domains {
+		if cert.Leaf.VerifyHostname(domain) != nil {
+			return nil, errors.New("stored certificate does not cover ", domain)
+		}
+	}
+	return &cert, nil
+}
+
+func (m *acmeManager) save(cert *tls.Certificate) error {
+	var certPEM []byte
+	for _, der := range cert.Certificate {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+	keyPEM, err := encodeECKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.config.StoragePath, 0700); err != nil {
+		return errors.New("failed to create storage directory").Base(err)
+	}
+	if err := writeFileAtomic(m.path(m.config.Domains[0]+".key"), keyPEM); err != nil {
+		return err
+	}
+	return writeFileAtomic(m.path(m.config.Domains[0]+".crt"), certPEM)
+}
+
+// accountKey loads the account key from storage, generating and storing a
+// new one if there is none.
+func (m *acmeManager) accountKey() (crypto.Signer, error) {
+	path := ""
+	if m.config.StoragePath != "" {
+		path = m.path("account.key")
+		if data, err := os.ReadFile(path); err == nil {
+			block, _ := pem.Decode(data)
+			if block == nil {
+				return nil, errors.New("invalid account key in ", path)
+			}
+			return x509.ParseECPrivateKey(block.Bytes)
+		}
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		keyPEM, err := encodeECKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(m.config.StoragePath, 0700); err != nil {
+			return nil, errors.New("failed to create storage directory").Base(err)
+		}
+		if err := writeFileAtomic(path, keyPEM); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+func (m *acmeManager) obtain(ctx context.Context) (*tls.Certificate, error) {
+	accountKey, err := m.accountKey()
+	if err != nil {
+		return nil, errors.New("failed to load account key").Base(err)
+	}
+	client := &acme.Client{
+		Key:          accountKey,
+		DirectoryURL: m.config.DirectoryUrl,
+		UserAgent:    "Xray",
+	}
+	account := &acme.Account{}
+	if m.config.Email != "" {
+		account.Contact = []string{"mailto:" + m.config.Email}
+	}
+	if _, err := client.Register(ctx, account, acme.AcceptTOS); err != nil && err != acme.ErrAccountAlreadyExists {
+		return nil, errors.New("failed to register account").Base(err)
+	}
+
+	order, err := client.AuthorizeOrder(ctx, acme.DomainIDs(m.config.Domains...))
+	if err != nil {
+		return nil, errors.New("failed to create order").Base(err)
+	}
+	for _, url := range order.AuthzURLs {
+		if err := m.authorize(ctx, client, url); err != nil {
+			return nil, err
+		}
+	}
+	if order, err = client.WaitOrder(ctx, order.URI); err != nil {
+		return nil, errors.New("order failed").Base(err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: m.config.Domains[0]},
+		DNSNames: m.config.Domains,
+	}, key)
+	if err != nil {
+		return nil, errors.New("failed to create certificate request").Base(err)
+	}
+	der, _, err := client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return nil, errors.New("failed to finalize order").Base(err)
+	}
+	leaf, err := x509.ParseCertificate(der[0])
+	if err != nil {
+		return nil, errors.New("invalid issued certificate").Base(err)
+	}
+	return &tls.Certificate{Certificate: der, PrivateKey: key, Leaf: leaf}, nil
+}
+
+func (m *acmeManager) authorize(ctx context.Context, client *acme.Client, url string) error {
+	authz, err := client.GetAuthorization(ctx, url)
+	if err != nil {
+		return errors.New("failed to get authorization").Base(err)
+	}
+	if authz.Status == acme.StatusValid {
+		return nil
+	}
+	domain := authz.Identifier.Value
+
+	var challenge *acme.Challenge
+	for _, c := range authz.Challenges {
+		if c.Type == "tls-alpn-01" && !m.config.DisableTlsAlpn ||
+			c.Type == "http-01" && m.config.HttpInbound != "" {
+			challenge = c
+			break
+		}
+	}
+	if challenge == nil {
+		return errors.New("no supported challenge offered for ", domain)
+	}
+
+	switch challenge.Type {
+	case "tls-alpn-01":
+		cert, err := client.TLSALPN01ChallengeCert(challenge.Token, domain)
+		if err != nil {
+			return err
+		}
+		m.challenges.Store(domain, &cert)
+		defer m.challenges.Delete(domain)
+	case "http-01":
+		response, err := client.HTTP01ChallengeResponse(challenge.Token)
+		if err != nil {
+			return err
+		}
+		path := client.HTTP01ChallengePath(challenge.Token)
+		m.httpTokens.Store(path, response)
+		defer m.httpTokens.Delete(path)
+	}
+
+	if _, err := client.Accept(ctx, challenge); err != nil {
+		return errors.New("failed to accept ", challenge.Type, " challenge for ", domain).Base(err)
+	}
+	if _, err := client.WaitAuthorization(ctx, authz.URI); err != nil {
+		return errors.New("authorization failed for ", domain).Base(err)
+	}
+	return nil
+}
+
+// isAcmeChallenge reports whether the client hello is a TLS-ALPN-01 validation request.
+func isAcmeChallenge(hello *tls.ClientHelloInfo) bool {
+	return len(hello.SupportedProtos) == 1 && hello.SupportedProtos[0] == acme.ALPNProto
+}
+
+func getAcmeChallengeCertificate(managers []*acmeManager, hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	sni := strings.ToLower(hello.ServerName)
+	for _, m := range managers {
+		if cert, found := m.challenges.Load(sni); found {
+			return cert.(*tls.Certificate), nil
+		}
+	}
+	return nil, errors.New("no pending ACME challenge for ", sni)
+}
+
+func getAcmeCertificates(managers []*acmeManager) []*tls.Certificate {
+	var certs []*tls.Certificate
+	for _, m := range managers {
+		if cert := m.cert.Load(); cert != nil {
+			certs = append(certs, cert)
+		}
+	}
+	return certs
+}
+
+func (c *Config) hasAcme() bool {
+	return slices.ContainsFunc(c.Certificate, func(entry *Certificate) bool {
+		return entry.Acme != nil
+	})
+}
+
+func hasAcmeTLSALPN(managers []*acmeManager) bool {
+	return slices.ContainsFunc(managers, func(m *acmeManager) bool {
+		return !m.config.DisableTlsAlpn
+	})
+}
+
+func encodeECKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return errors.New("failed to write ", path).Base(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return errors.New("failed to write ", path).Base(err)
+	}
+	return nil
+}
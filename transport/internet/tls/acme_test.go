@@ -0,0 +1,576 @@
+package tls_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	gotls "crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	. "github.com/xtls/xray-core/transport/internet/tls"
+	"golang.org/x/crypto/acme"
+)
+
+// fakeACME is a minimal in-process ACME CA. It validates challenges by
+// connecting to tlsAddr or httpAddr, whatever domain is being authorized.
+type fakeACME struct {
+	*httptest.Server
+	tlsAddr  string
+	httpAddr string
+
+	caKey  *ecdsa.PrivateKey
+	caCert *x509.Certificate
+	roots  *x509.CertPool
+
+	sync.Mutex
+	thumbprint string
+	authzs     []*fakeAuthz
+	orders     []*fakeOrder
+	issued     int
+}
+
+type fakeAuthz struct {
+	domain string
+	token  string
+	status string
+}
+
+type fakeOrder struct {
+	authzs []int
+	status string
+	chain  [][]byte
+}
+
+func newFakeACME(t *testing.T) *fakeACME {
+	ca := &fakeACME{}
+	var err error
+	ca.caKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	common.Must(err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Fake ACME CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &ca.caKey.PublicKey, ca.caKey)
+	common.Must(err)
+	ca.caCert, err = x509.ParseCertificate(der)
+	common.Must(err)
+	ca.roots = x509.NewCertPool()
+	ca.roots.AddCert(ca.caCert)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /dir", func(w http.ResponseWriter, r *http.Request) {
+		ca.reply(w, http.StatusOK, "", map[string]string{
+			"newNonce":   ca.URL + "/nonce",
+			"newAccount": ca.URL + "/account",
+			"newOrder":   ca.URL + "/order",
+		})
+	})
+	mux.HandleFunc("HEAD /nonce", func(w http.ResponseWriter, r *http.Request) {
+		ca.reply(w, http.StatusOK, "", nil)
+	})
+	mux.HandleFunc("POST /account", ca.handleAccount)
+	mux.HandleFunc("POST /order", ca.handleNewOrder)
+	mux.HandleFunc("POST /order/{id}", ca.handleOrder)
+	mux.HandleFunc("POST /authz/{id}", ca.handleAuthz)
+	mux.HandleFunc("POST /challenge/{id}/{type}", ca.handleChallenge)
+	mux.HandleFunc("POST /finalize/{id}", ca.handleFinalize)
+	mux.HandleFunc("POST /cert/{id}", ca.handleCert)
+	ca.Server = httptest.NewServer(mux)
+	t.Cleanup(ca.Close)
+	return ca
+}
+
+func (ca *fakeACME) reply(w http.ResponseWriter, status int, location string, v any) {
+	w.Header().Set("Replay-Nonce", strconv.FormatInt(time.Now().UnixNano(), 36))
+	if location != "" {
+		w.Header().Set("Location", location)
+	}
+	if v == nil {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (ca *fakeACME) fail(w http.ResponseWriter, err error) {
+	ca.reply(w, http.StatusBadRequest, "", map[string]string{
+		"type":   "urn:ietf:params:acme:error:malformed",
+		"detail": err.Error(),
+	})
+}
+
+// decodeJWS returns the protected header and the payload of a JWS request.
+func decodeJWS(r *http.Request, payload any) (map[string]json.RawMessage, error) {
+	var jws struct {
+		Protected string `json:"protected"`
+		Payload   string `json:"payload"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&jws); err != nil {
+		return nil, err
+	}
+	data, err := base64.RawURLEncoding.DecodeString(jws.Protected)
+	if err != nil {
+		return nil, err
+	}
+	header := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	if data, err = base64.RawURLEncoding.DecodeString(jws.Payload); err != nil {
+		return nil, err
+	}
+	if payload != nil && len(data) > 0 {
+		err = json.Unmarshal(data, payload)
+	}
+	return header, err
+}
+
+func (ca *fakeACME) handleAccount(w http.ResponseWriter, r *http.Request) {
+	header, err := decodeJWS(r, nil)
+	if err != nil {
+		ca.fail(w, err)
+		return
+	}
+	var jwk struct {
+		X string `json:"x"`
+		Y string `json:"y"`
+	}
+	if err := json.Unmarshal(header["jwk"], &jwk); err != nil {
+		ca.fail(w, err)
+		return
+	}
+	x, _ := base64.RawURLEncoding.DecodeString(jwk.X)
+	y, _ := base64.RawURLEncoding.DecodeString(jwk.Y)
+	thumbprint, err := acme.JWKThumbprint(&ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	})
+	if err != nil {
+		ca.fail(w, err)
+		return
+	}
+	ca.Lock()
+	ca.thumbprint = thumbprint
+	ca.Unlock()
+	ca.reply(w, http.StatusCreated, ca.URL+"/accounts/1", map[string]string{"status": "valid"})
+}
+
+func (ca *fakeACME) handleNewOrder(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Identifiers []struct {
+			Value string `json:"value"`
+		} `json:"identifiers"`
+	}
+	if _, err := decodeJWS(r, &request); err != nil {
+		ca.fail(w, err)
+		return
+	}
+	ca.Lock()
+	order := &fakeOrder{status: "pending"}
+	for _, id := range request.Identifiers {
+		order.authzs = append(order.authzs, len(ca.authzs))
+		ca.authzs = append(ca.authzs, &fakeAuthz{
+			domain: id.Value,
+			token:  fmt.Sprintf("token-%d", len(ca.authzs)),
+			status: "pending",
+		})
+	}
+	ca.orders = append(ca.orders, order)
+	id := len(ca.orders) - 1
+	ca.Unlock()
+	ca.replyOrder(w, http.StatusCreated, id)
+}
+
+func (ca *fakeACME) replyOrder(w http.ResponseWriter, status int, id int) {
+	ca.Lock()
+	order := ca.orders[id]
+	if order.status == "pending" {
+		order.status = "ready"
+		for _, i := range order.authzs {
+			if ca.authzs[i].status != "valid" {
+				order.status = "pending"
+			}
+		}
+	}
+	v := map[string]any{
+		"status":   order.status,
+		"finalize": fmt.Sprintf("%s/finalize/%d", ca.URL, id),
+	}
+	var authzs []string
+	for _, i := range order.authzs {
+		authzs = append(authzs, fmt.Sprintf("%s/authz/%d", ca.URL, i))
+	}
+	v["authorizations"] = authzs
+	if order.status == "valid" {
+		v["certificate"] = fmt.Sprintf("%s/cert/%d", ca.URL, id)
+	}
+	ca.Unlock()
+	ca.reply(w, status, fmt.Sprintf("%s/order/%d", ca.URL, id), v)
+}
+
+func (ca *fakeACME) pathID(r *http.Request, n int) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	return id, err == nil && id >= 0 && id < n
+}
+
+func (ca *fakeACME) handleOrder(w http.ResponseWriter, r *http.Request) {
+	decodeJWS(r, nil)
+	ca.Lock()
+	id, ok := ca.pathID(r, len(ca.orders))
+	ca.Unlock()
+	if !ok {
+		ca.fail(w, fmt.Errorf("unknown order"))
+		return
+	}
+	ca.replyOrder(w, http.StatusOK, id)
+}
+
+func (ca *fakeACME) replyAuthz(w http.ResponseWriter, id int) {
+	ca.Lock()
+	authz := ca.authzs[id]
+	v := map[string]any{
+		"status":     authz.status,
+		"identifier": map[string]string{"type": "dns", "value": authz.domain},
+		"challenges": []map[string]string{
+			{"type": "tls-alpn-01", "url": fmt.Sprintf("%s/challenge/%d/tls-alpn-01", ca.URL, id), "token": authz.token, "status": authz.status},
+			{"type": "http-01", "url": fmt.Sprintf("%s/challenge/%d/http-01", ca.URL, id), "token": authz.token, "status": authz.status},
+		},
+	}
+	ca.Unlock()
+	ca.reply(w, http.StatusOK, "", v)
+}
+
+func (ca *fakeACME) handleAuthz(w http.ResponseWriter, r *http.Request) {
+	decodeJWS(r, nil)
+	ca.Lock()
+	id, ok := ca.pathID(r, len(ca.authzs))
+	ca.Unlock()
+	if !ok {
+		ca.fail(w, fmt.Errorf("unknown authorization"))
+		return
+	}
+	ca.replyAuthz(w, id)
+}
+
+func (ca *fakeACME) handleChallenge(w http.ResponseWriter, r *http.Request) {
+	decodeJWS(r, nil)
+	ca.Lock()
+	id, ok := ca.pathID(r, len(ca.authzs))
+	if !ok {
+		ca.Unlock()
+		ca.fail(w, fmt.Errorf("unknown challenge"))
+		return
+	}
+	authz := ca.authzs[id]
+	keyAuth := authz.token + "." + ca.thumbprint
+	ca.Unlock()
+
+	var err error
+	switch r.PathValue("type") {
+	case "tls-alpn-01":
+		err = ca.validateTLSALPN(authz.domain, keyAuth)
+	case "http-01":
+		err = ca.validateHTTP(authz.token, keyAuth)
+	}
+	ca.Lock()
+	if err != nil {
+		authz.status = "invalid"
+	} else {
+		authz.status = "valid"
+	}
+	ca.Unlock()
+	ca.replyAuthz(w, id)
+}
+
+func (ca *fakeACME) validateTLSALPN(domain, keyAuth string) error {
+	conn, err := gotls.Dial("tcp", ca.tlsAddr, &gotls.Config{
+		ServerName:         domain,
+		NextProtos:         []string{acme.ALPNProto},
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	state := conn.ConnectionState()
+	if state.NegotiatedProtocol != acme.ALPNProto {
+		return fmt.Errorf("negotiated %q", state.NegotiatedProtocol)
+	}
+	digest := sha256.Sum256([]byte(keyAuth))
+	expected, _ := asn1.Marshal(digest[:])
+	for _, ext := range state.PeerCertificates[0].Extensions {
+		if ext.Id.Equal(asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 31}) && string(ext.Value) == string(expected) {
+			return nil
+		}
+	}
+	return fmt.Errorf("no matching acmeIdentifier")
+}
+
+func (ca *fakeACME) validateHTTP(token, keyAuth string) error {
+	resp, err := http.Get("http://" + ca.httpAddr + "/.well-known/acme-challenge/" + token)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != keyAuth {
+		return fmt.Errorf("unexpected key authorization %q", body)
+	}
+	return nil
+}
+
+func (ca *fakeACME) handleFinalize(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		CSR string `json:"csr"`
+	}
+	if _, err := decodeJWS(r, &request); err != nil {
+		ca.fail(w, err)
+		return
+	}
+	der, err := base64.RawURLEncoding.DecodeString(request.CSR)
+	if err != nil {
+		ca.fail(w, err)
+		return
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		ca.fail(w, err)
+		return
+	}
+	ca.Lock()
+	id, ok := ca.pathID(r, len(ca.orders))
+	if !ok || ca.orders[id].status != "ready" {
+		ca.Unlock()
+		ca.fail(w, fmt.Errorf("order is not ready"))
+		return
+	}
+	ca.issued++
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(int64(ca.issued + 1)),
+		Subject:      csr.Subject,
+		DNSNames:     csr.DNSNames,
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	leaf, err := x509.CreateCertificate(rand.Reader, template, ca.caCert, csr.PublicKey, ca.caKey)
+	if err == nil {
+		ca.orders[id].status = "valid"
+		ca.orders[id].chain = [][]byte{leaf, ca.caCert.Raw}
+	}
+	ca.Unlock()
+	if err != nil {
+		ca.fail(w, err)
+		return
+	}
+	ca.replyOrder(w, http.StatusOK, id)
+}
+
+func (ca *fakeACME) handleCert(w http.ResponseWriter, r *http.Request) {
+	decodeJWS(r, nil)
+	ca.Lock()
+	id, ok := ca.pathID(r, len(ca.orders))
+	var chain [][]byte
+	if ok {
+		chain = ca.orders[id].chain
+	}
+	ca.Unlock()
+	if len(chain) == 0 {
+		ca.fail(w, fmt.Errorf("certificate is not issued"))
+		return
+	}
+	w.Header().Set("Replay-Nonce", strconv.FormatInt(time.Now().UnixNano(), 36))
+	w.Header().Set("Content-Type", "application/pem-certificate-chain")
+	for _, der := range chain {
+		pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}
+}
+
+// serveTLS accepts TLS connections on listener and completes their handshakes.
+func serveTLS(t *testing.T, listener net.Listener, config *gotls.Config) {
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				conn := gotls.Server(conn, config)
+				conn.Handshake()
+				conn.Close()
+			}()
+		}
+	}()
+}
+
+// waitCertificate dials addr until it presents a certificate for domain
+// signed by the fake CA, other than the one with serial skip.
+func waitCertificate(t *testing.T, ca *fakeACME, addr, domain string, skip *big.Int) *x509.Certificate {
+	deadline := time.Now().Add(20 * time.Second)
+	for time.Now().Before(deadline) {
+		conn, err := gotls.Dial("tcp", addr, &gotls.Config{
+			ServerName: domain,
+			RootCAs:    ca.roots,
+		})
+		if err == nil {
+			cert := conn.ConnectionState().PeerCertificates[0]
+			conn.Close()
+			if skip == nil || cert.SerialNumber.Cmp(skip) != 0 {
+				return cert
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatal("no certificate for ", domain, " issued by the fake CA")
+	return nil
+}
+
+func TestAcmeTLSALPN(t *testing.T) {
+	ca := newFakeACME(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	ca.tlsAddr = listener.Addr().String()
+
+	storage := t.TempDir()
+	config := &Config{
+		Certificate: []*Certificate{{
+			Acme: &Acme{
+				DirectoryUrl: ca.URL + "/dir",
+				Domains:      []string{"example.com", "www.example.com"},
+				StoragePath:  storage,
+				// Shorter than the one hour validity the fake CA issues,
+				// so the certificate is renewed after a couple of seconds.
+				RenewBefore: 3600 - 2,
+			},
+		}},
+	}
+	serveTLS(t, listener, config.GetTLSConfig())
+
+	cert := waitCertificate(t, ca, ca.tlsAddr, "www.example.com", nil)
+	if cert.Subject.CommonName != "example.com" {
+		t.Error("unexpected common name ", cert.Subject.CommonName)
+	}
+	stored, err := os.ReadFile(filepath.Join(storage, "example.com.crt"))
+	common.Must(err)
+	if block, _ := pem.Decode(stored); block == nil || string(block.Bytes) != string(cert.Raw) {
+		t.Error("issued certificate is not stored")
+	}
+	if _, err := os.Stat(filepath.Join(storage, "account.key")); err != nil {
+		t.Error(err)
+	}
+
+	renewed := waitCertificate(t, ca, ca.tlsAddr, "example.com", cert.SerialNumber)
+	if renewed.NotAfter.Before(cert.NotAfter) {
+		t.Error("renewed certificate expires earlier")
+	}
+}
+
+func TestAcmeHTTP(t *testing.T) {
+	ca := newFakeACME(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	ca.tlsAddr = listener.Addr().String()
+	httpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	ca.httpAddr = httpListener.Addr().String()
+
+	// Stands in for the http inbound tagged "acme".
+	httpServer := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response, found := AcmeHTTP01Response("acme", r.URL.Path)
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, response)
+	})}
+	go httpServer.Serve(httpListener)
+	t.Cleanup(func() { httpServer.Close() })
+
+	config := &Config{
+		Certificate: []*Certificate{{
+			Acme: &Acme{
+				DirectoryUrl:   ca.URL + "/dir",
+				Domains:        []string{"example.org"},
+				StoragePath:    t.TempDir(),
+				HttpInbound:    "acme",
+				DisableTlsAlpn: true,
+			},
+		}},
+	}
+	tlsConfig := config.GetTLSConfig()
+	t.Cleanup(func() { Release(tlsConfig) })
+	for _, proto := range tlsConfig.NextProtos {
+		if proto == acme.ALPNProto {
+			t.Error("TLS-ALPN-01 is advertised while disabled")
+		}
+	}
+	serveTLS(t, listener, tlsConfig)
+
+	waitCertificate(t, ca, ca.tlsAddr, "example.org", nil)
+	if _, found := AcmeHTTP01Response("other", "/.well-known/acme-challenge/token"); found {
+		t.Error("challenge answered for an inbound without ACME configs")
+	}
+}
+
+func TestAcmeRelease(t *testing.T) {
+	ca := newFakeACME(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	ca.tlsAddr = listener.Addr().String()
+
+	config := &Config{
+		Certificate: []*Certificate{{
+			Acme: &Acme{
+				DirectoryUrl: ca.URL + "/dir",
+				Domains:      []string{"example.net"},
+				StoragePath:  t.TempDir(),
+				RenewBefore:  3600 - 1,
+			},
+		}},
+	}
+	tlsConfig := config.GetTLSConfig()
+	serveTLS(t, listener, tlsConfig)
+	waitCertificate(t, ca, ca.tlsAddr, "example.net", nil)
+
+	Release(tlsConfig)
+	time.Sleep(200 * time.Millisecond)
+	ca.Lock()
+	issued := ca.issued
+	ca.Unlock()
+
+	time.Sleep(3 * time.Second)
+	ca.Lock()
+	defer ca.Unlock()
+	if ca.issued != issued {
+		t.Error("certificate renewed after the listener is released")
+	}
+}
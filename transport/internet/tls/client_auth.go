@@ -0,0 +1,212 @@
+package tls
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/url"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/protocol"
+)
+
+const crlCheckInterval = time.Minute
+
+type clientUser struct {
+	subject string
+	san     string
+	spki    []byte
+	user    *protocol.MemoryUser
+}
+
+func (u *clientUser) matches(cert *x509.Certificate) bool {
+	if u.subject != "" && cert.Subject.CommonName != u.subject {
+		return false
+	}
+	if u.san != "" && !slices.Contains(cert.DNSNames, u.san) && !slices.Contains(cert.EmailAddresses, u.san) &&
+		!slices.ContainsFunc(cert.URIs, func(uri *url.URL) bool { return uri.String() == u.san }) {
+		return false
+	}
+	if len(u.spki) > 0 && !bytes.Equal(GenerateCertPublicKeyHash(cert), u.spki) {
+		return false
+	}
+	return true
+}
+
+func matchClientUser(users []*clientUser, state tls.ConnectionState) *protocol.MemoryUser {
+	if len(state.VerifiedChains) == 0 {
+		return nil
+	}
+	leaf := state.VerifiedChains[0][0]
+	for _, u := range users {
+		if u.matches(leaf) {
+			return u.user
+		}
+	}
+	return nil
+}
+
+// applyClientAuth has the server side config verify client certificates.
+func (c *Config) applyClientAuth(config *tls.Config) ([]*clientUser, error) {
+	if c.ClientAuth.Required {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	} else {
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	for _, entry := range c.Certificate {
+		if entry.Usage != Certificate_AUTHORITY_VERIFY {
+			continue
+		}
+		if config.ClientCAs == nil {
+			config.ClientCAs = x509.NewCertPool()
+		}
+		if !config.ClientCAs.AppendCertsFromPEM(entry.Certificate) {
+			return nil, errors.New("invalid client CA certificate")
+		}
+	}
+	if len(c.ClientAuth.CrlPath) > 0 {
+		crls := &crlSet{paths: c.ClientAuth.CrlPath}
+		if err := crls.load(); err != nil {
+			return nil, err
+		}
+		config.VerifyConnection = crls.verifyConnection
+	}
+
+	users := make([]*clientUser, 0, len(c.ClientAuth.User))
+	for _, u := range c.ClientAuth.User {
+		if u.Subject == "" && u.San == "" && len(u.SpkiSha256) == 0 {
+			return nil, errors.New("client user ", u.GetUser().GetEmail(), " matches no certificate field")
+		}
+		if u.User == nil {
+			return nil, errors.New("client user without user")
+		}
+		users = append(users, &clientUser{
+			subject: u.Subject,
+			san:     u.San,
+			spki:    u.SpkiSha256,
+			user: &protocol.MemoryUser{
+				Email: u.User.Email,
+				Level: u.User.Level,
+			},
+		})
+	}
+	return users, nil
+}
+
+// crlSet rejects client certificates revoked by any of its CRL files. The
+// files are reloaded when modified.
+type crlSet struct {
+	paths []string
+
+	access    sync.RWMutex
+	lists     []*x509.RevocationList
+	modified  []time.Time
+	checkedAt time.Time
+}
+
+func (s *crlSet) load() error {
+	lists := make([]*x509.RevocationList, 0, len(s.paths))
+	modified := make([]time.Time, 0, len(s.paths))
+	for _, path := range s.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return errors.New("failed to read CRL ", path).Base(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return errors.New("failed to read CRL ", path).Base(err)
+		}
+		if block, _ := pem.Decode(data); block != nil {
+			data = block.Bytes
+		}
+		list, err := x509.ParseRevocationList(data)
+		if err != nil {
+			return errors.New("invalid CRL ", path).Base(err)
+		}
+		lists = append(lists, list)
+		modified = append(modified, info.ModTime())
+	}
+	s.access.Lock()
+	s.lists = lists
+	s.modified = modified
+	s.checkedAt = time.Now()
+	s.access.Unlock()
+	return nil
+}
+
+func (s *crlSet) reloadIfModified() {
+	s.access.RLock()
+	due := time.Since(s.checkedAt) >= crlCheckInterval
+	s.access.RUnlock()
+	if !due {
+		return
+	}
+
+	s.access.Lock()
+	changed := false
+	for i, path := range s.paths {
+		if info, err := os.Stat(path); err == nil && !info.ModTime().Equal(s.modified[i]) {
+			changed = true
+		}
+	}
+	s.checkedAt = time.Now()
+	s.access.Unlock()
+	if changed {
+		if err := s.load(); err != nil {
+			errors.LogWarningInner(context.Background(), err, "failed to reload CRL, keeping the previous one")
+		}
+	}
+}
+
+func (s *crlSet) verifyConnection(state tls.ConnectionState) error {
+	if len(state.VerifiedChains) == 0 {
+		return nil
+	}
+	s.reloadIfModified()
+
+	s.access.RLock()
+	defer s.access.RUnlock()
+	chain := state.VerifiedChains[0]
+	for i, cert := range chain[:len(chain)-1] {
+		issuer := chain[i+1]
+		for _, list := range s.lists {
+			if !bytes.Equal(list.RawIssuer, cert.RawIssuer) || list.CheckSignatureFrom(issuer) != nil {
+				continue
+			}
+			for _, entry := range list.RevokedCertificateEntries {
+				if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+					return errors.New("certificate ", cert.Subject, " is revoked")
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ClientUser returns the user the verified client certificate of the server
+// connection maps to, or nil if there is none.
+func (c *Conn) ClientUser() *protocol.MemoryUser {
+	if c.server == nil || len(c.server.users) == 0 {
+		return nil
+	}
+	state := c.ConnectionState()
+	if !state.HandshakeComplete {
+		return nil
+	}
+	return matchClientUser(c.server.users, state)
+}
+
+// ClientUser returns the user the verified client certificate of the
+// handshake maps to, or nil if there is none.
+func (h *Handshake) ClientUser() *protocol.MemoryUser {
+	if h == nil || len(h.server.users) == 0 {
+		return nil
+	}
+	return matchClientUser(h.server.users, h.state)
+}
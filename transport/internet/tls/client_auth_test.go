@@ -0,0 +1,154 @@
+package tls_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	gotls "crypto/tls"
+	"crypto/x509"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/protocol/tls/cert"
+	. "github.com/xtls/xray-core/transport/internet/tls"
+)
+
+func TestClientAuthUsers(t *testing.T) {
+	clientAuth := func(c *x509.Certificate) {
+		c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+	ca := cert.MustGenerate(nil, cert.Authority(true), cert.CommonName("Test CA"),
+		cert.KeyUsage(x509.KeyUsageCertSign|x509.KeyUsageCRLSign), clientAuth)
+	keyPair := func(c *cert.Certificate) gotls.Certificate {
+		certPEM, keyPEM := c.ToPEM()
+		pair, err := gotls.X509KeyPair(certPEM, keyPEM)
+		common.Must(err)
+		return pair
+	}
+	alice := keyPair(cert.MustGenerate(ca, cert.CommonName("alice"), clientAuth))
+	bob := cert.MustGenerate(ca, cert.CommonName("bob"), clientAuth)
+
+	caCert, err := x509.ParseCertificate(ca.Certificate)
+	common.Must(err)
+	caKey, err := x509.ParsePKCS8PrivateKey(ca.PrivateKey)
+	common.Must(err)
+	bobCert, err := x509.ParseCertificate(bob.Certificate)
+	common.Must(err)
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(1),
+		ThisUpdate:                time.Now().Add(-time.Hour),
+		NextUpdate:                time.Now().Add(time.Hour),
+		RevokedCertificateEntries: []x509.RevocationListEntry{{SerialNumber: bobCert.SerialNumber, RevocationTime: time.Now()}},
+	}, caCert, caKey.(crypto.Signer))
+	common.Must(err)
+	crlPath := filepath.Join(t.TempDir(), "ca.crl")
+	common.Must(os.WriteFile(crlPath, crl, 0o600))
+
+	caCertificate := ParseCertificate(ca)
+	caCertificate.Key = nil
+	caCertificate.Usage = Certificate_AUTHORITY_VERIFY
+	config := &Config{
+		Certificate: []*Certificate{
+			ParseCertificate(cert.MustGenerate(nil, cert.DNSNames("example.com"))),
+			caCertificate,
+		},
+		ClientAuth: &ClientAuth{
+			CrlPath: []string{crlPath},
+			User: []*ClientUser{
+				{
+					Subject: "alice",
+					User:    &protocol.User{Email: "alice@example.com", Level: 1},
+				},
+			},
+		},
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+	users := make(chan *protocol.MemoryUser, 1)
+	serverConfig := config.GetTLSConfig()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			tlsConn := Server(conn, serverConfig).(*Conn)
+			if err := tlsConn.Handshake(); err == nil {
+				users <- tlsConn.ClientUser()
+			}
+			tlsConn.Close()
+		}
+	}()
+
+	dial := func(clientCerts ...gotls.Certificate) error {
+		conn, err := gotls.Dial("tcp", listener.Addr().String(), &gotls.Config{
+			ServerName:         "example.com",
+			InsecureSkipVerify: true,
+			Certificates:       clientCerts,
+		})
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+			return err
+		}
+		return nil
+	}
+
+	common.Must(dial(alice))
+	if user := <-users; user == nil || user.Email != "alice@example.com" || user.Level != 1 {
+		t.Error("unexpected user for alice: ", user)
+	}
+
+	common.Must(dial())
+	if user := <-users; user != nil {
+		t.Error("unexpected user without client certificate: ", user)
+	}
+
+	if err := dial(keyPair(bob)); err == nil {
+		t.Error("revoked client certificate accepted")
+	}
+}
+
+func TestPresentClientCertificate(t *testing.T) {
+	config := &Config{
+		Certificate: []*Certificate{
+			ParseCertificate(cert.MustGenerate(nil, cert.CommonName("client"))),
+		},
+	}
+	if config.GetTLSConfig().GetClientCertificate != nil {
+		t.Error("client certificate presented without presentClientCertificate")
+	}
+	config.PresentClientCertificate = true
+	if config.GetTLSConfig().GetClientCertificate == nil {
+		t.Error("client certificate not presented with presentClientCertificate")
+	}
+}
+
+func TestClientAuthFailureRejectsHandshakes(t *testing.T) {
+	config := &Config{
+		Certificate: []*Certificate{
+			ParseCertificate(cert.MustGenerate(nil, cert.DNSNames("example.com"))),
+		},
+		ClientAuth: &ClientAuth{
+			Required: true,
+			CrlPath:  []string{filepath.Join(t.TempDir(), "missing.crl")},
+		},
+	}
+	getConfigForClient := config.GetTLSConfig().GetConfigForClient
+	if getConfigForClient == nil {
+		t.Fatal("handshakes accepted without the CRL")
+	}
+	if _, err := getConfigForClient(&gotls.ClientHelloInfo{ServerName: "example.com"}); err == nil {
+		t.Error("handshake accepted without the CRL")
+	}
+}
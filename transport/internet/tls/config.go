@@ -20,6 +20,7 @@ import (
 	"github.com/xtls/xray-core/common/platform/filesystem"
 	"github.com/xtls/xray-core/common/protocol/tls/cert"
 	"github.com/xtls/xray-core/transport/internet"
+	"golang.org/x/crypto/acme"
 )
 
 var globalSessionCache = tls.NewLRUClientSessionCache(128)
@@ -39,6 +40,9 @@ func ParseCertificate(c *cert.Certificate) *Certificate {
 func (c *Config) loadSelfCertPool() (*x509.CertPool, error) {
 	root := x509.NewCertPool()
 	for _, cert := range c.Certificate {
+		if cert.Acme != nil {
+			continue
+		}
 		if !root.AppendCertsFromPEM(cert.Certificate) {
 			return nil, errors.New("failed to append cert").AtWarning()
 		}
@@ -50,7 +54,7 @@ func (c *Config) loadSelfCertPool() (*x509.CertPool, error) {
 func (c *Config) BuildCertificates() []*tls.Certificate {
 	certs := make([]*tls.Certificate, 0, len(c.Certificate))
 	for _, entry := range c.Certificate {
-		if entry.Usage != Certificate_ENCIPHERMENT {
+		if entry.Usage != Certificate_ENCIPHERMENT || entry.Acme != nil {
 			continue
 		}
 		getX509KeyPair := func() *tls.Certificate {
@@ -244,8 +248,15 @@ func getGetCertificateFunc(c *tls.Config, ca []*Certificate) func(hello *tls.Cli
 	}
 }
 
-func getNewGetCertificateFunc(certs []*tls.Certificate, rejectUnknownSNI bool) func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+func getNewGetCertificateFunc(staticCerts []*tls.Certificate, acmeManagers []*acmeManager, rejectUnknownSNI bool) func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
 	return func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		certs := staticCerts
+		if len(acmeManagers) > 0 {
+			if isAcmeChallenge(hello) {
+				return getAcmeChallengeCertificate(acmeManagers, hello)
+			}
+			certs = append(slices.Clip(certs), getAcmeCertificates(acmeManagers)...)
+		}
 		if len(certs) == 0 {
 			return nil, errNoCertificates
 		}
@@ -274,6 +285,33 @@ func getNewGetCertificateFunc(certs []*tls.Certificate, rejectUnknownSNI bool) f
 	}
 }
 
+func parseTLSVersion(version string) (uint16, bool) {
+	switch version {
+	case "1.0":
+		return tls.VersionTLS10, true
+	case "1.1":
+		return tls.VersionTLS11, true
+	case "1.2":
+		return tls.VersionTLS12, true
+	case "1.3":
+		return tls.VersionTLS13, true
+	}
+	return 0, false
+}
+
+// getClientCertificateFunc presents the first certificate the server accepts,
+// if it asks for one.
+func getClientCertificateFunc(certs []*tls.Certificate) func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		for _, cert := range certs {
+			if info.SupportsCertificate(cert) == nil {
+				return cert, nil
+			}
+		}
+		return new(tls.Certificate), nil
+	}
+}
+
 func (c *Config) parseServerName() string {
 	if IsFromMitm(c.ServerName) {
 		return ""
@@ -387,10 +425,19 @@ func (c *Config) GetTLSConfig(opts ...Option) *tls.Config {
 	}
 
 	caCerts := c.getCustomCA()
+	var acmeManagers []*acmeManager
 	if len(caCerts) > 0 {
+		if c.hasAcme() {
+			errors.LogError(context.Background(), "ACME certificates cannot be used together with certificates to issue")
+		}
 		config.GetCertificate = getGetCertificateFunc(config, caCerts)
 	} else {
-		config.GetCertificate = getNewGetCertificateFunc(c.BuildCertificates(), c.RejectUnknownSni)
+		acmeManagers = c.acquireAcmeManagers()
+		certs := c.BuildCertificates()
+		config.GetCertificate = getNewGetCertificateFunc(certs, acmeManagers, c.RejectUnknownSni)
+		if c.PresentClientCertificate {
+			config.GetClientCertificate = getClientCertificateFunc(certs)
+		}
 	}
 
 	if sn := c.parseServerName(); len(sn) > 0 {
@@ -404,27 +451,15 @@ func (c *Config) GetTLSConfig(opts ...Option) *tls.Config {
 	if len(config.NextProtos) == 0 {
 		config.NextProtos = []string{"h2", "http/1.1"}
 	}
-
-	switch c.MinVersion {
-	case "1.0":
-		config.MinVersion = tls.VersionTLS10
-	case "1.1":
-		config.MinVersion = tls.VersionTLS11
-	case "1.2":
-		config.MinVersion = tls.VersionTLS12
-	case "1.3":
-		config.MinVersion = tls.VersionTLS13
+	if hasAcmeTLSALPN(acmeManagers) {
+		config.NextProtos = append(config.NextProtos, acme.ALPNProto)
 	}
 
-	switch c.MaxVersion {
-	case "1.0":
-		config.MaxVersion = tls.VersionTLS10
-	case "1.1":
-		config.MaxVersion = tls.VersionTLS11
-	case "1.2":
-		config.MaxVersion = tls.VersionTLS12
-	case "1.3":
-		config.MaxVersion = tls.VersionTLS13
+	if version, found := parseTLSVersion(c.MinVersion); found {
+		config.MinVersion = version
+	}
+	if version, found := parseTLSVersion(c.MaxVersion); found {
+		config.MaxVersion = version
 	}
 
 	if len(c.CipherSuites) > 0 {
@@ -457,10 +492,51 @@ func (c *Config) GetTLSConfig(opts ...Option) *tls.Config {
 			}
 		}
 	}
+	server := new(serverConfig)
+	if c.ClientAuth != nil {
+		if server.users, err = c.applyClientAuth(config); err != nil {
+			errors.LogErrorInner(context.Background(), err, "failed to apply client authentication, rejecting all handshakes")
+			rejectHandshakes(config, err)
+		}
+	}
+	// profiles would replace the rejection of handshakes
+	if len(c.SniProfile) > 0 && config.GetConfigForClient == nil {
+		if server.profiles, err = c.applySNIProfiles(config); err != nil {
+			errors.LogErrorInner(context.Background(), err, "failed to apply SNI profiles, rejecting all handshakes")
+			rejectHandshakes(config, err)
+		}
+	}
+	if len(server.users) > 0 || len(server.profiles) > 0 {
+		serverConfigs.Store(config, server)
+	}
+	for _, p := range server.profiles {
+		acmeManagers = append(acmeManagers, p.acmeManagers...)
+	}
+	if len(acmeManagers) > 0 {
+		acmeListeners.Store(config, acmeManagers)
+	}
 
 	return config
 }
 
+// rejectHandshakes has every server side handshake with config fail with
+// err, so that a listener does not serve clients with only part of its
+// settings applied.
+func rejectHandshakes(config *tls.Config, err error) {
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		return nil, err
+	}
+}
+
+// serverConfig is what server side connections need from the Config their
+// tls.Config was built from, to report what their handshake established.
+type serverConfig struct {
+	profiles []*sniProfile
+	users    []*clientUser
+}
+
+var serverConfigs sync.Map // *tls.Config -> *serverConfig
+
 // Option for building TLS config.
 type Option func(*tls.Config)
 
@@ -0,0 +1,165 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"slices"
+	"strings"
+
+	"github.com/xtls/xray-core/common/errors"
+	"golang.org/x/crypto/acme"
+)
+
+type sniProfile struct {
+	name         string
+	names        []string
+	config       *tls.Config
+	acmeManagers []*acmeManager
+}
+
+func (p *sniProfile) matches(serverName string) bool {
+	for _, name := range p.names {
+		if name == serverName || strings.HasPrefix(name, "*.") && strings.HasSuffix(serverName, name[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSNIProfile(profiles []*sniProfile, serverName string) *sniProfile {
+	serverName = strings.ToLower(serverName)
+	for _, p := range profiles {
+		if p.matches(serverName) {
+			return p
+		}
+	}
+	return nil
+}
+
+// applySNIProfiles derives the tls.Config of every SNI profile from the
+// completed base config, and has the base config switch to them.
+func (c *Config) applySNIProfiles(base *tls.Config) ([]*sniProfile, error) {
+	profiles := make([]*sniProfile, 0, len(c.SniProfile))
+	fail := func(err error) ([]*sniProfile, error) {
+		for _, p := range profiles {
+			for _, m := range p.acmeManagers {
+				m.release()
+			}
+		}
+		return nil, err
+	}
+	for _, p := range c.SniProfile {
+		var clientCAs *x509.CertPool
+		if p.RequireClientCertificate {
+			// the profile must not fall back to the client CAs of the base
+			// config, or to the system roots if there are none
+			if len(p.ClientCa) == 0 {
+				return fail(errors.New("SNI profile ", p.Name, " requires client certificates without a client CA"))
+			}
+			clientCAs = x509.NewCertPool()
+			if !clientCAs.AppendCertsFromPEM(p.ClientCa) {
+				return fail(errors.New("invalid client CA of SNI profile ", p.Name))
+			}
+		}
+		config := base.Clone()
+		acmeTLSALPN := slices.Contains(base.NextProtos, acme.ALPNProto)
+		var acmeManagers []*acmeManager
+		certificates := p.Certificate
+		if len(certificates) == 0 && p.RejectUnknownSni && !c.RejectUnknownSni {
+			certificates = c.Certificate
+		}
+		if len(certificates) > 0 {
+			profileConfig := &Config{Certificate: certificates}
+			acmeManagers = profileConfig.acquireAcmeManagers()
+			config.GetCertificate = getNewGetCertificateFunc(profileConfig.BuildCertificates(), acmeManagers, p.RejectUnknownSni)
+			acmeTLSALPN = hasAcmeTLSALPN(acmeManagers)
+		}
+		if len(p.NextProtocol) > 0 {
+			config.NextProtos = slices.Clone(p.NextProtocol)
+		} else {
+			config.NextProtos = slices.DeleteFunc(slices.Clone(base.NextProtos), func(proto string) bool {
+				return proto == acme.ALPNProto
+			})
+		}
+		if acmeTLSALPN {
+			config.NextProtos = append(config.NextProtos, acme.ALPNProto)
+		}
+		if version, found := parseTLSVersion(p.MinVersion); found {
+			config.MinVersion = version
+		}
+		if clientCAs != nil {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+			config.ClientCAs = clientCAs
+		}
+		names := make([]string, len(p.ServerNames))
+		for i, name := range p.ServerNames {
+			names[i] = strings.ToLower(name)
+		}
+		profiles = append(profiles, &sniProfile{
+			name:         p.Name,
+			names:        names,
+			config:       config,
+			acmeManagers: acmeManagers,
+		})
+	}
+
+	base.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		if p := matchSNIProfile(profiles, hello.ServerName); p != nil {
+			return p.config, nil
+		}
+		return nil, nil
+	}
+	return profiles, nil
+}
+
+// SNIProfile returns the name of the SNI profile the server connection
+// matched during handshake, or an empty string if there is none.
+func (c *Conn) SNIProfile() string {
+	if c.server == nil || len(c.server.profiles) == 0 {
+		return ""
+	}
+	state := c.ConnectionState()
+	if !state.HandshakeComplete {
+		return ""
+	}
+	if p := matchSNIProfile(c.server.profiles, state.ServerName); p != nil {
+		return p.name
+	}
+	return ""
+}
+
+// Handshake is what the TLS handshake of a server connection established,
+// for transports that carry their connections over HTTP or gRPC and so
+// cannot hand the Conn accepted by Server to the inbound.
+type Handshake struct {
+	server *serverConfig
+	state  tls.ConnectionState
+}
+
+// NewHandshake returns the Handshake of a server connection accepted with
+// config, or nil if there is nothing to report about it.
+func NewHandshake(config *tls.Config, state *tls.ConnectionState) *Handshake {
+	if config == nil || state == nil || !state.HandshakeComplete {
+		return nil
+	}
+	server, found := serverConfigs.Load(config)
+	if !found {
+		return nil
+	}
+	return &Handshake{
+		server: server.(*serverConfig),
+		state:  *state,
+	}
+}
+
+// SNIProfile returns the name of the SNI profile the handshake matched, or
+// an empty string if there is none.
+func (h *Handshake) SNIProfile() string {
+	if h == nil {
+		return ""
+	}
+	if p := matchSNIProfile(h.server.profiles, h.state.ServerName); p != nil {
+		return p.name
+	}
+	return ""
+}
@@ -0,0 +1,141 @@
+package tls_test
+
+import (
+	gotls "crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/protocol/tls/cert"
+	. "github.com/xtls/xray-core/transport/internet/tls"
+)
+
+func TestSNIProfiles(t *testing.T) {
+	clientAuth := func(c *x509.Certificate) {
+		c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+	clientCA := cert.MustGenerate(nil, cert.Authority(true), cert.KeyUsage(x509.KeyUsageCertSign), clientAuth)
+	clientCAPEM, _ := clientCA.ToPEM()
+	clientCert := cert.MustGenerate(clientCA, cert.CommonName("admin"), clientAuth)
+	clientPEM, clientKeyPEM := clientCert.ToPEM()
+	clientKeyPair, err := gotls.X509KeyPair(clientPEM, clientKeyPEM)
+	common.Must(err)
+
+	config := &Config{
+		Certificate: []*Certificate{
+			ParseCertificate(cert.MustGenerate(nil, cert.DNSNames("example.com"))),
+		},
+		SniProfile: []*SniProfile{
+			{
+				Name:        "admin",
+				ServerNames: []string{"admin.example.com"},
+				Certificate: []*Certificate{
+					ParseCertificate(cert.MustGenerate(nil, cert.DNSNames("admin.example.com"))),
+				},
+				NextProtocol:             []string{"http/1.1"},
+				MinVersion:               "1.3",
+				RequireClientCertificate: true,
+				ClientCa:                 clientCAPEM,
+			},
+			{
+				Name:             "strict",
+				ServerNames:      []string{"*.strict.example.com"},
+				RejectUnknownSni: true,
+			},
+		},
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+	profiles := make(chan string, 1)
+	serverConfig := config.GetTLSConfig()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			tlsConn := Server(conn, serverConfig).(*Conn)
+			err = tlsConn.Handshake()
+			if err == nil {
+				profiles <- tlsConn.SNIProfile()
+			}
+			tlsConn.Close()
+		}
+	}()
+
+	dial := func(serverName string, clientCerts ...gotls.Certificate) (gotls.ConnectionState, error) {
+		conn, err := gotls.Dial("tcp", listener.Addr().String(), &gotls.Config{
+			ServerName:         serverName,
+			InsecureSkipVerify: true,
+			NextProtos:         []string{"h2", "http/1.1"},
+			Certificates:       clientCerts,
+		})
+		if err != nil {
+			return gotls.ConnectionState{}, err
+		}
+		defer conn.Close()
+		// Client certificates are verified after the client's handshake
+		// completes in TLS 1.3, so wait for the server to close.
+		if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+			return gotls.ConnectionState{}, err
+		}
+		return conn.ConnectionState(), nil
+	}
+
+	state, err := dial("example.com")
+	common.Must(err)
+	if name := state.PeerCertificates[0].DNSNames[0]; name != "example.com" {
+		t.Error("unexpected certificate for default profile: ", name)
+	}
+	if state.NegotiatedProtocol != "h2" {
+		t.Error("unexpected ALPN for default profile: ", state.NegotiatedProtocol)
+	}
+	if profile := <-profiles; profile != "" {
+		t.Error("unexpected profile: ", profile)
+	}
+
+	state, err = dial("admin.example.com", clientKeyPair)
+	common.Must(err)
+	if name := state.PeerCertificates[0].DNSNames[0]; name != "admin.example.com" {
+		t.Error("unexpected certificate for admin profile: ", name)
+	}
+	if state.NegotiatedProtocol != "http/1.1" || state.Version != gotls.VersionTLS13 {
+		t.Error("unexpected ALPN or version for admin profile: ", state.NegotiatedProtocol, state.Version)
+	}
+	if profile := <-profiles; profile != "admin" {
+		t.Error("unexpected profile: ", profile)
+	}
+
+	if _, err := dial("admin.example.com"); err == nil {
+		t.Error("admin profile accepted a client without certificate")
+	}
+
+	if _, err := dial("www.strict.example.com"); err == nil {
+		t.Error("strict profile accepted an unknown SNI")
+	}
+}
+
+func TestSNIProfileWithoutClientCA(t *testing.T) {
+	config := &Config{
+		Certificate: []*Certificate{
+			ParseCertificate(cert.MustGenerate(nil, cert.DNSNames("example.com"))),
+		},
+		SniProfile: []*SniProfile{
+			{
+				Name:                     "admin",
+				ServerNames:              []string{"admin.example.com"},
+				RequireClientCertificate: true,
+			},
+		},
+	}
+	getConfigForClient := config.GetTLSConfig().GetConfigForClient
+	for _, serverName := range []string{"admin.example.com", "example.com"} {
+		if _, err := getConfigForClient(&gotls.ClientHelloInfo{ServerName: serverName}); err == nil {
+			t.Error("handshake for ", serverName, " accepted without the client CA of the profile")
+		}
+	}
+}
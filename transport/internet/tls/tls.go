@@ -25,6 +25,8 @@ var _ Interface = (*Conn)(nil)
 
 type Conn struct {
 	*tls.Conn
+
+	server *serverConfig
 }
 
 const tlsCloseTimeout = 250 * time.Millisecond
@@ -65,7 +67,11 @@ func Client(c net.Conn, config *tls.Config) net.Conn {
 // Server initiates a TLS server handshake on the given connection.
 func Server(c net.Conn, config *tls.Config) net.Conn {
 	tlsConn := tls.Server(c, config)
-	return &Conn{Conn: tlsConn}
+	conn := &Conn{Conn: tlsConn}
+	if server, found := serverConfigs.Load(config); found {
+		conn.server = server.(*serverConfig)
+	}
+	return conn
 }
 
 type UConn struct {
@@ -135,6 +141,39 @@ func copyConfig(c *tls.Config) *utls.Config {
 		VerifyPeerCertificate:          c.VerifyPeerCertificate,
 		KeyLogWriter:                   c.KeyLogWriter,
 		EncryptedClientHelloConfigList: c.EncryptedClientHelloConfigList,
+		GetClientCertificate:           copyGetClientCertificate(c.GetClientCertificate),
+	}
+}
+
+func copyGetClientCertificate(f func(*tls.CertificateRequestInfo) (*tls.Certificate, error)) func(*utls.CertificateRequestInfo) (*utls.Certificate, error) {
+	if f == nil {
+		return nil
+	}
+	return func(info *utls.CertificateRequestInfo) (*utls.Certificate, error) {
+		schemes := make([]tls.SignatureScheme, len(info.SignatureSchemes))
+		for i, scheme := range info.SignatureSchemes {
+			schemes[i] = tls.SignatureScheme(scheme)
+		}
+		cert, err := f(&tls.CertificateRequestInfo{
+			AcceptableCAs:    info.AcceptableCAs,
+			SignatureSchemes: schemes,
+			Version:          info.Version,
+		})
+		if err != nil || cert == nil {
+			return nil, err
+		}
+		var algorithms []utls.SignatureScheme
+		for _, scheme := range cert.SupportedSignatureAlgorithms {
+			algorithms = append(algorithms, utls.SignatureScheme(scheme))
+		}
+		return &utls.Certificate{
+			Certificate:                  cert.Certificate,
+			PrivateKey:                   cert.PrivateKey,
+			SupportedSignatureAlgorithms: algorithms,
+			OCSPStaple:                   cert.OCSPStaple,
+			SignedCertificateTimestamps:  cert.SignedCertificateTimestamps,
+			Leaf:                         cert.Leaf,
+		}, nil
 	}
 }
 
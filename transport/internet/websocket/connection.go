@@ -8,7 +8,9 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/xtls/xray-core/common/buf"
 	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/protocol"
 	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/transport/internet/tls"
 )
 
 var _ buf.Writer = (*connection)(nil)
@@ -20,6 +22,7 @@ type connection struct {
 	conn       *websocket.Conn
 	reader     io.Reader
 	remoteAddr net.Addr
+	handshake  *tls.Handshake
 }
 
 func NewConnection(conn *websocket.Conn, remoteAddr net.Addr, extraReader io.Reader, heartbeatPeriod uint32) *connection {
@@ -122,3 +125,13 @@ func (c *connection) SetReadDeadline(t time.Time) error {
 func (c *connection) SetWriteDeadline(t time.Time) error {
 	return c.conn.SetWriteDeadline(t)
 }
+
+// SNIProfile returns the name of the TLS SNI profile the connection matched, if any.
+func (c *connection) SNIProfile() string {
+	return c.handshake.SNIProfile()
+}
+
+// ClientUser returns the user the TLS client certificate of the connection maps to, if any.
+func (c *connection) ClientUser() *protocol.MemoryUser {
+	return c.handshake.ClientUser()
+}
@@ -73,15 +73,18 @@ func (h *requestHandler) ServeHTTP(writer http.ResponseWriter, request *http.Req
 		}
 	}
 
-	h.ln.addConn(NewConnection(conn, remoteAddr, extraReader, h.ln.config.HeartbeatPeriod))
+	wsConn := NewConnection(conn, remoteAddr, extraReader, h.ln.config.HeartbeatPeriod)
+	wsConn.handshake = v2tls.NewHandshake(h.ln.tlsConfig, request.TLS)
+	h.ln.addConn(wsConn)
 }
 
 type Listener struct {
 	sync.Mutex
-	server   http.Server
-	listener net.Listener
-	config   *Config
-	addConn  internet.ConnHandler
+	server    http.Server
+	listener  net.Listener
+	tlsConfig *tls.Config
+	config    *Config
+	addConn   internet.ConnHandler
 }
 
 func ListenWS(ctx context.Context, address net.Address, port net.Port, streamSettings *internet.MemoryStreamConfig, addConn internet.ConnHandler) (internet.Listener, error) {
@@ -123,8 +126,8 @@ func ListenWS(ctx context.Context, address net.Address, port net.Port, streamSet
 	}
 
 	if config := v2tls.ConfigFromStreamSettings(streamSettings); config != nil {
-		if tlsConfig := config.GetTLSConfig(); tlsConfig != nil {
-			listener = tls.NewListener(listener, tlsConfig)
+		if l.tlsConfig = config.GetTLSConfig(); l.tlsConfig != nil {
+			listener = tls.NewListener(listener, l.tlsConfig)
 		}
 	}
 
@@ -156,6 +159,7 @@ func (ln *Listener) Addr() net.Addr {
 
 // Close implements net.Listener.Close().
 func (ln *Listener) Close() error {
+	v2tls.Release(ln.tlsConfig)
 	return ln.listener.Close()
 }
 
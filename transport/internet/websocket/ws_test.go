@@ -2,12 +2,14 @@ package websocket_test
 
 import (
 	"context"
+	"crypto/x509"
 	"runtime"
 	"testing"
 	"time"
 
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
 	"github.com/xtls/xray-core/common/protocol/tls/cert"
 	"github.com/xtls/xray-core/testing/servers/tcp"
 	"github.com/xtls/xray-core/transport/internet"
@@ -151,3 +153,97 @@ func Test_listenWSAndDial_TLS(t *testing.T) {
 		t.Error("end: ", end, " start: ", start)
 	}
 }
+
+func Test_listenWSAndDial_SNIProfile(t *testing.T) {
+	listenPort := tcp.PickPort()
+	serverSettings := &internet.MemoryStreamConfig{
+		ProtocolName:     "websocket",
+		ProtocolSettings: &Config{Path: "wss"},
+		SecurityType:     "tls",
+		SecuritySettings: &tls.Config{
+			Certificate: []*tls.Certificate{tls.ParseCertificate(cert.MustGenerate(nil, cert.CommonName("localhost")))},
+			SniProfile: []*tls.SniProfile{{
+				Name:        "local",
+				ServerNames: []string{"localhost"},
+			}},
+		},
+	}
+	profiles := make(chan string, 1)
+	listen, err := ListenWS(context.Background(), net.LocalHostIP, listenPort, serverSettings, func(conn stat.Connection) {
+		profiles <- conn.(interface{ SNIProfile() string }).SNIProfile()
+		_ = conn.Close()
+	})
+	common.Must(err)
+	defer listen.Close()
+
+	clientSettings := &internet.MemoryStreamConfig{
+		ProtocolName:     "websocket",
+		ProtocolSettings: &Config{Path: "wss"},
+		SecurityType:     "tls",
+		SecuritySettings: &tls.Config{AllowInsecure: true},
+	}
+	conn, err := Dial(context.Background(), net.TCPDestination(net.DomainAddress("localhost"), listenPort), clientSettings)
+	common.Must(err)
+	_ = conn.Close()
+
+	if profile := <-profiles; profile != "local" {
+		t.Error("unexpected profile: ", profile)
+	}
+}
+
+func Test_listenWSAndDial_ClientUser(t *testing.T) {
+	clientAuth := func(c *x509.Certificate) {
+		c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+	ca := cert.MustGenerate(nil, cert.Authority(true), cert.KeyUsage(x509.KeyUsageCertSign), clientAuth)
+	caCertificate := tls.ParseCertificate(ca)
+	caCertificate.Key = nil
+	caCertificate.Usage = tls.Certificate_AUTHORITY_VERIFY
+
+	listenPort := tcp.PickPort()
+	serverSettings := &internet.MemoryStreamConfig{
+		ProtocolName:     "websocket",
+		ProtocolSettings: &Config{Path: "wss"},
+		SecurityType:     "tls",
+		SecuritySettings: &tls.Config{
+			Certificate: []*tls.Certificate{
+				tls.ParseCertificate(cert.MustGenerate(nil, cert.CommonName("localhost"))),
+				caCertificate,
+			},
+			ClientAuth: &tls.ClientAuth{
+				Required: true,
+				User: []*tls.ClientUser{{
+					Subject: "alice",
+					User:    &protocol.User{Email: "alice@example.com"},
+				}},
+			},
+		},
+	}
+	users := make(chan *protocol.MemoryUser, 1)
+	listen, err := ListenWS(context.Background(), net.LocalHostIP, listenPort, serverSettings, func(conn stat.Connection) {
+		users <- conn.(interface{ ClientUser() *protocol.MemoryUser }).ClientUser()
+		_ = conn.Close()
+	})
+	common.Must(err)
+	defer listen.Close()
+
+	clientSettings := &internet.MemoryStreamConfig{
+		ProtocolName:     "websocket",
+		ProtocolSettings: &Config{Path: "wss"},
+		SecurityType:     "tls",
+		SecuritySettings: &tls.Config{
+			AllowInsecure: true,
+			Certificate: []*tls.Certificate{
+				tls.ParseCertificate(cert.MustGenerate(ca, cert.CommonName("alice"), clientAuth)),
+			},
+			PresentClientCertificate: true,
+		},
+	}
+	conn, err := Dial(context.Background(), net.TCPDestination(net.DomainAddress("localhost"), listenPort), clientSettings)
+	common.Must(err)
+	_ = conn.Close()
+
+	if user := <-users; user == nil || user.Email != "alice@example.com" {
+		t.Error("unexpected user: ", user)
+	}
+}